@@ -0,0 +1,69 @@
+// Команда create_tenant создает арендатора (барбершоп) и его владельца. В многотенантном режиме
+// регистрация через /auth/register закрыта, поэтому владелец нового арендатора создается только так:
+//
+//	go run ./app/cmd/create_tenant -name "Барбершоп на Невском" -username owner -email owner@example.com -password secret
+//
+// С -tenant-id владелец создается у существующего арендатора, у которого еще нет сотрудников.
+package main
+
+import (
+	"flag"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/repositories"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/services"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/tenant"
+	"log"
+
+	"github.com/0sokrat0/GoGRAFFApi.git/app/configs"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func main() {
+	configPath := flag.String("config", "/root/app/configs", "каталог с config.yaml")
+	tenantID := flag.Int("tenant-id", 0, "ID существующего арендатора; без него создается новый")
+	name := flag.String("name", "", "название нового арендатора")
+	username := flag.String("username", "", "логин владельца")
+	email := flag.String("email", "", "email владельца")
+	password := flag.String("password", "", "пароль владельца")
+	flag.Parse()
+
+	if *username == "" || *password == "" || (*tenantID == 0 && *name == "") {
+		log.Fatal("Required flags: -username, -password and -name or -tenant-id")
+	}
+
+	cfg, err := configs.LoadConfig(*configPath)
+	if err != nil {
+		log.Fatalf("Error loading config: %v", err)
+	}
+
+	// Без миграций: схема создается при запуске API
+	conn, err := gorm.Open(postgres.Open(configs.GetDSN(cfg.Database)), &gorm.Config{})
+	if err != nil {
+		log.Fatalf("Failed to connect to the database: %v", err)
+	}
+	if err := tenant.Register(conn); err != nil {
+		log.Fatalf("Failed to register tenant scope: %v", err)
+	}
+
+	if *tenantID == 0 {
+		created := &models.Tenant{Name: *name, IsActive: true}
+		if err := conn.Create(created).Error; err != nil {
+			log.Fatalf("Failed to create tenant: %v", err)
+		}
+		*tenantID = created.ID
+		log.Printf("Created tenant #%d %q.", created.ID, created.Name)
+	} else if _, err := repositories.NewTenantRepository(conn).GetTenantByID(*tenantID); err != nil {
+		log.Fatalf("Failed to load tenant #%d: %v", *tenantID, err)
+	}
+
+	scoped := tenant.Scope(conn, *tenantID)
+	history := services.NewHistoryService(repositories.NewHistoryRepository(scoped))
+	users := services.NewUserService(repositories.NewUserRepository(scoped), history)
+	owner := &models.User{Username: *username, Email: *email, PasswordHash: *password}
+	if err := users.RegisterOwner(owner); err != nil {
+		log.Fatalf("Failed to create owner of tenant #%d: %v", *tenantID, err)
+	}
+	log.Printf("Created owner #%d %q of tenant #%d.", owner.ID, owner.Username, *tenantID)
+}
@@ -1,10 +1,17 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/app"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/notify"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/repositories"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/tenant"
 	"github.com/0sokrat0/GoGRAFFApi.git/app/pkg/db"
 	"log"
+	"net/http"
+	"time"
 
 	"github.com/0sokrat0/GoGRAFFApi.git/app/configs"
 	_ "github.com/0sokrat0/GoGRAFFApi.git/app/docs"
@@ -25,11 +32,9 @@ func main() {
 		log.Fatalf("Error loading config: %v", err)
 	}
 
-	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		cfg.Database.Host, cfg.Database.Port, cfg.Database.User, cfg.Database.Password, cfg.Database.Name, cfg.Database.SslMode,
-	)
-
-	err = db.InitDB(dsn)
+	// Филиал по умолчанию для данных, созданных до появления филиалов
+	defaultLocation := models.Location{Name: cfg.App.Name, Timezone: cfg.App.Location().String(), IsActive: true}
+	err = db.InitDB(configs.GetDSN(cfg.Database), defaultLocation, cfg.App.Currency)
 	if err != nil {
 		log.Fatalf("Failed to connect to the database: %v", err)
 	}
@@ -39,10 +44,50 @@ func main() {
 		}
 	}()
 
-	router := app.SetupRouter(db.DB)
+	// Фоновая отправка уведомлений обслуживает всех арендаторов
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dispatcher := notify.NewDispatcher(
+		repositories.NewNotificationRepository(db.DB),
+		repositories.NewClientRepository(db.DB),
+		notify.SendersFromConfig(cfg.Notifications),
+		cfg.Notifications,
+	)
+	go dispatcher.Run(ctx)
+	go cleanupTokens(ctx, repositories.NewTokenRepository(db.DB))
+
+	// В однотенантном режиме все данные относятся к арендатору по умолчанию
+	var handler http.Handler = app.SetupRouter(tenant.Scope(db.DB, tenant.DefaultID))
+	if cfg.App.MultiTenant {
+		handler = app.NewTenantRouter(db.DB)
+	}
 
-	err = router.Run(fmt.Sprintf(":%d", cfg.App.Port))
+	err = http.ListenAndServe(fmt.Sprintf(":%d", cfg.App.Port), handler)
 	if err != nil {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
+
+// tokenCleanupInterval — период удаления истекших и использованных токенов
+const tokenCleanupInterval = time.Hour
+
+// cleanupTokens периодически удаляет из revoked_tokens и refresh_tokens записи всех арендаторов,
+// которые больше не нужны для проверки токенов
+func cleanupTokens(ctx context.Context, tokens repositories.TokenRepository) {
+	ticker := time.NewTicker(tokenCleanupInterval)
+	defer ticker.Stop()
+	for {
+		deleted, err := tokens.DeleteExpiredTokens(time.Now())
+		if err != nil {
+			log.Printf("Error deleting expired tokens: %v", err)
+		} else if deleted > 0 {
+			log.Printf("Deleted %d expired tokens", deleted)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
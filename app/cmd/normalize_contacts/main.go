@@ -0,0 +1,46 @@
+// Команда normalize_contacts однократно приводит телефоны клиентов и сотрудников к E.164,
+// а email — к нижнему регистру. Запускается после обновления, чтобы поиск по телефону
+// и email находил записи, сохраненные до появления нормализации:
+//
+//	go run ./app/cmd/normalize_contacts -dry-run
+//	go run ./app/cmd/normalize_contacts
+package main
+
+import (
+	"flag"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/pkg/db"
+	"log"
+
+	"github.com/0sokrat0/GoGRAFFApi.git/app/configs"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func main() {
+	configPath := flag.String("config", "/root/app/configs", "каталог с config.yaml")
+	dryRun := flag.Bool("dry-run", false, "только показать, сколько записей изменится")
+	flag.Parse()
+
+	cfg, err := configs.LoadConfig(*configPath)
+	if err != nil {
+		log.Fatalf("Error loading config: %v", err)
+	}
+
+	// Без миграций и ограничения арендатором: обрабатываются записи всех арендаторов
+	conn, err := gorm.Open(postgres.Open(configs.GetDSN(cfg.Database)), &gorm.Config{})
+	if err != nil {
+		log.Fatalf("Failed to connect to the database: %v", err)
+	}
+
+	result, err := db.NormalizeContacts(conn, *dryRun)
+	if err != nil {
+		log.Fatalf("Failed to normalize contacts: %v", err)
+	}
+	for _, issue := range result.Issues {
+		log.Printf("Skipped %s", issue)
+	}
+	if *dryRun {
+		log.Printf("Dry run: %d of %d records would be updated.", result.Updated, result.Checked)
+	}
+}
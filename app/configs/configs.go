@@ -3,13 +3,18 @@ package configs
 import (
 	"fmt"
 	"log"
+	"time"
+	_ "time/tzdata" // База часовых поясов на случай, если в системе ее нет
+
+	"github.com/0sokrat0/GoGRAFFApi.git/app/pkg/currency"
 
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	App      AppConfig      `mapstructure:"app"`
-	Database DatabaseConfig `mapstructure:"database"`
+	App           AppConfig           `mapstructure:"app"`
+	Database      DatabaseConfig      `mapstructure:"database"`
+	Notifications NotificationsConfig `mapstructure:"notifications"`
 }
 
 type AppConfig struct {
@@ -17,6 +22,30 @@ type AppConfig struct {
 	Environment string `mapstructure:"environment"`
 	Port        int    `mapstructure:"port"`
 	JWTSecret   string `mapstructure:"jwt_secret"`
+	// Timezone — часовой пояс салона по умолчанию (IANA, например Europe/Moscow): в нем создается
+	// первый филиал и отсчитываются записи без филиала
+	Timezone string `mapstructure:"timezone"`
+	// MultiTenant — несколько барбершопов в одной базе: арендатор определяется по JWT
+	// или заголовку X-Tenant-ID, данные арендаторов изолированы друг от друга
+	MultiTenant bool `mapstructure:"multi_tenant"`
+	// Currency — код валюты ISO 4217, в которой заданы цены, сохраненные до появления валют
+	Currency string `mapstructure:"currency"`
+	// AllowUnassignedStaff — барберы без назначения услуги (barber_services) выполняют ее по цене
+	// и длительности услуги. По умолчанию услугу выполняют только назначенные ей сотрудники.
+	AllowUnassignedStaff bool `mapstructure:"allow_unassigned_staff"`
+
+	AccessTokenTTL  time.Duration `mapstructure:"access_token_ttl"`
+	RefreshTokenTTL time.Duration `mapstructure:"refresh_token_ttl"`
+}
+
+// Location возвращает часовой пояс салона; пустое значение означает UTC.
+// Корректность значения проверяется в LoadConfig.
+func (c AppConfig) Location() *time.Location {
+	loc, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
 }
 
 type DatabaseConfig struct {
@@ -28,6 +57,41 @@ type DatabaseConfig struct {
 	SslMode  string `mapstructure:"sslmode"`
 }
 
+// NotificationsConfig — параметры фоновой отправки уведомлений.
+// Канал включается, только если заданы его настройки.
+type NotificationsConfig struct {
+	PollInterval   time.Duration `mapstructure:"poll_interval"`
+	BatchSize      int           `mapstructure:"batch_size"`
+	MaxAttempts    int           `mapstructure:"max_attempts"`
+	RetryBaseDelay time.Duration `mapstructure:"retry_base_delay"`
+	RetryMaxDelay  time.Duration `mapstructure:"retry_max_delay"`
+
+	// Напоминания о визите: за сколько до начала бронирования и по каким каналам (в порядке предпочтения)
+	ReminderOffsets  []time.Duration `mapstructure:"reminder_offsets"`
+	ReminderChannels []string        `mapstructure:"reminder_channels"`
+
+	Telegram TelegramConfig `mapstructure:"telegram"`
+	SMS      SMSConfig      `mapstructure:"sms"`
+	Email    EmailConfig    `mapstructure:"email"`
+}
+
+type TelegramConfig struct {
+	BotToken string `mapstructure:"bot_token"`
+}
+
+type SMSConfig struct {
+	GatewayURL string `mapstructure:"gateway_url"`
+	APIKey     string `mapstructure:"api_key"`
+}
+
+type EmailConfig struct {
+	Host     string `mapstructure:"host"`
+	Port     int    `mapstructure:"port"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	From     string `mapstructure:"from"`
+}
+
 var AppConfigInstance *Config
 
 func LoadConfig(path string) (*Config, error) {
@@ -44,13 +108,24 @@ func LoadConfig(path string) (*Config, error) {
 	if err := viper.Unmarshal(&config); err != nil {
 		return nil, fmt.Errorf("error unmarshalling config: %w", err)
 	}
+	if _, err := time.LoadLocation(config.App.Timezone); err != nil {
+		return nil, fmt.Errorf("invalid app.timezone %q: %w", config.App.Timezone, err)
+	}
+	if config.App.Currency == "" {
+		config.App.Currency = currency.Default
+	}
+	if !currency.IsValid(config.App.Currency) {
+		return nil, fmt.Errorf("invalid app.currency %q: %w", config.App.Currency, currency.ErrUnknown)
+	}
 
 	log.Println("Configuration loaded successfully.")
 	AppConfigInstance = &config
 	return &config, nil
 }
 
+// GetDSN формирует строку подключения к PostgreSQL. Сессия работает в UTC,
+// поэтому значения timestamptz читаются и записываются в UTC независимо от настроек сервера.
 func GetDSN(config DatabaseConfig) string {
-	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s TimeZone=UTC",
 		config.Host, config.Port, config.User, config.Password, config.Name, config.SslMode)
 }
@@ -7,8 +7,10 @@ import (
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 
+	"github.com/0sokrat0/GoGRAFFApi.git/app/configs"
 	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/handlers"
 	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/middleware"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
 	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/repositories"
 	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/routes"
 	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/services"
@@ -16,14 +18,27 @@ import (
 	"gorm.io/gorm"
 )
 
+// SetupRouter собирает роутер с базовыми маршрутами и API поверх database.
+// В однотенантном режиме database ограничивается арендатором по умолчанию (tenant.Scope).
 func SetupRouter(database *gorm.DB) *gin.Engine {
+	router := newEngine()
+	setupBaseRoutes(router)
+	setupAPIRoutes(router, database, true)
+	return router
+}
+
+func newEngine() *gin.Engine {
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.Default()
 
 	// Middleware
 	router.Use(middleware.RequestLogger())
 	router.Use(middleware.CORSMiddleware())
+	return router
+}
 
+// setupBaseRoutes регистрирует маршруты, не относящиеся к данным салона
+func setupBaseRoutes(router *gin.Engine) {
 	// Base Routes
 	router.GET("/ping", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{
@@ -33,54 +48,91 @@ func SetupRouter(database *gorm.DB) *gin.Engine {
 
 	// Swagger
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+}
 
+// setupAPIRoutes регистрирует маршруты /api; все репозитории работают через database.
+// registration открывает POST /auth/register для создания первого владельца.
+func setupAPIRoutes(router *gin.Engine, database *gorm.DB, registration bool) {
 	// Initialize repositories
-	authRepo := repositories.NewAuthUserRepository(database)
 	userRepo := repositories.NewUserRepository(database)
 	clientRepo := repositories.NewClientRepository(database)
 	bookingRepo := repositories.NewBookingRepository(database)
 	serviceRepo := repositories.NewServiceRepository(database)
 	scheduleRepo := repositories.NewScheduleRepository(database)
+	scheduleOverrideRepo := repositories.NewScheduleOverrideRepository(database)
 	breakRepo := repositories.NewBreakRepository(database)
 	notificationRepo := repositories.NewNotificationRepository(database)
+	tokenRepo := repositories.NewTokenRepository(database)
+	historyRepo := repositories.NewHistoryRepository(database)
+	templateRepo := repositories.NewNotificationTemplateRepository(database)
+	locationRepo := repositories.NewLocationRepository(database)
+	clientFieldRepo := repositories.NewClientFieldRepository(database)
 
 	// Initialize services
-	authHandler := handlers.NewAuthHandler(authRepo)
-	userService := services.NewUserService(userRepo)
-	clientService := services.NewClientService(clientRepo)
-	bookingService := services.NewBookingService(bookingRepo)
-	serviceService := services.NewServiceService(serviceRepo)
-	scheduleService := services.NewScheduleService(scheduleRepo)
-	breakService := services.NewBreakService(breakRepo)
+	notificationsConfig := configs.AppConfigInstance.Notifications
+	historyService := services.NewHistoryService(historyRepo)
+	locationService := services.NewLocationService(locationRepo, historyService, configs.AppConfigInstance.App.Location())
+	templateService := services.NewNotificationTemplateService(templateRepo, bookingRepo, locationService)
+	reminderService := services.NewReminderService(
+		notificationRepo, bookingRepo, templateService,
+		notificationsConfig.ReminderOffsets, notificationsConfig.ReminderChannels,
+	)
+	userService := services.NewUserService(userRepo, historyService)
+	authService := services.NewAuthService(userService, tokenRepo)
+	clientService := services.NewClientService(clientRepo, bookingRepo, clientFieldRepo, historyService)
+	scheduleService := services.NewScheduleService(scheduleRepo, scheduleOverrideRepo, historyService, locationService)
+	breakService := services.NewBreakService(breakRepo, historyService, locationService)
+	bookingService := services.NewBookingService(
+		bookingRepo, serviceRepo, scheduleService, breakService, historyService, reminderService, locationService,
+		userRepo, configs.AppConfigInstance.App.AllowUnassignedStaff,
+	)
+	serviceService := services.NewServiceService(serviceRepo, locationRepo, userRepo, historyService, configs.AppConfigInstance.App.AllowUnassignedStaff)
 	notificationService := services.NewNotificationService(notificationRepo)
 
 	// Initialize handlers
-	userHandler := handlers.NewUserHandler(userService)
+	authHandler := handlers.NewAuthHandler(userService, authService)
+	userHandler := handlers.NewUserHandler(userService, authService)
 	clientHandler := handlers.NewClientHandler(clientService)
 	bookingHandler := handlers.NewBookingHandler(bookingService)
 	serviceHandler := handlers.NewServiceHandler(serviceService)
 	scheduleHandler := handlers.NewScheduleHandler(scheduleService)
 	breakHandler := handlers.NewBreakHandler(breakService)
 	notificationHandler := handlers.NewNotificationHandler(notificationService)
+	historyHandler := handlers.NewHistoryHandler(historyService)
+	templateHandler := handlers.NewNotificationTemplateHandler(templateService)
+	locationHandler := handlers.NewLocationHandler(locationService)
 
 	// Public routes (без JWT)
 	api := router.Group("/api")
 	{
-		routes.SetupAuthRoutes(api, authHandler) // Routes for authentication (public)
+		routes.SetupAuthRoutes(api, authHandler, registration) // Routes for authentication (public)
 	}
 
+	// Матрица прав по ролям
+	allStaff := []string{models.RoleOwner, models.RoleAdmin, models.RoleBarber, models.RoleReceptionist}
+	managers := []string{models.RoleOwner, models.RoleAdmin}
+	frontDesk := []string{models.RoleOwner, models.RoleAdmin, models.RoleReceptionist}
+	withBarbers := []string{models.RoleOwner, models.RoleAdmin, models.RoleBarber}
+
 	// Protected routes (с JWT)
 	protected := router.Group("/api")
-	protected.Use(middleware.JWTMiddleware()) // Применяем JWT middleware ко всем маршрутам этой группы
+	protected.Use(middleware.JWTMiddleware(authService)) // Применяем JWT middleware ко всем маршрутам этой группы
+	withPolicy := func(read, write []string) *gin.RouterGroup {
+		return protected.Group("", middleware.Authorize(middleware.Policy{Read: read, Write: write}))
+	}
 	{
-		routes.SetupUserRoutes(protected, userHandler)                 // Routes for user management
-		routes.SetupClientRoutes(protected, clientHandler)             // Routes for client management
-		routes.SetupBookingRoutes(protected, bookingHandler)           // Routes for bookings
-		routes.SetupServiceRoutes(protected, serviceHandler)           // Routes for services
-		routes.SetupScheduleRoutes(protected, scheduleHandler)         // Routes for schedules
-		routes.SetupBreakRoutes(protected, breakHandler)               // Routes for breaks
-		routes.SetupNotificationRoutes(protected, notificationHandler) // Routes for notifications
+		routes.SetupSessionRoutes(protected, authHandler)                                        // Routes for session management
+		routes.SetupUserRoutes(withPolicy(managers, managers), userHandler)                      // Routes for user management
+		routes.SetupClientRoutes(withPolicy(allStaff, allStaff), clientHandler)                  // Routes for client management
+		routes.SetupClientFieldRoutes(withPolicy(allStaff, managers), clientHandler)             // Routes for client custom fields
+		routes.SetupBookingRoutes(withPolicy(allStaff, allStaff), bookingHandler)                // Routes for bookings
+		routes.SetupServiceRoutes(withPolicy(allStaff, managers), serviceHandler)                // Routes for services
+		routes.SetupScheduleRoutes(withPolicy(allStaff, withBarbers), scheduleHandler)           // Routes for schedules
+		routes.SetupScheduleOverrideRoutes(withPolicy(allStaff, withBarbers), scheduleHandler)   // Routes for schedule exceptions
+		routes.SetupBreakRoutes(withPolicy(allStaff, withBarbers), breakHandler)                 // Routes for breaks
+		routes.SetupNotificationRoutes(withPolicy(frontDesk, frontDesk), notificationHandler)    // Routes for notifications
+		routes.SetupNotificationTemplateRoutes(withPolicy(frontDesk, managers), templateHandler) // Routes for notification templates
+		routes.SetupHistoryRoutes(withPolicy(managers, managers), historyHandler)                // Routes for audit history
+		routes.SetupLocationRoutes(withPolicy(allStaff, managers), locationHandler)              // Routes for locations
 	}
-
-	return router
 }
@@ -0,0 +1,100 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/middleware"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/repositories"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/tenant"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+// TenantRouter — точка входа многотенантного режима. Запросы к /api направляются в роутер
+// арендатора, все репозитории которого работают через tenant.Scope и не видят чужих данных.
+// Роутеры арендаторов создаются при первом обращении и переиспользуются.
+type TenantRouter struct {
+	database *gorm.DB
+	tenants  repositories.TenantRepository
+	base     *gin.Engine
+
+	mu      sync.Mutex
+	routers map[int]*gin.Engine
+}
+
+func NewTenantRouter(database *gorm.DB) *TenantRouter {
+	base := newEngine()
+	setupBaseRoutes(base)
+
+	return &TenantRouter{
+		database: database,
+		tenants:  repositories.NewTenantRepository(database),
+		base:     base,
+		routers:  make(map[int]*gin.Engine),
+	}
+}
+
+func (t *TenantRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Базовые маршруты и CORS preflight не относятся к данным арендатора
+	if !strings.HasPrefix(r.URL.Path, "/api") || r.Method == http.MethodOptions {
+		t.base.ServeHTTP(w, r)
+		return
+	}
+
+	tenantID, err := middleware.ResolveTenant(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	current, err := t.tenants.GetTenantByID(tenantID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrTenantNotFound) {
+			writeError(w, http.StatusNotFound, "Арендатор не найден")
+			return
+		}
+		log.Printf("Failed to load tenant #%d: %v", tenantID, err)
+		writeError(w, http.StatusInternalServerError, "Не удалось определить арендатора")
+		return
+	}
+	if !current.IsActive {
+		writeError(w, http.StatusForbidden, "Арендатор отключен")
+		return
+	}
+
+	t.router(tenantID).ServeHTTP(w, r)
+}
+
+// router возвращает роутер арендатора, создавая его при первом обращении
+func (t *TenantRouter) router(tenantID int) *gin.Engine {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if router, ok := t.routers[tenantID]; ok {
+		return router
+	}
+	router := newEngine()
+	router.Use(middleware.TenantMiddleware(tenantID))
+	// Регистрация закрыта: иначе владельцем любого нового арендатора стал бы первый, кто укажет его X-Tenant-ID.
+	// Владелец создается вместе с арендатором командой cmd/create_tenant
+	setupAPIRoutes(router, tenant.Scope(t.database, tenantID), false)
+	t.routers[tenantID] = router
+	return router
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(utils.ErrorResponse(message)); err != nil {
+		log.Printf("Failed to write error response: %v", err)
+	}
+}
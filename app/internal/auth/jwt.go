@@ -8,21 +8,57 @@ import (
 )
 
 type Claims struct {
-	UserID uint `json:"user_id"`
+	UserID   int    `json:"user_id"`   // ID сотрудника (models.User)
+	TenantID int    `json:"tenant_id"` // Арендатор, к данным которого относится токен
+	Role     string `json:"role"`
 	jwt.StandardClaims
 }
 
-func GenerateToken(userID uint) (string, error) {
-	claims := Claims{
-		UserID: userID,
+// Сроки действия токенов по умолчанию, если они не заданы в конфигурации
+const (
+	defaultAccessTokenTTL  = 15 * time.Minute
+	defaultRefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+// GenerateToken выпускает access-токен с уникальным jti (claims.Id) для возможности отзыва
+func GenerateToken(userID, tenantID int, role string) (string, *Claims, error) {
+	jti, err := NewTokenID()
+	if err != nil {
+		return "", nil, err
+	}
+
+	now := time.Now()
+	claims := &Claims{
+		UserID:   userID,
+		TenantID: tenantID,
+		Role:     role,
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(time.Hour * 24).Unix(),
-			IssuedAt:  time.Now().Unix(),
+			Id:        jti,
+			ExpiresAt: now.Add(AccessTokenTTL()).Unix(),
+			IssuedAt:  now.Unix(),
 		},
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(configs.AppConfigInstance.App.JWTSecret))
+	signed, err := token.SignedString([]byte(configs.AppConfigInstance.App.JWTSecret))
+	if err != nil {
+		return "", nil, err
+	}
+	return signed, claims, nil
+}
+
+func AccessTokenTTL() time.Duration {
+	if ttl := configs.AppConfigInstance.App.AccessTokenTTL; ttl > 0 {
+		return ttl
+	}
+	return defaultAccessTokenTTL
+}
+
+func RefreshTokenTTL() time.Duration {
+	if ttl := configs.AppConfigInstance.App.RefreshTokenTTL; ttl > 0 {
+		return ttl
+	}
+	return defaultRefreshTokenTTL
 }
 
 func ValidateToken(tokenString string) (*Claims, error) {
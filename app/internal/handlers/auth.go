@@ -1,43 +1,53 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
-	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/auth"
 	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
-	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/repositories"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/services"
 	"github.com/0sokrat0/GoGRAFFApi.git/app/pkg/utils"
 	"github.com/gin-gonic/gin"
 )
 
 // LoginInput описывает входные данные для входа пользователя
 type LoginInput struct {
-	Username string `json:"username" binding:"required"`
+	Username string `json:"username" binding:"required"` // Username или Email сотрудника
 	Password string `json:"password" binding:"required"`
 }
 
-// RegisterInput описывает входные данные для регистрации пользователя
+// RegisterInput описывает входные данные для регистрации первого сотрудника (владельца)
 type RegisterInput struct {
 	Username string `json:"username" binding:"required"`
 	Password string `json:"password" binding:"required"`
+	Email    string `json:"email"`
+}
+
+// RefreshInput описывает refresh-токен для обновления или завершения сессии
+type RefreshInput struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
 }
 
 type AuthHandler struct {
-	repo *repositories.AuthUserRepository
+	UserService services.UserService
+	AuthService services.AuthService
 }
 
-func NewAuthHandler(repo *repositories.AuthUserRepository) *AuthHandler {
-	return &AuthHandler{repo: repo}
+func NewAuthHandler(userService services.UserService, authService services.AuthService) *AuthHandler {
+	return &AuthHandler{
+		UserService: userService,
+		AuthService: authService,
+	}
 }
 
 // LoginHandler handles user login
 // @Summary User login
-// @Description Authenticates the user and returns a JWT token
+// @Description Authenticates the staff user by username or email and returns an access token (JWT with the user ID and role) and a refresh token
 // @Tags Authentication
 // @Accept json
 // @Produce json
 // @Param input body LoginInput true "User credentials"
-// @Success 200 {object} map[string]interface{} "JWT token"
+// @Success 200 {object} services.TokenPair
 // @Failure 400 {object} map[string]interface{} "Invalid input"
 // @Failure 401 {object} map[string]interface{} "Invalid credentials"
 // @Router /auth/login [post]
@@ -49,38 +59,92 @@ func (h *AuthHandler) LoginHandler(c *gin.Context) {
 		return
 	}
 
-	user, err := h.repo.FindByUsername(input.Username)
+	tokens, err := h.AuthService.Login(input.Username, input.Password)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, utils.ErrorResponse("User not found"))
+		if errors.Is(err, services.ErrInvalidCredentials) {
+			c.JSON(http.StatusUnauthorized, utils.ErrorResponse("Invalid credentials"))
+		} else {
+			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to generate token"))
+		}
 		return
 	}
 
-	if !auth.CheckPassword(user.Password, input.Password) {
-		c.JSON(http.StatusUnauthorized, utils.ErrorResponse("Invalid password"))
+	c.JSON(http.StatusOK, utils.SuccessResponse(tokens))
+}
+
+// RefreshHandler rotates the refresh token
+// @Summary Refresh session
+// @Description Exchanges a refresh token for a new access/refresh token pair. The used refresh token is revoked; reusing it revokes the whole session
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param input body RefreshInput true "Refresh token"
+// @Success 200 {object} services.TokenPair
+// @Failure 400 {object} map[string]interface{} "Invalid input"
+// @Failure 401 {object} map[string]interface{} "Invalid refresh token"
+// @Router /auth/refresh [post]
+func (h *AuthHandler) RefreshHandler(c *gin.Context) {
+	var input RefreshInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid input"))
 		return
 	}
 
-	token, err := auth.GenerateToken(user.ID)
+	tokens, err := h.AuthService.Refresh(input.RefreshToken)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to generate token"))
+		if errors.Is(err, services.ErrInvalidRefreshToken) {
+			c.JSON(http.StatusUnauthorized, utils.ErrorResponse("Invalid refresh token"))
+		} else {
+			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to refresh token"))
+		}
 		return
 	}
 
-	c.JSON(http.StatusOK, utils.SuccessResponse(map[string]interface{}{
-		"token": token,
-	}))
+	c.JSON(http.StatusOK, utils.SuccessResponse(tokens))
 }
 
-// RegisterHandler handles user registration
-// @Summary Register a new user
-// @Description Creates a new user account
+// LogoutHandler ends the current session
+// @Summary Logout
+// @Security BearerAuth
+// @Description Revokes the current access token and the session of the given refresh token
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param input body RefreshInput false "Refresh token of the session"
+// @Success 200 {string} string "Logged out"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 500 {object} map[string]interface{} "Failed to logout"
+// @Router /auth/logout [post]
+func (h *AuthHandler) LogoutHandler(c *gin.Context) {
+	// Тело запроса необязательно: без refresh-токена отзывается только текущий access-токен
+	var input RefreshInput
+	_ = c.ShouldBindJSON(&input)
+
+	err := h.AuthService.Logout(
+		c.GetInt("user_id"),
+		input.RefreshToken,
+		c.GetString("jti"),
+		c.GetTime("token_expires_at"),
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to logout"))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Logged out"))
+}
+
+// RegisterHandler handles owner registration
+// @Summary Register the owner account
+// @Description Creates the first staff user with the owner role. Available only while no users exist and only in single-tenant mode; other staff are created via /users
 // @Tags Authentication
 // @Accept json
 // @Produce json
 // @Param input body RegisterInput true "User registration data"
 // @Success 201 {string} string "User registered successfully"
 // @Failure 400 {object} map[string]interface{} "Invalid input"
-// @Failure 409 {object} map[string]interface{} "Username already exists"
+// @Failure 403 {object} map[string]interface{} "Registration is closed"
 // @Failure 500 {object} map[string]interface{} "Failed to register user"
 // @Router /auth/register [post]
 func (h *AuthHandler) RegisterHandler(c *gin.Context) {
@@ -91,25 +155,20 @@ func (h *AuthHandler) RegisterHandler(c *gin.Context) {
 		return
 	}
 
-	_, err := h.repo.FindByUsername(input.Username)
-	if err == nil {
-		c.JSON(http.StatusConflict, utils.ErrorResponse("Username already exists"))
-		return
-	}
-
-	hashedPassword, err := auth.HashPassword(input.Password)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to hash password"))
-		return
-	}
-
-	newUser := &models.AuthUser{
-		Username: input.Username,
-		Password: hashedPassword,
+	newUser := &models.User{
+		Username:     input.Username,
+		PasswordHash: input.Password,
+		Email:        input.Email,
 	}
 
-	if err := h.repo.Create(newUser); err != nil {
-		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to register user"))
+	if err := h.UserService.RegisterOwner(newUser); err != nil {
+		if errors.Is(err, services.ErrRegistrationClosed) {
+			c.JSON(http.StatusForbidden, utils.ErrorResponse("Registration is closed"))
+		} else if errors.Is(err, models.ErrInvalidEmail) {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid email"))
+		} else {
+			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to register user"))
+		}
 		return
 	}
 
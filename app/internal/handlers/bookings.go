@@ -1,12 +1,17 @@
 package handlers
 
 import (
+	"errors"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/middleware"
 	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/repositories"
 	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/services"
 	"github.com/0sokrat0/GoGRAFFApi.git/app/pkg/utils"
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 )
 
 type BookingHandler struct {
@@ -20,7 +25,7 @@ func NewBookingHandler(bookingService services.BookingService) *BookingHandler {
 }
 
 // @Summary Создать бронирование
-// @Description Создает новое бронирование, если слот времени не занят
+// @Description Создает новое бронирование, если интервал (время + суммарная длительность услуг) укладывается в рабочие часы сотрудника, не попадает на перерыв и не пересекается с другими бронированиями. Несколько услуг передаются позициями items: [{"service_id": 1}, {"service_id": 2}] и выполняются подряд
 // @Security BearerAuth
 // @Tags Бронирования
 // @Accept json
@@ -28,7 +33,8 @@ func NewBookingHandler(bookingService services.BookingService) *BookingHandler {
 // @Param booking body models.Bookings true "Данные бронирования"
 // @Success 201 {object} models.Bookings
 // @Failure 400 {object} map[string]interface{} "Некорректный запрос"
-// @Failure 409 {object} map[string]interface{} "Слот времени уже занят"
+// @Failure 409 {object} map[string]interface{} "Слот времени пересекается с другими бронированиями или перерывом"
+// @Failure 422 {object} map[string]interface{} "Время вне рабочих часов сотрудника или сотрудник не выполняет услугу"
 // @Failure 500 {object} map[string]interface{} "Ошибка сервера"
 // @Router /bookings [post]
 func (h *BookingHandler) CreateBookingHandler(c *gin.Context) {
@@ -38,10 +44,35 @@ func (h *BookingHandler) CreateBookingHandler(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректные данные: "+err.Error()))
 		return
 	}
+	// История статусов и связанные записи не задаются при создании: история пишется только переходами статуса
+	booking.StatusHistory = nil
+	booking.Client, booking.Service, booking.User = models.Client{}, models.Service{}, models.User{}
 
-	if err := h.BookingService.CreateBooking(&booking); err != nil {
-		if err == services.ErrTimeSlotOccupied {
-			c.JSON(http.StatusConflict, utils.ErrorResponse("Временной слот уже занят"))
+	// Барбер может записывать клиентов только к себе
+	if staffID, restricted := middleware.BarberScope(c); restricted {
+		if booking.UserID == 0 {
+			booking.UserID = staffID
+		}
+		if booking.UserID != staffID {
+			c.JSON(http.StatusForbidden, utils.ErrorResponse("Недостаточно прав"))
+			return
+		}
+	}
+
+	if err := h.BookingService.CreateBooking(c.GetInt("user_id"), &booking); err != nil {
+		var conflict *services.BookingConflictError
+		if errors.As(err, &conflict) {
+			c.JSON(http.StatusConflict, utils.ErrorResponseWithData("Временной слот уже занят", conflict))
+		} else if errors.Is(err, services.ErrDuringBreak) {
+			c.JSON(http.StatusConflict, utils.ErrorResponse("Время бронирования попадает на перерыв сотрудника"))
+		} else if errors.Is(err, services.ErrOutsideWorkingHours) {
+			c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse("Время бронирования вне рабочих часов сотрудника"))
+		} else if errors.Is(err, services.ErrBarberNotAssigned) {
+			c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse("Сотрудник не выполняет эту услугу"))
+		} else if errors.Is(err, models.ErrCurrencyMismatch) {
+			c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse("Цены услуг визита заданы в разных валютах"))
+		} else if errors.Is(err, repositories.ErrServiceNotFound) {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse("Услуга не найдена"))
 		} else {
 			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Не удалось создать бронирование"))
 		}
@@ -70,7 +101,7 @@ func (h *BookingHandler) GetBookingHandler(c *gin.Context) {
 
 	booking, err := h.BookingService.GetBookingByID(id)
 	if err != nil {
-		if err == services.ErrBookingNotFound {
+		if errors.Is(err, repositories.ErrBookingNotFound) {
 			c.JSON(http.StatusNotFound, utils.ErrorResponse("Бронирование не найдено"))
 		} else {
 			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Ошибка при получении бронирования"))
@@ -78,6 +109,11 @@ func (h *BookingHandler) GetBookingHandler(c *gin.Context) {
 		return
 	}
 
+	if staffID, restricted := middleware.BarberScope(c); restricted && booking.UserID != staffID {
+		c.JSON(http.StatusForbidden, utils.ErrorResponse("Недостаточно прав"))
+		return
+	}
+
 	c.JSON(http.StatusOK, utils.SuccessResponse(booking))
 }
 
@@ -86,16 +122,33 @@ func (h *BookingHandler) GetBookingHandler(c *gin.Context) {
 // @Description Получает список всех бронирований
 // @Tags Бронирования
 // @Produce json
+// @Param limit query int false "Размер страницы (по умолчанию 50, не более 200)"
+// @Param offset query int false "Смещение"
+// @Param cursor query string false "Курсор следующей страницы из meta.next_cursor"
+// @Param sort query string false "Сортировка через запятую, '-' — по убыванию: booking_time, status, created_at, id"
+// @Param status query string false "Статус бронирования"
+// @Param user_id query int false "ID сотрудника"
+// @Param client_id query int false "ID клиента"
+// @Param service_id query int false "ID услуги"
+// @Param from query string false "Начало периода по booking_time (RFC 3339 или YYYY-MM-DD)"
+// @Param to query string false "Конец периода по booking_time, не включается"
+// @Param location_id query int false "ID филиала"
 // @Success 200 {array} models.Bookings
+// @Failure 400 {object} map[string]interface{} "Некорректные параметры списка"
 // @Failure 500 {object} map[string]interface{} "Ошибка сервера"
 // @Router /bookings [get]
 func (h *BookingHandler) GetAllBookingsHandler(c *gin.Context) {
-	bookings, err := h.BookingService.GetAllBookings()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Не удалось получить список бронирований"))
+	query, ok := parseListQuery(c)
+	if !ok {
 		return
 	}
-	c.JSON(http.StatusOK, utils.SuccessResponse(bookings))
+	// Барбер видит только свои бронирования
+	if staffID, restricted := middleware.BarberScope(c); restricted {
+		query.Filters["user_id"] = strconv.Itoa(staffID)
+	}
+
+	bookings, total, err := h.BookingService.GetAllBookings(query)
+	respondList(c, bookings, query, total, err, "Не удалось получить список бронирований")
 }
 
 // @Summary Обновить бронирование
@@ -109,6 +162,8 @@ func (h *BookingHandler) GetAllBookingsHandler(c *gin.Context) {
 // @Success 200 {object} models.Bookings
 // @Failure 400 {object} map[string]interface{} "Некорректный запрос"
 // @Failure 404 {object} map[string]interface{} "Бронирование не найдено"
+// @Failure 409 {object} map[string]interface{} "Пересечение с другими бронированиями или перерывом, либо бронирование уже завершено, отменено или пропущено"
+// @Failure 422 {object} map[string]interface{} "Время вне рабочих часов сотрудника или сотрудник не выполняет услугу"
 // @Failure 500 {object} map[string]interface{} "Ошибка сервера"
 // @Router /bookings/{id} [put]
 func (h *BookingHandler) UpdateBookingHandler(c *gin.Context) {
@@ -124,9 +179,32 @@ func (h *BookingHandler) UpdateBookingHandler(c *gin.Context) {
 		return
 	}
 
-	if err := h.BookingService.UpdateBooking(id, &input); err != nil {
-		if err == services.ErrBookingNotFound {
+	if !h.authorizeBooking(c, id) {
+		return
+	}
+	if staffID, restricted := middleware.BarberScope(c); restricted && input.UserID != staffID {
+		c.JSON(http.StatusForbidden, utils.ErrorResponse("Недостаточно прав"))
+		return
+	}
+
+	if err := h.BookingService.UpdateBooking(c.GetInt("user_id"), id, &input); err != nil {
+		var conflict *services.BookingConflictError
+		if errors.Is(err, repositories.ErrBookingNotFound) {
 			c.JSON(http.StatusNotFound, utils.ErrorResponse("Бронирование не найдено"))
+		} else if errors.Is(err, services.ErrBookingClosed) {
+			c.JSON(http.StatusConflict, utils.ErrorResponse("Завершенное, отмененное или пропущенное бронирование нельзя изменить"))
+		} else if errors.As(err, &conflict) {
+			c.JSON(http.StatusConflict, utils.ErrorResponseWithData("Временной слот уже занят", conflict))
+		} else if errors.Is(err, services.ErrDuringBreak) {
+			c.JSON(http.StatusConflict, utils.ErrorResponse("Время бронирования попадает на перерыв сотрудника"))
+		} else if errors.Is(err, services.ErrOutsideWorkingHours) {
+			c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse("Время бронирования вне рабочих часов сотрудника"))
+		} else if errors.Is(err, services.ErrBarberNotAssigned) {
+			c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse("Сотрудник не выполняет эту услугу"))
+		} else if errors.Is(err, models.ErrCurrencyMismatch) {
+			c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse("Цены услуг визита заданы в разных валютах"))
+		} else if errors.Is(err, repositories.ErrServiceNotFound) {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse("Услуга не найдена"))
 		} else {
 			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Не удалось обновить бронирование"))
 		}
@@ -152,8 +230,16 @@ func (h *BookingHandler) DeleteBookingHandler(c *gin.Context) {
 		return
 	}
 
-	if err := h.BookingService.DeleteBooking(id); err != nil {
-		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Не удалось удалить бронирование"))
+	if !h.authorizeBooking(c, id) {
+		return
+	}
+
+	if err := h.BookingService.DeleteBooking(c.GetInt("user_id"), id); err != nil {
+		if errors.Is(err, repositories.ErrBookingNotFound) {
+			c.JSON(http.StatusNotFound, utils.ErrorResponse("Бронирование не найдено"))
+		} else {
+			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Не удалось удалить бронирование"))
+		}
 		return
 	}
 
@@ -162,20 +248,23 @@ func (h *BookingHandler) DeleteBookingHandler(c *gin.Context) {
 
 // @Summary Проверить доступность бронирования
 // @Security BearerAuth
-// @Description Проверяет, доступен ли временной слот для пользователя
+// @Description Проверяет, что визит из услуг, начинающийся в booking_time, не пересекается с активными бронированиями сотрудника. Длительность визита — сумма длительностей услуг у сотрудника в филиале
 // @Tags Бронирования
 // @Produce json
 // @Param user_id query int true "ID пользователя"
-// @Param booking_time query string true "Время бронирования"
+// @Param booking_time query string true "Время бронирования в формате RFC 3339 со смещением, например 2025-03-14T15:30:00+03:00"
+// @Param service_id query []int true "ID услуг визита: service_id=1&service_id=2 или service_id=1,2" collectionFormat(multi)
+// @Param location_id query int false "ID филиала"
 // @Success 200 {object} map[string]interface{} "Доступность слота"
 // @Failure 400 {object} map[string]interface{} "Некорректный запрос"
+// @Failure 422 {object} map[string]interface{} "Сотрудник не выполняет эту услугу"
 // @Failure 500 {object} map[string]interface{} "Ошибка сервера"
 // @Router /bookings/availability [get]
 func (h *BookingHandler) CheckBookingAvailabilityHandler(c *gin.Context) {
 	userIDStr := c.Query("user_id")
-	bookingTime := c.Query("booking_time")
+	bookingTimeStr := c.Query("booking_time")
 
-	if userIDStr == "" || bookingTime == "" {
+	if userIDStr == "" || bookingTimeStr == "" {
 		c.JSON(http.StatusBadRequest, utils.ErrorResponse("user_id и booking_time обязательны"))
 		return
 	}
@@ -185,16 +274,148 @@ func (h *BookingHandler) CheckBookingAvailabilityHandler(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректный user_id"))
 		return
 	}
+	bookingTime, err := time.Parse(time.RFC3339, bookingTimeStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректный booking_time, ожидается RFC 3339 со смещением"))
+		return
+	}
+
+	serviceIDs, err := queryServiceIDs(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректный service_id"))
+		return
+	}
+	locationID, ok := queryLocationID(c)
+	if !ok {
+		return
+	}
 
-	available, err := h.BookingService.CheckAvailability(userID, bookingTime)
+	available, err := h.BookingService.CheckAvailability(locationID, userID, serviceIDs, bookingTime)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Ошибка при проверке доступности"))
+		if errors.Is(err, repositories.ErrServiceNotFound) {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse("Услуга не найдена"))
+		} else if errors.Is(err, services.ErrBarberNotAssigned) {
+			c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse("Сотрудник не выполняет эту услугу"))
+		} else {
+			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Ошибка при проверке доступности"))
+		}
 		return
 	}
 
 	c.JSON(http.StatusOK, utils.SuccessResponse(map[string]bool{"available": available}))
 }
 
+// @Summary Найти свободные слоты
+// @Security BearerAuth
+// @Description Возвращает все доступные времена начала визита из одной или нескольких услуг на указанную дату с учетом расписания, перерывов, бронирований и суммарной длительности услуг у сотрудника. Без user_id слоты считаются для всех сотрудников, которым назначены все услуги, без location_id — во всех филиалах
+// @Tags Бронирования
+// @Produce json
+// @Param location_id query int false "ID филиала; день отсчитывается в его часовом поясе"
+// @Param user_id query int false "ID сотрудника"
+// @Param service_id query []int true "ID услуг в порядке выполнения: service_id=1&service_id=2 или service_id=1,2" collectionFormat(multi)
+// @Param date query string true "Дата в формате YYYY-MM-DD"
+// @Success 200 {array} services.BarberSlots
+// @Failure 400 {object} map[string]interface{} "Некорректный запрос"
+// @Failure 422 {object} map[string]interface{} "Сотрудник не выполняет эту услугу"
+// @Failure 500 {object} map[string]interface{} "Ошибка сервера"
+// @Router /bookings/slots [get]
+func (h *BookingHandler) GetFreeSlotsHandler(c *gin.Context) {
+	serviceIDs, err := queryServiceIDs(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректный service_id"))
+		return
+	}
+
+	date, err := time.Parse("2006-01-02", c.Query("date"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректная дата, ожидается формат YYYY-MM-DD"))
+		return
+	}
+
+	var userID int
+	if userIDStr := c.Query("user_id"); userIDStr != "" {
+		userID, err = strconv.Atoi(userIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректный user_id"))
+			return
+		}
+	}
+
+	locationID, ok := queryLocationID(c)
+	if !ok {
+		return
+	}
+
+	slots, err := h.BookingService.FindFreeSlots(locationID, userID, serviceIDs, date)
+	if err != nil {
+		if errors.Is(err, repositories.ErrServiceNotFound) {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse("Услуга не найдена"))
+		} else if errors.Is(err, services.ErrBarberNotAssigned) {
+			c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse("Сотрудник не выполняет эту услугу"))
+		} else {
+			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Не удалось получить свободные слоты"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse(slots))
+}
+
+// @Summary Выручка по сотрудникам
+// @Security BearerAuth
+// @Description Суммирует цены завершенных бронирований по сотрудникам за период с учетом индивидуальных цен. Барбер видит только свою выручку
+// @Tags Бронирования
+// @Produce json
+// @Param from query string true "Начало периода в формате YYYY-MM-DD"
+// @Param to query string true "Конец периода включительно в формате YYYY-MM-DD"
+// @Param location_id query int false "ID филиала; дни отсчитываются в его часовом поясе"
+// @Param user_id query int false "ID сотрудника"
+// @Success 200 {array} repositories.BarberRevenue
+// @Failure 400 {object} map[string]interface{} "Некорректный запрос"
+// @Failure 500 {object} map[string]interface{} "Ошибка сервера"
+// @Router /bookings/revenue [get]
+func (h *BookingHandler) GetRevenueHandler(c *gin.Context) {
+	from, err := time.Parse("2006-01-02", c.Query("from"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректная дата from, ожидается формат YYYY-MM-DD"))
+		return
+	}
+	to, err := time.Parse("2006-01-02", c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректная дата to, ожидается формат YYYY-MM-DD"))
+		return
+	}
+
+	var userID int
+	if userIDStr := c.Query("user_id"); userIDStr != "" {
+		userID, err = strconv.Atoi(userIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректный user_id"))
+			return
+		}
+	}
+	if staffID, restricted := middleware.BarberScope(c); restricted {
+		userID = staffID
+	}
+
+	locationID, ok := queryLocationID(c)
+	if !ok {
+		return
+	}
+
+	revenue, err := h.BookingService.RevenueByBarber(locationID, userID, from, to)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidRevenueRange) {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error()))
+		} else {
+			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Не удалось получить выручку"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse(revenue))
+}
+
 // @Summary Получить бронирования клиента
 // @Security BearerAuth
 // @Description Получает список бронирований по ID клиента
@@ -217,6 +438,9 @@ func (h *BookingHandler) GetBookingsByClientHandler(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Не удалось получить бронирования"))
 		return
 	}
+	if staffID, restricted := middleware.BarberScope(c); restricted {
+		bookings = filterBookingsByUser(bookings, staffID)
+	}
 	c.JSON(http.StatusOK, utils.SuccessResponse(bookings))
 }
 
@@ -242,6 +466,9 @@ func (h *BookingHandler) GetBookingsByServiceHandler(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Не удалось получить бронирования"))
 		return
 	}
+	if staffID, restricted := middleware.BarberScope(c); restricted {
+		bookings = filterBookingsByUser(bookings, staffID)
+	}
 	c.JSON(http.StatusOK, utils.SuccessResponse(bookings))
 }
 
@@ -262,6 +489,11 @@ func (h *BookingHandler) GetBookingsByUserHandler(c *gin.Context) {
 		return
 	}
 
+	if staffID, restricted := middleware.BarberScope(c); restricted && userID != staffID {
+		c.JSON(http.StatusForbidden, utils.ErrorResponse("Недостаточно прав"))
+		return
+	}
+
 	bookings, err := h.BookingService.GetBookingsByUserID(userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Не удалось получить бронирования"))
@@ -269,3 +501,162 @@ func (h *BookingHandler) GetBookingsByUserHandler(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, utils.SuccessResponse(bookings))
 }
+
+// @Summary Подтвердить бронирование
+// @Security BearerAuth
+// @Description Переводит бронирование из статуса pending в confirmed
+// @Tags Бронирования
+// @Produce json
+// @Param id path int true "ID бронирования"
+// @Success 200 {object} models.Bookings
+// @Failure 400 {object} map[string]interface{} "Некорректный ID"
+// @Failure 404 {object} map[string]interface{} "Бронирование не найдено"
+// @Failure 409 {object} map[string]interface{} "Недопустимый переход статуса"
+// @Failure 500 {object} map[string]interface{} "Ошибка сервера"
+// @Router /bookings/{id}/confirm [post]
+func (h *BookingHandler) ConfirmBookingHandler(c *gin.Context) {
+	h.changeStatus(c, models.BookingStatusConfirmed)
+}
+
+// @Summary Начать обслуживание
+// @Security BearerAuth
+// @Description Переводит подтвержденное бронирование в статус in_progress
+// @Tags Бронирования
+// @Produce json
+// @Param id path int true "ID бронирования"
+// @Success 200 {object} models.Bookings
+// @Failure 400 {object} map[string]interface{} "Некорректный ID"
+// @Failure 404 {object} map[string]interface{} "Бронирование не найдено"
+// @Failure 409 {object} map[string]interface{} "Недопустимый переход статуса"
+// @Failure 500 {object} map[string]interface{} "Ошибка сервера"
+// @Router /bookings/{id}/start [post]
+func (h *BookingHandler) StartBookingHandler(c *gin.Context) {
+	h.changeStatus(c, models.BookingStatusInProgress)
+}
+
+// @Summary Завершить бронирование
+// @Security BearerAuth
+// @Description Переводит бронирование из статуса in_progress в completed
+// @Tags Бронирования
+// @Produce json
+// @Param id path int true "ID бронирования"
+// @Success 200 {object} models.Bookings
+// @Failure 400 {object} map[string]interface{} "Некорректный ID"
+// @Failure 404 {object} map[string]interface{} "Бронирование не найдено"
+// @Failure 409 {object} map[string]interface{} "Недопустимый переход статуса"
+// @Failure 500 {object} map[string]interface{} "Ошибка сервера"
+// @Router /bookings/{id}/complete [post]
+func (h *BookingHandler) CompleteBookingHandler(c *gin.Context) {
+	h.changeStatus(c, models.BookingStatusCompleted)
+}
+
+// @Summary Отменить бронирование
+// @Security BearerAuth
+// @Description Отменяет бронирование в статусе pending или confirmed
+// @Tags Бронирования
+// @Produce json
+// @Param id path int true "ID бронирования"
+// @Success 200 {object} models.Bookings
+// @Failure 400 {object} map[string]interface{} "Некорректный ID"
+// @Failure 404 {object} map[string]interface{} "Бронирование не найдено"
+// @Failure 409 {object} map[string]interface{} "Недопустимый переход статуса"
+// @Failure 500 {object} map[string]interface{} "Ошибка сервера"
+// @Router /bookings/{id}/cancel [post]
+func (h *BookingHandler) CancelBookingHandler(c *gin.Context) {
+	h.changeStatus(c, models.BookingStatusCancelled)
+}
+
+// @Summary Отметить неявку
+// @Security BearerAuth
+// @Description Отмечает, что клиент не пришел на бронирование в статусе pending или confirmed
+// @Tags Бронирования
+// @Produce json
+// @Param id path int true "ID бронирования"
+// @Success 200 {object} models.Bookings
+// @Failure 400 {object} map[string]interface{} "Некорректный ID"
+// @Failure 404 {object} map[string]interface{} "Бронирование не найдено"
+// @Failure 409 {object} map[string]interface{} "Недопустимый переход статуса"
+// @Failure 500 {object} map[string]interface{} "Ошибка сервера"
+// @Router /bookings/{id}/no-show [post]
+func (h *BookingHandler) NoShowBookingHandler(c *gin.Context) {
+	h.changeStatus(c, models.BookingStatusNoShow)
+}
+
+// changeStatus выполняет переход статуса от имени пользователя из JWT
+func (h *BookingHandler) changeStatus(c *gin.Context, status string) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректный ID бронирования"))
+		return
+	}
+
+	if !h.authorizeBooking(c, id) {
+		return
+	}
+
+	booking, err := h.BookingService.ChangeStatus(id, status, c.GetInt("user_id"))
+	if err != nil {
+		if errors.Is(err, repositories.ErrBookingNotFound) {
+			c.JSON(http.StatusNotFound, utils.ErrorResponse("Бронирование не найдено"))
+		} else if errors.Is(err, services.ErrInvalidTransition) || errors.Is(err, repositories.ErrBookingStatusChanged) {
+			c.JSON(http.StatusConflict, utils.ErrorResponse(err.Error()))
+		} else {
+			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Не удалось изменить статус бронирования"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse(booking))
+}
+
+// authorizeBooking для барбера проверяет, что бронирование принадлежит ему.
+// Если доступ запрещен, ответ уже отправлен и возвращается false.
+func (h *BookingHandler) authorizeBooking(c *gin.Context, id int) bool {
+	staffID, restricted := middleware.BarberScope(c)
+	if !restricted {
+		return true
+	}
+
+	booking, err := h.BookingService.GetBookingByID(id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrBookingNotFound) {
+			c.JSON(http.StatusNotFound, utils.ErrorResponse("Бронирование не найдено"))
+		} else {
+			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Ошибка при получении бронирования"))
+		}
+		return false
+	}
+	if booking.UserID != staffID {
+		c.JSON(http.StatusForbidden, utils.ErrorResponse("Недостаточно прав"))
+		return false
+	}
+	return true
+}
+
+// queryServiceIDs разбирает service_id, повторенный в запросе или перечисленный через запятую
+func queryServiceIDs(c *gin.Context) ([]int, error) {
+	var ids []int
+	for _, value := range c.QueryArray("service_id") {
+		for _, raw := range strings.Split(value, ",") {
+			id, err := strconv.Atoi(strings.TrimSpace(raw))
+			if err != nil {
+				return nil, err
+			}
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, errors.New("не указаны услуги")
+	}
+	return ids, nil
+}
+
+func filterBookingsByUser(bookings []models.Bookings, userID int) []models.Bookings {
+	filtered := make([]models.Bookings, 0, len(bookings))
+	for _, booking := range bookings {
+		if booking.UserID == userID {
+			filtered = append(filtered, booking)
+		}
+	}
+	return filtered
+}
@@ -1,16 +1,22 @@
 package handlers
 
 import (
+	"errors"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/middleware"
 	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
 	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/repositories"
 	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/services"
 	"github.com/0sokrat0/GoGRAFFApi.git/app/pkg/utils"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// maxOccurrencesPeriod — наибольший период, за который раскрываются повторения перерывов
+const maxOccurrencesPeriod = 366 * 24 * time.Hour
+
 type BreakHandler struct {
 	BreakService services.BreakService
 }
@@ -22,7 +28,8 @@ func NewBreakHandler(breakService services.BreakService) *BreakHandler {
 }
 
 // @Summary Создать перерыв
-// @Description Создает новый перерыв
+// @Description Создает перерыв. Для повторяющегося перерыва укажите recurrence (daily, weekdays, weekly),
+// @Description для weekly — recurrence_days, и при необходимости recurrence_until (последний день повторения)
 // @Security BearerAuth
 // @Tags Перерывы
 // @Accept json
@@ -39,29 +46,129 @@ func (h *BreakHandler) CreateBreakHandler(c *gin.Context) {
 		return
 	}
 
-	if err := h.BreakService.CreateBreak(&breakModel); err != nil {
-		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Не удалось создать перерыв"))
+	if staffID, restricted := middleware.BarberScope(c); restricted && breakModel.UserID != staffID {
+		c.JSON(http.StatusForbidden, utils.ErrorResponse("Недостаточно прав"))
+		return
+	}
+
+	if err := h.BreakService.CreateBreak(c.GetInt("user_id"), &breakModel); err != nil {
+		respondBreakError(c, err, "Не удалось создать перерыв")
 		return
 	}
 
 	c.JSON(http.StatusCreated, utils.SuccessResponse(breakModel))
 }
 
+// @Summary Создать несколько перерывов
+// @Description Создает перерывы из списка в одной транзакции: при ошибке не создается ни один
+// @Security BearerAuth
+// @Tags Перерывы
+// @Accept json
+// @Produce json
+// @Param breaks body []models.Break true "Список перерывов"
+// @Success 201 {array} models.Break
+// @Failure 400 {object} map[string]interface{} "Некорректный запрос"
+// @Failure 403 {object} map[string]interface{} "Недостаточно прав"
+// @Failure 500 {object} map[string]interface{} "Ошибка сервера"
+// @Router /breaks/bulk [post]
+func (h *BreakHandler) CreateBreaksHandler(c *gin.Context) {
+	var breaks []models.Break
+	if err := c.ShouldBindJSON(&breaks); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректные данные: "+err.Error()))
+		return
+	}
+	if len(breaks) == 0 {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Список перерывов пуст"))
+		return
+	}
+
+	if staffID, restricted := middleware.BarberScope(c); restricted {
+		for _, b := range breaks {
+			if b.UserID != staffID {
+				c.JSON(http.StatusForbidden, utils.ErrorResponse("Недостаточно прав"))
+				return
+			}
+		}
+	}
+
+	if err := h.BreakService.CreateBreaks(c.GetInt("user_id"), breaks); err != nil {
+		respondBreakError(c, err, "Не удалось создать перерывы")
+		return
+	}
+
+	c.JSON(http.StatusCreated, utils.SuccessResponse(breaks))
+}
+
+// @Summary Вхождения перерывов за период
+// @Security BearerAuth
+// @Description Возвращает перерывы сотрудника в интервале [from, to) с раскрытыми повторениями
+// @Tags Перерывы
+// @Produce json
+// @Param user_id query int true "ID сотрудника"
+// @Param from query string true "Начало периода (RFC 3339 или YYYY-MM-DD)"
+// @Param to query string true "Конец периода (RFC 3339 или YYYY-MM-DD), не больше года после from"
+// @Success 200 {array} models.BreakOccurrence
+// @Failure 400 {object} map[string]interface{} "Некорректный запрос"
+// @Failure 403 {object} map[string]interface{} "Недостаточно прав"
+// @Failure 500 {object} map[string]interface{} "Ошибка сервера"
+// @Router /breaks/occurrences [get]
+func (h *BreakHandler) GetOccurrencesHandler(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Query("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректный ID пользователя"))
+		return
+	}
+
+	from, errFrom := parseQueryTime(c.Query("from"))
+	to, errTo := parseQueryTime(c.Query("to"))
+	if errFrom != nil || errTo != nil || !to.After(from) || to.Sub(from) > maxOccurrencesPeriod {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректный период: укажите from и to, не больше года"))
+		return
+	}
+
+	if staffID, restricted := middleware.BarberScope(c); restricted && userID != staffID {
+		c.JSON(http.StatusForbidden, utils.ErrorResponse("Недостаточно прав"))
+		return
+	}
+
+	occurrences, err := h.BreakService.Occurrences(userID, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Не удалось получить перерывы"))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse(occurrences))
+}
+
 // @Summary Получить все перерывы
 // @Security BearerAuth
 // @Description Возвращает список всех перерывов
 // @Tags Перерывы
 // @Produce json
+// @Param limit query int false "Размер страницы (по умолчанию 50, не более 200)"
+// @Param offset query int false "Смещение"
+// @Param cursor query string false "Курсор следующей страницы из meta.next_cursor"
+// @Param sort query string false "Сортировка через запятую, '-' — по убыванию: break_start, user_id, created_at, id"
+// @Param user_id query int false "ID сотрудника"
+// @Param from query string false "Начало периода по break_start"
+// @Param to query string false "Конец периода по break_start"
+// @Param location_id query int false "ID филиала"
 // @Success 200 {array} models.Break
+// @Failure 400 {object} map[string]interface{} "Некорректные параметры списка"
 // @Failure 500 {object} map[string]interface{} "Ошибка сервера"
 // @Router /breaks [get]
 func (h *BreakHandler) GetAllBreaksHandler(c *gin.Context) {
-	breaks, err := h.BreakService.GetAllBreaks()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Не удалось получить список перерывов"))
+	query, ok := parseListQuery(c)
+	if !ok {
 		return
 	}
-	c.JSON(http.StatusOK, utils.SuccessResponse(breaks))
+	// Барбер видит только свои перерывы
+	if staffID, restricted := middleware.BarberScope(c); restricted {
+		query.Filters["user_id"] = strconv.Itoa(staffID)
+	}
+
+	breaks, total, err := h.BreakService.GetAllBreaks(query)
+	respondList(c, breaks, query, total, err, "Не удалось получить список перерывов")
 }
 
 // @Summary Получить перерыв
@@ -91,12 +198,17 @@ func (h *BreakHandler) GetBreakHandler(c *gin.Context) {
 		return
 	}
 
+	if staffID, restricted := middleware.BarberScope(c); restricted && breakModel.UserID != staffID {
+		c.JSON(http.StatusForbidden, utils.ErrorResponse("Недостаточно прав"))
+		return
+	}
+
 	c.JSON(http.StatusOK, utils.SuccessResponse(breakModel))
 }
 
 // @Summary Обновить перерыв
 // @Security BearerAuth
-// @Description Обновляет данные перерыва по ID
+// @Description Обновляет данные перерыва по ID; для повторяющегося перерыва изменяется вся серия
 // @Tags Перерывы
 // @Accept json
 // @Produce json
@@ -120,12 +232,16 @@ func (h *BreakHandler) UpdateBreakHandler(c *gin.Context) {
 		return
 	}
 
-	if err := h.BreakService.UpdateBreak(id, &input); err != nil {
-		if err == repositories.ErrBreakNotFound {
-			c.JSON(http.StatusNotFound, utils.ErrorResponse("Перерыв не найден"))
-		} else {
-			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Не удалось обновить перерыв"))
-		}
+	if !h.authorizeBreak(c, id) {
+		return
+	}
+	if staffID, restricted := middleware.BarberScope(c); restricted && input.UserID != staffID {
+		c.JSON(http.StatusForbidden, utils.ErrorResponse("Недостаточно прав"))
+		return
+	}
+
+	if err := h.BreakService.UpdateBreak(c.GetInt("user_id"), id, &input); err != nil {
+		respondBreakError(c, err, "Не удалось обновить перерыв")
 		return
 	}
 
@@ -134,7 +250,7 @@ func (h *BreakHandler) UpdateBreakHandler(c *gin.Context) {
 
 // @Summary Удалить перерыв
 // @Security BearerAuth
-// @Description Удаляет перерыв по ID
+// @Description Удаляет перерыв по ID; для повторяющегося перерыва удаляется вся серия вместе с измененными вхождениями
 // @Tags Перерывы
 // @Param id path int true "ID перерыва"
 // @Success 200 {object} map[string]interface{} "Сообщение об успешном удалении"
@@ -148,10 +264,133 @@ func (h *BreakHandler) DeleteBreakHandler(c *gin.Context) {
 		return
 	}
 
-	if err := h.BreakService.DeleteBreak(id); err != nil {
-		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Не удалось удалить перерыв"))
+	if !h.authorizeBreak(c, id) {
+		return
+	}
+
+	if err := h.BreakService.DeleteBreak(c.GetInt("user_id"), id); err != nil {
+		if err == repositories.ErrBreakNotFound {
+			c.JSON(http.StatusNotFound, utils.ErrorResponse("Перерыв не найден"))
+		} else {
+			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Не удалось удалить перерыв"))
+		}
 		return
 	}
 
 	c.JSON(http.StatusOK, utils.SuccessResponse("Перерыв успешно удалён"))
 }
+
+// @Summary Изменить одно вхождение перерыва
+// @Security BearerAuth
+// @Description Переносит вхождение повторяющегося перерыва в дату date: оно исключается из серии и заменяется отдельным перерывом с новыми break_start и break_end
+// @Tags Перерывы
+// @Accept json
+// @Produce json
+// @Param id path int true "ID серии"
+// @Param date path string true "Дата вхождения (YYYY-MM-DD)"
+// @Param break body models.Break true "Новое время вхождения"
+// @Success 200 {object} models.Break
+// @Failure 400 {object} map[string]interface{} "Некорректный запрос или нет вхождения в эту дату"
+// @Failure 404 {object} map[string]interface{} "Перерыв не найден"
+// @Failure 409 {object} map[string]interface{} "Вхождение уже удалено или изменено"
+// @Failure 500 {object} map[string]interface{} "Ошибка сервера"
+// @Router /breaks/{id}/occurrences/{date} [put]
+func (h *BreakHandler) UpdateOccurrenceHandler(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректный ID перерыва"))
+		return
+	}
+
+	var input models.Break
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректные данные: "+err.Error()))
+		return
+	}
+
+	if !h.authorizeBreak(c, id) {
+		return
+	}
+
+	replacement, err := h.BreakService.UpdateOccurrence(c.GetInt("user_id"), id, c.Param("date"), &input)
+	if err != nil {
+		respondBreakError(c, err, "Не удалось изменить вхождение перерыва")
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse(replacement))
+}
+
+// @Summary Удалить одно вхождение перерыва
+// @Security BearerAuth
+// @Description Удаляет вхождение повторяющегося перерыва в дату date, остальные вхождения серии сохраняются
+// @Tags Перерывы
+// @Param id path int true "ID серии"
+// @Param date path string true "Дата вхождения (YYYY-MM-DD)"
+// @Success 200 {object} map[string]interface{} "Сообщение об успешном удалении"
+// @Failure 400 {object} map[string]interface{} "Некорректный запрос или нет вхождения в эту дату"
+// @Failure 404 {object} map[string]interface{} "Перерыв не найден"
+// @Failure 409 {object} map[string]interface{} "Вхождение уже удалено или изменено"
+// @Failure 500 {object} map[string]interface{} "Ошибка сервера"
+// @Router /breaks/{id}/occurrences/{date} [delete]
+func (h *BreakHandler) DeleteOccurrenceHandler(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректный ID перерыва"))
+		return
+	}
+
+	if !h.authorizeBreak(c, id) {
+		return
+	}
+
+	if err := h.BreakService.DeleteOccurrence(c.GetInt("user_id"), id, c.Param("date")); err != nil {
+		respondBreakError(c, err, "Не удалось удалить вхождение перерыва")
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Вхождение перерыва успешно удалено"))
+}
+
+// respondBreakError отвечает на ошибку операции с перерывом
+func respondBreakError(c *gin.Context, err error, failMessage string) {
+	switch {
+	case errors.Is(err, repositories.ErrBreakNotFound):
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Перерыв не найден"))
+	case errors.Is(err, repositories.ErrBreakExceptionConflict):
+		c.JSON(http.StatusConflict, utils.ErrorResponse(err.Error()))
+	case errors.Is(err, services.ErrInvalidBreakTime),
+		errors.Is(err, services.ErrInvalidRecurrence),
+		errors.Is(err, services.ErrRecurringBreakTooLong),
+		errors.Is(err, services.ErrNotRecurringBreak),
+		errors.Is(err, services.ErrNotBreakOccurrence),
+		errors.Is(err, services.ErrInvalidBreakDate):
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error()))
+	default:
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse(failMessage))
+	}
+}
+
+// authorizeBreak для барбера проверяет, что перерыв принадлежит ему.
+// Если доступ запрещен, ответ уже отправлен и возвращается false.
+func (h *BreakHandler) authorizeBreak(c *gin.Context, id int) bool {
+	staffID, restricted := middleware.BarberScope(c)
+	if !restricted {
+		return true
+	}
+
+	breakModel, err := h.BreakService.GetBreakByID(id)
+	if err != nil {
+		if err == repositories.ErrBreakNotFound {
+			c.JSON(http.StatusNotFound, utils.ErrorResponse("Перерыв не найден"))
+		} else {
+			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Ошибка при получении перерыва"))
+		}
+		return false
+	}
+	if breakModel.UserID != staffID {
+		c.JSON(http.StatusForbidden, utils.ErrorResponse("Недостаточно прав"))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,316 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/repositories"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/services"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/pkg/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ClientNoteInput — текст заметки о клиенте
+type ClientNoteInput struct {
+	Text string `json:"text" binding:"required"`
+}
+
+// ClientFieldInput — описание дополнительного поля; key и type задаются только при создании
+type ClientFieldInput struct {
+	Key      string   `json:"key"`
+	Label    string   `json:"label" binding:"required"`
+	Type     string   `json:"type"`
+	Options  []string `json:"options"`
+	Required bool     `json:"required"`
+	Position int      `json:"position"`
+}
+
+func (in ClientFieldInput) toModel() *models.ClientField {
+	return &models.ClientField{
+		Key:      in.Key,
+		Label:    in.Label,
+		Type:     in.Type,
+		Options:  in.Options,
+		Required: in.Required,
+		Position: in.Position,
+	}
+}
+
+// @Summary Теги клиентов
+// @Security BearerAuth
+// @Description Возвращает теги, которые используются у клиентов, с числом клиентов по убыванию
+// @Tags Клиенты
+// @Produce json
+// @Success 200 {array} repositories.TagUsage
+// @Failure 500 {object} map[string]interface{} "Ошибка сервера"
+// @Router /clients/tags [get]
+func (h *ClientHandler) GetClientTagsHandler(c *gin.Context) {
+	tags, err := h.ClientService.GetTagUsage()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Не удалось получить теги клиентов"))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse(tags))
+}
+
+// @Summary Заметки о клиенте
+// @Security BearerAuth
+// @Description Возвращает заметки сотрудников о клиенте, новые первыми
+// @Tags Клиенты
+// @Produce json
+// @Param id path int true "ID клиента"
+// @Success 200 {array} models.ClientNote
+// @Failure 400 {object} map[string]interface{} "Некорректный запрос"
+// @Failure 404 {object} map[string]interface{} "Клиент не найден"
+// @Router /clients/{id}/notes [get]
+func (h *ClientHandler) GetClientNotesHandler(c *gin.Context) {
+	clientID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректный ID клиента"))
+		return
+	}
+
+	notes, err := h.ClientService.GetClientNotes(clientID)
+	if err != nil {
+		respondClientNoteError(c, err, "Не удалось получить заметки")
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse(notes))
+}
+
+// @Summary Добавить заметку о клиенте
+// @Security BearerAuth
+// @Description Добавляет заметку, например «аллергия на средство X» или «предпочитает насадку №2»; автор — текущий сотрудник
+// @Tags Клиенты
+// @Accept json
+// @Produce json
+// @Param id path int true "ID клиента"
+// @Param note body ClientNoteInput true "Текст заметки"
+// @Success 201 {object} models.ClientNote
+// @Failure 400 {object} map[string]interface{} "Некорректный запрос"
+// @Failure 404 {object} map[string]interface{} "Клиент не найден"
+// @Router /clients/{id}/notes [post]
+func (h *ClientHandler) AddClientNoteHandler(c *gin.Context) {
+	clientID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректный ID клиента"))
+		return
+	}
+
+	var input ClientNoteInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректные данные: "+err.Error()))
+		return
+	}
+
+	note, err := h.ClientService.AddClientNote(c.GetInt("user_id"), clientID, input.Text)
+	if err != nil {
+		respondClientNoteError(c, err, "Не удалось добавить заметку")
+		return
+	}
+
+	c.JSON(http.StatusCreated, utils.SuccessResponse(note))
+}
+
+// @Summary Изменить заметку о клиенте
+// @Security BearerAuth
+// @Tags Клиенты
+// @Accept json
+// @Produce json
+// @Param id path int true "ID клиента"
+// @Param note_id path int true "ID заметки"
+// @Param note body ClientNoteInput true "Текст заметки"
+// @Success 200 {object} models.ClientNote
+// @Failure 400 {object} map[string]interface{} "Некорректный запрос"
+// @Failure 404 {object} map[string]interface{} "Заметка не найдена"
+// @Router /clients/{id}/notes/{note_id} [put]
+func (h *ClientHandler) UpdateClientNoteHandler(c *gin.Context) {
+	clientID, noteID, ok := clientNoteIDs(c)
+	if !ok {
+		return
+	}
+
+	var input ClientNoteInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректные данные: "+err.Error()))
+		return
+	}
+
+	note, err := h.ClientService.UpdateClientNote(c.GetInt("user_id"), clientID, noteID, input.Text)
+	if err != nil {
+		respondClientNoteError(c, err, "Не удалось изменить заметку")
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse(note))
+}
+
+// @Summary Удалить заметку о клиенте
+// @Security BearerAuth
+// @Tags Клиенты
+// @Param id path int true "ID клиента"
+// @Param note_id path int true "ID заметки"
+// @Success 200 {object} map[string]interface{} "Заметка удалена"
+// @Failure 400 {object} map[string]interface{} "Некорректный запрос"
+// @Failure 404 {object} map[string]interface{} "Заметка не найдена"
+// @Router /clients/{id}/notes/{note_id} [delete]
+func (h *ClientHandler) DeleteClientNoteHandler(c *gin.Context) {
+	clientID, noteID, ok := clientNoteIDs(c)
+	if !ok {
+		return
+	}
+
+	if err := h.ClientService.DeleteClientNote(c.GetInt("user_id"), clientID, noteID); err != nil {
+		respondClientNoteError(c, err, "Не удалось удалить заметку")
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Заметка удалена"))
+}
+
+// clientNoteIDs читает ID клиента и заметки из пути; при ошибке отвечает 400
+func clientNoteIDs(c *gin.Context) (int, int, bool) {
+	clientID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректный ID клиента"))
+		return 0, 0, false
+	}
+	noteID, err := strconv.Atoi(c.Param("note_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректный ID заметки"))
+		return 0, 0, false
+	}
+	return clientID, noteID, true
+}
+
+func respondClientNoteError(c *gin.Context, err error, failMessage string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidNote):
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error()))
+	case errors.Is(err, repositories.ErrClientNotFound):
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Клиент не найден"))
+	case errors.Is(err, repositories.ErrClientNoteNotFound):
+		c.JSON(http.StatusNotFound, utils.ErrorResponse(err.Error()))
+	default:
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse(failMessage))
+	}
+}
+
+// @Summary Дополнительные поля клиентов
+// @Security BearerAuth
+// @Description Возвращает дополнительные поля карточки клиента, настроенные салоном, в порядке position
+// @Tags Клиенты
+// @Produce json
+// @Success 200 {array} models.ClientField
+// @Failure 500 {object} map[string]interface{} "Ошибка сервера"
+// @Router /clients/fields [get]
+func (h *ClientHandler) GetClientFieldsHandler(c *gin.Context) {
+	fields, err := h.ClientService.GetClientFields()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Не удалось получить дополнительные поля"))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse(fields))
+}
+
+// @Summary Создать дополнительное поле клиентов
+// @Security BearerAuth
+// @Description Добавляет поле в карточку клиента. key — латинские буквы, цифры и _, type — text, number, boolean, date (YYYY-MM-DD) или select с вариантами options. Значения передаются в custom_fields клиента по key
+// @Tags Клиенты
+// @Accept json
+// @Produce json
+// @Param field body ClientFieldInput true "Описание поля"
+// @Success 201 {object} models.ClientField
+// @Failure 400 {object} map[string]interface{} "Некорректный запрос"
+// @Failure 409 {object} map[string]interface{} "Поле с таким ключом уже существует"
+// @Router /clients/fields [post]
+func (h *ClientHandler) CreateClientFieldHandler(c *gin.Context) {
+	var input ClientFieldInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректные данные: "+err.Error()))
+		return
+	}
+
+	field := input.toModel()
+	if err := h.ClientService.CreateClientField(c.GetInt("user_id"), field); err != nil {
+		respondClientFieldError(c, err, "Не удалось создать дополнительное поле")
+		return
+	}
+
+	c.JSON(http.StatusCreated, utils.SuccessResponse(field))
+}
+
+// @Summary Изменить дополнительное поле клиентов
+// @Security BearerAuth
+// @Description Меняет название, варианты, обязательность и порядок поля; key и type не меняются
+// @Tags Клиенты
+// @Accept json
+// @Produce json
+// @Param id path int true "ID поля"
+// @Param field body ClientFieldInput true "Описание поля"
+// @Success 200 {object} models.ClientField
+// @Failure 400 {object} map[string]interface{} "Некорректный запрос"
+// @Failure 404 {object} map[string]interface{} "Поле не найдено"
+// @Router /clients/fields/{id} [put]
+func (h *ClientHandler) UpdateClientFieldHandler(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректный ID поля"))
+		return
+	}
+
+	var input ClientFieldInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректные данные: "+err.Error()))
+		return
+	}
+
+	field, err := h.ClientService.UpdateClientField(c.GetInt("user_id"), id, input.toModel())
+	if err != nil {
+		respondClientFieldError(c, err, "Не удалось изменить дополнительное поле")
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse(field))
+}
+
+// @Summary Удалить дополнительное поле клиентов
+// @Security BearerAuth
+// @Tags Клиенты
+// @Param id path int true "ID поля"
+// @Success 200 {object} map[string]interface{} "Поле удалено"
+// @Failure 400 {object} map[string]interface{} "Некорректный запрос"
+// @Failure 404 {object} map[string]interface{} "Поле не найдено"
+// @Router /clients/fields/{id} [delete]
+func (h *ClientHandler) DeleteClientFieldHandler(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректный ID поля"))
+		return
+	}
+
+	if err := h.ClientService.DeleteClientField(c.GetInt("user_id"), id); err != nil {
+		respondClientFieldError(c, err, "Не удалось удалить дополнительное поле")
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Дополнительное поле удалено"))
+}
+
+func respondClientFieldError(c *gin.Context, err error, failMessage string) {
+	switch {
+	case errors.Is(err, models.ErrInvalidClientField):
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error()))
+	case errors.Is(err, repositories.ErrClientFieldExists):
+		c.JSON(http.StatusConflict, utils.ErrorResponse(err.Error()))
+	case errors.Is(err, repositories.ErrClientFieldNotFound):
+		c.JSON(http.StatusNotFound, utils.ErrorResponse(err.Error()))
+	default:
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse(failMessage))
+	}
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
 	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/repositories"
 	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/services"
@@ -40,8 +41,12 @@ func (h *ClientHandler) CreateClientHandler(c *gin.Context) {
 		return
 	}
 
-	if err := h.ClientService.CreateClient(&client); err != nil {
-		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Не удалось создать клиента"))
+	if err := h.ClientService.CreateClient(c.GetInt("user_id"), &client); err != nil {
+		if isClientInputError(err) {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error()))
+		} else {
+			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Не удалось создать клиента"))
+		}
 		return
 	}
 
@@ -53,17 +58,27 @@ func (h *ClientHandler) CreateClientHandler(c *gin.Context) {
 // @Description Возвращает список всех клиентов
 // @Tags Клиенты
 // @Produce json
+// @Param limit query int false "Размер страницы (по умолчанию 50, не более 200)"
+// @Param offset query int false "Смещение"
+// @Param cursor query string false "Курсор следующей страницы из meta.next_cursor"
+// @Param sort query string false "Сортировка через запятую, '-' — по убыванию: first_name, last_name, created_at, id"
+// @Param email query string false "Email клиента"
+// @Param phone_number query string false "Номер телефона клиента"
+// @Param tag query string false "Тег клиента, например vip"
+// @Param from query string false "Начало периода по created_at"
+// @Param to query string false "Конец периода по created_at"
 // @Success 200 {array} models.Client
+// @Failure 400 {object} map[string]interface{} "Некорректные параметры списка"
 // @Failure 500 {object} map[string]interface{} "Ошибка сервера"
 // @Router /clients [get]
 func (h *ClientHandler) GetAllClientsHandler(c *gin.Context) {
-	clients, err := h.ClientService.GetAllClients()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Не удалось получить список клиентов"))
+	query, ok := parseListQuery(c)
+	if !ok {
 		return
 	}
 
-	c.JSON(http.StatusOK, utils.SuccessResponse(clients))
+	clients, total, err := h.ClientService.GetAllClients(query)
+	respondList(c, clients, query, total, err, "Не удалось получить список клиентов")
 }
 
 // @Summary Получить клиента
@@ -96,9 +111,40 @@ func (h *ClientHandler) GetClientHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, utils.SuccessResponse(client))
 }
 
+// @Summary Профиль клиента
+// @Security BearerAuth
+// @Description Возвращает историю визитов клиента: число бронирований по статусам, сумму завершенных визитов, любимые услуги и сотрудников, последний и ближайший визит и средний интервал между визитами
+// @Tags Клиенты
+// @Produce json
+// @Param id path int true "ID клиента"
+// @Success 200 {object} services.ClientProfile
+// @Failure 400 {object} map[string]interface{} "Некорректный запрос"
+// @Failure 404 {object} map[string]interface{} "Клиент не найден"
+// @Failure 500 {object} map[string]interface{} "Ошибка сервера"
+// @Router /clients/{id}/profile [get]
+func (h *ClientHandler) GetClientProfileHandler(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректный ID клиента"))
+		return
+	}
+
+	profile, err := h.ClientService.GetClientProfile(id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrClientNotFound) {
+			c.JSON(http.StatusNotFound, utils.ErrorResponse("Клиент не найден"))
+		} else {
+			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Не удалось получить профиль клиента"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse(profile))
+}
+
 // @Summary Обновить клиента
 // @Security BearerAuth
-// @Description Обновляет данные клиента по ID
+// @Description Обновляет данные клиента по ID. Теги (tags) и дополнительные поля (custom_fields) заменяются целиком, если переданы; значение null удаляет поле
 // @Tags Клиенты
 // @Accept json
 // @Produce json
@@ -123,9 +169,11 @@ func (h *ClientHandler) UpdateClientHandler(c *gin.Context) {
 		return
 	}
 
-	if err := h.ClientService.UpdateClient(id, &input); err != nil {
+	if err := h.ClientService.UpdateClient(c.GetInt("user_id"), id, &input); err != nil {
 		if err == repositories.ErrClientNotFound {
 			c.JSON(http.StatusNotFound, utils.ErrorResponse("Клиент не найден"))
+		} else if isClientInputError(err) {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error()))
 		} else {
 			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Не удалось обновить клиента"))
 		}
@@ -149,8 +197,12 @@ func (h *ClientHandler) DeleteClientHandler(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректный ID клиента"))
 		return
 	}
-	if err := h.ClientService.DeleteClient(id); err != nil {
-		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Не удалось удалить клиента"))
+	if err := h.ClientService.DeleteClient(c.GetInt("user_id"), id); err != nil {
+		if err == repositories.ErrClientNotFound {
+			c.JSON(http.StatusNotFound, utils.ErrorResponse("Клиент не найден"))
+		} else {
+			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Не удалось удалить клиента"))
+		}
 		return
 	}
 	c.JSON(http.StatusOK, utils.SuccessResponse("Клиент успешно удалён"))
@@ -226,8 +278,8 @@ func (h *ClientHandler) QuickAddClientHandler(c *gin.Context) {
 		return
 	}
 
-	if err := h.ClientService.QuickAddClient(&client); err != nil {
-		if err.Error() == "номер телефона или Telegram ID обязательны" {
+	if err := h.ClientService.QuickAddClient(c.GetInt("user_id"), &client); err != nil {
+		if err.Error() == "номер телефона или Telegram ID обязательны" || isClientInputError(err) {
 			c.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error()))
 		} else if err == repositories.ErrClientAlreadyExists {
 			c.JSON(http.StatusConflict, utils.ErrorResponse("Клиент уже существует"))
@@ -240,23 +292,45 @@ func (h *ClientHandler) QuickAddClientHandler(c *gin.Context) {
 	c.JSON(http.StatusCreated, utils.SuccessResponse(client))
 }
 
-// @Summary Найти клиента по контактным данным
+// @Summary Найти клиентов
 // @Security BearerAuth
-// @Description Ищет клиента по Email или номеру телефона
+// @Description С параметром q возвращает клиентов, у которых совпадают части имени и фамилии (в любом порядке, с учетом опечаток), фрагмент телефона, ник Telegram или email, по убыванию релевантности (score от 0 до 1).
+// @Description Без q ищет одного клиента по точному Email или номеру телефона; номер сравнивается в формате E.164, поэтому +7 (999) 123-45-67 и 89991234567 равнозначны
 // @Tags Клиенты
 // @Produce json
+// @Param q query string false "Поисковая строка: «Петр Сидоров», «сидоров п», «999 12», «@petr», «petr@example»"
+// @Param limit query int false "Размер выдачи для q (по умолчанию 50, не более 200)"
 // @Param email query string false "Email клиента"
 // @Param phone query string false "Номер телефона клиента"
-// @Success 200 {object} models.Client
+// @Success 200 {array} services.ClientSearchResult "Результаты поиска по q"
+// @Success 200 {object} models.Client "Клиент, найденный по email или телефону"
 // @Failure 400 {object} map[string]interface{} "Некорректный запрос"
 // @Failure 404 {object} map[string]interface{} "Клиент не найден"
 // @Router /clients/search [get]
 func (h *ClientHandler) SearchClientHandler(c *gin.Context) {
+	if q, ok := c.GetQuery("q"); ok {
+		limit, ok := queryLimit(c)
+		if !ok {
+			return
+		}
+		results, err := h.ClientService.SearchClients(q, limit)
+		if err != nil {
+			if errors.Is(err, services.ErrEmptySearchQuery) {
+				c.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error()))
+			} else {
+				c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Ошибка при поиске клиентов"))
+			}
+			return
+		}
+		c.JSON(http.StatusOK, utils.SuccessResponse(results))
+		return
+	}
+
 	email := c.Query("email")
 	phone := c.Query("phone")
 
 	if email == "" && phone == "" {
-		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Необходимо указать email или номер телефона"))
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Необходимо указать q, email или номер телефона"))
 		return
 	}
 
@@ -264,6 +338,8 @@ func (h *ClientHandler) SearchClientHandler(c *gin.Context) {
 	if err != nil {
 		if err == repositories.ErrClientNotFound {
 			c.JSON(http.StatusNotFound, utils.ErrorResponse("Клиент с такими контактными данными не найден"))
+		} else if isContactError(err) {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error()))
 		} else {
 			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Ошибка при поиске клиента"))
 		}
@@ -305,3 +381,110 @@ func (h *ClientHandler) CheckClientExistenceHandler(c *gin.Context) {
 
 	c.JSON(http.StatusOK, utils.SuccessResponse(map[string]bool{"exists": exists}))
 }
+
+// MergeClientsInput — клиенты для объединения
+type MergeClientsInput struct {
+	PrimaryID   int `json:"primary_id" binding:"required"`   // Клиент, который сохраняется
+	DuplicateID int `json:"duplicate_id" binding:"required"` // Клиент, который удаляется
+}
+
+// @Summary Найти дубликаты клиентов
+// @Security BearerAuth
+// @Description Возвращает пары клиентов, которые, вероятно, являются одним человеком: оценка от 0 до 1 складывается из совпадения нормализованного телефона, email и сходства имени и фамилии (в любом порядке)
+// @Tags Клиенты
+// @Produce json
+// @Param min_score query number false "Минимальная оценка (по умолчанию 0.5)"
+// @Param limit query int true "Максимум пар (не более 200)"
+// @Success 200 {object} services.DuplicateSearch
+// @Failure 400 {object} map[string]interface{} "Некорректный запрос"
+// @Failure 500 {object} map[string]interface{} "Ошибка сервера"
+// @Router /clients/duplicates [get]
+func (h *ClientHandler) FindDuplicateClientsHandler(c *gin.Context) {
+	minScore := services.DefaultDuplicateScore
+	if raw := c.Query("min_score"); raw != "" {
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil || value < 0 || value > 1 {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse("min_score должен быть числом от 0 до 1"))
+			return
+		}
+		minScore = value
+	}
+	// Поиск дубликатов перебирает всех клиентов, поэтому размер выдачи задается явно
+	if c.Query("limit") == "" {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("limit обязателен"))
+		return
+	}
+	limit, ok := queryLimit(c)
+	if !ok {
+		return
+	}
+
+	search, err := h.ClientService.FindDuplicates(minScore, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Не удалось найти дубликаты клиентов"))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse(search))
+}
+
+// @Summary Объединить клиентов
+// @Security BearerAuth
+// @Description Переносит бронирования и уведомления дубликата на сохраняемого клиента, дополняет его пустые контакты контактами дубликата и удаляет дубликат в одной транзакции. Объединение записывается в журнал изменений
+// @Tags Клиенты
+// @Accept json
+// @Produce json
+// @Param merge body MergeClientsInput true "Клиенты для объединения"
+// @Success 200 {object} services.ClientMerge
+// @Failure 400 {object} map[string]interface{} "Некорректный запрос"
+// @Failure 404 {object} map[string]interface{} "Клиент не найден"
+// @Failure 500 {object} map[string]interface{} "Ошибка сервера"
+// @Router /clients/merge [post]
+func (h *ClientHandler) MergeClientsHandler(c *gin.Context) {
+	var input MergeClientsInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректные данные: "+err.Error()))
+		return
+	}
+
+	merge, err := h.ClientService.MergeClients(c.GetInt("user_id"), input.PrimaryID, input.DuplicateID)
+	if err != nil {
+		if errors.Is(err, services.ErrMergeSameClient) {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error()))
+		} else if errors.Is(err, repositories.ErrClientNotFound) {
+			c.JSON(http.StatusNotFound, utils.ErrorResponse("Клиент не найден"))
+		} else {
+			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Не удалось объединить клиентов"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse(merge))
+}
+
+// queryLimit читает limit для выдачи без страниц: по умолчанию DefaultListLimit, не более MaxListLimit.
+// При ошибке отвечает 400 и возвращает false.
+func queryLimit(c *gin.Context) (int, bool) {
+	raw := c.Query("limit")
+	if raw == "" {
+		return repositories.DefaultListLimit, true
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value <= 0 {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректный limit"))
+		return 0, false
+	}
+	return min(value, repositories.MaxListLimit), true
+}
+
+// isContactError сообщает, что телефон или email указаны в неверном формате
+func isContactError(err error) bool {
+	return errors.Is(err, models.ErrInvalidPhone) || errors.Is(err, models.ErrInvalidEmail)
+}
+
+// isClientInputError сообщает, что данные клиента не прошли проверку: язык, контакты, теги или дополнительные поля
+func isClientInputError(err error) bool {
+	return errors.Is(err, services.ErrInvalidLanguage) || isContactError(err) ||
+		errors.Is(err, models.ErrInvalidTag) || errors.Is(err, models.ErrInvalidFieldValue) ||
+		errors.Is(err, services.ErrUnknownCustomField) || errors.Is(err, services.ErrRequiredCustomField)
+}
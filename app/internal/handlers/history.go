@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HistoryHandler struct {
+	HistoryService services.HistoryService
+}
+
+func NewHistoryHandler(historyService services.HistoryService) *HistoryHandler {
+	return &HistoryHandler{
+		HistoryService: historyService,
+	}
+}
+
+// @Summary Журнал изменений
+// @Security BearerAuth
+// @Description Возвращает записи журнала изменений, новые первыми. Даты принимаются в формате RFC 3339 или YYYY-MM-DD; to не включается в период.
+// @Tags История
+// @Produce json
+// @Param actor_id query int false "ID сотрудника, выполнившего изменение"
+// @Param entity_type query string false "Тип сущности: booking, client, service, schedule, break, user"
+// @Param entity_id query int false "ID сущности"
+// @Param from query string false "Начало периода"
+// @Param to query string false "Конец периода"
+// @Param action query string false "Действие: create, update, delete"
+// @Param limit query int false "Размер страницы (по умолчанию 50, не более 200)"
+// @Param offset query int false "Смещение"
+// @Param cursor query string false "Курсор следующей страницы из meta.next_cursor"
+// @Param sort query string false "Сортировка через запятую, '-' — по убыванию: created_at, id"
+// @Success 200 {array} models.HistoryLogs
+// @Failure 400 {object} map[string]interface{} "Некорректный запрос"
+// @Failure 500 {object} map[string]interface{} "Ошибка сервера"
+// @Router /history [get]
+func (h *HistoryHandler) GetHistoryHandler(c *gin.Context) {
+	query, ok := parseListQuery(c)
+	if !ok {
+		return
+	}
+
+	logs, total, err := h.HistoryService.GetHistory(query)
+	respondList(c, logs, query, total, err, "Не удалось получить журнал изменений")
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/repositories"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/pkg/utils"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// listParams — служебные параметры списка; остальные параметры запроса считаются фильтрами по полям
+var listParams = map[string]bool{
+	"limit":  true,
+	"offset": true,
+	"cursor": true,
+	"sort":   true,
+	"from":   true,
+	"to":     true,
+}
+
+// parseListQuery читает из запроса limit, offset или cursor, sort, from, to и фильтры по полям.
+// При ошибке ответ 400 уже отправлен и возвращается false.
+func parseListQuery(c *gin.Context) (repositories.ListQuery, bool) {
+	query := repositories.ListQuery{
+		Sort:    c.Query("sort"),
+		Filters: make(map[string]string),
+	}
+	var err error
+
+	if limit := c.Query("limit"); limit != "" {
+		if query.Limit, err = strconv.Atoi(limit); err != nil || query.Limit < 0 {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректный limit"))
+			return query, false
+		}
+	}
+	if offset := c.Query("offset"); offset != "" {
+		if query.Offset, err = strconv.Atoi(offset); err != nil || query.Offset < 0 {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректный offset"))
+			return query, false
+		}
+	}
+	// Курсор из next_cursor имеет приоритет над offset
+	if cursor := c.Query("cursor"); cursor != "" {
+		if query.Offset, err = repositories.DecodeCursor(cursor); err != nil {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректный cursor"))
+			return query, false
+		}
+	}
+
+	if from := c.Query("from"); from != "" {
+		if query.From, err = parseQueryTime(from); err != nil {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректная дата from"))
+			return query, false
+		}
+	}
+	if to := c.Query("to"); to != "" {
+		if query.To, err = parseQueryTime(to); err != nil {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректная дата to"))
+			return query, false
+		}
+	}
+
+	for key, values := range c.Request.URL.Query() {
+		if listParams[key] || len(values) == 0 || values[0] == "" {
+			continue
+		}
+		query.Filters[key] = values[0]
+	}
+
+	return query.Normalize(), true
+}
+
+// respondList отправляет страницу списка с метаданными пагинации.
+// Ошибки параметров списка (неизвестное поле сортировки или фильтра) возвращаются как 400.
+func respondList(c *gin.Context, data interface{}, query repositories.ListQuery, total int64, err error, failMessage string) {
+	if err != nil {
+		if errors.Is(err, repositories.ErrInvalidListQuery) {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error()))
+		} else {
+			c.JSON(http.StatusInternalServerError, utils.ErrorResponse(failMessage))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.ListResponse(data, utils.ListMeta{
+		Total:      total,
+		Limit:      query.Limit,
+		Offset:     query.Offset,
+		NextCursor: query.NextCursor(total),
+	}))
+}
+
+// parseQueryTime принимает дату в формате RFC 3339 или YYYY-MM-DD
+func parseQueryTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/repositories"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/services"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/pkg/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LocationHandler struct {
+	LocationService services.LocationService
+}
+
+func NewLocationHandler(locationService services.LocationService) *LocationHandler {
+	return &LocationHandler{
+		LocationService: locationService,
+	}
+}
+
+// @Summary Создать филиал
+// @Security BearerAuth
+// @Description Создает филиал: timezone — часовой пояс IANA, opening_hours — часы работы по дням недели (day: monday…sunday, open и close — ЧЧ:ММ)
+// @Tags Филиалы
+// @Accept json
+// @Produce json
+// @Param location body models.Location true "Данные филиала"
+// @Success 201 {object} models.Location
+// @Failure 400 {object} map[string]interface{} "Некорректный запрос"
+// @Failure 500 {object} map[string]interface{} "Ошибка сервера"
+// @Router /locations [post]
+func (h *LocationHandler) CreateLocationHandler(c *gin.Context) {
+	var location models.Location
+	if err := c.ShouldBindJSON(&location); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректные данные: "+err.Error()))
+		return
+	}
+
+	if err := h.LocationService.CreateLocation(c.GetInt("user_id"), &location); err != nil {
+		respondLocationError(c, err, "Не удалось создать филиал")
+		return
+	}
+
+	c.JSON(http.StatusCreated, utils.SuccessResponse(location))
+}
+
+// @Summary Получить филиалы
+// @Security BearerAuth
+// @Description Возвращает список филиалов
+// @Tags Филиалы
+// @Produce json
+// @Param limit query int false "Размер страницы (по умолчанию 50, не более 200)"
+// @Param offset query int false "Смещение"
+// @Param cursor query string false "Курсор следующей страницы из meta.next_cursor"
+// @Param sort query string false "Сортировка через запятую, '-' — по убыванию: name, created_at, id"
+// @Param is_active query bool false "Только действующие или только закрытые филиалы"
+// @Param timezone query string false "Часовой пояс"
+// @Success 200 {array} models.Location
+// @Failure 400 {object} map[string]interface{} "Некорректные параметры списка"
+// @Failure 500 {object} map[string]interface{} "Ошибка сервера"
+// @Router /locations [get]
+func (h *LocationHandler) GetAllLocationsHandler(c *gin.Context) {
+	query, ok := parseListQuery(c)
+	if !ok {
+		return
+	}
+
+	locations, total, err := h.LocationService.GetAllLocations(query)
+	respondList(c, locations, query, total, err, "Не удалось получить список филиалов")
+}
+
+// @Summary Получить филиал
+// @Security BearerAuth
+// @Description Возвращает данные филиала по ID
+// @Tags Филиалы
+// @Produce json
+// @Param id path int true "ID филиала"
+// @Success 200 {object} models.Location
+// @Failure 400 {object} map[string]interface{} "Некорректный запрос"
+// @Failure 404 {object} map[string]interface{} "Филиал не найден"
+// @Router /locations/{id} [get]
+func (h *LocationHandler) GetLocationHandler(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректный ID филиала"))
+		return
+	}
+
+	location, err := h.LocationService.GetLocationByID(id)
+	if err != nil {
+		respondLocationError(c, err, "Ошибка при получении филиала")
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse(location))
+}
+
+// @Summary Обновить филиал
+// @Security BearerAuth
+// @Description Обновляет данные филиала по ID
+// @Tags Филиалы
+// @Accept json
+// @Produce json
+// @Param id path int true "ID филиала"
+// @Param location body models.Location true "Обновленные данные филиала"
+// @Success 200 {object} map[string]interface{} "Сообщение об успешном обновлении"
+// @Failure 400 {object} map[string]interface{} "Некорректный запрос"
+// @Failure 404 {object} map[string]interface{} "Филиал не найден"
+// @Failure 500 {object} map[string]interface{} "Ошибка сервера"
+// @Router /locations/{id} [put]
+func (h *LocationHandler) UpdateLocationHandler(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректный ID филиала"))
+		return
+	}
+
+	var input models.Location
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректные данные: "+err.Error()))
+		return
+	}
+
+	if err := h.LocationService.UpdateLocation(c.GetInt("user_id"), id, &input); err != nil {
+		respondLocationError(c, err, "Не удалось обновить филиал")
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Филиал успешно обновлен"))
+}
+
+// @Summary Удалить филиал
+// @Security BearerAuth
+// @Description Удаляет филиал, к которому не привязаны сотрудники, расписания, перерывы и бронирования
+// @Tags Филиалы
+// @Param id path int true "ID филиала"
+// @Success 200 {object} map[string]interface{} "Сообщение об успешном удалении"
+// @Failure 400 {object} map[string]interface{} "Некорректный запрос"
+// @Failure 404 {object} map[string]interface{} "Филиал не найден"
+// @Failure 409 {object} map[string]interface{} "Филиал используется"
+// @Failure 500 {object} map[string]interface{} "Ошибка сервера"
+// @Router /locations/{id} [delete]
+func (h *LocationHandler) DeleteLocationHandler(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректный ID филиала"))
+		return
+	}
+
+	if err := h.LocationService.DeleteLocation(c.GetInt("user_id"), id); err != nil {
+		respondLocationError(c, err, "Не удалось удалить филиал")
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Филиал успешно удален"))
+}
+
+// respondLocationError отвечает на ошибку операции с филиалом
+func respondLocationError(c *gin.Context, err error, failMessage string) {
+	switch {
+	case errors.Is(err, repositories.ErrLocationNotFound):
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Филиал не найден"))
+	case errors.Is(err, repositories.ErrLocationInUse):
+		c.JSON(http.StatusConflict, utils.ErrorResponse(err.Error()))
+	case errors.Is(err, services.ErrInvalidLocation),
+		errors.Is(err, services.ErrInvalidTimezone),
+		errors.Is(err, services.ErrInvalidOpeningHours):
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error()))
+	default:
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse(failMessage))
+	}
+}
+
+// queryLocationID читает необязательный параметр location_id; 0 — все филиалы.
+// Если значение некорректно, ответ уже отправлен и возвращается false.
+func queryLocationID(c *gin.Context) (int, bool) {
+	value := c.Query("location_id")
+	if value == "" {
+		return 0, true
+	}
+	locationID, err := strconv.Atoi(value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректный location_id"))
+		return 0, false
+	}
+	return locationID, true
+}
@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/repositories"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/services"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/pkg/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationTemplateHandler struct {
+	TemplateService services.NotificationTemplateService
+}
+
+func NewNotificationTemplateHandler(templateService services.NotificationTemplateService) *NotificationTemplateHandler {
+	return &NotificationTemplateHandler{
+		TemplateService: templateService,
+	}
+}
+
+// TemplatePreview — результат отображения шаблона
+type TemplatePreview struct {
+	Text string `json:"text"`
+}
+
+// @Summary Создать шаблон уведомления
+// @Security BearerAuth
+// @Description Создает шаблон в синтаксисе text/template. Доступны .Client, .Booking, .Service, .Barber и функции date, time, datetime.
+// @Tags Шаблоны уведомлений
+// @Accept json
+// @Produce json
+// @Param template body models.NotificationTemplate true "Данные шаблона"
+// @Success 201 {object} models.NotificationTemplate
+// @Failure 400 {object} map[string]interface{} "Некорректный шаблон"
+// @Failure 409 {object} map[string]interface{} "Шаблон с таким кодом и языком уже существует"
+// @Failure 500 {object} map[string]interface{} "Ошибка сервера"
+// @Router /notification-templates [post]
+func (h *NotificationTemplateHandler) CreateTemplateHandler(c *gin.Context) {
+	var template models.NotificationTemplate
+	if err := c.ShouldBindJSON(&template); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректные данные: "+err.Error()))
+		return
+	}
+
+	if err := h.TemplateService.CreateTemplate(&template); err != nil {
+		respondTemplateError(c, err, "Не удалось создать шаблон")
+		return
+	}
+
+	c.JSON(http.StatusCreated, utils.SuccessResponse(template))
+}
+
+// @Summary Получить шаблоны уведомлений
+// @Security BearerAuth
+// @Description Возвращает список шаблонов уведомлений
+// @Tags Шаблоны уведомлений
+// @Produce json
+// @Param limit query int false "Размер страницы (по умолчанию 50, не более 200)"
+// @Param offset query int false "Смещение"
+// @Param cursor query string false "Курсор следующей страницы из meta.next_cursor"
+// @Param sort query string false "Сортировка через запятую, '-' — по убыванию: code, language, updated_at, id"
+// @Param code query string false "Код шаблона"
+// @Param language query string false "Язык шаблона"
+// @Success 200 {array} models.NotificationTemplate
+// @Failure 400 {object} map[string]interface{} "Некорректные параметры списка"
+// @Failure 500 {object} map[string]interface{} "Ошибка сервера"
+// @Router /notification-templates [get]
+func (h *NotificationTemplateHandler) GetAllTemplatesHandler(c *gin.Context) {
+	query, ok := parseListQuery(c)
+	if !ok {
+		return
+	}
+
+	templates, total, err := h.TemplateService.GetAllTemplates(query)
+	respondList(c, templates, query, total, err, "Не удалось получить шаблоны")
+}
+
+// @Summary Получить шаблон уведомления
+// @Security BearerAuth
+// @Description Возвращает шаблон уведомления по ID
+// @Tags Шаблоны уведомлений
+// @Produce json
+// @Param id path int true "ID шаблона"
+// @Success 200 {object} models.NotificationTemplate
+// @Failure 400 {object} map[string]interface{} "Некорректный запрос"
+// @Failure 404 {object} map[string]interface{} "Шаблон не найден"
+// @Router /notification-templates/{id} [get]
+func (h *NotificationTemplateHandler) GetTemplateHandler(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректный ID шаблона"))
+		return
+	}
+
+	template, err := h.TemplateService.GetTemplateByID(id)
+	if err != nil {
+		respondTemplateError(c, err, "Ошибка при получении шаблона")
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse(template))
+}
+
+// @Summary Предпросмотр шаблона уведомления
+// @Security BearerAuth
+// @Description Отображает шаблон на данных бронирования booking_id или, если он не указан, на примере бронирования
+// @Tags Шаблоны уведомлений
+// @Produce json
+// @Param id path int true "ID шаблона"
+// @Param booking_id query int false "ID бронирования"
+// @Success 200 {object} TemplatePreview
+// @Failure 400 {object} map[string]interface{} "Некорректный шаблон или запрос"
+// @Failure 404 {object} map[string]interface{} "Шаблон или бронирование не найдены"
+// @Router /notification-templates/{id}/preview [get]
+func (h *NotificationTemplateHandler) PreviewTemplateHandler(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректный ID шаблона"))
+		return
+	}
+
+	bookingID := 0
+	if value := c.Query("booking_id"); value != "" {
+		if bookingID, err = strconv.Atoi(value); err != nil {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректный ID бронирования"))
+			return
+		}
+	}
+
+	text, err := h.TemplateService.Preview(id, bookingID)
+	if err != nil {
+		respondTemplateError(c, err, "Не удалось отобразить шаблон")
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse(TemplatePreview{Text: text}))
+}
+
+// @Summary Обновить шаблон уведомления
+// @Security BearerAuth
+// @Description Обновляет шаблон уведомления по ID
+// @Tags Шаблоны уведомлений
+// @Accept json
+// @Produce json
+// @Param id path int true "ID шаблона"
+// @Param template body models.NotificationTemplate true "Обновленные данные шаблона"
+// @Success 200 {object} map[string]interface{} "Сообщение об успешном обновлении"
+// @Failure 400 {object} map[string]interface{} "Некорректный шаблон"
+// @Failure 404 {object} map[string]interface{} "Шаблон не найден"
+// @Failure 409 {object} map[string]interface{} "Шаблон с таким кодом и языком уже существует"
+// @Failure 500 {object} map[string]interface{} "Ошибка сервера"
+// @Router /notification-templates/{id} [put]
+func (h *NotificationTemplateHandler) UpdateTemplateHandler(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректный ID шаблона"))
+		return
+	}
+
+	var input models.NotificationTemplate
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректные данные: "+err.Error()))
+		return
+	}
+
+	if err := h.TemplateService.UpdateTemplate(id, &input); err != nil {
+		respondTemplateError(c, err, "Не удалось обновить шаблон")
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Шаблон успешно обновлен"))
+}
+
+// @Summary Удалить шаблон уведомления
+// @Security BearerAuth
+// @Description Удаляет шаблон уведомления по ID
+// @Tags Шаблоны уведомлений
+// @Param id path int true "ID шаблона"
+// @Success 200 {object} map[string]interface{} "Сообщение об успешном удалении"
+// @Failure 400 {object} map[string]interface{} "Некорректный запрос"
+// @Failure 404 {object} map[string]interface{} "Шаблон не найден"
+// @Failure 500 {object} map[string]interface{} "Ошибка сервера"
+// @Router /notification-templates/{id} [delete]
+func (h *NotificationTemplateHandler) DeleteTemplateHandler(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректный ID шаблона"))
+		return
+	}
+
+	if err := h.TemplateService.DeleteTemplate(id); err != nil {
+		respondTemplateError(c, err, "Не удалось удалить шаблон")
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Шаблон успешно удален"))
+}
+
+func respondTemplateError(c *gin.Context, err error, failMessage string) {
+	switch {
+	case errors.Is(err, repositories.ErrTemplateNotFound):
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Шаблон не найден"))
+	case errors.Is(err, repositories.ErrBookingNotFound):
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Бронирование не найдено"))
+	case errors.Is(err, repositories.ErrTemplateAlreadyExists):
+		c.JSON(http.StatusConflict, utils.ErrorResponse(err.Error()))
+	case errors.Is(err, services.ErrInvalidTemplate), errors.Is(err, services.ErrInvalidLanguage):
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error()))
+	default:
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse(failMessage))
+	}
+}
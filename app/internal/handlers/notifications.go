@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
 	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/repositories"
 	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/services"
@@ -41,7 +42,11 @@ func (h *NotificationHandler) CreateNotificationHandler(c *gin.Context) {
 	}
 
 	if err := h.NotificationService.CreateNotification(&notification); err != nil {
-		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Не удалось создать уведомление"))
+		if errors.Is(err, services.ErrInvalidNotificationType) {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error()))
+		} else {
+			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Не удалось создать уведомление"))
+		}
 		return
 	}
 
@@ -53,17 +58,27 @@ func (h *NotificationHandler) CreateNotificationHandler(c *gin.Context) {
 // @Description Возвращает список всех уведомлений
 // @Tags Уведомления
 // @Produce json
+// @Param limit query int false "Размер страницы (по умолчанию 50, не более 200)"
+// @Param offset query int false "Смещение"
+// @Param cursor query string false "Курсор следующей страницы из meta.next_cursor"
+// @Param sort query string false "Сортировка через запятую, '-' — по убыванию: created_at, sent_at, status, id"
+// @Param client_id query int false "ID клиента"
+// @Param status query string false "Статус уведомления"
+// @Param notification_type query string false "Тип уведомления"
+// @Param from query string false "Начало периода по created_at"
+// @Param to query string false "Конец периода по created_at"
 // @Success 200 {array} models.Notification
+// @Failure 400 {object} map[string]interface{} "Некорректные параметры списка"
 // @Failure 500 {object} map[string]interface{} "Ошибка сервера"
 // @Router /notifications [get]
 func (h *NotificationHandler) GetAllNotificationsHandler(c *gin.Context) {
-	notifications, err := h.NotificationService.GetAllNotifications()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Не удалось получить уведомления"))
+	query, ok := parseListQuery(c)
+	if !ok {
 		return
 	}
 
-	c.JSON(http.StatusOK, utils.SuccessResponse(notifications))
+	notifications, total, err := h.NotificationService.GetAllNotifications(query)
+	respondList(c, notifications, query, total, err, "Не удалось получить уведомления")
 }
 
 // @Summary Получить уведомление по ID
@@ -125,6 +140,8 @@ func (h *NotificationHandler) UpdateNotificationHandler(c *gin.Context) {
 	if err := h.NotificationService.UpdateNotification(id, &input); err != nil {
 		if err == repositories.ErrNotificationNotFound {
 			c.JSON(http.StatusNotFound, utils.ErrorResponse("Уведомление не найдено"))
+		} else if errors.Is(err, services.ErrInvalidNotificationType) || errors.Is(err, services.ErrInvalidNotificationStatus) {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error()))
 		} else {
 			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Не удалось обновить уведомление"))
 		}
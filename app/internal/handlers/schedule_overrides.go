@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/middleware"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/pkg/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Создать исключение из расписания
+// @Security BearerAuth
+// @Description Создает исключение на дату: day_off — выходной, hours — особые часы вместо еженедельных, extra — дополнительная смена
+// @Tags Исключения из расписания
+// @Accept json
+// @Produce json
+// @Param override body models.ScheduleOverride true "Данные исключения"
+// @Success 201 {object} models.ScheduleOverride
+// @Failure 400 {object} map[string]interface{} "Некорректный запрос"
+// @Failure 403 {object} map[string]interface{} "Недостаточно прав"
+// @Failure 500 {object} map[string]interface{} "Ошибка сервера"
+// @Router /schedule-overrides [post]
+func (h *ScheduleHandler) CreateOverrideHandler(c *gin.Context) {
+	var override models.ScheduleOverride
+	if err := c.ShouldBindJSON(&override); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректные данные: "+err.Error()))
+		return
+	}
+
+	if staffID, restricted := middleware.BarberScope(c); restricted && override.UserID != staffID {
+		c.JSON(http.StatusForbidden, utils.ErrorResponse("Недостаточно прав"))
+		return
+	}
+
+	if override.UserID == 0 {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Не указан сотрудник"))
+		return
+	}
+
+	if err := h.ScheduleService.CreateOverride(c.GetInt("user_id"), &override); err != nil {
+		respondScheduleError(c, err, "Не удалось создать исключение из расписания")
+		return
+	}
+
+	c.JSON(http.StatusCreated, utils.SuccessResponse(override))
+}
+
+// @Summary Получить исключения из расписания
+// @Security BearerAuth
+// @Description Возвращает список исключений из расписания
+// @Tags Исключения из расписания
+// @Produce json
+// @Param limit query int false "Размер страницы (по умолчанию 50, не более 200)"
+// @Param offset query int false "Смещение"
+// @Param cursor query string false "Курсор следующей страницы из meta.next_cursor"
+// @Param sort query string false "Сортировка через запятую, '-' — по убыванию: user_id, date, created_at, id"
+// @Param user_id query int false "ID сотрудника"
+// @Param date query string false "Дата (YYYY-MM-DD)"
+// @Param kind query string false "Вид исключения: day_off, hours, extra"
+// @Param location_id query int false "ID филиала"
+// @Success 200 {array} models.ScheduleOverride
+// @Failure 400 {object} map[string]interface{} "Некорректные параметры списка"
+// @Failure 500 {object} map[string]interface{} "Ошибка сервера"
+// @Router /schedule-overrides [get]
+func (h *ScheduleHandler) GetAllOverridesHandler(c *gin.Context) {
+	query, ok := parseListQuery(c)
+	if !ok {
+		return
+	}
+	// Барбер видит только свои исключения
+	if staffID, restricted := middleware.BarberScope(c); restricted {
+		query.Filters["user_id"] = strconv.Itoa(staffID)
+	}
+
+	overrides, total, err := h.ScheduleService.GetAllOverrides(query)
+	respondList(c, overrides, query, total, err, "Не удалось получить исключения из расписания")
+}
+
+// @Summary Получить исключение из расписания
+// @Security BearerAuth
+// @Description Возвращает исключение из расписания по ID
+// @Tags Исключения из расписания
+// @Produce json
+// @Param id path int true "ID исключения"
+// @Success 200 {object} models.ScheduleOverride
+// @Failure 400 {object} map[string]interface{} "Некорректный запрос"
+// @Failure 404 {object} map[string]interface{} "Исключение не найдено"
+// @Router /schedule-overrides/{id} [get]
+func (h *ScheduleHandler) GetOverrideHandler(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректный ID исключения"))
+		return
+	}
+
+	override, ok := h.authorizeOverride(c, id)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, utils.SuccessResponse(override))
+}
+
+// @Summary Обновить исключение из расписания
+// @Security BearerAuth
+// @Description Обновляет дату, вид, время и причину исключения по ID
+// @Tags Исключения из расписания
+// @Accept json
+// @Produce json
+// @Param id path int true "ID исключения"
+// @Param override body models.ScheduleOverride true "Обновленные данные исключения"
+// @Success 200 {object} map[string]interface{} "Сообщение об успешном обновлении"
+// @Failure 400 {object} map[string]interface{} "Некорректный запрос"
+// @Failure 404 {object} map[string]interface{} "Исключение не найдено"
+// @Failure 500 {object} map[string]interface{} "Ошибка сервера"
+// @Router /schedule-overrides/{id} [put]
+func (h *ScheduleHandler) UpdateOverrideHandler(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректный ID исключения"))
+		return
+	}
+
+	var input models.ScheduleOverride
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректные данные: "+err.Error()))
+		return
+	}
+
+	if _, ok := h.authorizeOverride(c, id); !ok {
+		return
+	}
+
+	if err := h.ScheduleService.UpdateOverride(c.GetInt("user_id"), id, &input); err != nil {
+		respondScheduleError(c, err, "Не удалось обновить исключение из расписания")
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Исключение из расписания успешно обновлено"))
+}
+
+// @Summary Удалить исключение из расписания
+// @Security BearerAuth
+// @Description Удаляет исключение из расписания по ID
+// @Tags Исключения из расписания
+// @Param id path int true "ID исключения"
+// @Success 200 {object} map[string]interface{} "Сообщение об успешном удалении"
+// @Failure 400 {object} map[string]interface{} "Некорректный запрос"
+// @Failure 404 {object} map[string]interface{} "Исключение не найдено"
+// @Failure 500 {object} map[string]interface{} "Ошибка сервера"
+// @Router /schedule-overrides/{id} [delete]
+func (h *ScheduleHandler) DeleteOverrideHandler(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректный ID исключения"))
+		return
+	}
+
+	if _, ok := h.authorizeOverride(c, id); !ok {
+		return
+	}
+
+	if err := h.ScheduleService.DeleteOverride(c.GetInt("user_id"), id); err != nil {
+		respondScheduleError(c, err, "Не удалось удалить исключение из расписания")
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Исключение из расписания успешно удалено"))
+}
+
+// authorizeOverride загружает исключение и для барбера проверяет, что оно принадлежит ему.
+// Если доступ запрещен или исключение не найдено, ответ уже отправлен и возвращается false.
+func (h *ScheduleHandler) authorizeOverride(c *gin.Context, id int) (*models.ScheduleOverride, bool) {
+	override, err := h.ScheduleService.GetOverrideByID(id)
+	if err != nil {
+		respondScheduleError(c, err, "Ошибка при получении исключения из расписания")
+		return nil, false
+	}
+
+	if staffID, restricted := middleware.BarberScope(c); restricted && override.UserID != staffID {
+		c.JSON(http.StatusForbidden, utils.ErrorResponse("Недостаточно прав"))
+		return nil, false
+	}
+	return override, true
+}
@@ -1,12 +1,15 @@
 package handlers
 
 import (
+	"errors"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/middleware"
 	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
 	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/repositories"
 	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/services"
 	"github.com/0sokrat0/GoGRAFFApi.git/app/pkg/utils"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -23,7 +26,7 @@ func NewScheduleHandler(scheduleService services.ScheduleService) *ScheduleHandl
 
 // @Summary Создать расписание
 // @Security BearerAuth
-// @Description Создает новое расписание
+// @Description Создает еженедельные рабочие часы: schedule_day — monday…sunday, start_time и end_time — ЧЧ:ММ
 // @Tags Расписания
 // @Accept json
 // @Produce json
@@ -39,13 +42,18 @@ func (h *ScheduleHandler) CreateScheduleHandler(c *gin.Context) {
 		return
 	}
 
-	if schedule.UserID == 0 || schedule.ScheduleDay == "" || schedule.StartTime == "" || schedule.EndTime == "" {
+	if staffID, restricted := middleware.BarberScope(c); restricted && schedule.UserID != staffID {
+		c.JSON(http.StatusForbidden, utils.ErrorResponse("Недостаточно прав"))
+		return
+	}
+
+	if schedule.UserID == 0 || !schedule.ScheduleDay.IsValid() {
 		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Все поля обязательны"))
 		return
 	}
 
-	if err := h.ScheduleService.CreateSchedule(&schedule); err != nil {
-		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Не удалось создать расписание"))
+	if err := h.ScheduleService.CreateSchedule(c.GetInt("user_id"), &schedule); err != nil {
+		respondScheduleError(c, err, "Не удалось создать расписание")
 		return
 	}
 
@@ -57,16 +65,29 @@ func (h *ScheduleHandler) CreateScheduleHandler(c *gin.Context) {
 // @Description Возвращает список всех расписаний
 // @Tags Расписания
 // @Produce json
+// @Param limit query int false "Размер страницы (по умолчанию 50, не более 200)"
+// @Param offset query int false "Смещение"
+// @Param cursor query string false "Курсор следующей страницы из meta.next_cursor"
+// @Param sort query string false "Сортировка через запятую, '-' — по убыванию: user_id, schedule_day, created_at, id"
+// @Param user_id query int false "ID сотрудника"
+// @Param schedule_day query string false "День недели"
+// @Param location_id query int false "ID филиала"
 // @Success 200 {array} models.Schedule
+// @Failure 400 {object} map[string]interface{} "Некорректные параметры списка"
 // @Failure 500 {object} map[string]interface{} "Ошибка сервера"
 // @Router /schedules [get]
 func (h *ScheduleHandler) GetAllSchedulesHandler(c *gin.Context) {
-	schedules, err := h.ScheduleService.GetAllSchedules()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Не удалось получить расписания"))
+	query, ok := parseListQuery(c)
+	if !ok {
 		return
 	}
-	c.JSON(http.StatusOK, utils.SuccessResponse(schedules))
+	// Барбер видит только свои расписания
+	if staffID, restricted := middleware.BarberScope(c); restricted {
+		query.Filters["user_id"] = strconv.Itoa(staffID)
+	}
+
+	schedules, total, err := h.ScheduleService.GetAllSchedules(query)
+	respondList(c, schedules, query, total, err, "Не удалось получить расписания")
 }
 
 // @Summary Получить расписание
@@ -95,6 +116,11 @@ func (h *ScheduleHandler) GetScheduleHandler(c *gin.Context) {
 		}
 		return
 	}
+
+	if staffID, restricted := middleware.BarberScope(c); restricted && schedule.UserID != staffID {
+		c.JSON(http.StatusForbidden, utils.ErrorResponse("Недостаточно прав"))
+		return
+	}
 	c.JSON(http.StatusOK, utils.SuccessResponse(schedule))
 }
 
@@ -124,12 +150,12 @@ func (h *ScheduleHandler) UpdateScheduleHandler(c *gin.Context) {
 		return
 	}
 
-	if err := h.ScheduleService.UpdateSchedule(id, &input); err != nil {
-		if err == repositories.ErrScheduleNotFound {
-			c.JSON(http.StatusNotFound, utils.ErrorResponse("Расписание не найдено"))
-		} else {
-			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Не удалось обновить расписание"))
-		}
+	if !h.authorizeSchedule(c, id) {
+		return
+	}
+
+	if err := h.ScheduleService.UpdateSchedule(c.GetInt("user_id"), id, &input); err != nil {
+		respondScheduleError(c, err, "Не удалось обновить расписание")
 		return
 	}
 
@@ -152,8 +178,16 @@ func (h *ScheduleHandler) DeleteScheduleHandler(c *gin.Context) {
 		return
 	}
 
-	if err := h.ScheduleService.DeleteSchedule(id); err != nil {
-		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Не удалось удалить расписание"))
+	if !h.authorizeSchedule(c, id) {
+		return
+	}
+
+	if err := h.ScheduleService.DeleteSchedule(c.GetInt("user_id"), id); err != nil {
+		if err == repositories.ErrScheduleNotFound {
+			c.JSON(http.StatusNotFound, utils.ErrorResponse("Расписание не найдено"))
+		} else {
+			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Не удалось удалить расписание"))
+		}
 		return
 	}
 
@@ -177,6 +211,11 @@ func (h *ScheduleHandler) FilterSchedulesByUserHandler(c *gin.Context) {
 		return
 	}
 
+	if staffID, restricted := middleware.BarberScope(c); restricted && userID != staffID {
+		c.JSON(http.StatusForbidden, utils.ErrorResponse("Недостаточно прав"))
+		return
+	}
+
 	schedules, err := h.ScheduleService.FilterSchedulesByUser(userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Не удалось выполнить фильтрацию расписаний"))
@@ -185,3 +224,93 @@ func (h *ScheduleHandler) FilterSchedulesByUserHandler(c *gin.Context) {
 
 	c.JSON(http.StatusOK, utils.SuccessResponse(schedules))
 }
+
+// @Summary Рабочие часы сотрудника на дату
+// @Security BearerAuth
+// @Description Возвращает рабочие интервалы сотрудника в указанный день: еженедельное расписание с учетом исключений на эту дату и часов работы филиала
+// @Tags Расписания
+// @Produce json
+// @Param user_id query int true "ID сотрудника"
+// @Param location_id query int false "ID филиала; без него — во всех филиалах"
+// @Param date query string true "Дата (YYYY-MM-DD)"
+// @Success 200 {array} services.WorkingInterval
+// @Failure 400 {object} map[string]interface{} "Некорректный запрос"
+// @Failure 403 {object} map[string]interface{} "Недостаточно прав"
+// @Failure 500 {object} map[string]interface{} "Ошибка сервера"
+// @Router /schedules/working-hours [get]
+func (h *ScheduleHandler) GetWorkingHoursHandler(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Query("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректный ID пользователя"))
+		return
+	}
+
+	date, err := time.Parse("2006-01-02", c.Query("date"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректная дата, ожидается YYYY-MM-DD"))
+		return
+	}
+
+	if staffID, restricted := middleware.BarberScope(c); restricted && userID != staffID {
+		c.JSON(http.StatusForbidden, utils.ErrorResponse("Недостаточно прав"))
+		return
+	}
+
+	locationID, ok := queryLocationID(c)
+	if !ok {
+		return
+	}
+
+	work, err := h.ScheduleService.WorkingIntervals(locationID, userID, date)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Не удалось получить рабочие часы"))
+		return
+	}
+	if work == nil {
+		work = []services.WorkingInterval{}
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse(work))
+}
+
+// respondScheduleError отвечает на ошибку расписания или исключения из него
+func respondScheduleError(c *gin.Context, err error, failMessage string) {
+	switch {
+	case errors.Is(err, repositories.ErrScheduleNotFound):
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Расписание не найдено"))
+	case errors.Is(err, repositories.ErrScheduleOverrideNotFound):
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Исключение из расписания не найдено"))
+	case errors.Is(err, services.ErrInvalidScheduleTime),
+		errors.Is(err, services.ErrInvalidScheduleDay),
+		errors.Is(err, services.ErrInvalidOverrideDate),
+		errors.Is(err, services.ErrInvalidOverrideKind),
+		errors.Is(err, services.ErrInvalidOverrideHours):
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error()))
+	default:
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse(failMessage))
+	}
+}
+
+// authorizeSchedule для барбера проверяет, что расписание принадлежит ему.
+// Если доступ запрещен, ответ уже отправлен и возвращается false.
+func (h *ScheduleHandler) authorizeSchedule(c *gin.Context, id int) bool {
+	staffID, restricted := middleware.BarberScope(c)
+	if !restricted {
+		return true
+	}
+
+	schedule, err := h.ScheduleService.GetScheduleByID(id)
+	if err != nil {
+		if err == repositories.ErrScheduleNotFound {
+			c.JSON(http.StatusNotFound, utils.ErrorResponse("Расписание не найдено"))
+		} else {
+			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Ошибка при получении расписания"))
+		}
+		return false
+	}
+	if schedule.UserID != staffID {
+		c.JSON(http.StatusForbidden, utils.ErrorResponse("Недостаточно прав"))
+		return false
+	}
+	return true
+}
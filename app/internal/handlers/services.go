@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
 	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/repositories"
 	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/services"
@@ -15,6 +16,17 @@ type ServiceHandler struct {
 	ServiceService services.ServiceService
 }
 
+// LocationPriceInput — цена услуги в филиале
+type LocationPriceInput struct {
+	Price models.Money `json:"price" binding:"required"` // {"amount": "1500.00", "currency": "RUB"}; валюта должна совпадать с валютой услуги
+}
+
+// BarberServiceInput — индивидуальные цена и длительность услуги у сотрудника; пустое поле — значение услуги
+type BarberServiceInput struct {
+	Price    *models.Money `json:"price"`    // В валюте услуги
+	Duration *int          `json:"duration"` // Минуты
+}
+
 func NewServiceHandler(serviceService services.ServiceService) *ServiceHandler {
 	return &ServiceHandler{
 		ServiceService: serviceService,
@@ -39,12 +51,12 @@ func (h *ServiceHandler) CreateServiceHandler(c *gin.Context) {
 		return
 	}
 
-	if service.Price <= 0 || service.Duration <= 0 {
+	if !service.Price.IsPositive() || service.Duration <= 0 {
 		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Цена и продолжительность должны быть больше нуля"))
 		return
 	}
 
-	if err := h.ServiceService.CreateService(&service); err != nil {
+	if err := h.ServiceService.CreateService(c.GetInt("user_id"), &service); err != nil {
 		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Не удалось создать услугу"))
 		return
 	}
@@ -57,16 +69,23 @@ func (h *ServiceHandler) CreateServiceHandler(c *gin.Context) {
 // @Description Возвращает список всех услуг
 // @Tags Услуги
 // @Produce json
+// @Param limit query int false "Размер страницы (по умолчанию 50, не более 200)"
+// @Param offset query int false "Смещение"
+// @Param cursor query string false "Курсор следующей страницы из meta.next_cursor"
+// @Param sort query string false "Сортировка через запятую, '-' — по убыванию: name, price, duration, created_at, id"
+// @Param is_active query bool false "Только активные или только неактивные услуги"
 // @Success 200 {array} models.Service
+// @Failure 400 {object} map[string]interface{} "Некорректные параметры списка"
 // @Failure 500 {object} map[string]interface{} "Ошибка сервера"
 // @Router /services [get]
 func (h *ServiceHandler) GetAllServicesHandler(c *gin.Context) {
-	services, err := h.ServiceService.GetAllServices()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Не удалось получить список услуг"))
+	query, ok := parseListQuery(c)
+	if !ok {
 		return
 	}
-	c.JSON(http.StatusOK, utils.SuccessResponse(services))
+
+	services, total, err := h.ServiceService.GetAllServices(query)
+	respondList(c, services, query, total, err, "Не удалось получить список услуг")
 }
 
 // @Summary Получить услугу
@@ -125,14 +144,16 @@ func (h *ServiceHandler) UpdateServiceHandler(c *gin.Context) {
 		return
 	}
 
-	if input.Price <= 0 || input.Duration <= 0 {
+	if !input.Price.IsPositive() || input.Duration <= 0 {
 		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Цена и продолжительность должны быть больше нуля"))
 		return
 	}
 
-	if err := h.ServiceService.UpdateService(id, &input); err != nil {
+	if err := h.ServiceService.UpdateService(c.GetInt("user_id"), id, &input); err != nil {
 		if err == repositories.ErrServiceNotFound {
 			c.JSON(http.StatusNotFound, utils.ErrorResponse("Услуга не найдена"))
+		} else if errors.Is(err, models.ErrCurrencyMismatch) {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse("Нельзя сменить валюту услуги, пока заданы цены в филиалах или у сотрудников"))
 		} else {
 			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Не удалось обновить услугу"))
 		}
@@ -158,8 +179,12 @@ func (h *ServiceHandler) DeleteServiceHandler(c *gin.Context) {
 		return
 	}
 
-	if err := h.ServiceService.DeleteService(id); err != nil {
-		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Не удалось удалить услугу"))
+	if err := h.ServiceService.DeleteService(c.GetInt("user_id"), id); err != nil {
+		if err == repositories.ErrServiceNotFound {
+			c.JSON(http.StatusNotFound, utils.ErrorResponse("Услуга не найдена"))
+		} else {
+			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Не удалось удалить услугу"))
+		}
 		return
 	}
 
@@ -182,10 +207,212 @@ func (h *ServiceHandler) DeactivateServiceHandler(c *gin.Context) {
 		return
 	}
 
-	if err := h.ServiceService.DeactivateService(id); err != nil {
-		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Не удалось деактивировать услугу"))
+	if err := h.ServiceService.DeactivateService(c.GetInt("user_id"), id); err != nil {
+		if err == repositories.ErrServiceNotFound {
+			c.JSON(http.StatusNotFound, utils.ErrorResponse("Услуга не найдена"))
+		} else {
+			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Не удалось деактивировать услугу"))
+		}
 		return
 	}
 
 	c.JSON(http.StatusOK, utils.SuccessResponse("Услуга успешно деактивирована"))
 }
+
+// @Summary Задать цену услуги в филиале
+// @Security BearerAuth
+// @Description Задает цену услуги в филиале вместо базовой
+// @Tags Услуги
+// @Accept json
+// @Produce json
+// @Param id path int true "ID услуги"
+// @Param location_id path int true "ID филиала"
+// @Param price body LocationPriceInput true "Цена в филиале"
+// @Success 200 {object} models.Service
+// @Failure 400 {object} map[string]interface{} "Некорректный запрос"
+// @Failure 404 {object} map[string]interface{} "Услуга или филиал не найдены"
+// @Failure 500 {object} map[string]interface{} "Ошибка сервера"
+// @Router /services/{id}/locations/{location_id}/price [put]
+func (h *ServiceHandler) SetLocationPriceHandler(c *gin.Context) {
+	id, locationID, ok := parseServiceLocation(c)
+	if !ok {
+		return
+	}
+
+	var input LocationPriceInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректные данные: "+err.Error()))
+		return
+	}
+
+	service, err := h.ServiceService.SetLocationPrice(c.GetInt("user_id"), id, locationID, input.Price)
+	if err != nil {
+		respondServiceError(c, err, "Не удалось задать цену в филиале")
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse(service))
+}
+
+// @Summary Удалить цену услуги в филиале
+// @Security BearerAuth
+// @Description Возвращает услуге в филиале базовую цену
+// @Tags Услуги
+// @Param id path int true "ID услуги"
+// @Param location_id path int true "ID филиала"
+// @Success 200 {object} map[string]interface{} "Сообщение об успешном удалении"
+// @Failure 400 {object} map[string]interface{} "Некорректный запрос"
+// @Failure 404 {object} map[string]interface{} "Услуга не найдена или цена в филиале не задана"
+// @Failure 500 {object} map[string]interface{} "Ошибка сервера"
+// @Router /services/{id}/locations/{location_id}/price [delete]
+func (h *ServiceHandler) DeleteLocationPriceHandler(c *gin.Context) {
+	id, locationID, ok := parseServiceLocation(c)
+	if !ok {
+		return
+	}
+
+	if err := h.ServiceService.DeleteLocationPrice(c.GetInt("user_id"), id, locationID); err != nil {
+		respondServiceError(c, err, "Не удалось удалить цену в филиале")
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Цена в филиале удалена"))
+}
+
+// @Summary Сотрудники, выполняющие услугу
+// @Security BearerAuth
+// @Description Возвращает сотрудников, которые выполняют услугу, с их индивидуальными ценами и длительностями. С app.allow_unassigned_staff в список входят и барберы без назначения (без price и duration)
+// @Tags Услуги
+// @Produce json
+// @Param id path int true "ID услуги"
+// @Success 200 {array} models.BarberService
+// @Failure 400 {object} map[string]interface{} "Некорректный запрос"
+// @Failure 404 {object} map[string]interface{} "Услуга не найдена"
+// @Failure 500 {object} map[string]interface{} "Ошибка сервера"
+// @Router /services/{id}/barbers [get]
+func (h *ServiceHandler) GetServiceBarbersHandler(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректный ID услуги"))
+		return
+	}
+
+	barbers, err := h.ServiceService.GetServiceBarbers(id)
+	if err != nil {
+		respondServiceError(c, err, "Не удалось получить сотрудников услуги")
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse(barbers))
+}
+
+// @Summary Назначить услугу сотруднику
+// @Security BearerAuth
+// @Description Назначает услугу сотруднику или меняет его индивидуальные цену и длительность
+// @Tags Услуги
+// @Accept json
+// @Produce json
+// @Param id path int true "ID услуги"
+// @Param user_id path int true "ID сотрудника"
+// @Param terms body BarberServiceInput true "Цена и длительность у сотрудника"
+// @Success 200 {object} models.BarberService
+// @Failure 400 {object} map[string]interface{} "Некорректный запрос"
+// @Failure 404 {object} map[string]interface{} "Услуга или сотрудник не найдены"
+// @Failure 500 {object} map[string]interface{} "Ошибка сервера"
+// @Router /services/{id}/barbers/{user_id} [put]
+func (h *ServiceHandler) AssignBarberHandler(c *gin.Context) {
+	id, userID, ok := parseServiceBarber(c)
+	if !ok {
+		return
+	}
+
+	var input BarberServiceInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректные данные: "+err.Error()))
+		return
+	}
+
+	assignment := &models.BarberService{ServiceID: id, UserID: userID, Price: input.Price, Duration: input.Duration}
+	if err := h.ServiceService.AssignBarber(c.GetInt("user_id"), assignment); err != nil {
+		respondServiceError(c, err, "Не удалось назначить услугу сотруднику")
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse(assignment))
+}
+
+// @Summary Снять услугу с сотрудника
+// @Security BearerAuth
+// @Description Сотрудник перестает выполнять услугу; существующие бронирования сохраняются
+// @Tags Услуги
+// @Param id path int true "ID услуги"
+// @Param user_id path int true "ID сотрудника"
+// @Success 200 {object} map[string]interface{} "Сообщение об успешном удалении"
+// @Failure 400 {object} map[string]interface{} "Некорректный запрос"
+// @Failure 404 {object} map[string]interface{} "Услуга не назначена сотруднику"
+// @Failure 500 {object} map[string]interface{} "Ошибка сервера"
+// @Router /services/{id}/barbers/{user_id} [delete]
+func (h *ServiceHandler) UnassignBarberHandler(c *gin.Context) {
+	id, userID, ok := parseServiceBarber(c)
+	if !ok {
+		return
+	}
+
+	if err := h.ServiceService.UnassignBarber(c.GetInt("user_id"), id, userID); err != nil {
+		respondServiceError(c, err, "Не удалось снять услугу с сотрудника")
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Услуга снята с сотрудника"))
+}
+
+// parseServiceBarber разбирает ID услуги и сотрудника из пути.
+// Если они некорректны, ответ уже отправлен и возвращается false.
+func parseServiceBarber(c *gin.Context) (int, int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректный ID услуги"))
+		return 0, 0, false
+	}
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректный ID сотрудника"))
+		return 0, 0, false
+	}
+	return id, userID, true
+}
+
+// parseServiceLocation разбирает ID услуги и филиала из пути.
+// Если они некорректны, ответ уже отправлен и возвращается false.
+func parseServiceLocation(c *gin.Context) (int, int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректный ID услуги"))
+		return 0, 0, false
+	}
+	locationID, err := strconv.Atoi(c.Param("location_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректный ID филиала"))
+		return 0, 0, false
+	}
+	return id, locationID, true
+}
+
+// respondServiceError отвечает на ошибку операции с услугой
+func respondServiceError(c *gin.Context, err error, failMessage string) {
+	switch {
+	case errors.Is(err, repositories.ErrServiceNotFound):
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Услуга не найдена"))
+	case errors.Is(err, repositories.ErrLocationNotFound):
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Филиал не найден"))
+	case errors.Is(err, repositories.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Сотрудник не найден"))
+	case errors.Is(err, repositories.ErrServiceLocationPriceNotFound), errors.Is(err, repositories.ErrBarberServiceNotFound):
+		c.JSON(http.StatusNotFound, utils.ErrorResponse(err.Error()))
+	case errors.Is(err, services.ErrInvalidServicePrice), errors.Is(err, services.ErrInvalidServiceDuration),
+		errors.Is(err, models.ErrCurrencyMismatch):
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error()))
+	default:
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse(failMessage))
+	}
+}
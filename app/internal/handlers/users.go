@@ -11,13 +11,35 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// UserInput описывает входные данные для создания и обновления сотрудника.
+// Пароль передается открытым текстом и хешируется в UserService.
+type UserInput struct {
+	Username    string `json:"username"`
+	Password    string `json:"password"`
+	Role        string `json:"role"`
+	Email       string `json:"email"`
+	PhoneNumber string `json:"phone_number"`
+}
+
+func (in UserInput) toModel() *models.User {
+	return &models.User{
+		Username:     in.Username,
+		PasswordHash: in.Password,
+		Role:         in.Role,
+		Email:        in.Email,
+		PhoneNumber:  in.PhoneNumber,
+	}
+}
+
 type UserHandler struct {
 	UserService services.UserService
+	AuthService services.AuthService
 }
 
-func NewUserHandler(userService services.UserService) *UserHandler {
+func NewUserHandler(userService services.UserService, authService services.AuthService) *UserHandler {
 	return &UserHandler{
 		UserService: userService,
+		AuthService: authService,
 	}
 }
 
@@ -27,19 +49,20 @@ func NewUserHandler(userService services.UserService) *UserHandler {
 // @Tags Пользователи
 // @Accept json
 // @Produce json
-// @Param user body models.User true "Данные пользователя"
+// @Param user body UserInput true "Данные пользователя"
 // @Success 201 {object} models.User
 // @Failure 400 {object} map[string]interface{} "Некорректный запрос"
 // @Failure 500 {object} map[string]interface{} "Ошибка сервера"
 // @Router /users [post]
 func (h *UserHandler) CreateUserHandler(c *gin.Context) {
-	var user models.User
-	if err := c.ShouldBindJSON(&user); err != nil {
+	var input UserInput
+	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректные данные: "+err.Error()))
 		return
 	}
 
-	if err := h.UserService.CreateUser(&user); err != nil {
+	user := input.toModel()
+	if err := h.UserService.CreateUser(c.GetInt("user_id"), user); err != nil {
 		c.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error()))
 		return
 	}
@@ -52,17 +75,24 @@ func (h *UserHandler) CreateUserHandler(c *gin.Context) {
 // @Description Возвращает список всех пользователей
 // @Tags Пользователи
 // @Produce json
+// @Param limit query int false "Размер страницы (по умолчанию 50, не более 200)"
+// @Param offset query int false "Смещение"
+// @Param cursor query string false "Курсор следующей страницы из meta.next_cursor"
+// @Param sort query string false "Сортировка через запятую, '-' — по убыванию: username, role, created_at, last_login_at, id"
+// @Param role query string false "Роль сотрудника"
+// @Param location_id query int false "ID филиала"
 // @Success 200 {array} models.User
+// @Failure 400 {object} map[string]interface{} "Некорректные параметры списка"
 // @Failure 500 {object} map[string]interface{} "Ошибка сервера"
 // @Router /users [get]
 func (h *UserHandler) GetAllUsersHandler(c *gin.Context) {
-	users, err := h.UserService.GetAllUsers()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Не удалось получить список пользователей"))
+	query, ok := parseListQuery(c)
+	if !ok {
 		return
 	}
 
-	c.JSON(http.StatusOK, utils.SuccessResponse(users))
+	users, total, err := h.UserService.GetAllUsers(query)
+	respondList(c, users, query, total, err, "Не удалось получить список пользователей")
 }
 
 // @Summary Получить пользователя
@@ -102,7 +132,7 @@ func (h *UserHandler) GetUserHandler(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path int true "ID пользователя"
-// @Param user body models.User true "Обновленные данные пользователя"
+// @Param user body UserInput true "Обновленные данные пользователя"
 // @Success 200 {object} map[string]interface{} "Пользователь успешно обновлен"
 // @Failure 400 {object} map[string]interface{} "Некорректный запрос"
 // @Failure 404 {object} map[string]interface{} "Пользователь не найден"
@@ -115,13 +145,13 @@ func (h *UserHandler) UpdateUserHandler(c *gin.Context) {
 		return
 	}
 
-	var input models.User
+	var input UserInput
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректные данные: "+err.Error()))
 		return
 	}
 
-	if err := h.UserService.UpdateUser(id, &input); err != nil {
+	if err := h.UserService.UpdateUser(c.GetInt("user_id"), id, input.toModel()); err != nil {
 		if err == repositories.ErrUserNotFound {
 			c.JSON(http.StatusNotFound, utils.ErrorResponse("Пользователь не найден"))
 		} else {
@@ -135,7 +165,7 @@ func (h *UserHandler) UpdateUserHandler(c *gin.Context) {
 
 // @Summary Удалить пользователя
 // @Security BearerAuth
-// @Description Удаляет пользователя
+// @Description Удаляет пользователя и завершает все его сессии
 // @Tags Пользователи
 // @Param id path int true "ID пользователя"
 // @Success 200 {object} map[string]interface{} "Пользователь успешно удален"
@@ -149,47 +179,43 @@ func (h *UserHandler) DeleteUserHandler(c *gin.Context) {
 		return
 	}
 
-	if err := h.UserService.DeleteUser(id); err != nil {
-		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Не удалось удалить пользователя"))
+	if err := h.UserService.DeleteUser(c.GetInt("user_id"), id); err != nil {
+		if err == repositories.ErrUserNotFound {
+			c.JSON(http.StatusNotFound, utils.ErrorResponse("Пользователь не найден"))
+		} else {
+			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Не удалось удалить пользователя"))
+		}
+		return
+	}
+
+	if err := h.AuthService.RevokeUserSessions(id); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Пользователь удален, но не удалось завершить его сессии"))
 		return
 	}
 
 	c.JSON(http.StatusOK, utils.SuccessResponse("Пользователь успешно удален"))
 }
 
-//// @Summary Аутентификация пользователя
-//// @Security BearerAuth
-//// @Description Проверяет email или username и пароль для аутентификации
-//// @Tags Пользователи
-//// @Accept json
-//// @Produce json
-//// @Param credentials body map[string]string true "Email/Username и пароль"
-//// @Success 200 {object} map[string]interface{} "Аутентификация успешна"
-//// @Failure 400 {object} map[string]interface{} "Некорректный запрос"
-//// @Failure 401 {object} map[string]interface{} "Неверные учетные данные"
-//// @Router /users/authenticate [post]
-//func (h *UserHandler) AuthenticateUserHandler(c *gin.Context) {
-//	var credentials map[string]string
-//	if err := c.ShouldBindJSON(&credentials); err != nil {
-//		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректные данные: "+err.Error()))
-//		return
-//	}
-//
-//	identifier := credentials["identifier"]
-//	password := credentials["password"]
-//
-//	if identifier == "" || password == "" {
-//		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Обязательные поля: identifier и password"))
-//		return
-//	}
-//
-//	user, err := h.UserService.AuthenticateUser(identifier, password)
-//	if err != nil {
-//		c.JSON(http.StatusUnauthorized, utils.ErrorResponse(err.Error()))
-//		return
-//	}
-//
-//	// Здесь можно обновить поле LastLoginAt или сгенерировать JWT токен
-//
-//	c.JSON(http.StatusOK, utils.SuccessResponse("Аутентификация успешна"))
-//}
+// @Summary Завершить сессии пользователя
+// @Security BearerAuth
+// @Description Отзывает все refresh- и access-токены пользователя, например при увольнении сотрудника
+// @Tags Пользователи
+// @Param id path int true "ID пользователя"
+// @Success 200 {object} map[string]interface{} "Сессии пользователя завершены"
+// @Failure 400 {object} map[string]interface{} "Некорректный запрос"
+// @Failure 500 {object} map[string]interface{} "Ошибка сервера"
+// @Router /users/{id}/revoke-sessions [post]
+func (h *UserHandler) RevokeUserSessionsHandler(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Некорректный ID пользователя"))
+		return
+	}
+
+	if err := h.AuthService.RevokeUserSessions(id); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Не удалось завершить сессии пользователя"))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Сессии пользователя завершены"))
+}
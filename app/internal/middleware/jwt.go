@@ -3,12 +3,18 @@ package middleware
 import (
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/auth"
 	"github.com/gin-gonic/gin"
 )
 
-func JWTMiddleware() gin.HandlerFunc {
+// RevocationChecker сообщает, отозван ли access-токен с указанным jti
+type RevocationChecker interface {
+	IsTokenRevoked(jti string) (bool, error)
+}
+
+func JWTMiddleware(revocations RevocationChecker) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -25,7 +31,28 @@ func JWTMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		if tenantID, ok := c.Get("tenant_id"); ok && tenantID != claims.TenantID {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token belongs to another tenant"})
+			c.Abort()
+			return
+		}
+
+		revoked, err := revocations.IsTokenRevoked(claims.Id)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify token"})
+			c.Abort()
+			return
+		}
+		if revoked {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token has been revoked"})
+			c.Abort()
+			return
+		}
+
 		c.Set("user_id", claims.UserID)
+		c.Set("role", claims.Role)
+		c.Set("jti", claims.Id)
+		c.Set("token_expires_at", time.Unix(claims.ExpiresAt, 0))
 		c.Next()
 	}
 }
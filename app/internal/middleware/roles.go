@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// Policy описывает, каким ролям доступна группа маршрутов
+type Policy struct {
+	Read  []string // GET
+	Write []string // POST, PUT, DELETE
+}
+
+// Authorize проверяет роль из JWT по матрице прав группы маршрутов.
+// Должен подключаться после JWTMiddleware.
+func Authorize(policy Policy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		allowed := policy.Write
+		if c.Request.Method == http.MethodGet {
+			allowed = policy.Read
+		}
+
+		role := c.GetString("role")
+		for _, r := range allowed {
+			if r == role {
+				c.Next()
+				return
+			}
+		}
+
+		c.JSON(http.StatusForbidden, utils.ErrorResponse("Недостаточно прав"))
+		c.Abort()
+	}
+}
+
+// BarberScope возвращает ID сотрудника, если текущий пользователь — барбер
+// и доступ ограничен его собственными бронированиями, расписаниями и перерывами
+func BarberScope(c *gin.Context) (int, bool) {
+	if c.GetString("role") != models.RoleBarber {
+		return 0, false
+	}
+	return c.GetInt("user_id"), true
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/auth"
+	"github.com/gin-gonic/gin"
+)
+
+// TenantHeader — заголовок, в котором передается арендатор для запросов без JWT (вход, обновление токена)
+const TenantHeader = "X-Tenant-ID"
+
+var (
+	ErrTenantRequired = errors.New("не указан арендатор: передайте JWT или заголовок " + TenantHeader)
+	ErrInvalidTenant  = errors.New("некорректный заголовок " + TenantHeader)
+	ErrTenantMismatch = errors.New("заголовок " + TenantHeader + " не совпадает с арендатором токена")
+)
+
+// ResolveTenant определяет арендатора запроса. Арендатор из действительного JWT имеет приоритет;
+// заголовок X-Tenant-ID используется для запросов без токена и должен совпадать с токеном, если передан вместе с ним.
+// Недействительный токен не учитывается: его отклонит JWTMiddleware.
+func ResolveTenant(r *http.Request) (int, error) {
+	headerTenantID := 0
+	if header := strings.TrimSpace(r.Header.Get(TenantHeader)); header != "" {
+		id, err := strconv.Atoi(header)
+		if err != nil || id <= 0 {
+			return 0, ErrInvalidTenant
+		}
+		headerTenantID = id
+	}
+
+	if authHeader := r.Header.Get("Authorization"); authHeader != "" {
+		claims, err := auth.ValidateToken(strings.TrimPrefix(authHeader, "Bearer "))
+		if err == nil && claims.TenantID > 0 {
+			if headerTenantID != 0 && headerTenantID != claims.TenantID {
+				return 0, ErrTenantMismatch
+			}
+			return claims.TenantID, nil
+		}
+	}
+
+	if headerTenantID == 0 {
+		return 0, ErrTenantRequired
+	}
+	return headerTenantID, nil
+}
+
+// TenantMiddleware закрепляет маршруты за арендатором tenantID: JWTMiddleware
+// отклоняет токены, выпущенные для другого арендатора
+func TenantMiddleware(tenantID int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("tenant_id", tenantID)
+		c.Next()
+	}
+}
@@ -2,12 +2,26 @@ package models
 
 import "time"
 
+// Статусы бронирования
+const (
+	BookingStatusPending    = "pending"
+	BookingStatusConfirmed  = "confirmed"
+	BookingStatusInProgress = "in_progress"
+	BookingStatusCompleted  = "completed"
+	BookingStatusCancelled  = "cancelled"
+	BookingStatusNoShow     = "no_show"
+)
+
 type Bookings struct {
 	ID          int       `gorm:"primaryKey" json:"id"`
+	TenantID    int       `gorm:"not null;default:1;index" json:"-"` // Арендатор (барбершоп), которому принадлежит запись
 	ClientID    int       `gorm:"not null;index" json:"client_id"`
-	ServiceID   int       `gorm:"not null;index" json:"service_id"`
+	ServiceID   int       `gorm:"not null;index" json:"service_id"` // Первая услуга бронирования
 	UserID      int       `gorm:"not null;index" json:"user_id"`
-	BookingTime time.Time `gorm:"not null" json:"booking_time"`
+	LocationID  int       `gorm:"index" json:"location_id"`                    // Филиал; 0 — в любом филиале сотрудника
+	BookingTime time.Time `gorm:"not null" json:"booking_time"`                // Момент начала в UTC (timestamptz); в API — RFC 3339 со смещением
+	Price       Money     `gorm:"embedded;embeddedPrefix:price_" json:"price"` // Сумма цен услуг у сотрудника в филиале на момент записи
+	Duration    int       `json:"duration"`                                    // Сумма длительностей услуг в минутах; 0 — длительность услуги
 	Status      string    `gorm:"size:50;default:'pending'" json:"status"`
 	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updated_at"`
@@ -15,4 +29,58 @@ type Bookings struct {
 	Client  Client  `gorm:"foreignKey:ClientID" json:"client"`
 	Service Service `gorm:"foreignKey:ServiceID" json:"service"`
 	User    User    `gorm:"foreignKey:UserID" json:"user"`
+
+	// Items — услуги визита в порядке выполнения. При создании достаточно передать service_id позиций,
+	// без Items бронирование состоит из одной услуги ServiceID.
+	Items []BookingItem `gorm:"foreignKey:BookingID" json:"items"`
+
+	StatusHistory []BookingStatusChange `gorm:"foreignKey:BookingID" json:"status_history,omitempty"`
+}
+
+// Length возвращает длительность визита. Для бронирований без зафиксированной длительности
+// берется длительность услуги, которая должна быть загружена в Service.
+func (b Bookings) Length() time.Duration {
+	if b.Duration > 0 {
+		return time.Duration(b.Duration) * time.Minute
+	}
+	return time.Duration(b.Service.Duration) * time.Minute
+}
+
+// BookingItem — услуга в составе бронирования. Услуги выполняются подряд в порядке Position,
+// поэтому визит длится сумму длительностей позиций
+type BookingItem struct {
+	ID        int       `gorm:"primaryKey" json:"id"`
+	TenantID  int       `gorm:"not null;default:1;index" json:"-"` // Арендатор (барбершоп), которому принадлежит запись
+	BookingID int       `gorm:"not null;index" json:"booking_id"`
+	ServiceID int       `gorm:"not null;index" json:"service_id"`
+	Position  int       `gorm:"not null" json:"position"`                    // Порядковый номер в визите, с 0
+	Price     Money     `gorm:"embedded;embeddedPrefix:price_" json:"price"` // Цена услуги у сотрудника в филиале на момент записи
+	Duration  int       `gorm:"not null" json:"duration"`                    // Длительность в минутах у сотрудника
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+
+	Service Service `gorm:"foreignKey:ServiceID" json:"service"`
+}
+
+// ServiceIDs возвращает услуги бронирования в порядке выполнения
+func (b Bookings) ServiceIDs() []int {
+	if len(b.Items) == 0 {
+		return []int{b.ServiceID}
+	}
+	ids := make([]int, 0, len(b.Items))
+	for _, item := range b.Items {
+		ids = append(ids, item.ServiceID)
+	}
+	return ids
+}
+
+// BookingStatusChange фиксирует переход бронирования между статусами: кто и когда его выполнил
+type BookingStatusChange struct {
+	ID         int       `gorm:"primaryKey" json:"id"`
+	TenantID   int       `gorm:"not null;default:1;index" json:"-"` // Арендатор (барбершоп), которому принадлежит запись
+	BookingID  int       `gorm:"not null;index" json:"booking_id"`
+	FromStatus string    `gorm:"size:50;not null" json:"from_status"`
+	ToStatus   string    `gorm:"size:50;not null" json:"to_status"`
+	ChangedBy  int       `gorm:"not null" json:"changed_by"` // ID пользователя из JWT
+	ChangedAt  time.Time `gorm:"autoCreateTime" json:"changed_at"`
 }
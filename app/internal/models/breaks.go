@@ -2,13 +2,63 @@ package models
 
 import "time"
 
+// Правила повторения перерыва
+const (
+	// BreakRecurrenceDaily — каждый день
+	BreakRecurrenceDaily = "daily"
+	// BreakRecurrenceWeekdays — с понедельника по пятницу
+	BreakRecurrenceWeekdays = "weekdays"
+	// BreakRecurrenceWeekly — каждую неделю в дни RecurrenceDays
+	BreakRecurrenceWeekly = "weekly"
+)
+
+// IsValidBreakRecurrence проверяет правило повторения; пустое значение — разовый перерыв
+func IsValidBreakRecurrence(recurrence string) bool {
+	switch recurrence {
+	case "", BreakRecurrenceDaily, BreakRecurrenceWeekdays, BreakRecurrenceWeekly:
+		return true
+	}
+	return false
+}
+
+// Break — перерыв сотрудника. Для повторяющегося перерыва BreakStart и BreakEnd задают первое вхождение,
+// следующие вхождения начинаются в то же время суток в дни, подходящие под правило Recurrence.
 type Break struct {
-	ID         int       `gorm:"primaryKey" json:"id"`
-	UserID     int       `gorm:"not null;index" json:"user_id"`
-	BreakStart time.Time `gorm:"not null" json:"break_start"`
-	BreakEnd   time.Time `gorm:"not null" json:"break_end"`
-	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt  time.Time `gorm:"autoUpdateTime" json:"updated_at"`
-
-	User User `gorm:"foreignKey:UserID" json:"user"`
+	ID              int        `gorm:"primaryKey" json:"id"`
+	TenantID        int        `gorm:"not null;default:1;index" json:"-"` // Арендатор (барбершоп), которому принадлежит запись
+	UserID          int        `gorm:"not null;index" json:"user_id"`
+	LocationID      int        `gorm:"index" json:"location_id"` // Филиал, в часовом поясе которого повторяется перерыв
+	BreakStart      time.Time  `gorm:"not null" json:"break_start"`
+	BreakEnd        time.Time  `gorm:"not null" json:"break_end"`
+	Recurrence      string     `gorm:"size:20" json:"recurrence,omitempty"`
+	RecurrenceDays  []Weekday  `gorm:"type:text;serializer:json" json:"recurrence_days,omitempty"` // Дни недели для weekly
+	RecurrenceUntil *time.Time `json:"recurrence_until,omitempty"`                                 // Последний день повторения включительно; пусто — без ограничения
+	SeriesID        *int       `gorm:"index" json:"series_id,omitempty"`                           // Серия, из которой перерыв выделен при изменении одного вхождения
+	CreatedAt       time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt       time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	User       User             `gorm:"foreignKey:UserID" json:"user"`
+	Exceptions []BreakException `gorm:"foreignKey:BreakID" json:"exceptions,omitempty"`
+}
+
+// IsRecurring сообщает, что перерыв повторяется
+func (b Break) IsRecurring() bool {
+	return b.Recurrence != ""
+}
+
+// BreakException — вхождение повторяющегося перерыва, которое удалено или заменено отдельным перерывом
+type BreakException struct {
+	ID             int       `gorm:"primaryKey" json:"id"`
+	TenantID       int       `gorm:"not null;default:1;index" json:"-"` // Арендатор (барбершоп), которому принадлежит запись
+	BreakID        int       `gorm:"not null;uniqueIndex:idx_break_exception" json:"break_id"`
+	OccurrenceDate string    `gorm:"size:10;not null;uniqueIndex:idx_break_exception" json:"occurrence_date"` // Дата вхождения в формате ГГГГ-ММ-ДД
+	CreatedAt      time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// BreakOccurrence — конкретное вхождение перерыва
+type BreakOccurrence struct {
+	BreakID int       `json:"break_id"`
+	UserID  int       `json:"user_id"`
+	Start   time.Time `json:"start"`
+	End     time.Time `json:"end"`
 }
@@ -0,0 +1,131 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	ErrInvalidClientField = errors.New("некорректное дополнительное поле: ключ из латинских букв, цифр и _, тип text, number, boolean, date или select; у select — варианты")
+	ErrInvalidFieldValue  = errors.New("некорректное значение дополнительного поля")
+	ErrInvalidTag         = errors.New("некорректный тег: от 1 до 50 символов")
+)
+
+// Типы дополнительных полей клиента и значения, которые в них хранятся
+const (
+	ClientFieldText    = "text"    // Строка до 1000 символов
+	ClientFieldNumber  = "number"  // Число
+	ClientFieldBoolean = "boolean" // true или false
+	ClientFieldDate    = "date"    // Дата YYYY-MM-DD
+	ClientFieldSelect  = "select"  // Один из вариантов Options
+)
+
+const (
+	maxFieldTextLength = 1000
+	maxTagLength       = 50
+)
+
+var clientFieldKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+
+// ClientField — дополнительное поле карточки клиента, которое настраивает арендатор,
+// например «Дата рождения» (date) или «Тип волос» (select). Значения хранятся в Client.CustomFields по Key.
+type ClientField struct {
+	ID        int       `gorm:"primaryKey" json:"id"`
+	TenantID  int       `gorm:"not null;default:1;index;uniqueIndex:idx_client_fields_tenant_key" json:"-"` // Арендатор (барбершоп), которому принадлежит запись
+	Key       string    `gorm:"size:50;not null;uniqueIndex:idx_client_fields_tenant_key" json:"key"`       // Ключ в custom_fields; не меняется после создания
+	Label     string    `gorm:"size:100;not null" json:"label"`
+	Type      string    `gorm:"size:20;not null" json:"type"`                       // Не меняется после создания
+	Options   []string  `gorm:"type:text;serializer:json" json:"options,omitempty"` // Варианты для select
+	Required  bool      `json:"required"`
+	Position  int       `gorm:"default:0" json:"position"` // Порядок в карточке клиента
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// Validate проверяет ключ, тип и варианты поля
+func (f *ClientField) Validate() error {
+	if !clientFieldKeyPattern.MatchString(f.Key) || strings.TrimSpace(f.Label) == "" {
+		return ErrInvalidClientField
+	}
+	switch f.Type {
+	case ClientFieldText, ClientFieldNumber, ClientFieldBoolean, ClientFieldDate:
+		if len(f.Options) > 0 {
+			return ErrInvalidClientField
+		}
+	case ClientFieldSelect:
+		if len(f.Options) == 0 {
+			return ErrInvalidClientField
+		}
+		for _, option := range f.Options {
+			if strings.TrimSpace(option) == "" {
+				return ErrInvalidClientField
+			}
+		}
+	default:
+		return ErrInvalidClientField
+	}
+	return nil
+}
+
+// NormalizeValue приводит значение из JSON к типу поля: text и select — строка, number — float64,
+// boolean — bool, date — строка YYYY-MM-DD
+func (f *ClientField) NormalizeValue(value interface{}) (interface{}, error) {
+	invalid := fmt.Errorf("%w %s: ожидается %s", ErrInvalidFieldValue, f.Key, f.Type)
+	switch f.Type {
+	case ClientFieldText:
+		text, ok := value.(string)
+		if !ok || utf8.RuneCountInString(text) > maxFieldTextLength {
+			return nil, invalid
+		}
+		return strings.TrimSpace(text), nil
+	case ClientFieldNumber:
+		switch number := value.(type) {
+		case float64:
+			return number, nil
+		case int:
+			return float64(number), nil
+		case int64:
+			return float64(number), nil
+		}
+	case ClientFieldBoolean:
+		if flag, ok := value.(bool); ok {
+			return flag, nil
+		}
+	case ClientFieldDate:
+		if date, ok := value.(string); ok {
+			if _, err := time.Parse("2006-01-02", date); err == nil {
+				return date, nil
+			}
+		}
+	case ClientFieldSelect:
+		if option, ok := value.(string); ok {
+			for _, allowed := range f.Options {
+				if option == allowed {
+					return option, nil
+				}
+			}
+		}
+	}
+	return nil, invalid
+}
+
+// NormalizeTags приводит теги к нижнему регистру, убирает лишние пробелы и повторы, сохраняя порядок
+func NormalizeTags(tags []string) ([]string, error) {
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.Join(strings.Fields(tag), " "))
+		if tag == "" || utf8.RuneCountInString(tag) > maxTagLength {
+			return nil, ErrInvalidTag
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized, nil
+}
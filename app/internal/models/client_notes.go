@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// ClientNote — заметка сотрудника о клиенте: «аллергия на средство X», «предпочитает насадку №2»
+type ClientNote struct {
+	ID        int       `gorm:"primaryKey" json:"id"`
+	TenantID  int       `gorm:"not null;default:1;index" json:"-"` // Арендатор (барбершоп), которому принадлежит запись
+	ClientID  int       `gorm:"not null;index" json:"client_id"`
+	AuthorID  int       `gorm:"not null" json:"author_id"` // Сотрудник, оставивший или последним изменивший заметку
+	Text      string    `gorm:"type:text;not null" json:"text"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
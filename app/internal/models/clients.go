@@ -1,15 +1,42 @@
 package models
 
-import "time"
+import (
+	"strconv"
+	"time"
+)
 
 type Client struct {
-	ID          int       `gorm:"primaryKey" json:"id"`
-	FirstName   string    `json:"first_name"`
-	LastName    string    `json:"last_name"`
-	Email       string    `gorm:"unique;size:255;index" json:"email"`
-	PhoneNumber string    `gorm:"size:20" json:"phone_number"`
-	TgID        int64     `gorm:"unique" json:"tg_id"`
-	TgNickname  string    `gorm:"size:100" json:"tg_nickname"`
-	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	ID          int    `gorm:"primaryKey" json:"id"`
+	TenantID    int    `gorm:"not null;default:1;index;uniqueIndex:idx_clients_tenant_email;uniqueIndex:idx_clients_tenant_tg_id" json:"-"` // Арендатор (барбершоп), которому принадлежит запись
+	FirstName   string `json:"first_name"`
+	LastName    string `json:"last_name"`
+	Email       string `gorm:"size:255;index;uniqueIndex:idx_clients_tenant_email" json:"email"`
+	PhoneNumber string `gorm:"size:20" json:"phone_number"`
+	TgID        int64  `gorm:"uniqueIndex:idx_clients_tenant_tg_id" json:"tg_id"`
+	TgNickname  string `gorm:"size:100" json:"tg_nickname"`
+	Language    string `gorm:"size:5;default:'ru'" json:"language"` // Язык уведомлений: ru или en
+	// Tags — метки клиента в нижнем регистре: «vip», «студент», «проблемный клиент»
+	Tags []string `gorm:"type:text;serializer:json" json:"tags"`
+	// CustomFields — значения дополнительных полей арендатора (ClientField) по ключу, приведенные к типу поля
+	CustomFields map[string]interface{} `gorm:"type:text;serializer:json" json:"custom_fields"`
+	CreatedAt    time.Time              `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time              `gorm:"autoUpdateTime" json:"updated_at"`
+
+	Notes []ClientNote `gorm:"foreignKey:ClientID" json:"notes,omitempty"` // Заметки сотрудников, новые первыми
+}
+
+// ContactFor возвращает адрес клиента в канале уведомлений: Telegram — TgID, SMS — телефон, email — Email.
+// Пустая строка означает, что связаться с клиентом по этому каналу нельзя.
+func (c *Client) ContactFor(notificationType string) string {
+	switch notificationType {
+	case NotificationTypeTelegram:
+		if c.TgID != 0 {
+			return strconv.FormatInt(c.TgID, 10)
+		}
+	case NotificationTypeSMS:
+		return c.PhoneNumber
+	case NotificationTypeEmail:
+		return c.Email
+	}
+	return ""
 }
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"errors"
+	"net/mail"
+	"strings"
+)
+
+var (
+	ErrInvalidPhone = errors.New("некорректный номер телефона: ожидается международный формат, например +79991234567")
+	ErrInvalidEmail = errors.New("некорректный email")
+)
+
+// DefaultPhoneCountryCode — код страны для номеров, записанных без него:
+// 10 цифр (9991234567) или 11 цифр с 8 в начале (89991234567)
+const DefaultPhoneCountryCode = "7"
+
+// Допустимая длина номера E.164 без «+»
+const (
+	minPhoneDigits = 8
+	maxPhoneDigits = 15
+)
+
+// NormalizePhone приводит номер к формату E.164: «+» и только цифры.
+// Пробелы, скобки, дефисы и точки отбрасываются; «+7 (999) 123-45-67», «8 999 123 45 67»
+// и «9991234567» дают +79991234567. Пустая строка остается пустой.
+func NormalizePhone(phone string) (string, error) {
+	phone = strings.TrimSpace(phone)
+	if phone == "" {
+		return "", nil
+	}
+
+	international := strings.HasPrefix(phone, "+")
+	if international {
+		phone = phone[1:]
+	} else if strings.HasPrefix(phone, "00") {
+		// Международный префикс 00 вместо «+»
+		international, phone = true, phone[2:]
+	}
+
+	digits := make([]byte, 0, len(phone))
+	for _, r := range phone {
+		switch {
+		case r >= '0' && r <= '9':
+			digits = append(digits, byte(r))
+		case r == ' ' || r == '-' || r == '(' || r == ')' || r == '.':
+		default:
+			return "", ErrInvalidPhone
+		}
+	}
+
+	number := string(digits)
+	if !international {
+		switch {
+		case len(number) == 11 && number[0] == '8':
+			number = DefaultPhoneCountryCode + number[1:]
+		case len(number) == 10:
+			number = DefaultPhoneCountryCode + number
+		}
+	}
+	if len(number) < minPhoneDigits || len(number) > maxPhoneDigits || number[0] == '0' {
+		return "", ErrInvalidPhone
+	}
+	return "+" + number, nil
+}
+
+// NormalizeEmail убирает пробелы по краям, приводит адрес к нижнему регистру и проверяет формат:
+// только адрес без имени получателя, с доменом из нескольких частей. Пустая строка остается пустой.
+func NormalizeEmail(email string) (string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" {
+		return "", nil
+	}
+
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email {
+		return "", ErrInvalidEmail
+	}
+	at := strings.LastIndex(email, "@")
+	domain := email[at+1:]
+	if !strings.Contains(domain, ".") || strings.HasPrefix(domain, ".") || strings.HasSuffix(domain, ".") {
+		return "", ErrInvalidEmail
+	}
+	return email, nil
+}
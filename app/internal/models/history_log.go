@@ -1,8 +1,44 @@
 package models
 
+import "time"
+
+// Действия, фиксируемые в журнале изменений
+const (
+	HistoryActionCreate = "create"
+	HistoryActionUpdate = "update"
+	HistoryActionDelete = "delete"
+	HistoryActionMerge  = "merge" // Объединение дубликатов: запись о сохраненной и об удаленной сущности
+)
+
+// Типы сущностей в журнале изменений
+const (
+	EntityBooking  = "booking"
+	EntityClient   = "client"
+	EntityService  = "service"
+	EntitySchedule = "schedule"
+	EntityBreak    = "break"
+	EntityUser     = "user"
+
+	EntityScheduleOverride = "schedule_override"
+	EntityLocation         = "location"
+	EntityBarberService    = "barber_service"
+	EntityClientNote       = "client_note"
+	EntityClientField      = "client_field"
+)
+
+// FieldChange — значение поля до и после изменения
+type FieldChange struct {
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after,omitempty"`
+}
+
 type HistoryLogs struct {
-	ID        uint   `gorm:"primaryKey" json:"id"`             // Уникальный идентификатор записи
-	UserID    uint   `gorm:"not null" json:"user_id"`          // Ссылка на пользователя
-	Action    string `gorm:"not null" json:"action"`           // Описание действия
-	CreatedAt string `gorm:"autoCreateTime" json:"created_at"` // Дата действия
+	ID         uint                   `gorm:"primaryKey" json:"id"`                                         // Уникальный идентификатор записи
+	TenantID   int                    `gorm:"not null;default:1;index" json:"-"`                            // Арендатор (барбершоп), которому принадлежит запись
+	UserID     int                    `gorm:"not null;index" json:"user_id"`                                // Автор изменения (ID из JWT, 0 — система)
+	Action     string                 `gorm:"not null" json:"action"`                                       // Описание действия
+	EntityType string                 `gorm:"size:50;not null;index:idx_history_entity" json:"entity_type"` // Тип измененной сущности
+	EntityID   int                    `gorm:"not null;index:idx_history_entity" json:"entity_id"`           // ID измененной сущности
+	Changes    map[string]FieldChange `gorm:"type:text;serializer:json" json:"changes"`                     // Измененные поля: значения до и после
+	CreatedAt  time.Time              `gorm:"autoCreateTime;index" json:"created_at"`                       // Дата действия
 }
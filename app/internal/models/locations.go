@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// OpeningHours — часы работы филиала в один день недели
+type OpeningHours struct {
+	Day   Weekday   `json:"day"`
+	Open  TimeOfDay `json:"open"`
+	Close TimeOfDay `json:"close"`
+}
+
+// Location — филиал сети. Расписания, перерывы и бронирования филиала отсчитываются в его часовом поясе,
+// рабочие часы сотрудников ограничиваются часами работы филиала, если они заданы.
+type Location struct {
+	ID           int            `gorm:"primaryKey" json:"id"`
+	TenantID     int            `gorm:"not null;default:1;index" json:"-"` // Арендатор (барбершоп), которому принадлежит запись
+	Name         string         `gorm:"size:100;not null" json:"name"`
+	Address      string         `gorm:"size:255" json:"address"`
+	Timezone     string         `gorm:"size:64;not null" json:"timezone"`                         // Часовой пояс IANA, например Europe/Moscow
+	OpeningHours []OpeningHours `gorm:"type:text;serializer:json" json:"opening_hours,omitempty"` // Пусто — без ограничений
+	IsActive     bool           `gorm:"default:true" json:"is_active"`
+	CreatedAt    time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TimeLocation возвращает часовой пояс филиала
+func (l Location) TimeLocation() (*time.Location, error) {
+	return time.LoadLocation(l.Timezone)
+}
@@ -0,0 +1,156 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/pkg/currency"
+	"math/big"
+	"strings"
+)
+
+var (
+	ErrInvalidMoney     = errors.New("некорректная сумма")
+	ErrInvalidCurrency  = currency.ErrUnknown
+	ErrCurrencyMismatch = errors.New("суммы в разных валютах")
+)
+
+// IsValidCurrency проверяет, что код валюты поддерживается (см. пакет currency)
+func IsValidCurrency(code string) bool {
+	return currency.IsValid(code)
+}
+
+// CurrencyExponent возвращает число знаков после запятой в валюте; для неизвестной валюты — 2
+func CurrencyExponent(code string) int {
+	return currency.Exponent(code)
+}
+
+// Money — денежная сумма в минорных единицах валюты (копейках, центах) без ошибок округления float.
+// В базе хранится двумя колонками <префикс>amount и <префикс>currency (gorm embedded),
+// в JSON — объектом {"amount": "1500.00", "currency": "RUB"} с десятичной строкой.
+type Money struct {
+	Amount   int64  // Сумма в минорных единицах
+	Currency string `gorm:"size:3"` // Код валюты ISO 4217
+}
+
+// NewMoney создает сумму из минорных единиц
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// ParseMoney разбирает десятичную сумму ("1500", "1500.5") в валюте currency.
+// Знаков после запятой не может быть больше, чем в валюте.
+func ParseMoney(amount, currency string) (Money, error) {
+	if !IsValidCurrency(currency) {
+		return Money{}, ErrInvalidCurrency
+	}
+
+	value, ok := new(big.Rat).SetString(strings.TrimSpace(amount))
+	if !ok {
+		return Money{}, ErrInvalidMoney
+	}
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(CurrencyExponent(currency))), nil)
+	value.Mul(value, new(big.Rat).SetInt(scale))
+	if !value.IsInt() || !value.Num().IsInt64() {
+		return Money{}, ErrInvalidMoney
+	}
+	return Money{Amount: value.Num().Int64(), Currency: currency}, nil
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+func (m Money) IsPositive() bool {
+	return m.Amount > 0
+}
+
+// Add складывает суммы одной валюты. Нулевое значение Money без валюты складывается с любой суммой,
+// поэтому с него можно начинать подсчет итога.
+func (m Money) Add(other Money) (Money, error) {
+	switch {
+	case m.Currency == "":
+		return Money{Amount: m.Amount + other.Amount, Currency: other.Currency}, nil
+	case other.Currency == "" || other.Currency == m.Currency:
+		return Money{Amount: m.Amount + other.Amount, Currency: m.Currency}, nil
+	}
+	return Money{}, ErrCurrencyMismatch
+}
+
+// Mul умножает сумму на целое число, например цену на количество
+func (m Money) Mul(n int64) Money {
+	return Money{Amount: m.Amount * n, Currency: m.Currency}
+}
+
+// SumMoney складывает суммы одной валюты
+func SumMoney(amounts ...Money) (Money, error) {
+	var total Money
+	for _, amount := range amounts {
+		var err error
+		if total, err = total.Add(amount); err != nil {
+			return Money{}, err
+		}
+	}
+	return total, nil
+}
+
+// Decimal возвращает сумму десятичной строкой с числом знаков валюты: "1500.00"
+func (m Money) Decimal() string {
+	exponent := CurrencyExponent(m.Currency)
+	amount := m.Amount
+	sign := ""
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	if exponent == 0 {
+		return fmt.Sprintf("%s%d", sign, amount)
+	}
+
+	scale := int64(1)
+	for i := 0; i < exponent; i++ {
+		scale *= 10
+	}
+	return fmt.Sprintf("%s%d.%0*d", sign, amount/scale, exponent, amount%scale)
+}
+
+func (m Money) String() string {
+	return strings.TrimSpace(m.Decimal() + " " + m.Currency)
+}
+
+// moneyJSON — представление Money в API
+type moneyJSON struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON{Amount: m.Decimal(), Currency: m.Currency})
+}
+
+// UnmarshalJSON принимает сумму строкой или числом; валюта обязательна,
+// так как от нее зависит число знаков после запятой
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var value struct {
+		Amount   json.RawMessage `json:"amount"`
+		Currency string          `json:"currency"`
+	}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return ErrInvalidMoney
+	}
+
+	var amount string
+	if err := json.Unmarshal(value.Amount, &amount); err != nil {
+		var number json.Number
+		if err := json.Unmarshal(value.Amount, &number); err != nil {
+			return ErrInvalidMoney
+		}
+		amount = number.String()
+	}
+
+	parsed, err := ParseMoney(amount, strings.ToUpper(value.Currency))
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// Коды шаблонов уведомлений
+const (
+	TemplateBookingConfirmed = "booking_confirmed"
+	TemplateBookingCancelled = "booking_cancelled"
+	TemplateReminder24h      = "reminder_24h"
+	TemplateReminder2h       = "reminder_2h"
+	// TemplateReminder используется для напоминаний, для интервала которых нет отдельного шаблона
+	TemplateReminder         = "reminder"
+	TemplateBirthdayGreeting = "birthday_greeting"
+)
+
+// Языки уведомлений
+const (
+	LanguageRU      = "ru"
+	LanguageEN      = "en"
+	DefaultLanguage = LanguageRU
+)
+
+// IsValidLanguage проверяет, что язык поддерживается
+func IsValidLanguage(language string) bool {
+	switch language {
+	case LanguageRU, LanguageEN:
+		return true
+	}
+	return false
+}
+
+// NotificationTemplate — текст уведомления в синтаксисе text/template.
+// Для каждого кода хранится по одному шаблону на язык.
+type NotificationTemplate struct {
+	ID        int       `gorm:"primaryKey" json:"id"`
+	TenantID  int       `gorm:"not null;default:1;index;uniqueIndex:idx_template_code_language" json:"-"` // Арендатор (барбершоп), которому принадлежит запись
+	Code      string    `gorm:"size:100;not null;uniqueIndex:idx_template_code_language" json:"code"`
+	Language  string    `gorm:"size:5;not null;uniqueIndex:idx_template_code_language" json:"language"`
+	Body      string    `gorm:"type:text;not null" json:"body"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
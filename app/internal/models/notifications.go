@@ -2,11 +2,43 @@ package models
 
 import "time"
 
+// Статусы доставки уведомления
+const (
+	NotificationStatusPending = "pending"
+	NotificationStatusSent    = "sent"
+	NotificationStatusFailed  = "failed"
+	// NotificationStatusCancelled — напоминание отменено вместе с бронированием или заменено новым
+	NotificationStatusCancelled = "cancelled"
+)
+
+// Каналы доставки (NotificationType)
+const (
+	NotificationTypeTelegram = "telegram"
+	NotificationTypeSMS      = "sms"
+	NotificationTypeEmail    = "email"
+)
+
+// IsValidNotificationType проверяет, что для типа уведомления есть канал доставки
+func IsValidNotificationType(notificationType string) bool {
+	switch notificationType {
+	case NotificationTypeTelegram, NotificationTypeSMS, NotificationTypeEmail:
+		return true
+	}
+	return false
+}
+
 type Notification struct {
-	ID               int       `gorm:"primaryKey" json:"id"`
-	ClientID         int       `gorm:"not null" json:"client_id"`
-	Message          string    `gorm:"type:text;not null" json:"message"`
-	NotificationType string    `gorm:"size:50" json:"notification_type"`
-	SentAt           time.Time `gorm:"autoCreateTime" json:"sent_at"`
-	Status           string    `gorm:"size:50;default:'pending'" json:"status"`
+	ID               int        `gorm:"primaryKey" json:"id"`
+	TenantID         int        `gorm:"not null;default:1;index" json:"-"` // Арендатор (барбершоп), которому принадлежит запись
+	ClientID         int        `gorm:"not null" json:"client_id"`
+	Message          string     `gorm:"type:text;not null" json:"message"`
+	NotificationType string     `gorm:"size:50" json:"notification_type"`
+	BookingID        *int       `gorm:"index" json:"booking_id,omitempty"`               // Бронирование, о котором напоминает уведомление
+	ScheduledFor     *time.Time `gorm:"index:idx_notification_due" json:"scheduled_for"` // Не отправлять раньше этого времени; пусто — сразу
+	Status           string     `gorm:"size:50;default:'pending';index:idx_notification_due" json:"status"`
+	Attempts         int        `gorm:"not null;default:0" json:"attempts"`                // Количество неудачных попыток отправки
+	NextAttemptAt    *time.Time `gorm:"index:idx_notification_due" json:"next_attempt_at"` // Не отправлять раньше этого времени (повтор после ошибки)
+	LastError        string     `gorm:"type:text" json:"last_error,omitempty"`
+	SentAt           *time.Time `json:"sent_at"` // Время успешной отправки
+	CreatedAt        time.Time  `gorm:"autoCreateTime" json:"created_at"`
 }
@@ -1,13 +1,264 @@
 package models
 
-import "time"
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
 
+var (
+	ErrInvalidWeekday   = errors.New("некорректный день недели")
+	ErrInvalidTimeOfDay = errors.New("некорректное время, ожидается ЧЧ:ММ")
+)
+
+// Weekday — день недели по ISO 8601: 1 — понедельник, 7 — воскресенье, 0 — не задан.
+// В JSON и базе данных хранится английским названием в нижнем регистре ("monday"),
+// поэтому в тегах gorm колонке задается строковый тип (type:varchar(10)), иначе она создается числовой.
+type Weekday int
+
+const (
+	Monday Weekday = iota + 1
+	Tuesday
+	Wednesday
+	Thursday
+	Friday
+	Saturday
+	Sunday
+)
+
+var weekdayNames = [...]string{"", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"}
+
+// WeekdayOf возвращает день недели даты t
+func WeekdayOf(t time.Time) Weekday {
+	if t.Weekday() == time.Sunday {
+		return Sunday
+	}
+	return Weekday(t.Weekday())
+}
+
+// ParseWeekday принимает английское название дня недели или его первые три буквы без учета регистра,
+// а также номер дня по ISO 8601
+func ParseWeekday(value string) (Weekday, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if n, err := strconv.Atoi(value); err == nil {
+		if day := Weekday(n); day.IsValid() {
+			return day, nil
+		}
+		return 0, ErrInvalidWeekday
+	}
+	if len(value) >= 3 {
+		for day := Monday; day <= Sunday; day++ {
+			if name := weekdayNames[day]; value == name || value == name[:3] {
+				return day, nil
+			}
+		}
+	}
+	return 0, ErrInvalidWeekday
+}
+
+func (d Weekday) IsValid() bool {
+	return d >= Monday && d <= Sunday
+}
+
+func (d Weekday) String() string {
+	if !d.IsValid() {
+		return ""
+	}
+	return weekdayNames[d]
+}
+
+func (d Weekday) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Weekday) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		var n int
+		if json.Unmarshal(data, &n) != nil {
+			return ErrInvalidWeekday
+		}
+		value = strconv.Itoa(n)
+	}
+	day, err := ParseWeekday(value)
+	if err != nil {
+		return err
+	}
+	*d = day
+	return nil
+}
+
+func (d Weekday) Value() (driver.Value, error) {
+	if !d.IsValid() {
+		return nil, ErrInvalidWeekday
+	}
+	return d.String(), nil
+}
+
+func (d *Weekday) Scan(src interface{}) error {
+	var value string
+	switch v := src.(type) {
+	case string:
+		value = v
+	case []byte:
+		value = string(v)
+	default:
+		return fmt.Errorf("%w: %v", ErrInvalidWeekday, src)
+	}
+	day, err := ParseWeekday(value)
+	if err != nil {
+		return err
+	}
+	*d = day
+	return nil
+}
+
+// TimeOfDay — время суток в минутах от полуночи. 24:00 допускается как конец рабочего дня.
+// В JSON и базе данных хранится строкой "ЧЧ:ММ"; колонке задается тип type:varchar(8).
+type TimeOfDay int
+
+const endOfDay = TimeOfDay(24 * 60)
+
+// NewTimeOfDay возвращает время суток hour:minute
+func NewTimeOfDay(hour, minute int) TimeOfDay {
+	return TimeOfDay(hour*60 + minute)
+}
+
+// ParseTimeOfDay разбирает время в формате ЧЧ:ММ (секунды, если есть, отбрасываются)
+func ParseTimeOfDay(value string) (TimeOfDay, error) {
+	parts := strings.Split(strings.TrimSpace(value), ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, ErrInvalidTimeOfDay
+	}
+	hour, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, ErrInvalidTimeOfDay
+	}
+	minute, err := strconv.Atoi(parts[1])
+	if err != nil || len(parts[1]) != 2 {
+		return 0, ErrInvalidTimeOfDay
+	}
+
+	t := NewTimeOfDay(hour, minute)
+	if hour < 0 || minute < 0 || minute > 59 || t > endOfDay {
+		return 0, ErrInvalidTimeOfDay
+	}
+	return t, nil
+}
+
+func (t TimeOfDay) IsValid() bool {
+	return t >= 0 && t <= endOfDay
+}
+
+func (t TimeOfDay) Hour() int {
+	return int(t) / 60
+}
+
+func (t TimeOfDay) Minute() int {
+	return int(t) % 60
+}
+
+func (t TimeOfDay) String() string {
+	return fmt.Sprintf("%02d:%02d", t.Hour(), t.Minute())
+}
+
+// On возвращает момент времени t в день day (в часовом поясе day)
+func (t TimeOfDay) On(day time.Time) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), 0, 0, day.Location())
+}
+
+func (t TimeOfDay) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+func (t *TimeOfDay) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return ErrInvalidTimeOfDay
+	}
+	parsed, err := ParseTimeOfDay(value)
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
+
+func (t TimeOfDay) Value() (driver.Value, error) {
+	if !t.IsValid() {
+		return nil, ErrInvalidTimeOfDay
+	}
+	return t.String(), nil
+}
+
+func (t *TimeOfDay) Scan(src interface{}) error {
+	var value string
+	switch v := src.(type) {
+	case string:
+		value = v
+	case []byte:
+		value = string(v)
+	case time.Time:
+		value = v.Format("15:04")
+	default:
+		return fmt.Errorf("%w: %v", ErrInvalidTimeOfDay, src)
+	}
+	parsed, err := ParseTimeOfDay(value)
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
+
+// Schedule — еженедельные рабочие часы сотрудника в указанный день недели
 type Schedule struct {
 	ID          int       `gorm:"primaryKey" json:"id"`
+	TenantID    int       `gorm:"not null;default:1;index" json:"-"` // Арендатор (барбершоп), которому принадлежит запись
 	UserID      int       `gorm:"not null" json:"user_id"`
-	ScheduleDay string    `gorm:"size:10;not null" json:"schedule_day"`
-	StartTime   string    `gorm:"not null" json:"start_time"`
-	EndTime     string    `gorm:"not null" json:"end_time"`
+	LocationID  int       `gorm:"index" json:"location_id"` // Филиал, в котором сотрудник работает в эти часы
+	ScheduleDay Weekday   `gorm:"type:varchar(10);not null" json:"schedule_day"`
+	StartTime   TimeOfDay `gorm:"type:varchar(8);not null" json:"start_time"`
+	EndTime     TimeOfDay `gorm:"type:varchar(8);not null" json:"end_time"`
 	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updated_at"`
 }
+
+// Виды исключений из еженедельного расписания
+const (
+	// ScheduleOverrideDayOff — выходной: еженедельные часы в этот день не действуют
+	ScheduleOverrideDayOff = "day_off"
+	// ScheduleOverrideHours — особые часы (например, сокращенный день) вместо еженедельных
+	ScheduleOverrideHours = "hours"
+	// ScheduleOverrideExtra — дополнительная смена сверх еженедельных часов
+	ScheduleOverrideExtra = "extra"
+)
+
+// IsValidScheduleOverrideKind проверяет вид исключения из расписания
+func IsValidScheduleOverrideKind(kind string) bool {
+	switch kind {
+	case ScheduleOverrideDayOff, ScheduleOverrideHours, ScheduleOverrideExtra:
+		return true
+	}
+	return false
+}
+
+// ScheduleOverride — исключение из еженедельного расписания сотрудника на конкретную дату
+// (отпуск, праздник, сокращенный день, дополнительная смена)
+type ScheduleOverride struct {
+	ID         int       `gorm:"primaryKey" json:"id"`
+	TenantID   int       `gorm:"not null;default:1;index" json:"-"` // Арендатор (барбершоп), которому принадлежит запись
+	UserID     int       `gorm:"not null;index:idx_schedule_override_user_date" json:"user_id"`
+	Date       string    `gorm:"size:10;not null;index:idx_schedule_override_user_date" json:"date"` // Дата в формате ГГГГ-ММ-ДД
+	LocationID int       `gorm:"index" json:"location_id"`                                           // Филиал; 0 — исключение действует во всех филиалах
+	Kind       string    `gorm:"size:20;not null" json:"kind"`
+	StartTime  TimeOfDay `gorm:"type:varchar(8)" json:"start_time"` // Для day_off не используется
+	EndTime    TimeOfDay `gorm:"type:varchar(8)" json:"end_time"`
+	Reason     string    `gorm:"size:255" json:"reason,omitempty"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
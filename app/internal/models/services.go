@@ -4,11 +4,66 @@ import "time"
 
 type Service struct {
 	ID          int       `gorm:"primaryKey" json:"id"`
+	TenantID    int       `gorm:"not null;default:1;index" json:"-"` // Арендатор (барбершоп), которому принадлежит запись
 	Name        string    `gorm:"size:255;not null" json:"name"`
 	Description string    `gorm:"type:text" json:"description"`
-	Price       float64   `gorm:"not null" json:"price"`
+	Price       Money     `gorm:"embedded;embeddedPrefix:price_" json:"price"`
 	Duration    int       `gorm:"not null" json:"duration"` // Продолжительность в минутах
 	IsActive    bool      `gorm:"default:true" json:"is_active"`
 	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+
+	LocationPrices []ServiceLocationPrice `gorm:"foreignKey:ServiceID" json:"location_prices,omitempty"`
+}
+
+// ServiceLocationPrice — цена услуги в филиале, отличающаяся от базовой
+type ServiceLocationPrice struct {
+	ID         int       `gorm:"primaryKey" json:"id"`
+	TenantID   int       `gorm:"not null;default:1;index" json:"-"` // Арендатор (барбершоп), которому принадлежит запись
+	ServiceID  int       `gorm:"not null;uniqueIndex:idx_service_location_price" json:"service_id"`
+	LocationID int       `gorm:"not null;uniqueIndex:idx_service_location_price" json:"location_id"`
+	Price      Money     `gorm:"embedded;embeddedPrefix:price_" json:"price"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// PriceAt возвращает цену услуги в филиале locationID: цену филиала, если она задана, иначе базовую.
+// Цены филиалов должны быть загружены в LocationPrices.
+func (s Service) PriceAt(locationID int) Money {
+	for _, price := range s.LocationPrices {
+		if price.LocationID == locationID {
+			return price.Price
+		}
+	}
+	return s.Price
+}
+
+// BarberService — назначение услуги сотруднику: только назначенные сотрудники выполняют услугу
+// (кроме барберов при app.allow_unassigned_staff).
+// Price и Duration задают индивидуальные цену и длительность; nil — действуют цена и длительность услуги.
+type BarberService struct {
+	ID        int       `gorm:"primaryKey" json:"id"`
+	TenantID  int       `gorm:"not null;default:1;index" json:"-"` // Арендатор (барбершоп), которому принадлежит запись
+	UserID    int       `gorm:"not null;uniqueIndex:idx_barber_service" json:"user_id"`
+	ServiceID int       `gorm:"not null;uniqueIndex:idx_barber_service;index" json:"service_id"`
+	Price     *Money    `gorm:"embedded;embeddedPrefix:price_" json:"price,omitempty"`
+	Duration  *int      `json:"duration,omitempty"` // Продолжительность в минутах
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+
+	User *User `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+// Terms возвращает цену и длительность (в минутах) услуги s у сотрудника в филиале locationID:
+// индивидуальные значения сотрудника, если они заданы, иначе цену филиала и длительность услуги.
+// Цены филиалов должны быть загружены в s.LocationPrices.
+func (b BarberService) Terms(s Service, locationID int) (Money, int) {
+	price, duration := s.PriceAt(locationID), s.Duration
+	if b.Price != nil {
+		price = *b.Price
+	}
+	if b.Duration != nil {
+		duration = *b.Duration
+	}
+	return price, duration
 }
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Tenant — арендатор: отдельный барбершоп, обслуживаемый той же установкой API.
+// Данные арендаторов изолированы друг от друга по полю TenantID остальных моделей.
+type Tenant struct {
+	ID        int       `gorm:"primaryKey" json:"id"`
+	Name      string    `gorm:"size:100;not null" json:"name"`
+	IsActive  bool      `gorm:"default:true" json:"is_active"` // Запросы к неактивному арендатору отклоняются
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// OwnerRegistration — регистрация владельца арендатора через /auth/register. Уникальный tenant_id
+// гарантирует, что из одновременных регистраций одного арендатора пройдет только одна.
+type OwnerRegistration struct {
+	ID        int       `gorm:"primaryKey"`
+	TenantID  int       `gorm:"not null;default:1;uniqueIndex"`
+	UserID    int       `gorm:"not null"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// RefreshToken — refresh-токен сессии. Хранится только SHA-256 хеш токена,
+// при каждом обновлении токен ротируется в пределах одной цепочки (FamilyID).
+type RefreshToken struct {
+	ID              int        `gorm:"primaryKey" json:"id"`
+	TenantID        int        `gorm:"not null;default:1;index" json:"-"` // Арендатор (барбершоп), которому принадлежит запись
+	UserID          int        `gorm:"not null;index" json:"user_id"`
+	FamilyID        string     `gorm:"size:64;not null;index" json:"family_id"`
+	TokenHash       string     `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	AccessJTI       string     `gorm:"size:64;not null" json:"-"` // jti access-токена, выданного вместе с refresh-токеном
+	AccessExpiresAt time.Time  `gorm:"not null" json:"-"`
+	ExpiresAt       time.Time  `gorm:"not null" json:"expires_at"`
+	RevokedAt       *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt       time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// RevokedToken — отозванный access-токен, проверяется в JWTMiddleware до истечения его срока действия
+type RevokedToken struct {
+	JTI       string    `gorm:"primaryKey;size:64" json:"jti"`
+	TenantID  int       `gorm:"not null;default:1;index" json:"-"` // Арендатор (барбершоп), которому принадлежит запись
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
@@ -2,13 +2,32 @@ package models
 
 import "time"
 
+// Роли сотрудников
+const (
+	RoleOwner        = "owner"
+	RoleAdmin        = "admin"
+	RoleBarber       = "barber"
+	RoleReceptionist = "receptionist"
+)
+
+// IsValidRole проверяет, что роль входит в список поддерживаемых
+func IsValidRole(role string) bool {
+	switch role {
+	case RoleOwner, RoleAdmin, RoleBarber, RoleReceptionist:
+		return true
+	}
+	return false
+}
+
 type User struct {
 	ID           int        `gorm:"primaryKey" json:"id"`
-	Username     string     `gorm:"unique;size:50;not null" json:"username"`
+	TenantID     int        `gorm:"not null;default:1;index;uniqueIndex:idx_users_tenant_username;uniqueIndex:idx_users_tenant_email" json:"-"` // Арендатор (барбершоп), которому принадлежит запись
+	Username     string     `gorm:"size:50;not null;uniqueIndex:idx_users_tenant_username" json:"username"`
 	PasswordHash string     `gorm:"not null" json:"-"`
 	Role         string     `gorm:"size:50" json:"role"`
-	Email        string     `gorm:"unique;size:100;index" json:"email"`
+	Email        string     `gorm:"size:100;index;uniqueIndex:idx_users_tenant_email" json:"email"`
 	PhoneNumber  string     `gorm:"size:20" json:"phone_number"`
+	LocationID   int        `gorm:"index" json:"location_id"` // Основной филиал сотрудника
 	CreatedAt    time.Time  `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt    time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
 	LastLoginAt  *time.Time `gorm:"index" json:"last_login_at,omitempty"`
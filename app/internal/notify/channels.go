@@ -0,0 +1,132 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/configs"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
+	"mime"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const httpTimeout = 10 * time.Second
+
+// SendersFromConfig создает отправителей для каналов, настроенных в конфигурации
+func SendersFromConfig(cfg configs.NotificationsConfig) map[string]Sender {
+	senders := make(map[string]Sender)
+	if cfg.Telegram.BotToken != "" {
+		senders[models.NotificationTypeTelegram] = NewTelegramSender(cfg.Telegram.BotToken)
+	}
+	if cfg.SMS.GatewayURL != "" {
+		senders[models.NotificationTypeSMS] = NewSMSSender(cfg.SMS.GatewayURL, cfg.SMS.APIKey)
+	}
+	if cfg.Email.Host != "" {
+		senders[models.NotificationTypeEmail] = NewEmailSender(cfg.Email)
+	}
+	return senders
+}
+
+// TelegramSender отправляет сообщения через Telegram Bot API; To — chat_id клиента (Client.TgID)
+type TelegramSender struct {
+	apiURL string
+	client *http.Client
+}
+
+func NewTelegramSender(botToken string) *TelegramSender {
+	return &TelegramSender{
+		apiURL: "https://api.telegram.org/bot" + botToken + "/sendMessage",
+		client: &http.Client{Timeout: httpTimeout},
+	}
+}
+
+func (s *TelegramSender) Send(ctx context.Context, msg Message) error {
+	form := url.Values{"chat_id": {msg.To}, "text": {msg.Text}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.apiURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return doRequest(s.client, req, "telegram")
+}
+
+// SMSSender отправляет SMS через HTTP-шлюз: POST {"to": ..., "text": ...} с ключом в заголовке Authorization
+type SMSSender struct {
+	gatewayURL string
+	apiKey     string
+	client     *http.Client
+}
+
+func NewSMSSender(gatewayURL, apiKey string) *SMSSender {
+	return &SMSSender{
+		gatewayURL: gatewayURL,
+		apiKey:     apiKey,
+		client:     &http.Client{Timeout: httpTimeout},
+	}
+}
+
+func (s *SMSSender) Send(ctx context.Context, msg Message) error {
+	body, err := json.Marshal(map[string]string{"to": msg.To, "text": msg.Text})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.gatewayURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	}
+	return doRequest(s.client, req, "sms")
+}
+
+// doRequest выполняет запрос к шлюзу. Ответы 4xx, кроме 429, считаются неустранимыми ошибками.
+func doRequest(client *http.Client, req *http.Request, channel string) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	err = fmt.Errorf("%s: шлюз ответил %s", channel, resp.Status)
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+		return Permanent(err)
+	}
+	return err
+}
+
+// EmailSender отправляет письма через SMTP; To — Client.Email
+type EmailSender struct {
+	cfg configs.EmailConfig
+}
+
+func NewEmailSender(cfg configs.EmailConfig) *EmailSender {
+	return &EmailSender{cfg: cfg}
+}
+
+func (s *EmailSender) Send(_ context.Context, msg Message) error {
+	addr := s.cfg.Host + ":" + strconv.Itoa(s.cfg.Port)
+
+	var auth smtp.Auth
+	if s.cfg.Username != "" {
+		auth = smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)
+	}
+
+	body := "From: " + s.cfg.From + "\r\n" +
+		"To: " + msg.To + "\r\n" +
+		"Subject: " + mime.QEncoding.Encode("utf-8", "Уведомление") + "\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=UTF-8\r\n" +
+		"\r\n" + msg.Text + "\r\n"
+	return smtp.SendMail(addr, auth, s.cfg.From, []string{msg.To}, []byte(body))
+}
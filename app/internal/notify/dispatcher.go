@@ -0,0 +1,200 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/configs"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/repositories"
+	"log"
+	"time"
+)
+
+// Параметры диспетчера по умолчанию, если они не заданы в конфигурации
+const (
+	defaultPollInterval   = 30 * time.Second
+	defaultBatchSize      = 50
+	defaultMaxAttempts    = 5
+	defaultRetryBaseDelay = time.Minute
+	defaultRetryMaxDelay  = time.Hour
+)
+
+var (
+	ErrChannelNotConfigured = errors.New("канал доставки не настроен")
+	ErrNoRecipientAddress   = errors.New("у клиента нет адреса для этого канала")
+)
+
+// Dispatcher периодически выбирает ожидающие уведомления и отправляет их через Sender
+// соответствующего канала. После ошибки отправка повторяется с экспоненциальной задержкой,
+// после MaxAttempts попыток уведомление получает статус failed.
+// Рассчитан на один экземпляр на базу данных.
+type Dispatcher struct {
+	notifications repositories.NotificationRepository
+	clients       repositories.ClientRepository
+	senders       map[string]Sender
+
+	pollInterval   time.Duration
+	batchSize      int
+	maxAttempts    int
+	retryBaseDelay time.Duration
+	retryMaxDelay  time.Duration
+
+	now func() time.Time
+}
+
+func NewDispatcher(
+	notifications repositories.NotificationRepository,
+	clients repositories.ClientRepository,
+	senders map[string]Sender,
+	cfg configs.NotificationsConfig,
+) *Dispatcher {
+	d := &Dispatcher{
+		notifications:  notifications,
+		clients:        clients,
+		senders:        senders,
+		pollInterval:   cfg.PollInterval,
+		batchSize:      cfg.BatchSize,
+		maxAttempts:    cfg.MaxAttempts,
+		retryBaseDelay: cfg.RetryBaseDelay,
+		retryMaxDelay:  cfg.RetryMaxDelay,
+		now:            time.Now,
+	}
+	if d.pollInterval <= 0 {
+		d.pollInterval = defaultPollInterval
+	}
+	if d.batchSize <= 0 {
+		d.batchSize = defaultBatchSize
+	}
+	if d.maxAttempts <= 0 {
+		d.maxAttempts = defaultMaxAttempts
+	}
+	if d.retryBaseDelay <= 0 {
+		d.retryBaseDelay = defaultRetryBaseDelay
+	}
+	if d.retryMaxDelay <= 0 {
+		d.retryMaxDelay = defaultRetryMaxDelay
+	}
+	return d
+}
+
+// SetClock подменяет источник текущего времени (для тестов)
+func (d *Dispatcher) SetClock(now func() time.Time) {
+	d.now = now
+}
+
+// Run обрабатывает очередь каждые pollInterval до отмены ctx
+func (d *Dispatcher) Run(ctx context.Context) {
+	log.Printf("Notification dispatcher started (poll interval %s, channels: %d).", d.pollInterval, len(d.senders))
+
+	ticker := time.NewTicker(d.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := d.DispatchPending(ctx); err != nil {
+			log.Printf("notify: dispatch failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("Notification dispatcher stopped.")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchPending отправляет одну порцию уведомлений, время которых наступило,
+// и возвращает количество успешно отправленных
+func (d *Dispatcher) DispatchPending(ctx context.Context) (int, error) {
+	due, err := d.notifications.FindDueNotifications(d.now(), d.batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for i := range due {
+		if ctx.Err() != nil {
+			break
+		}
+		notification := &due[i]
+
+		sendErr := d.send(ctx, notification)
+		if ctx.Err() != nil {
+			// Отправка прервана остановкой: уведомление останется pending
+			break
+		}
+		d.applyResult(notification, sendErr)
+		if err := d.notifications.UpdateNotification(notification); err != nil {
+			return sent, fmt.Errorf("уведомление #%d: %w", notification.ID, err)
+		}
+		if sendErr == nil {
+			sent++
+		}
+	}
+	return sent, nil
+}
+
+func (d *Dispatcher) send(ctx context.Context, notification *models.Notification) error {
+	sender, ok := d.senders[notification.NotificationType]
+	if !ok {
+		return Permanent(fmt.Errorf("%w: %s", ErrChannelNotConfigured, notification.NotificationType))
+	}
+
+	client, err := d.clients.GetClientByID(notification.ClientID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrClientNotFound) {
+			return Permanent(err)
+		}
+		return err
+	}
+
+	to := client.ContactFor(notification.NotificationType)
+	if to == "" {
+		return Permanent(ErrNoRecipientAddress)
+	}
+
+	return sender.Send(ctx, Message{
+		NotificationID: notification.ID,
+		To:             to,
+		Text:           notification.Message,
+	})
+}
+
+// applyResult переводит уведомление в sent, назначает повтор или помечает failed
+func (d *Dispatcher) applyResult(notification *models.Notification, err error) {
+	now := d.now()
+	if err == nil {
+		notification.Status = models.NotificationStatusSent
+		notification.SentAt = &now
+		notification.NextAttemptAt = nil
+		notification.LastError = ""
+		return
+	}
+
+	notification.Attempts++
+	notification.LastError = err.Error()
+	if IsPermanent(err) || notification.Attempts >= d.maxAttempts {
+		notification.Status = models.NotificationStatusFailed
+		notification.NextAttemptAt = nil
+		return
+	}
+
+	next := now.Add(d.backoff(notification.Attempts))
+	notification.NextAttemptAt = &next
+}
+
+// backoff — задержка перед повтором: retryBaseDelay * 2^(attempts-1), не больше retryMaxDelay
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.retryBaseDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= d.retryMaxDelay {
+			return d.retryMaxDelay
+		}
+	}
+	if delay > d.retryMaxDelay {
+		return d.retryMaxDelay
+	}
+	return delay
+}
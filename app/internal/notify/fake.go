@@ -0,0 +1,45 @@
+package notify
+
+import (
+	"context"
+	"sync"
+)
+
+// FakeSender запоминает отправленные сообщения в памяти; используется в тестах.
+// Ошибки из очереди Fail возвращаются по одной на каждый вызов Send.
+type FakeSender struct {
+	mu       sync.Mutex
+	sent     []Message
+	failures []error
+}
+
+func NewFakeSender() *FakeSender {
+	return &FakeSender{}
+}
+
+func (s *FakeSender) Send(_ context.Context, msg Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.failures) > 0 {
+		err := s.failures[0]
+		s.failures = s.failures[1:]
+		return err
+	}
+	s.sent = append(s.sent, msg)
+	return nil
+}
+
+// Fail ставит в очередь ошибки для следующих вызовов Send
+func (s *FakeSender) Fail(errs ...error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = append(s.failures, errs...)
+}
+
+// Sent возвращает копию успешно отправленных сообщений
+func (s *FakeSender) Sent() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.sent...)
+}
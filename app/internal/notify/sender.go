@@ -0,0 +1,45 @@
+package notify
+
+import (
+	"context"
+	"errors"
+)
+
+// Message — уведомление, подготовленное к отправке по конкретному каналу
+type Message struct {
+	NotificationID int
+	To             string // Адрес получателя в канале: chat_id, номер телефона или email
+	Text           string
+}
+
+// Sender доставляет сообщение по одному каналу (telegram, sms, email)
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// permanentError — ошибка, при которой повторная отправка бессмысленна
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent помечает ошибку отправки как неустранимую: уведомление сразу получает статус failed
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent сообщает, что ошибка помечена через Permanent
+func IsPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
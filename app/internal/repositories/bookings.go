@@ -2,25 +2,118 @@ package repositories
 
 import (
 	"errors"
+	"fmt"
 	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"sort"
+	"time"
 )
 
 var (
 	ErrBookingNotFound  = errors.New("бронирование не найдено")
 	ErrTimeSlotOccupied = errors.New("временной слот уже занят")
+	// ErrBookingStatusChanged возвращается, если статус изменился параллельно с текущим переходом
+	ErrBookingStatusChanged = errors.New("статус бронирования был изменён")
 )
 
+// BookingConflictError возвращается, когда интервал бронирования пересекается
+// с другими активными бронированиями того же сотрудника.
+type BookingConflictError struct {
+	ConflictingIDs []int `json:"conflicting_booking_ids"`
+}
+
+func (e *BookingConflictError) Error() string {
+	return fmt.Sprintf("%s: пересечение с бронированиями %v", ErrTimeSlotOccupied, e.ConflictingIDs)
+}
+
+// Is позволяет проверять конфликт через errors.Is(err, ErrTimeSlotOccupied)
+func (e *BookingConflictError) Is(target error) bool {
+	return target == ErrTimeSlotOccupied
+}
+
+// BarberRevenue — выручка сотрудника за период по завершенным бронированиям.
+// Суммы в разных валютах не складываются: на каждую валюту сотрудника приходится своя строка.
+type BarberRevenue struct {
+	UserID   int          `json:"user_id"`
+	Bookings int64        `json:"bookings"`
+	Revenue  models.Money `json:"revenue"`
+}
+
+// RevenueFilter — период [From, To) и необязательные филиал и сотрудник отчета о выручке (0 — все)
+type RevenueFilter struct {
+	From       time.Time
+	To         time.Time
+	LocationID int
+	UserID     int
+}
+
+// ClientVisitStats — история бронирований клиента для профиля
+type ClientVisitStats struct {
+	StatusCounts map[string]int64 // Число бронирований по статусам
+	Spend        []models.Money   // Сумма завершенных визитов, по одной на валюту
+	Services     []ServiceVisits  // Услуги завершенных визитов, от частых к редким
+	Barbers      []BarberVisits   // Сотрудники завершенных визитов, от частых к редким
+	VisitTimes   []time.Time      // Начала завершенных визитов по возрастанию
+	NextVisit    *time.Time       // Ближайшее предстоящее ожидающее или подтвержденное бронирование
+}
+
+// ServiceVisits — услуга и число визитов с ней
+type ServiceVisits struct {
+	ServiceID int    `json:"service_id"`
+	Name      string `json:"name"`
+	Visits    int64  `json:"visits"`
+}
+
+// BarberVisits — сотрудник и число визитов к нему
+type BarberVisits struct {
+	UserID   int    `json:"user_id"`
+	Username string `json:"username"`
+	Visits   int64  `json:"visits"`
+}
+
+// maxBookingDuration ограничивает выборку кандидатов при поиске пересечений:
+// бронирование не может длиться дольше суток
+const maxBookingDuration = 24 * time.Hour
+
+// bookingListSpec — поля списка бронирований
+var bookingListSpec = listSpec{
+	sortable: map[string]string{
+		"id":           "id",
+		"booking_time": "booking_time",
+		"status":       "status",
+		"created_at":   "created_at",
+	},
+	filterable: map[string]listFilter{
+		"status":      {column: "status", kind: filterString},
+		"user_id":     {column: "user_id", kind: filterInt},
+		"client_id":   {column: "client_id", kind: filterInt},
+		"service_id":  {column: "service_id", kind: filterInt, where: withServiceCondition},
+		"location_id": {column: "location_id", kind: filterInt},
+	},
+	timeColumn:  "booking_time",
+	defaultSort: "booking_time",
+}
+
+// withServiceCondition отбирает бронирования, в которые входит услуга на любой позиции
+const withServiceCondition = "id IN (SELECT booking_id FROM booking_items WHERE service_id = ?)"
+
 type BookingRepository interface {
 	CreateBooking(booking *models.Bookings) error
 	GetBookingByID(id int) (*models.Bookings, error)
-	GetAllBookings() ([]models.Bookings, error)
+	GetAllBookings(query ListQuery) ([]models.Bookings, int64, error)
 	UpdateBooking(booking *models.Bookings) error
 	DeleteBooking(id int) error
-	IsTimeSlotOccupied(userID int, bookingTime string) (bool, error)
+	FindOverlappingBookings(userID int, start, end time.Time, excludeID int) ([]models.Bookings, error)
+	ChangeBookingStatus(change *models.BookingStatusChange) error
 	GetBookingsByClientID(clientID int) ([]models.Bookings, error)
+	// GetBookingsByServiceID возвращает бронирования, в которые входит услуга
 	GetBookingsByServiceID(serviceID int) ([]models.Bookings, error)
 	GetBookingsByUserID(userID int) ([]models.Bookings, error)
+	// GetRevenueByBarber суммирует цены завершенных бронирований по сотрудникам
+	GetRevenueByBarber(filter RevenueFilter) ([]BarberRevenue, error)
+	// GetClientVisitStats собирает историю визитов клиента; предстоящие визиты ищутся после now
+	GetClientVisitStats(clientID int, now time.Time) (*ClientVisitStats, error)
 }
 
 type bookingRepository struct {
@@ -33,13 +126,61 @@ func NewBookingRepository(db *gorm.DB) BookingRepository {
 	}
 }
 
+// CreateBooking сохраняет бронирование вместе с позициями в одной транзакции. Клиент, услуга, сотрудник
+// и история статусов, переданные в booking, не сохраняются. Пересечение с другими активными бронированиями
+// сотрудника проверяется в той же транзакции и возвращается как *BookingConflictError.
 func (r *bookingRepository) CreateBooking(booking *models.Bookings) error {
-	return r.db.Create(booking).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := checkStaffOverlap(tx, booking); err != nil {
+			return err
+		}
+		if err := tx.Omit(clause.Associations).Create(booking).Error; err != nil {
+			return err
+		}
+		return saveBookingItems(tx, booking)
+	})
+}
+
+// saveBookingItems сохраняет позиции бронирования и удаляет позиции, которых в нем больше нет.
+// Позиции сохраняются отдельными запросами, чтобы каждая получила арендатора соединения.
+func saveBookingItems(tx *gorm.DB, booking *models.Bookings) error {
+	kept := make([]int, 0, len(booking.Items))
+	for i := range booking.Items {
+		item := &booking.Items[i]
+		item.BookingID = booking.ID
+		if err := tx.Omit(clause.Associations).Save(item).Error; err != nil {
+			return err
+		}
+		kept = append(kept, item.ID)
+	}
+
+	stale := tx.Where("booking_id = ?", booking.ID)
+	if len(kept) > 0 {
+		stale = stale.Where("id NOT IN ?", kept)
+	}
+	return stale.Delete(&models.BookingItem{}).Error
+}
+
+// preloadItems загружает позиции бронирований с услугами в порядке выполнения
+func preloadItems(db *gorm.DB) *gorm.DB {
+	return db.Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("position, id") }).Preload("Items.Service")
+}
+
+// sortItems упорядочивает позиции, загруженные без сортировки
+func sortItems(bookings []models.Bookings) {
+	for i := range bookings {
+		items := bookings[i].Items
+		sort.SliceStable(items, func(a, b int) bool { return items[a].Position < items[b].Position })
+	}
 }
 
 func (r *bookingRepository) GetBookingByID(id int) (*models.Bookings, error) {
 	var booking models.Bookings
-	if err := r.db.Preload("Client").Preload("Service").Preload("User").First(&booking, id).Error; err != nil {
+	err := r.db.Preload("Client").Preload("Client.Notes", func(db *gorm.DB) *gorm.DB { return db.Order("created_at DESC, id DESC") }).
+		Preload("Service").Preload("User").Scopes(preloadItems).
+		Preload("StatusHistory", func(db *gorm.DB) *gorm.DB { return db.Order("changed_at, id") }).
+		First(&booking, id).Error
+	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, ErrBookingNotFound
 		}
@@ -48,36 +189,113 @@ func (r *bookingRepository) GetBookingByID(id int) (*models.Bookings, error) {
 	return &booking, nil
 }
 
-func (r *bookingRepository) GetAllBookings() ([]models.Bookings, error) {
+func (r *bookingRepository) GetAllBookings(query ListQuery) ([]models.Bookings, int64, error) {
 	var bookings []models.Bookings
-	if err := r.db.Preload("Client").Preload("Service").Preload("User").Find(&bookings).Error; err != nil {
-		return nil, err
+	total, err := paginate(r.db, &models.Bookings{}, &bookings, bookingListSpec, query, "Client", "Service", "User", "Items", "Items.Service")
+	if err != nil {
+		return nil, 0, err
 	}
-	return bookings, nil
+	sortItems(bookings)
+	return bookings, total, nil
 }
 
+// UpdateBooking сохраняет бронирование и заменяет его позиции на booking.Items. Пересечение
+// проверяется так же, как в CreateBooking.
 func (r *bookingRepository) UpdateBooking(booking *models.Bookings) error {
-	return r.db.Save(booking).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := checkStaffOverlap(tx, booking); err != nil {
+			return err
+		}
+		// Связанные сущности загружены через Preload и не должны перезаписывать внешние ключи
+		if err := tx.Omit(clause.Associations).Save(booking).Error; err != nil {
+			return err
+		}
+		return saveBookingItems(tx, booking)
+	})
 }
 
 func (r *bookingRepository) DeleteBooking(id int) error {
-	if err := r.db.Delete(&models.Bookings{}, id).Error; err != nil {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("booking_id = ?", id).Delete(&models.BookingItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Bookings{}, id).Error
+	})
+}
+
+// FindOverlappingBookings возвращает активные (не отменённые) бронирования сотрудника,
+// интервал которых (BookingTime + длительность визита) пересекается с [start, end).
+// Бронирование с ID excludeID не учитывается (используется при обновлении).
+func (r *bookingRepository) FindOverlappingBookings(userID int, start, end time.Time, excludeID int) ([]models.Bookings, error) {
+	return findOverlapping(r.db, userID, start, end, excludeID)
+}
+
+// checkStaffOverlap блокирует строку сотрудника до конца транзакции и проверяет, что активное бронирование
+// не пересекается с другими. Блокировка выстраивает в очередь параллельные записи к одному сотруднику,
+// поэтому между проверкой и сохранением никто не займет тот же интервал.
+func checkStaffOverlap(tx *gorm.DB, booking *models.Bookings) error {
+	if booking.Status == models.BookingStatusCancelled {
+		return nil
+	}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Find(&models.User{}, booking.UserID).Error; err != nil {
 		return err
 	}
-	return nil
+
+	start := booking.BookingTime
+	overlapping, err := findOverlapping(tx, booking.UserID, start, start.Add(booking.Length()), booking.ID)
+	if err != nil {
+		return err
+	}
+	if len(overlapping) == 0 {
+		return nil
+	}
+
+	conflict := &BookingConflictError{ConflictingIDs: make([]int, 0, len(overlapping))}
+	for _, b := range overlapping {
+		conflict.ConflictingIDs = append(conflict.ConflictingIDs, b.ID)
+	}
+	return conflict
+}
+
+func findOverlapping(db *gorm.DB, userID int, start, end time.Time, excludeID int) ([]models.Bookings, error) {
+	var candidates []models.Bookings
+	err := db.Joins("Service").
+		Where("bookings.user_id = ? AND bookings.status <> ? AND bookings.id <> ?", userID, models.BookingStatusCancelled, excludeID).
+		Where("bookings.booking_time < ? AND bookings.booking_time > ?", end, start.Add(-maxBookingDuration)).
+		Find(&candidates).Error
+	if err != nil {
+		return nil, err
+	}
+
+	overlapping := make([]models.Bookings, 0, len(candidates))
+	for _, booking := range candidates {
+		bookingEnd := booking.BookingTime.Add(booking.Length())
+		if booking.BookingTime.Before(end) && bookingEnd.After(start) {
+			overlapping = append(overlapping, booking)
+		}
+	}
+	return overlapping, nil
 }
 
-func (r *bookingRepository) IsTimeSlotOccupied(userID int, bookingTime string) (bool, error) {
-	var count int64
-	err := r.db.Model(&models.Bookings{}).
-		Where("user_id = ? AND booking_time = ?", userID, bookingTime).
-		Count(&count).Error
-	return count > 0, err
+// ChangeBookingStatus атомарно переводит бронирование из FromStatus в ToStatus и сохраняет запись о переходе
+func (r *bookingRepository) ChangeBookingStatus(change *models.BookingStatusChange) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Bookings{}).
+			Where("id = ? AND status = ?", change.BookingID, change.FromStatus).
+			Update("status", change.ToStatus)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrBookingStatusChanged
+		}
+		return tx.Create(change).Error
+	})
 }
 
 func (r *bookingRepository) GetBookingsByClientID(clientID int) ([]models.Bookings, error) {
 	var bookings []models.Bookings
-	if err := r.db.Where("client_id = ?", clientID).Find(&bookings).Error; err != nil {
+	if err := r.db.Scopes(preloadItems).Where("client_id = ?", clientID).Find(&bookings).Error; err != nil {
 		return nil, err
 	}
 	return bookings, nil
@@ -85,7 +303,7 @@ func (r *bookingRepository) GetBookingsByClientID(clientID int) ([]models.Bookin
 
 func (r *bookingRepository) GetBookingsByServiceID(serviceID int) ([]models.Bookings, error) {
 	var bookings []models.Bookings
-	if err := r.db.Where("service_id = ?", serviceID).Find(&bookings).Error; err != nil {
+	if err := r.db.Scopes(preloadItems).Where(withServiceCondition, serviceID).Find(&bookings).Error; err != nil {
 		return nil, err
 	}
 	return bookings, nil
@@ -93,8 +311,113 @@ func (r *bookingRepository) GetBookingsByServiceID(serviceID int) ([]models.Book
 
 func (r *bookingRepository) GetBookingsByUserID(userID int) ([]models.Bookings, error) {
 	var bookings []models.Bookings
-	if err := r.db.Where("user_id = ?", userID).Find(&bookings).Error; err != nil {
+	if err := r.db.Scopes(preloadItems).Where("user_id = ?", userID).Find(&bookings).Error; err != nil {
 		return nil, err
 	}
 	return bookings, nil
 }
+
+// GetRevenueByBarber учитывает зафиксированную в бронировании цену; суммирование идет в минорных
+// единицах, поэтому итог не зависит от округления
+func (r *bookingRepository) GetRevenueByBarber(filter RevenueFilter) ([]BarberRevenue, error) {
+	query := r.db.Model(&models.Bookings{}).
+		Select("bookings.user_id AS user_id, bookings.price_currency AS currency, "+
+			"COUNT(*) AS bookings, SUM(bookings.price_amount) AS amount").
+		Where("bookings.status = ? AND bookings.booking_time >= ? AND bookings.booking_time < ?",
+			models.BookingStatusCompleted, filter.From, filter.To)
+	if filter.LocationID != 0 {
+		query = query.Where("bookings.location_id = ?", filter.LocationID)
+	}
+	if filter.UserID != 0 {
+		query = query.Where("bookings.user_id = ?", filter.UserID)
+	}
+
+	var rows []struct {
+		UserID   int
+		Currency string
+		Bookings int64
+		Amount   int64
+	}
+	err := query.Group("bookings.user_id, bookings.price_currency").
+		Order("bookings.user_id, bookings.price_currency").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	revenue := make([]BarberRevenue, 0, len(rows))
+	for _, row := range rows {
+		revenue = append(revenue, BarberRevenue{
+			UserID:   row.UserID,
+			Bookings: row.Bookings,
+			Revenue:  models.NewMoney(row.Amount, row.Currency),
+		})
+	}
+	return revenue, nil
+}
+
+func (r *bookingRepository) GetClientVisitStats(clientID int, now time.Time) (*ClientVisitStats, error) {
+	stats := &ClientVisitStats{StatusCounts: make(map[string]int64)}
+
+	var statuses []struct {
+		Status string
+		Count  int64
+	}
+	err := r.db.Model(&models.Bookings{}).Select("status, COUNT(*) AS count").
+		Where("client_id = ?", clientID).Group("status").Scan(&statuses).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range statuses {
+		stats.StatusCounts[row.Status] = row.Count
+	}
+
+	completed := r.db.Model(&models.Bookings{}).
+		Where("bookings.client_id = ? AND bookings.status = ?", clientID, models.BookingStatusCompleted)
+
+	var spend []struct {
+		Currency string
+		Amount   int64
+	}
+	err = completed.Session(&gorm.Session{}).Select("bookings.price_currency AS currency, SUM(bookings.price_amount) AS amount").
+		Group("bookings.price_currency").Order("bookings.price_currency").Scan(&spend).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range spend {
+		stats.Spend = append(stats.Spend, models.NewMoney(row.Amount, row.Currency))
+	}
+
+	err = completed.Session(&gorm.Session{}).
+		Select("booking_items.service_id AS service_id, services.name AS name, COUNT(*) AS visits").
+		Joins("JOIN booking_items ON booking_items.booking_id = bookings.id").
+		Joins("JOIN services ON services.id = booking_items.service_id").
+		Group("booking_items.service_id, services.name").Order("visits DESC, service_id").Scan(&stats.Services).Error
+	if err != nil {
+		return nil, err
+	}
+
+	err = completed.Session(&gorm.Session{}).
+		Select("bookings.user_id AS user_id, users.username AS username, COUNT(*) AS visits").
+		Joins("JOIN users ON users.id = bookings.user_id").
+		Group("bookings.user_id, users.username").Order("visits DESC, user_id").Scan(&stats.Barbers).Error
+	if err != nil {
+		return nil, err
+	}
+
+	err = completed.Session(&gorm.Session{}).Order("bookings.booking_time").Pluck("bookings.booking_time", &stats.VisitTimes).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var next []models.Bookings
+	err = r.db.Where("client_id = ? AND status IN ? AND booking_time > ?",
+		clientID, []string{models.BookingStatusPending, models.BookingStatusConfirmed}, now).
+		Order("booking_time").Limit(1).Find(&next).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(next) > 0 {
+		stats.NextVisit = &next[0].BookingTime
+	}
+	return stats, nil
+}
@@ -3,20 +3,45 @@ package repositories
 import (
 	"errors"
 	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
+	"time"
 
 	"gorm.io/gorm"
 )
 
 var (
-	ErrBreakNotFound = errors.New("перерыв не найден")
+	ErrBreakNotFound          = errors.New("перерыв не найден")
+	ErrBreakExceptionConflict = errors.New("это вхождение перерыва уже удалено или изменено")
 )
 
+// breakListSpec — поля списка перерывов
+var breakListSpec = listSpec{
+	sortable: map[string]string{
+		"id":          "id",
+		"user_id":     "user_id",
+		"break_start": "break_start",
+		"created_at":  "created_at",
+	},
+	filterable: map[string]listFilter{
+		"user_id":     {column: "user_id", kind: filterInt},
+		"location_id": {column: "location_id", kind: filterInt},
+	},
+	timeColumn:  "break_start",
+	defaultSort: "break_start",
+}
+
 type BreakRepository interface {
 	CreateBreak(breaks *models.Break) error
+	// CreateBreaks создает несколько перерывов в одной транзакции
+	CreateBreaks(breaks []models.Break) error
 	GetBreakByID(id int) (*models.Break, error)
-	GetAllBreaks() ([]models.Break, error)
+	GetAllBreaks(query ListQuery) ([]models.Break, int64, error)
 	UpdateBreak(breaks *models.Break) error
 	DeleteBreak(id int) error
+	GetBreaksByUserID(userID int) ([]models.Break, error)
+	GetBreaksByUserInRange(userID int, from, to time.Time) ([]models.Break, error)
+	// CreateBreakException исключает вхождение повторяющегося перерыва; если replacement не nil,
+	// вместо вхождения в той же транзакции создается отдельный перерыв
+	CreateBreakException(exception *models.BreakException, replacement *models.Break) error
 }
 
 type breakRepository struct {
@@ -33,9 +58,18 @@ func (r *breakRepository) CreateBreak(breaks *models.Break) error {
 	return r.db.Create(breaks).Error
 }
 
+func (r *breakRepository) CreateBreaks(breaks []models.Break) error {
+	if len(breaks) == 0 {
+		return nil
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return tx.Create(&breaks).Error
+	})
+}
+
 func (r *breakRepository) GetBreakByID(id int) (*models.Break, error) {
 	var breakModel models.Break
-	if err := r.db.First(&breakModel, id).Error; err != nil {
+	if err := r.db.Preload("Exceptions").First(&breakModel, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, ErrBreakNotFound
 		}
@@ -44,21 +78,74 @@ func (r *breakRepository) GetBreakByID(id int) (*models.Break, error) {
 	return &breakModel, nil
 }
 
-func (r *breakRepository) GetAllBreaks() ([]models.Break, error) {
+func (r *breakRepository) GetAllBreaks(query ListQuery) ([]models.Break, int64, error) {
 	var breaks []models.Break
-	if err := r.db.Find(&breaks).Error; err != nil {
-		return nil, err
+	total, err := paginate(r.db, &models.Break{}, &breaks, breakListSpec, query)
+	if err != nil {
+		return nil, 0, err
 	}
-	return breaks, nil
+	return breaks, total, nil
 }
 
 func (r *breakRepository) UpdateBreak(breaks *models.Break) error {
 	return r.db.Save(breaks).Error
 }
 
+// DeleteBreak удаляет перерыв, а для серии — также ее исключения и выделенные из нее вхождения
 func (r *breakRepository) DeleteBreak(id int) error {
-	if err := r.db.Delete(&models.Break{}, id).Error; err != nil {
-		return err
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("break_id = ?", id).Delete(&models.BreakException{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("series_id = ?", id).Delete(&models.Break{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Break{}, id).Error
+	})
+}
+
+func (r *breakRepository) GetBreaksByUserID(userID int) ([]models.Break, error) {
+	var breaks []models.Break
+	if err := r.db.Where("user_id = ?", userID).Find(&breaks).Error; err != nil {
+		return nil, err
 	}
-	return nil
+	return breaks, nil
+}
+
+// GetBreaksByUserInRange возвращает разовые перерывы сотрудника, пересекающиеся с интервалом [from, to),
+// и повторяющиеся перерывы, вхождения которых могут в него попасть (вместе с исключениями).
+// Вхождения повторяющихся перерывов раскрывает BreakService.
+func (r *breakRepository) GetBreaksByUserInRange(userID int, from, to time.Time) ([]models.Break, error) {
+	var breaks []models.Break
+	err := r.db.Preload("Exceptions").
+		Where("user_id = ? AND break_start < ?", userID, to).
+		Where(r.db.Where("(recurrence IS NULL OR recurrence = '') AND break_end > ?", from).
+			Or("recurrence <> '' AND (recurrence_until IS NULL OR recurrence_until >= ?)", from.AddDate(0, 0, -1))).
+		Find(&breaks).Error
+	if err != nil {
+		return nil, err
+	}
+	return breaks, nil
+}
+
+func (r *breakRepository) CreateBreakException(exception *models.BreakException, replacement *models.Break) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var existing int64
+		if err := tx.Model(&models.BreakException{}).
+			Where("break_id = ? AND occurrence_date = ?", exception.BreakID, exception.OccurrenceDate).
+			Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return ErrBreakExceptionConflict
+		}
+
+		if err := tx.Create(exception).Error; err != nil {
+			return err
+		}
+		if replacement != nil {
+			return tx.Create(replacement).Error
+		}
+		return nil
+	})
 }
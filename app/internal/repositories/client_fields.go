@@ -0,0 +1,82 @@
+package repositories
+
+import (
+	"errors"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrClientFieldNotFound = errors.New("дополнительное поле не найдено")
+	ErrClientFieldExists   = errors.New("дополнительное поле с таким ключом уже существует")
+)
+
+type ClientFieldRepository interface {
+	CreateClientField(field *models.ClientField) error
+	GetClientFieldByID(id int) (*models.ClientField, error)
+	GetClientFieldByKey(key string) (*models.ClientField, error)
+	// GetAllClientFields возвращает поля арендатора в порядке Position
+	GetAllClientFields() ([]models.ClientField, error)
+	UpdateClientField(field *models.ClientField) error
+	DeleteClientField(id int) error
+}
+
+type clientFieldRepository struct {
+	db *gorm.DB
+}
+
+func NewClientFieldRepository(db *gorm.DB) ClientFieldRepository {
+	return &clientFieldRepository{
+		db: db,
+	}
+}
+
+func (r *clientFieldRepository) CreateClientField(field *models.ClientField) error {
+	return r.db.Create(field).Error
+}
+
+func (r *clientFieldRepository) GetClientFieldByID(id int) (*models.ClientField, error) {
+	var field models.ClientField
+	if err := r.db.First(&field, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrClientFieldNotFound
+		}
+		return nil, err
+	}
+	return &field, nil
+}
+
+func (r *clientFieldRepository) GetClientFieldByKey(key string) (*models.ClientField, error) {
+	var field models.ClientField
+	if err := r.db.Where("key = ?", key).First(&field).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrClientFieldNotFound
+		}
+		return nil, err
+	}
+	return &field, nil
+}
+
+func (r *clientFieldRepository) GetAllClientFields() ([]models.ClientField, error) {
+	var fields []models.ClientField
+	if err := r.db.Order("position, id").Find(&fields).Error; err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+func (r *clientFieldRepository) UpdateClientField(field *models.ClientField) error {
+	return r.db.Save(field).Error
+}
+
+func (r *clientFieldRepository) DeleteClientField(id int) error {
+	result := r.db.Delete(&models.ClientField{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrClientFieldNotFound
+	}
+	return nil
+}
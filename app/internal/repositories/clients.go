@@ -3,19 +3,85 @@ package repositories
 import (
 	"errors"
 	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
+	"sort"
+	"strings"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var (
 	ErrClientNotFound      = errors.New("клиент не найден")
 	ErrClientAlreadyExists = errors.New("клиент уже существует")
+	ErrClientNoteNotFound  = errors.New("заметка не найдена")
 )
 
+// ClientMergeResult — сколько записей перенесено на сохраненного клиента при объединении
+type ClientMergeResult struct {
+	Bookings      int64 `json:"bookings"`
+	Notifications int64 `json:"notifications"`
+	Notes         int64 `json:"notes"`
+}
+
+// DuplicateBlock — клиенты с общим телефоном, email или ключом имени, среди которых ищутся дубликаты
+type DuplicateBlock struct {
+	Key       string `gorm:"column:block_key"`
+	Size      int
+	ClientIDs []int `gorm:"-"` // Не заполняется для блоков больше maxSize
+}
+
+// duplicateBlockKeys — ключи блоков для поиска дубликатов. Телефон и email хранятся нормализованными
+// и сравниваются как есть. Ключи имени — каждая из частей, имя и фамилия, целиком с первой буквой
+// другой, поэтому «Иван Петров» и «Петров Иван» попадают в блок «петров и».
+var duplicateBlockKeys = []struct{ key, condition string }{
+	{"'phone:' || phone_number", "phone_number <> ''"},
+	{"'email:' || email", "email <> ''"},
+	{"'name:' || TRIM(LOWER(TRIM(first_name)) || ' ' || LOWER(SUBSTR(TRIM(last_name), 1, 1)))", "TRIM(first_name) <> ''"},
+	{"'name:' || TRIM(LOWER(TRIM(last_name)) || ' ' || LOWER(SUBSTR(TRIM(first_name), 1, 1)))", "TRIM(last_name) <> ''"},
+}
+
+// ClientMerger дополняет primary данными дубликата и возвращает записи журнала об объединении.
+// Вызывается в транзакции MergeClients, когда оба клиента уже загружены и заблокированы.
+type ClientMerger func(primary, duplicate *models.Client) ([]models.HistoryLogs, error)
+
+// TagUsage — тег и число клиентов с ним
+type TagUsage struct {
+	Name    string `json:"name"`
+	Clients int64  `json:"clients"`
+}
+
+// ClientSearch — разобранный поисковый запрос: слова ищутся в имени, фамилии, нике Telegram и email,
+// цифры — в номере телефона
+type ClientSearch struct {
+	Terms []string // Слова в нижнем регистре, каждое должно совпасть хотя бы с одним полем или телефоном
+	Phone string   // Цифры номера или его фрагмента
+}
+
+// maxSearchCandidates — сколько наиболее похожих клиентов PostgreSQL возвращает для ранжирования
+const maxSearchCandidates = 200
+
+// clientListSpec — поля списка клиентов
+var clientListSpec = listSpec{
+	sortable: map[string]string{
+		"id":         "id",
+		"first_name": "first_name",
+		"last_name":  "last_name",
+		"created_at": "created_at",
+	},
+	filterable: map[string]listFilter{
+		"email":        {column: "email", kind: filterEmail},
+		"phone_number": {column: "phone_number", kind: filterPhone},
+		// Теги хранятся JSON-массивом строк, поэтому ищется тег в кавычках
+		"tag": {column: "tags", kind: filterTag, where: `tags LIKE ? ESCAPE '\'`},
+	},
+	timeColumn:  "created_at",
+	defaultSort: "id",
+}
+
 type ClientRepository interface {
 	CreateClient(client *models.Client) error
 	GetClientByID(id int) (*models.Client, error)
-	GetAllClients() ([]models.Client, error)
+	GetAllClients(query ListQuery) ([]models.Client, int64, error)
 	UpdateClient(client *models.Client) error
 	DeleteClient(id int) error
 	GetClientByTelegramID(tgID int64) (*models.Client, error)
@@ -23,6 +89,25 @@ type ClientRepository interface {
 	QuickAddClient(client *models.Client) error
 	SearchClientByEmailOrPhone(email, phone string) (*models.Client, error)
 	CheckClientExistence(phoneNumber string, tgID int64) (bool, error)
+	// SearchClients возвращает клиентов, подходящих под запрос, для ранжирования по релевантности.
+	// В PostgreSQL отбор идет по триграммным индексам (pg_trgm), в остальных базах возвращаются все клиенты.
+	SearchClients(search ClientSearch) ([]models.Client, error)
+	// FindDuplicateBlocks возвращает страницу блоков из двух и более клиентов по возрастанию ключа.
+	// Участники перечисляются только в блоках не больше maxSize клиентов.
+	FindDuplicateBlocks(maxSize, offset, limit int) ([]DuplicateBlock, error)
+	// GetClientsByIDs возвращает клиентов с указанными ID по возрастанию ID
+	GetClientsByIDs(ids []int) ([]models.Client, error)
+	// MergeClients в одной транзакции блокирует обоих клиентов, дополняет primaryID через merge, переносит
+	// бронирования, уведомления и заметки дубликата, удаляет дубликат, сохраняет primaryID и записи журнала
+	MergeClients(primaryID, duplicateID int, merge ClientMerger) (*models.Client, *ClientMergeResult, error)
+	// GetTagUsage возвращает теги клиентов по убыванию числа клиентов
+	GetTagUsage() ([]TagUsage, error)
+
+	GetClientNotes(clientID int) ([]models.ClientNote, error)
+	GetClientNote(clientID, noteID int) (*models.ClientNote, error)
+	CreateClientNote(note *models.ClientNote) error
+	UpdateClientNote(note *models.ClientNote) error
+	DeleteClientNote(clientID, noteID int) error
 }
 
 type clientRepository struct {
@@ -35,8 +120,10 @@ func NewClientRepository(db *gorm.DB) ClientRepository {
 	}
 }
 
+// Заметки клиента сохраняются отдельно (CreateClientNote), а не как связанные записи клиента
+
 func (r *clientRepository) CreateClient(client *models.Client) error {
-	return r.db.Create(client).Error
+	return r.db.Omit(clause.Associations).Create(client).Error
 }
 
 func (r *clientRepository) GetClientByID(id int) (*models.Client, error) {
@@ -50,23 +137,26 @@ func (r *clientRepository) GetClientByID(id int) (*models.Client, error) {
 	return &client, nil
 }
 
-func (r *clientRepository) GetAllClients() ([]models.Client, error) {
+func (r *clientRepository) GetAllClients(query ListQuery) ([]models.Client, int64, error) {
 	var clients []models.Client
-	if err := r.db.Find(&clients).Error; err != nil {
-		return nil, err
+	total, err := paginate(r.db, &models.Client{}, &clients, clientListSpec, query)
+	if err != nil {
+		return nil, 0, err
 	}
-	return clients, nil
+	return clients, total, nil
 }
 
 func (r *clientRepository) UpdateClient(client *models.Client) error {
-	return r.db.Save(client).Error
+	return r.db.Omit(clause.Associations).Save(client).Error
 }
 
 func (r *clientRepository) DeleteClient(id int) error {
-	if err := r.db.Delete(&models.Client{}, id).Error; err != nil {
-		return err
-	}
-	return nil
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("client_id = ?", id).Delete(&models.ClientNote{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Client{}, id).Error
+	})
 }
 
 func (r *clientRepository) GetClientByTelegramID(tgID int64) (*models.Client, error) {
@@ -107,7 +197,7 @@ func (r *clientRepository) QuickAddClient(client *models.Client) error {
 		}
 	}
 
-	return r.db.Create(client).Error
+	return r.db.Omit(clause.Associations).Create(client).Error
 }
 
 func (r *clientRepository) SearchClientByEmailOrPhone(email, phone string) (*models.Client, error) {
@@ -142,3 +232,236 @@ func (r *clientRepository) CheckClientExistence(phoneNumber string, tgID int64)
 	}
 	return count > 0, nil
 }
+
+func (r *clientRepository) SearchClients(search ClientSearch) ([]models.Client, error) {
+	if r.db.Dialector.Name() != "postgres" {
+		return r.findAllClients()
+	}
+
+	query := r.db.Model(&models.Client{})
+	similarity := make([]string, 0, len(search.Terms)+1)
+	var args []interface{}
+	for _, term := range search.Terms {
+		pattern := "%" + escapeLike(term) + "%"
+		// ILIKE с подстрокой и оператор сходства со словом <% используют GIN-индексы gin_trgm_ops
+		query = query.Where(
+			"(first_name ILIKE ? OR last_name ILIKE ? OR tg_nickname ILIKE ? OR email ILIKE ? OR phone_number LIKE ? OR ? <% first_name OR ? <% last_name)",
+			pattern, pattern, pattern, pattern, pattern, term, term,
+		)
+		similarity = append(similarity, "GREATEST(similarity(first_name, ?), similarity(last_name, ?), similarity(tg_nickname, ?), similarity(email, ?))")
+		args = append(args, term, term, term, term)
+	}
+	if search.Phone != "" {
+		query = query.Where("phone_number LIKE ?", "%"+search.Phone+"%")
+		similarity = append(similarity, "similarity(phone_number, ?)")
+		args = append(args, search.Phone)
+	}
+	if len(similarity) == 0 {
+		return []models.Client{}, nil
+	}
+
+	var clients []models.Client
+	order := clause.OrderBy{Expression: clause.Expr{SQL: strings.Join(similarity, " + ") + " DESC, id", Vars: args}}
+	if err := query.Order(order).Limit(maxSearchCandidates).Find(&clients).Error; err != nil {
+		return nil, err
+	}
+	return clients, nil
+}
+
+// escapeLike экранирует символы шаблона LIKE, чтобы они искались буквально
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
+
+func (r *clientRepository) findAllClients() ([]models.Client, error) {
+	var clients []models.Client
+	if err := r.db.Order("id").Find(&clients).Error; err != nil {
+		return nil, err
+	}
+	return clients, nil
+}
+
+func (r *clientRepository) GetClientsByIDs(ids []int) ([]models.Client, error) {
+	clients := make([]models.Client, 0, len(ids))
+	if len(ids) == 0 {
+		return clients, nil
+	}
+	if err := r.db.Where("id IN ?", ids).Order("id").Find(&clients).Error; err != nil {
+		return nil, err
+	}
+	return clients, nil
+}
+
+// duplicateKeys строит выборку пар (block_key, id) по всем ключам блоков
+func (r *clientRepository) duplicateKeys() *gorm.DB {
+	parts := make([]interface{}, 0, len(duplicateBlockKeys))
+	placeholders := make([]string, 0, len(duplicateBlockKeys))
+	for _, key := range duplicateBlockKeys {
+		parts = append(parts, r.db.Model(&models.Client{}).Select(key.key+" AS block_key, id").Where(key.condition))
+		placeholders = append(placeholders, "?")
+	}
+	return r.db.Raw(strings.Join(placeholders, " UNION ALL "), parts...)
+}
+
+func (r *clientRepository) FindDuplicateBlocks(maxSize, offset, limit int) ([]DuplicateBlock, error) {
+	var blocks []DuplicateBlock
+	err := r.db.Table("(?) AS block_keys", r.duplicateKeys()).
+		Select("block_key, COUNT(DISTINCT id) AS size").
+		Group("block_key").Having("COUNT(DISTINCT id) > 1").
+		Order("block_key").Offset(offset).Limit(limit).
+		Scan(&blocks).Error
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]int, len(blocks))
+	keys := make([]string, 0, len(blocks))
+	for i, block := range blocks {
+		if block.Size <= maxSize {
+			index[block.Key] = i
+			keys = append(keys, block.Key)
+		}
+	}
+	if len(keys) == 0 {
+		return blocks, nil
+	}
+
+	var members []struct {
+		Key string `gorm:"column:block_key"`
+		ID  int
+	}
+	err = r.db.Table("(?) AS block_keys", r.duplicateKeys()).
+		Distinct("block_key", "id").Where("block_key IN ?", keys).
+		Order("block_key, id").
+		Scan(&members).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, member := range members {
+		block := &blocks[index[member.Key]]
+		block.ClientIDs = append(block.ClientIDs, member.ID)
+	}
+	return blocks, nil
+}
+
+func (r *clientRepository) MergeClients(primaryID, duplicateID int, merge ClientMerger) (*models.Client, *ClientMergeResult, error) {
+	var primary *models.Client
+	result := &ClientMergeResult{}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Клиенты блокируются в порядке id, поэтому встречные объединения не блокируют друг друга
+		var clients []models.Client
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id IN ?", []int{primaryID, duplicateID}).
+			Order("id").Find(&clients).Error
+		if err != nil {
+			return err
+		}
+		if len(clients) != 2 {
+			return ErrClientNotFound
+		}
+		primary = &clients[0]
+		duplicate := &clients[1]
+		if primary.ID != primaryID {
+			primary, duplicate = duplicate, primary
+		}
+		logs, err := merge(primary, duplicate)
+		if err != nil {
+			return err
+		}
+
+		bookings := tx.Model(&models.Bookings{}).Where("client_id = ?", duplicateID).Update("client_id", primary.ID)
+		if bookings.Error != nil {
+			return bookings.Error
+		}
+		notifications := tx.Model(&models.Notification{}).Where("client_id = ?", duplicateID).Update("client_id", primary.ID)
+		if notifications.Error != nil {
+			return notifications.Error
+		}
+		notes := tx.Model(&models.ClientNote{}).Where("client_id = ?", duplicateID).Update("client_id", primary.ID)
+		if notes.Error != nil {
+			return notes.Error
+		}
+		result.Bookings, result.Notifications, result.Notes = bookings.RowsAffected, notifications.RowsAffected, notes.RowsAffected
+
+		// Дубликат удаляется раньше сохранения: его email и Telegram ID уникальны у арендатора
+		if err := tx.Delete(&models.Client{}, duplicateID).Error; err != nil {
+			return err
+		}
+		if err := tx.Omit(clause.Associations).Save(primary).Error; err != nil {
+			return err
+		}
+		// Записи журнала сохраняются отдельными запросами, чтобы каждая получила арендатора соединения
+		for i := range logs {
+			if err := tx.Create(&logs[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return primary, result, nil
+}
+
+func (r *clientRepository) GetTagUsage() ([]TagUsage, error) {
+	var clients []models.Client
+	if err := r.db.Select("id", "tags").Where("tags IS NOT NULL").Find(&clients).Error; err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64)
+	for _, client := range clients {
+		for _, tag := range client.Tags {
+			counts[tag]++
+		}
+	}
+	usage := make([]TagUsage, 0, len(counts))
+	for name, count := range counts {
+		usage = append(usage, TagUsage{Name: name, Clients: count})
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].Clients != usage[j].Clients {
+			return usage[i].Clients > usage[j].Clients
+		}
+		return usage[i].Name < usage[j].Name
+	})
+	return usage, nil
+}
+
+func (r *clientRepository) GetClientNotes(clientID int) ([]models.ClientNote, error) {
+	var notes []models.ClientNote
+	if err := r.db.Where("client_id = ?", clientID).Order("created_at DESC, id DESC").Find(&notes).Error; err != nil {
+		return nil, err
+	}
+	return notes, nil
+}
+
+func (r *clientRepository) GetClientNote(clientID, noteID int) (*models.ClientNote, error) {
+	var note models.ClientNote
+	if err := r.db.Where("client_id = ?", clientID).First(&note, noteID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrClientNoteNotFound
+		}
+		return nil, err
+	}
+	return &note, nil
+}
+
+func (r *clientRepository) CreateClientNote(note *models.ClientNote) error {
+	return r.db.Create(note).Error
+}
+
+func (r *clientRepository) UpdateClientNote(note *models.ClientNote) error {
+	return r.db.Save(note).Error
+}
+
+func (r *clientRepository) DeleteClientNote(clientID, noteID int) error {
+	result := r.db.Where("client_id = ?", clientID).Delete(&models.ClientNote{}, noteID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrClientNoteNotFound
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package repositories
+
+import (
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// historyListSpec — поля журнала изменений; actor_id соответствует автору изменения
+var historyListSpec = listSpec{
+	sortable: map[string]string{
+		"id":         "id",
+		"created_at": "created_at",
+	},
+	filterable: map[string]listFilter{
+		"actor_id":    {column: "user_id", kind: filterInt},
+		"action":      {column: "action", kind: filterString},
+		"entity_type": {column: "entity_type", kind: filterString},
+		"entity_id":   {column: "entity_id", kind: filterInt},
+	},
+	timeColumn:  "created_at",
+	defaultSort: "-created_at,-id",
+}
+
+type HistoryRepository interface {
+	CreateLog(log *models.HistoryLogs) error
+	FindLogs(query ListQuery) ([]models.HistoryLogs, int64, error)
+}
+
+type historyRepository struct {
+	db *gorm.DB
+}
+
+func NewHistoryRepository(db *gorm.DB) HistoryRepository {
+	return &historyRepository{
+		db: db,
+	}
+}
+
+func (r *historyRepository) CreateLog(log *models.HistoryLogs) error {
+	return r.db.Create(log).Error
+}
+
+func (r *historyRepository) FindLogs(query ListQuery) ([]models.HistoryLogs, int64, error) {
+	var logs []models.HistoryLogs
+	total, err := paginate(r.db, &models.HistoryLogs{}, &logs, historyListSpec, query)
+	if err != nil {
+		return nil, 0, err
+	}
+	return logs, total, nil
+}
@@ -0,0 +1,249 @@
+package repositories
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidListQuery = errors.New("некорректные параметры списка")
+)
+
+const (
+	DefaultListLimit = 50
+	MaxListLimit     = 200
+)
+
+// ListQuery — параметры выборки списка: пагинация, сортировка и фильтры.
+// Sort содержит имена полей через запятую, "-" перед полем задает обратный порядок.
+// Filters сопоставляет имя поля со значением; допустимые поля определяет репозиторий.
+type ListQuery struct {
+	Limit   int
+	Offset  int
+	Sort    string
+	Filters map[string]string
+	From    time.Time
+	To      time.Time
+}
+
+// Normalize подставляет лимит по умолчанию и ограничивает его сверху
+func (q ListQuery) Normalize() ListQuery {
+	if q.Limit <= 0 {
+		q.Limit = DefaultListLimit
+	}
+	if q.Limit > MaxListLimit {
+		q.Limit = MaxListLimit
+	}
+	if q.Offset < 0 {
+		q.Offset = 0
+	}
+	return q
+}
+
+// NextCursor возвращает курсор следующей страницы или пустую строку, если страница последняя
+func (q ListQuery) NextCursor(total int64) string {
+	q = q.Normalize()
+	next := q.Offset + q.Limit
+	if int64(next) >= total {
+		return ""
+	}
+	return EncodeCursor(next)
+}
+
+// EncodeCursor упаковывает смещение в непрозрачный курсор
+func EncodeCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("o:" + strconv.Itoa(offset)))
+}
+
+// DecodeCursor извлекает смещение из курсора, выданного EncodeCursor
+func DecodeCursor(cursor string) (int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || !strings.HasPrefix(string(raw), "o:") {
+		return 0, fmt.Errorf("%w: курсор", ErrInvalidListQuery)
+	}
+	offset, err := strconv.Atoi(strings.TrimPrefix(string(raw), "o:"))
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("%w: курсор", ErrInvalidListQuery)
+	}
+	return offset, nil
+}
+
+// filterKind определяет, к какому типу приводится значение фильтра
+type filterKind int
+
+const (
+	filterString filterKind = iota
+	filterInt
+	filterBool
+	filterWeekday
+	filterPhone // Номер телефона, приводится к E.164
+	filterEmail // Email, приводится к нижнему регистру
+	filterTag   // Тег в JSON-массиве, ищется через LIKE по строке в кавычках
+)
+
+type listFilter struct {
+	column string
+	kind   filterKind
+	// where заменяет условие "column = ?", например подзапросом
+	where string
+}
+
+// listSpec описывает список сущности: поля для сортировки и фильтрации,
+// колонку для from/to и порядок по умолчанию
+type listSpec struct {
+	sortable    map[string]string
+	filterable  map[string]listFilter
+	timeColumn  string
+	defaultSort string
+}
+
+// paginate выбирает страницу списка в dest и возвращает общее количество записей с учетом фильтров.
+// preloads применяются только к выборке страницы.
+func paginate(db *gorm.DB, model interface{}, dest interface{}, spec listSpec, q ListQuery, preloads ...string) (int64, error) {
+	q = q.Normalize()
+
+	filters, err := spec.filterScope(q)
+	if err != nil {
+		return 0, err
+	}
+	order, err := spec.order(q.Sort)
+	if err != nil {
+		return 0, err
+	}
+
+	var total int64
+	if err := db.Model(model).Scopes(filters).Count(&total).Error; err != nil {
+		return 0, err
+	}
+
+	query := db.Model(model).Scopes(filters)
+	for _, preload := range preloads {
+		query = query.Preload(preload)
+	}
+	if err := query.Order(order).Limit(q.Limit).Offset(q.Offset).Find(dest).Error; err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+func (s listSpec) filterScope(q ListQuery) (func(*gorm.DB) *gorm.DB, error) {
+	type condition struct {
+		query string
+		value interface{}
+	}
+	var conditions []condition
+
+	for field, raw := range q.Filters {
+		filter, ok := s.filterable[field]
+		if !ok {
+			return nil, fmt.Errorf("%w: фильтр по полю %s не поддерживается", ErrInvalidListQuery, field)
+		}
+
+		var value interface{} = raw
+		switch filter.kind {
+		case filterInt:
+			n, err := strconv.Atoi(raw)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s должно быть числом", ErrInvalidListQuery, field)
+			}
+			value = n
+		case filterBool:
+			b, err := strconv.ParseBool(raw)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s должно быть true или false", ErrInvalidListQuery, field)
+			}
+			value = b
+		case filterWeekday:
+			day, err := models.ParseWeekday(raw)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s должно быть днем недели", ErrInvalidListQuery, field)
+			}
+			value = day
+		case filterPhone:
+			phone, err := models.NormalizePhone(raw)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s должно быть номером телефона", ErrInvalidListQuery, field)
+			}
+			value = phone
+		case filterEmail:
+			email, err := models.NormalizeEmail(raw)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s должно быть email", ErrInvalidListQuery, field)
+			}
+			value = email
+		case filterTag:
+			tags, err := models.NormalizeTags([]string{raw})
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s должно быть тегом", ErrInvalidListQuery, field)
+			}
+			quoted, _ := json.Marshal(tags[0])
+			value = "%" + escapeLike(string(quoted)) + "%"
+		}
+		query := filter.column + " = ?"
+		if filter.where != "" {
+			query = filter.where
+		}
+		conditions = append(conditions, condition{query, value})
+	}
+
+	if !q.From.IsZero() || !q.To.IsZero() {
+		if s.timeColumn == "" {
+			return nil, fmt.Errorf("%w: фильтр по периоду не поддерживается", ErrInvalidListQuery)
+		}
+		if !q.From.IsZero() {
+			conditions = append(conditions, condition{s.timeColumn + " >= ?", q.From})
+		}
+		if !q.To.IsZero() {
+			conditions = append(conditions, condition{s.timeColumn + " < ?", q.To})
+		}
+	}
+
+	return func(tx *gorm.DB) *gorm.DB {
+		for _, c := range conditions {
+			tx = tx.Where(c.query, c.value)
+		}
+		return tx
+	}, nil
+}
+
+// order строит ORDER BY; id всегда добавляется последним, чтобы страницы не пересекались
+func (s listSpec) order(sort string) (string, error) {
+	if sort == "" {
+		sort = s.defaultSort
+	}
+
+	var parts []string
+	hasID := false
+	for _, field := range strings.Split(sort, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		direction := "ASC"
+		if strings.HasPrefix(field, "-") {
+			direction = "DESC"
+			field = field[1:]
+		}
+
+		column, ok := s.sortable[field]
+		if !ok {
+			return "", fmt.Errorf("%w: сортировка по полю %s не поддерживается", ErrInvalidListQuery, field)
+		}
+		if column == "id" {
+			hasID = true
+		}
+		parts = append(parts, column+" "+direction)
+	}
+	if !hasID {
+		parts = append(parts, "id ASC")
+	}
+	return strings.Join(parts, ", "), nil
+}
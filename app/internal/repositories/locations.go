@@ -0,0 +1,110 @@
+package repositories
+
+import (
+	"errors"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrLocationNotFound = errors.New("филиал не найден")
+	ErrLocationInUse    = errors.New("филиал используется: к нему привязаны сотрудники, расписания, перерывы или бронирования")
+)
+
+// locationListSpec — поля списка филиалов
+var locationListSpec = listSpec{
+	sortable: map[string]string{
+		"id":         "id",
+		"name":       "name",
+		"created_at": "created_at",
+	},
+	filterable: map[string]listFilter{
+		"is_active": {column: "is_active", kind: filterBool},
+		"timezone":  {column: "timezone", kind: filterString},
+	},
+	timeColumn:  "created_at",
+	defaultSort: "id",
+}
+
+// locationReferences — сущности, привязанные к филиалу; пока они есть, филиал нельзя удалить
+var locationReferences = []interface{}{
+	&models.User{},
+	&models.Schedule{},
+	&models.ScheduleOverride{},
+	&models.Break{},
+	&models.Bookings{},
+}
+
+type LocationRepository interface {
+	CreateLocation(location *models.Location) error
+	GetLocationByID(id int) (*models.Location, error)
+	GetAllLocations(query ListQuery) ([]models.Location, int64, error)
+	UpdateLocation(location *models.Location) error
+	// DeleteLocation удаляет филиал вместе с ценами услуг в нем; филиал с привязанными записями не удаляется
+	DeleteLocation(id int) error
+}
+
+type locationRepository struct {
+	db *gorm.DB
+}
+
+func NewLocationRepository(db *gorm.DB) LocationRepository {
+	return &locationRepository{
+		db: db,
+	}
+}
+
+func (r *locationRepository) CreateLocation(location *models.Location) error {
+	return r.db.Create(location).Error
+}
+
+func (r *locationRepository) GetLocationByID(id int) (*models.Location, error) {
+	var location models.Location
+	if err := r.db.First(&location, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrLocationNotFound
+		}
+		return nil, err
+	}
+	return &location, nil
+}
+
+func (r *locationRepository) GetAllLocations(query ListQuery) ([]models.Location, int64, error) {
+	var locations []models.Location
+	total, err := paginate(r.db, &models.Location{}, &locations, locationListSpec, query)
+	if err != nil {
+		return nil, 0, err
+	}
+	return locations, total, nil
+}
+
+func (r *locationRepository) UpdateLocation(location *models.Location) error {
+	return r.db.Save(location).Error
+}
+
+func (r *locationRepository) DeleteLocation(id int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, model := range locationReferences {
+			var count int64
+			if err := tx.Model(model).Where("location_id = ?", id).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return ErrLocationInUse
+			}
+		}
+
+		if err := tx.Where("location_id = ?", id).Delete(&models.ServiceLocationPrice{}).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&models.Location{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrLocationNotFound
+		}
+		return nil
+	})
+}
@@ -0,0 +1,108 @@
+package repositories
+
+import (
+	"errors"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrTemplateNotFound      = errors.New("шаблон уведомления не найден")
+	ErrTemplateAlreadyExists = errors.New("шаблон с таким кодом и языком уже существует")
+)
+
+// templateListSpec — поля списка шаблонов уведомлений
+var templateListSpec = listSpec{
+	sortable: map[string]string{
+		"id":         "id",
+		"code":       "code",
+		"language":   "language",
+		"updated_at": "updated_at",
+	},
+	filterable: map[string]listFilter{
+		"code":     {column: "code", kind: filterString},
+		"language": {column: "language", kind: filterString},
+	},
+	timeColumn:  "updated_at",
+	defaultSort: "code,language",
+}
+
+type NotificationTemplateRepository interface {
+	CreateTemplate(template *models.NotificationTemplate) error
+	GetTemplateByID(id int) (*models.NotificationTemplate, error)
+	GetTemplateByCode(code, language string) (*models.NotificationTemplate, error)
+	GetAllTemplates(query ListQuery) ([]models.NotificationTemplate, int64, error)
+	UpdateTemplate(template *models.NotificationTemplate) error
+	DeleteTemplate(id int) error
+}
+
+type notificationTemplateRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationTemplateRepository(db *gorm.DB) NotificationTemplateRepository {
+	return &notificationTemplateRepository{
+		db: db,
+	}
+}
+
+func (r *notificationTemplateRepository) CreateTemplate(template *models.NotificationTemplate) error {
+	if _, err := r.GetTemplateByCode(template.Code, template.Language); err == nil {
+		return ErrTemplateAlreadyExists
+	} else if !errors.Is(err, ErrTemplateNotFound) {
+		return err
+	}
+	return r.db.Create(template).Error
+}
+
+func (r *notificationTemplateRepository) GetTemplateByID(id int) (*models.NotificationTemplate, error) {
+	var template models.NotificationTemplate
+	if err := r.db.First(&template, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTemplateNotFound
+		}
+		return nil, err
+	}
+	return &template, nil
+}
+
+func (r *notificationTemplateRepository) GetTemplateByCode(code, language string) (*models.NotificationTemplate, error) {
+	var template models.NotificationTemplate
+	if err := r.db.Where("code = ? AND language = ?", code, language).First(&template).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTemplateNotFound
+		}
+		return nil, err
+	}
+	return &template, nil
+}
+
+func (r *notificationTemplateRepository) GetAllTemplates(query ListQuery) ([]models.NotificationTemplate, int64, error) {
+	var templates []models.NotificationTemplate
+	total, err := paginate(r.db, &models.NotificationTemplate{}, &templates, templateListSpec, query)
+	if err != nil {
+		return nil, 0, err
+	}
+	return templates, total, nil
+}
+
+func (r *notificationTemplateRepository) UpdateTemplate(template *models.NotificationTemplate) error {
+	if existing, err := r.GetTemplateByCode(template.Code, template.Language); err == nil && existing.ID != template.ID {
+		return ErrTemplateAlreadyExists
+	} else if err != nil && !errors.Is(err, ErrTemplateNotFound) {
+		return err
+	}
+	return r.db.Save(template).Error
+}
+
+func (r *notificationTemplateRepository) DeleteTemplate(id int) error {
+	result := r.db.Delete(&models.NotificationTemplate{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTemplateNotFound
+	}
+	return nil
+}
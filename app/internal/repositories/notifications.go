@@ -3,6 +3,7 @@ package repositories
 import (
 	"errors"
 	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -11,12 +12,34 @@ var (
 	ErrNotificationNotFound = errors.New("уведомление не найдено")
 )
 
+// notificationListSpec — поля списка уведомлений
+var notificationListSpec = listSpec{
+	sortable: map[string]string{
+		"id":            "id",
+		"created_at":    "created_at",
+		"sent_at":       "sent_at",
+		"status":        "status",
+		"scheduled_for": "scheduled_for",
+	},
+	filterable: map[string]listFilter{
+		"client_id":         {column: "client_id", kind: filterInt},
+		"booking_id":        {column: "booking_id", kind: filterInt},
+		"status":            {column: "status", kind: filterString},
+		"notification_type": {column: "notification_type", kind: filterString},
+	},
+	timeColumn:  "created_at",
+	defaultSort: "-created_at",
+}
+
 type NotificationRepository interface {
 	CreateNotification(notification *models.Notification) error
 	GetNotificationByID(id int) (*models.Notification, error)
-	GetAllNotifications() ([]models.Notification, error)
+	GetAllNotifications(query ListQuery) ([]models.Notification, int64, error)
 	UpdateNotification(notification *models.Notification) error
 	DeleteNotification(id int) error
+	FindDueNotifications(now time.Time, limit int) ([]models.Notification, error)
+	ReplaceBookingReminders(bookingID int, reminders []models.Notification) error
+	CancelBookingNotifications(bookingID int) error
 }
 
 type notificationRepository struct {
@@ -44,12 +67,13 @@ func (r *notificationRepository) GetNotificationByID(id int) (*models.Notificati
 	return &notification, nil
 }
 
-func (r *notificationRepository) GetAllNotifications() ([]models.Notification, error) {
+func (r *notificationRepository) GetAllNotifications(query ListQuery) ([]models.Notification, int64, error) {
 	var notifications []models.Notification
-	if err := r.db.Find(&notifications).Error; err != nil {
-		return nil, err
+	total, err := paginate(r.db, &models.Notification{}, &notifications, notificationListSpec, query)
+	if err != nil {
+		return nil, 0, err
 	}
-	return notifications, nil
+	return notifications, total, nil
 }
 
 func (r *notificationRepository) UpdateNotification(notification *models.Notification) error {
@@ -62,3 +86,44 @@ func (r *notificationRepository) DeleteNotification(id int) error {
 	}
 	return nil
 }
+
+// FindDueNotifications возвращает ожидающие отправки уведомления, у которых наступило
+// запланированное время (scheduled_for) и время повторной попытки
+func (r *notificationRepository) FindDueNotifications(now time.Time, limit int) ([]models.Notification, error) {
+	var notifications []models.Notification
+	err := r.db.
+		Where("status = ?", models.NotificationStatusPending).
+		Where("scheduled_for IS NULL OR scheduled_for <= ?", now).
+		Where("next_attempt_at IS NULL OR next_attempt_at <= ?", now).
+		Order("id").
+		Limit(limit).
+		Find(&notifications).Error
+	if err != nil {
+		return nil, err
+	}
+	return notifications, nil
+}
+
+// ReplaceBookingReminders отменяет неотправленные напоминания бронирования (уведомления с scheduled_for)
+// и ставит в очередь новые
+func (r *notificationRepository) ReplaceBookingReminders(bookingID int, reminders []models.Notification) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.Notification{}).
+			Where("booking_id = ? AND status = ? AND scheduled_for IS NOT NULL", bookingID, models.NotificationStatusPending).
+			Update("status", models.NotificationStatusCancelled).Error
+		if err != nil {
+			return err
+		}
+		if len(reminders) == 0 {
+			return nil
+		}
+		return tx.Create(&reminders).Error
+	})
+}
+
+// CancelBookingNotifications отменяет все неотправленные уведомления бронирования
+func (r *notificationRepository) CancelBookingNotifications(bookingID int) error {
+	return r.db.Model(&models.Notification{}).
+		Where("booking_id = ? AND status = ?", bookingID, models.NotificationStatusPending).
+		Update("status", models.NotificationStatusCancelled).Error
+}
@@ -0,0 +1,95 @@
+package repositories
+
+import (
+	"errors"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrScheduleOverrideNotFound = errors.New("исключение из расписания не найдено")
+)
+
+// scheduleOverrideListSpec — поля списка исключений из расписания
+var scheduleOverrideListSpec = listSpec{
+	sortable: map[string]string{
+		"id":         "id",
+		"user_id":    "user_id",
+		"date":       "date",
+		"created_at": "created_at",
+	},
+	filterable: map[string]listFilter{
+		"user_id":     {column: "user_id", kind: filterInt},
+		"date":        {column: "date", kind: filterString},
+		"kind":        {column: "kind", kind: filterString},
+		"location_id": {column: "location_id", kind: filterInt},
+	},
+	timeColumn:  "created_at",
+	defaultSort: "date",
+}
+
+type ScheduleOverrideRepository interface {
+	CreateOverride(override *models.ScheduleOverride) error
+	GetOverrideByID(id int) (*models.ScheduleOverride, error)
+	GetAllOverrides(query ListQuery) ([]models.ScheduleOverride, int64, error)
+	UpdateOverride(override *models.ScheduleOverride) error
+	DeleteOverride(id int) error
+	// GetOverridesByDate возвращает исключения на дату (ГГГГ-ММ-ДД); userID 0 — для всех сотрудников
+	GetOverridesByDate(userID int, date string) ([]models.ScheduleOverride, error)
+}
+
+type scheduleOverrideRepository struct {
+	db *gorm.DB
+}
+
+func NewScheduleOverrideRepository(db *gorm.DB) ScheduleOverrideRepository {
+	return &scheduleOverrideRepository{
+		db: db,
+	}
+}
+
+func (r *scheduleOverrideRepository) CreateOverride(override *models.ScheduleOverride) error {
+	return r.db.Create(override).Error
+}
+
+func (r *scheduleOverrideRepository) GetOverrideByID(id int) (*models.ScheduleOverride, error) {
+	var override models.ScheduleOverride
+	if err := r.db.First(&override, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrScheduleOverrideNotFound
+		}
+		return nil, err
+	}
+	return &override, nil
+}
+
+func (r *scheduleOverrideRepository) GetAllOverrides(query ListQuery) ([]models.ScheduleOverride, int64, error) {
+	var overrides []models.ScheduleOverride
+	total, err := paginate(r.db, &models.ScheduleOverride{}, &overrides, scheduleOverrideListSpec, query)
+	if err != nil {
+		return nil, 0, err
+	}
+	return overrides, total, nil
+}
+
+func (r *scheduleOverrideRepository) UpdateOverride(override *models.ScheduleOverride) error {
+	return r.db.Save(override).Error
+}
+
+func (r *scheduleOverrideRepository) DeleteOverride(id int) error {
+	return r.db.Delete(&models.ScheduleOverride{}, id).Error
+}
+
+func (r *scheduleOverrideRepository) GetOverridesByDate(userID int, date string) ([]models.ScheduleOverride, error) {
+	query := r.db.Where("date = ?", date)
+	if userID != 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	var overrides []models.ScheduleOverride
+	if err := query.Order("id").Find(&overrides).Error; err != nil {
+		return nil, err
+	}
+	return overrides, nil
+}
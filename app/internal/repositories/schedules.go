@@ -11,13 +11,31 @@ var (
 	ErrScheduleNotFound = errors.New("расписание не найдено")
 )
 
+// scheduleListSpec — поля списка расписаний
+var scheduleListSpec = listSpec{
+	sortable: map[string]string{
+		"id":           "id",
+		"user_id":      "user_id",
+		"schedule_day": "schedule_day",
+		"created_at":   "created_at",
+	},
+	filterable: map[string]listFilter{
+		"user_id":      {column: "user_id", kind: filterInt},
+		"schedule_day": {column: "schedule_day", kind: filterWeekday},
+		"location_id":  {column: "location_id", kind: filterInt},
+	},
+	timeColumn:  "created_at",
+	defaultSort: "id",
+}
+
 type ScheduleRepository interface {
 	CreateSchedule(schedule *models.Schedule) error
 	GetScheduleByID(id int) (*models.Schedule, error)
-	GetAllSchedules() ([]models.Schedule, error)
+	GetAllSchedules(query ListQuery) ([]models.Schedule, int64, error)
 	UpdateSchedule(schedule *models.Schedule) error
 	DeleteSchedule(id int) error
 	FilterSchedulesByUser(userID int) ([]models.Schedule, error)
+	GetSchedulesByDay(day models.Weekday) ([]models.Schedule, error)
 }
 
 type scheduleRepository struct {
@@ -45,12 +63,13 @@ func (r *scheduleRepository) GetScheduleByID(id int) (*models.Schedule, error) {
 	return &schedule, nil
 }
 
-func (r *scheduleRepository) GetAllSchedules() ([]models.Schedule, error) {
+func (r *scheduleRepository) GetAllSchedules(query ListQuery) ([]models.Schedule, int64, error) {
 	var schedules []models.Schedule
-	if err := r.db.Find(&schedules).Error; err != nil {
-		return nil, err
+	total, err := paginate(r.db, &models.Schedule{}, &schedules, scheduleListSpec, query)
+	if err != nil {
+		return nil, 0, err
 	}
-	return schedules, nil
+	return schedules, total, nil
 }
 
 func (r *scheduleRepository) UpdateSchedule(schedule *models.Schedule) error {
@@ -71,3 +90,11 @@ func (r *scheduleRepository) FilterSchedulesByUser(userID int) ([]models.Schedul
 	}
 	return schedules, nil
 }
+
+func (r *scheduleRepository) GetSchedulesByDay(day models.Weekday) ([]models.Schedule, error) {
+	var schedules []models.Schedule
+	if err := r.db.Where("schedule_day = ?", day).Find(&schedules).Error; err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
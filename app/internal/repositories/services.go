@@ -5,19 +5,49 @@ import (
 	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var (
-	ErrServiceNotFound = errors.New("услуга не найдена")
+	ErrServiceNotFound              = errors.New("услуга не найдена")
+	ErrServiceLocationPriceNotFound = errors.New("цена услуги в филиале не задана")
+	ErrBarberServiceNotFound        = errors.New("услуга не назначена сотруднику")
 )
 
+// serviceListSpec — поля списка услуг
+var serviceListSpec = listSpec{
+	sortable: map[string]string{
+		"id":         "id",
+		"name":       "name",
+		"price":      "price_amount",
+		"duration":   "duration",
+		"created_at": "created_at",
+	},
+	filterable: map[string]listFilter{
+		"is_active": {column: "is_active", kind: filterBool},
+	},
+	timeColumn:  "created_at",
+	defaultSort: "id",
+}
+
 type ServiceRepository interface {
 	CreateService(service *models.Service) error
 	GetServiceByID(id int) (*models.Service, error)
-	GetAllServices() ([]models.Service, error)
+	GetAllServices(query ListQuery) ([]models.Service, int64, error)
 	UpdateService(service *models.Service) error
 	DeleteService(id int) error
 	DeactivateService(id int) error
+
+	// SetLocationPrice задает цену услуги в филиале, заменяя прежнюю
+	SetLocationPrice(price *models.ServiceLocationPrice) error
+	DeleteLocationPrice(serviceID, locationID int) error
+
+	// AssignBarber назначает услугу сотруднику, заменяя прежние индивидуальные цену и длительность
+	AssignBarber(assignment *models.BarberService) error
+	UnassignBarber(serviceID, userID int) error
+	GetBarberService(serviceID, userID int) (*models.BarberService, error)
+	// GetServiceBarbers возвращает назначения услуги с загруженными сотрудниками
+	GetServiceBarbers(serviceID int) ([]models.BarberService, error)
 }
 
 type serviceRepository struct {
@@ -36,7 +66,7 @@ func (r *serviceRepository) CreateService(service *models.Service) error {
 
 func (r *serviceRepository) GetServiceByID(id int) (*models.Service, error) {
 	var service models.Service
-	if err := r.db.First(&service, id).Error; err != nil {
+	if err := r.db.Preload("LocationPrices").First(&service, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, ErrServiceNotFound
 		}
@@ -45,23 +75,30 @@ func (r *serviceRepository) GetServiceByID(id int) (*models.Service, error) {
 	return &service, nil
 }
 
-func (r *serviceRepository) GetAllServices() ([]models.Service, error) {
+func (r *serviceRepository) GetAllServices(query ListQuery) ([]models.Service, int64, error) {
 	var services []models.Service
-	if err := r.db.Find(&services).Error; err != nil {
-		return nil, err
+	total, err := paginate(r.db, &models.Service{}, &services, serviceListSpec, query)
+	if err != nil {
+		return nil, 0, err
 	}
-	return services, nil
+	return services, total, nil
 }
 
 func (r *serviceRepository) UpdateService(service *models.Service) error {
-	return r.db.Save(service).Error
+	// Цены в филиалах меняются только через SetLocationPrice и DeleteLocationPrice
+	return r.db.Omit("LocationPrices").Save(service).Error
 }
 
 func (r *serviceRepository) DeleteService(id int) error {
-	if err := r.db.Delete(&models.Service{}, id).Error; err != nil {
-		return err
-	}
-	return nil
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("service_id = ?", id).Delete(&models.ServiceLocationPrice{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("service_id = ?", id).Delete(&models.BarberService{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Service{}, id).Error
+	})
 }
 
 func (r *serviceRepository) DeactivateService(id int) error {
@@ -70,3 +107,58 @@ func (r *serviceRepository) DeactivateService(id int) error {
 	}
 	return nil
 }
+
+func (r *serviceRepository) SetLocationPrice(price *models.ServiceLocationPrice) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "service_id"}, {Name: "location_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"price_amount", "price_currency", "updated_at"}),
+	}).Create(price).Error
+}
+
+func (r *serviceRepository) DeleteLocationPrice(serviceID, locationID int) error {
+	result := r.db.Where("service_id = ? AND location_id = ?", serviceID, locationID).Delete(&models.ServiceLocationPrice{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrServiceLocationPriceNotFound
+	}
+	return nil
+}
+
+func (r *serviceRepository) AssignBarber(assignment *models.BarberService) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "service_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"price_amount", "price_currency", "duration", "updated_at"}),
+	}).Create(assignment).Error
+}
+
+func (r *serviceRepository) UnassignBarber(serviceID, userID int) error {
+	result := r.db.Where("service_id = ? AND user_id = ?", serviceID, userID).Delete(&models.BarberService{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrBarberServiceNotFound
+	}
+	return nil
+}
+
+func (r *serviceRepository) GetBarberService(serviceID, userID int) (*models.BarberService, error) {
+	var assignment models.BarberService
+	if err := r.db.Where("service_id = ? AND user_id = ?", serviceID, userID).First(&assignment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrBarberServiceNotFound
+		}
+		return nil, err
+	}
+	return &assignment, nil
+}
+
+func (r *serviceRepository) GetServiceBarbers(serviceID int) ([]models.BarberService, error) {
+	var assignments []models.BarberService
+	if err := r.db.Preload("User").Where("service_id = ?", serviceID).Order("user_id").Find(&assignments).Error; err != nil {
+		return nil, err
+	}
+	return assignments, nil
+}
@@ -0,0 +1,39 @@
+package repositories
+
+import (
+	"errors"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrTenantNotFound = errors.New("арендатор не найден")
+)
+
+// TenantRepository читает справочник арендаторов. Работает с соединением без ограничения
+// арендатором: по нему определяется, к какому арендатору направить запрос.
+type TenantRepository interface {
+	GetTenantByID(id int) (*models.Tenant, error)
+}
+
+type tenantRepository struct {
+	db *gorm.DB
+}
+
+func NewTenantRepository(db *gorm.DB) TenantRepository {
+	return &tenantRepository{
+		db: db,
+	}
+}
+
+func (r *tenantRepository) GetTenantByID(id int) (*models.Tenant, error) {
+	var tenant models.Tenant
+	if err := r.db.First(&tenant, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTenantNotFound
+		}
+		return nil, err
+	}
+	return &tenant, nil
+}
@@ -0,0 +1,140 @@
+package repositories
+
+import (
+	"errors"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrRefreshTokenNotFound = errors.New("refresh-токен не найден")
+	ErrRefreshTokenRevoked  = errors.New("refresh-токен отозван")
+)
+
+type TokenRepository interface {
+	CreateRefreshToken(token *models.RefreshToken) error
+	GetRefreshTokenByHash(hash string) (*models.RefreshToken, error)
+	RotateRefreshToken(oldID int, next *models.RefreshToken) error
+	RevokeFamily(familyID string) error
+	RevokeUserTokens(userID int) error
+	RevokeAccessToken(jti string, expiresAt time.Time) error
+	IsAccessTokenRevoked(jti string) (bool, error)
+	DeleteExpiredTokens(now time.Time) (int64, error)
+}
+
+type tokenRepository struct {
+	db *gorm.DB
+}
+
+func NewTokenRepository(db *gorm.DB) TokenRepository {
+	return &tokenRepository{
+		db: db,
+	}
+}
+
+func (r *tokenRepository) CreateRefreshToken(token *models.RefreshToken) error {
+	return r.db.Create(token).Error
+}
+
+func (r *tokenRepository) GetRefreshTokenByHash(hash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	if err := r.db.Where("token_hash = ?", hash).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRefreshTokenNotFound
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// RotateRefreshToken отзывает использованный refresh-токен и сохраняет новый в одной транзакции.
+// Если старый токен уже отозван (параллельное обновление), возвращается ErrRefreshTokenRevoked.
+func (r *tokenRepository) RotateRefreshToken(oldID int, next *models.RefreshToken) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND revoked_at IS NULL", oldID).
+			Update("revoked_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrRefreshTokenRevoked
+		}
+		return tx.Create(next).Error
+	})
+}
+
+// RevokeFamily отзывает все токены сессии: refresh-токены цепочки и выданные вместе с ними access-токены
+func (r *tokenRepository) RevokeFamily(familyID string) error {
+	return r.revokeWhere("family_id = ?", familyID)
+}
+
+// RevokeUserTokens завершает все сессии сотрудника
+func (r *tokenRepository) RevokeUserTokens(userID int) error {
+	return r.revokeWhere("user_id = ?", userID)
+}
+
+func (r *tokenRepository) revokeWhere(query string, args ...interface{}) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var tokens []models.RefreshToken
+		if err := tx.Where(query, args...).Where("access_expires_at > ?", time.Now()).Find(&tokens).Error; err != nil {
+			return err
+		}
+
+		if len(tokens) > 0 {
+			revoked := make([]models.RevokedToken, 0, len(tokens))
+			for _, token := range tokens {
+				revoked = append(revoked, models.RevokedToken{JTI: token.AccessJTI, ExpiresAt: token.AccessExpiresAt})
+			}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&revoked).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&models.RefreshToken{}).
+			Where(query, args...).
+			Where("revoked_at IS NULL").
+			Update("revoked_at", time.Now()).Error
+	})
+}
+
+func (r *tokenRepository) RevokeAccessToken(jti string, expiresAt time.Time) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.RevokedToken{JTI: jti, ExpiresAt: expiresAt}).Error
+}
+
+func (r *tokenRepository) IsAccessTokenRevoked(jti string) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.RevokedToken{}).Where("jti = ?", jti).Count(&count).Error
+	return count > 0, err
+}
+
+// DeleteExpiredTokens удаляет записи, которые больше не участвуют в проверке токенов: отозванные
+// access-токены с истекшим сроком, истекшие refresh-токены и использованные refresh-токены
+// завершенных сессий. Использованные токены действующей сессии остаются до истечения срока,
+// чтобы Refresh распознал их повторное предъявление. Возвращает число удаленных записей.
+func (r *tokenRepository) DeleteExpiredTokens(now time.Time) (int64, error) {
+	var deleted int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("expires_at <= ?", now).Delete(&models.RevokedToken{})
+		if result.Error != nil {
+			return result.Error
+		}
+		deleted += result.RowsAffected
+
+		activeFamilies := tx.Model(&models.RefreshToken{}).
+			Select("family_id").
+			Where("revoked_at IS NULL AND expires_at > ?", now)
+		result = tx.Where("expires_at <= ? OR (revoked_at IS NOT NULL AND family_id NOT IN (?))", now, activeFamilies).
+			Delete(&models.RefreshToken{})
+		if result.Error != nil {
+			return result.Error
+		}
+		deleted += result.RowsAffected
+		return nil
+	})
+	return deleted, err
+}
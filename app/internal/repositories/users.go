@@ -5,20 +5,44 @@ import (
 	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var (
 	ErrUserNotFound = errors.New("пользователь не найден")
+	ErrOwnerExists  = errors.New("у арендатора уже есть сотрудники")
 )
 
+// userListSpec — поля списка сотрудников
+var userListSpec = listSpec{
+	sortable: map[string]string{
+		"id":            "id",
+		"username":      "username",
+		"role":          "role",
+		"created_at":    "created_at",
+		"last_login_at": "last_login_at",
+	},
+	filterable: map[string]listFilter{
+		"role":        {column: "role", kind: filterString},
+		"location_id": {column: "location_id", kind: filterInt},
+	},
+	timeColumn:  "created_at",
+	defaultSort: "id",
+}
+
 type UserRepository interface {
 	CreateUser(user *models.User) error
 	GetUserByID(id int) (*models.User, error)
-	GetAllUsers() ([]models.User, error)
+	GetAllUsers(query ListQuery) ([]models.User, int64, error)
 	UpdateUser(user *models.User) error
 	DeleteUser(id int) error
 	GetUserByEmail(email string) (*models.User, error)
 	GetUserByUsername(username string) (*models.User, error)
+	CountUsers() (int64, error)
+	// GetUsersByRole возвращает всех сотрудников с ролью role по возрастанию ID
+	GetUsersByRole(role string) ([]models.User, error)
+	// CreateOwner создает первого сотрудника арендатора; если сотрудники уже есть, возвращает ErrOwnerExists
+	CreateOwner(user *models.User) error
 }
 
 type userRepository struct {
@@ -32,6 +56,10 @@ func NewUserRepository(db *gorm.DB) UserRepository {
 }
 
 func (r *userRepository) CreateUser(user *models.User) error {
+	// Пустой Email сохраняется как NULL, чтобы не нарушать уникальность
+	if user.Email == "" {
+		return r.db.Omit("Email").Create(user).Error
+	}
 	return r.db.Create(user).Error
 }
 
@@ -46,23 +74,38 @@ func (r *userRepository) GetUserByID(id int) (*models.User, error) {
 	return &user, nil
 }
 
-func (r *userRepository) GetAllUsers() ([]models.User, error) {
+func (r *userRepository) GetAllUsers(query ListQuery) ([]models.User, int64, error) {
 	var users []models.User
-	if err := r.db.Find(&users).Error; err != nil {
-		return nil, err
+	total, err := paginate(r.db, &models.User{}, &users, userListSpec, query)
+	if err != nil {
+		return nil, 0, err
 	}
-	return users, nil
+	return users, total, nil
 }
 
 func (r *userRepository) UpdateUser(user *models.User) error {
+	if user.Email == "" {
+		return r.db.Omit("Email").Save(user).Error
+	}
 	return r.db.Save(user).Error
 }
 
 func (r *userRepository) DeleteUser(id int) error {
-	if err := r.db.Delete(&models.User{}, id).Error; err != nil {
-		return err
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// Назначенные сотруднику услуги удаляются вместе с ним
+		if err := tx.Where("user_id = ?", id).Delete(&models.BarberService{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.User{}, id).Error
+	})
+}
+
+func (r *userRepository) GetUsersByRole(role string) ([]models.User, error) {
+	var users []models.User
+	if err := r.db.Where("role = ?", role).Order("id").Find(&users).Error; err != nil {
+		return nil, err
 	}
-	return nil
+	return users, nil
 }
 
 func (r *userRepository) GetUserByEmail(email string) (*models.User, error) {
@@ -86,3 +129,43 @@ func (r *userRepository) GetUserByUsername(username string) (*models.User, error
 	}
 	return &user, nil
 }
+
+func (r *userRepository) CountUsers() (int64, error) {
+	var count int64
+	err := r.db.Model(&models.User{}).Count(&count).Error
+	return count, err
+}
+
+// CreateOwner проверяет отсутствие сотрудников и создает сотрудника в одной транзакции. Сначала
+// записывается регистрация арендатора: при одновременных запросах вторая вставка ждет завершения первой
+// транзакции и ничего не вставляет, поэтому владельцем становится только один сотрудник.
+func (r *userRepository) CreateOwner(user *models.User) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		registration := &models.OwnerRegistration{}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(registration)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrOwnerExists
+		}
+
+		var count int64
+		if err := tx.Model(&models.User{}).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrOwnerExists
+		}
+
+		create := tx
+		if user.Email == "" {
+			// Пустой Email сохраняется как NULL, чтобы не нарушать уникальность
+			create = tx.Omit("Email")
+		}
+		if err := create.Create(user).Error; err != nil {
+			return err
+		}
+		return tx.Model(registration).Update("user_id", user.ID).Error
+	})
+}
@@ -5,10 +5,23 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func SetupAuthRoutes(router *gin.RouterGroup, authHandler *handlers.AuthHandler) {
+// SetupAuthRoutes регистрирует публичные маршруты входа. Регистрация владельца (registration)
+// доступна только в однотенантном режиме
+func SetupAuthRoutes(router *gin.RouterGroup, authHandler *handlers.AuthHandler, registration bool) {
 	authRoutes := router.Group("/auth")
 	{
 		authRoutes.POST("/login", authHandler.LoginHandler)
-		authRoutes.POST("/register", authHandler.RegisterHandler)
+		if registration {
+			authRoutes.POST("/register", authHandler.RegisterHandler)
+		}
+		authRoutes.POST("/refresh", authHandler.RefreshHandler)
+	}
+}
+
+// SetupSessionRoutes регистрирует маршруты, требующие действующего access-токена
+func SetupSessionRoutes(router *gin.RouterGroup, authHandler *handlers.AuthHandler) {
+	authRoutes := router.Group("/auth")
+	{
+		authRoutes.POST("/logout", authHandler.LogoutHandler)
 	}
 }
@@ -2,12 +2,11 @@ package routes
 
 import (
 	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/handlers"
-	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/middleware"
 	"github.com/gin-gonic/gin"
 )
 
 func SetupBookingRoutes(router *gin.RouterGroup, bookingHandler *handlers.BookingHandler) {
-	bookingRoutes := router.Group("/bookings", middleware.JWTMiddleware())
+	bookingRoutes := router.Group("/bookings")
 	{
 		bookingRoutes.POST("/", bookingHandler.CreateBookingHandler)
 		bookingRoutes.GET("/", bookingHandler.GetAllBookingsHandler)
@@ -18,5 +17,12 @@ func SetupBookingRoutes(router *gin.RouterGroup, bookingHandler *handlers.Bookin
 		bookingRoutes.GET("/user/:user_id", bookingHandler.GetBookingsByUserHandler)
 		bookingRoutes.GET("/service/:service_id", bookingHandler.GetBookingsByServiceHandler)
 		bookingRoutes.GET("/availability", bookingHandler.CheckBookingAvailabilityHandler)
+		bookingRoutes.GET("/slots", bookingHandler.GetFreeSlotsHandler)
+		bookingRoutes.GET("/revenue", bookingHandler.GetRevenueHandler)
+		bookingRoutes.POST("/:id/confirm", bookingHandler.ConfirmBookingHandler)
+		bookingRoutes.POST("/:id/start", bookingHandler.StartBookingHandler)
+		bookingRoutes.POST("/:id/complete", bookingHandler.CompleteBookingHandler)
+		bookingRoutes.POST("/:id/cancel", bookingHandler.CancelBookingHandler)
+		bookingRoutes.POST("/:id/no-show", bookingHandler.NoShowBookingHandler)
 	}
 }
@@ -2,17 +2,20 @@ package routes
 
 import (
 	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/handlers"
-	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/middleware"
 	"github.com/gin-gonic/gin"
 )
 
 func SetupBreakRoutes(router *gin.RouterGroup, breakHandler *handlers.BreakHandler) {
-	breakRoutes := router.Group("/breaks", middleware.JWTMiddleware())
+	breakRoutes := router.Group("/breaks")
 	{
 		breakRoutes.POST("/", breakHandler.CreateBreakHandler)
+		breakRoutes.POST("/bulk", breakHandler.CreateBreaksHandler)
 		breakRoutes.GET("/", breakHandler.GetAllBreaksHandler)
+		breakRoutes.GET("/occurrences", breakHandler.GetOccurrencesHandler)
 		breakRoutes.GET("/:id", breakHandler.GetBreakHandler)
 		breakRoutes.PUT("/:id", breakHandler.UpdateBreakHandler)
 		breakRoutes.DELETE("/:id", breakHandler.DeleteBreakHandler)
+		breakRoutes.PUT("/:id/occurrences/:date", breakHandler.UpdateOccurrenceHandler)
+		breakRoutes.DELETE("/:id/occurrences/:date", breakHandler.DeleteOccurrenceHandler)
 	}
 }
@@ -2,22 +2,40 @@ package routes
 
 import (
 	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/handlers"
-	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/middleware"
 	"github.com/gin-gonic/gin"
 )
 
 func SetupClientRoutes(router *gin.RouterGroup, clientHandler *handlers.ClientHandler) {
-	clientRoutes := router.Group("/clients", middleware.JWTMiddleware())
+	clientRoutes := router.Group("/clients")
 	{
 		clientRoutes.POST("/", clientHandler.CreateClientHandler)
 		clientRoutes.GET("/", clientHandler.GetAllClientsHandler)
 		clientRoutes.GET("/telegram/:tg_id", clientHandler.GetClientByTelegramIDHandler)
 		clientRoutes.GET("/filter", clientHandler.FilterClientsByNameHandler)
+		clientRoutes.GET("/duplicates", clientHandler.FindDuplicateClientsHandler)
+		clientRoutes.POST("/merge", clientHandler.MergeClientsHandler)
 		clientRoutes.GET("/:id", clientHandler.GetClientHandler)
+		clientRoutes.GET("/:id/profile", clientHandler.GetClientProfileHandler)
 		clientRoutes.PUT("/:id", clientHandler.UpdateClientHandler)
 		clientRoutes.DELETE("/:id", clientHandler.DeleteClientHandler)
 		clientRoutes.POST("/quick_add", clientHandler.QuickAddClientHandler)
 		clientRoutes.GET("/search", clientHandler.SearchClientHandler)
 		clientRoutes.GET("/check", clientHandler.CheckClientExistenceHandler)
+		clientRoutes.GET("/tags", clientHandler.GetClientTagsHandler)
+		clientRoutes.GET("/:id/notes", clientHandler.GetClientNotesHandler)
+		clientRoutes.POST("/:id/notes", clientHandler.AddClientNoteHandler)
+		clientRoutes.PUT("/:id/notes/:note_id", clientHandler.UpdateClientNoteHandler)
+		clientRoutes.DELETE("/:id/notes/:note_id", clientHandler.DeleteClientNoteHandler)
+	}
+}
+
+// SetupClientFieldRoutes регистрирует дополнительные поля карточки клиента, которые настраивает арендатор
+func SetupClientFieldRoutes(router *gin.RouterGroup, clientHandler *handlers.ClientHandler) {
+	fieldRoutes := router.Group("/clients/fields")
+	{
+		fieldRoutes.GET("/", clientHandler.GetClientFieldsHandler)
+		fieldRoutes.POST("/", clientHandler.CreateClientFieldHandler)
+		fieldRoutes.PUT("/:id", clientHandler.UpdateClientFieldHandler)
+		fieldRoutes.DELETE("/:id", clientHandler.DeleteClientFieldHandler)
 	}
 }
@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/handlers"
+	"github.com/gin-gonic/gin"
+)
+
+func SetupHistoryRoutes(router *gin.RouterGroup, historyHandler *handlers.HistoryHandler) {
+	historyRoutes := router.Group("/history")
+	{
+		historyRoutes.GET("/", historyHandler.GetHistoryHandler)
+	}
+}
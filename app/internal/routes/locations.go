@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/handlers"
+	"github.com/gin-gonic/gin"
+)
+
+func SetupLocationRoutes(router *gin.RouterGroup, locationHandler *handlers.LocationHandler) {
+	locationRoutes := router.Group("/locations")
+	{
+		locationRoutes.POST("/", locationHandler.CreateLocationHandler)
+		locationRoutes.GET("/", locationHandler.GetAllLocationsHandler)
+		locationRoutes.GET("/:id", locationHandler.GetLocationHandler)
+		locationRoutes.PUT("/:id", locationHandler.UpdateLocationHandler)
+		locationRoutes.DELETE("/:id", locationHandler.DeleteLocationHandler)
+	}
+}
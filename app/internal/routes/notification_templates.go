@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/handlers"
+	"github.com/gin-gonic/gin"
+)
+
+func SetupNotificationTemplateRoutes(router *gin.RouterGroup, templateHandler *handlers.NotificationTemplateHandler) {
+	templateRoutes := router.Group("/notification-templates")
+	{
+		templateRoutes.POST("/", templateHandler.CreateTemplateHandler)
+		templateRoutes.GET("/", templateHandler.GetAllTemplatesHandler)
+		templateRoutes.GET("/:id", templateHandler.GetTemplateHandler)
+		templateRoutes.GET("/:id/preview", templateHandler.PreviewTemplateHandler)
+		templateRoutes.PUT("/:id", templateHandler.UpdateTemplateHandler)
+		templateRoutes.DELETE("/:id", templateHandler.DeleteTemplateHandler)
+	}
+}
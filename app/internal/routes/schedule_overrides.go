@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/handlers"
+	"github.com/gin-gonic/gin"
+)
+
+func SetupScheduleOverrideRoutes(router *gin.RouterGroup, scheduleHandler *handlers.ScheduleHandler) {
+	overrideRoutes := router.Group("/schedule-overrides")
+	{
+		overrideRoutes.POST("/", scheduleHandler.CreateOverrideHandler)
+		overrideRoutes.GET("/", scheduleHandler.GetAllOverridesHandler)
+		overrideRoutes.GET("/:id", scheduleHandler.GetOverrideHandler)
+		overrideRoutes.PUT("/:id", scheduleHandler.UpdateOverrideHandler)
+		overrideRoutes.DELETE("/:id", scheduleHandler.DeleteOverrideHandler)
+	}
+}
@@ -2,12 +2,11 @@ package routes
 
 import (
 	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/handlers"
-	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/middleware"
 	"github.com/gin-gonic/gin"
 )
 
 func SetupScheduleRoutes(router *gin.RouterGroup, scheduleHandler *handlers.ScheduleHandler) {
-	scheduleRoutes := router.Group("/schedules", middleware.JWTMiddleware())
+	scheduleRoutes := router.Group("/schedules")
 	{
 		scheduleRoutes.POST("/", scheduleHandler.CreateScheduleHandler)
 		scheduleRoutes.GET("/", scheduleHandler.GetAllSchedulesHandler)
@@ -15,5 +14,6 @@ func SetupScheduleRoutes(router *gin.RouterGroup, scheduleHandler *handlers.Sche
 		scheduleRoutes.PUT("/:id", scheduleHandler.UpdateScheduleHandler)
 		scheduleRoutes.DELETE("/:id", scheduleHandler.DeleteScheduleHandler)
 		scheduleRoutes.GET("/filter", scheduleHandler.FilterSchedulesByUserHandler)
+		scheduleRoutes.GET("/working-hours", scheduleHandler.GetWorkingHoursHandler)
 	}
 }
@@ -2,12 +2,11 @@ package routes
 
 import (
 	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/handlers"
-	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/middleware"
 	"github.com/gin-gonic/gin"
 )
 
 func SetupServiceRoutes(router *gin.RouterGroup, serviceHandler *handlers.ServiceHandler) {
-	serviceRoutes := router.Group("/services", middleware.JWTMiddleware())
+	serviceRoutes := router.Group("/services")
 	{
 		serviceRoutes.POST("/", serviceHandler.CreateServiceHandler)
 		serviceRoutes.GET("/", serviceHandler.GetAllServicesHandler)
@@ -15,5 +14,10 @@ func SetupServiceRoutes(router *gin.RouterGroup, serviceHandler *handlers.Servic
 		serviceRoutes.PUT("/:id", serviceHandler.UpdateServiceHandler)
 		serviceRoutes.DELETE("/:id", serviceHandler.DeleteServiceHandler)
 		serviceRoutes.PUT("/:id/deactivate", serviceHandler.DeactivateServiceHandler)
+		serviceRoutes.PUT("/:id/locations/:location_id/price", serviceHandler.SetLocationPriceHandler)
+		serviceRoutes.DELETE("/:id/locations/:location_id/price", serviceHandler.DeleteLocationPriceHandler)
+		serviceRoutes.GET("/:id/barbers", serviceHandler.GetServiceBarbersHandler)
+		serviceRoutes.PUT("/:id/barbers/:user_id", serviceHandler.AssignBarberHandler)
+		serviceRoutes.DELETE("/:id/barbers/:user_id", serviceHandler.UnassignBarberHandler)
 	}
 }
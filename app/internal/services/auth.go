@@ -0,0 +1,162 @@
+package services
+
+import (
+	"errors"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/auth"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/repositories"
+	"time"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("refresh-токен недействителен")
+)
+
+// TokenPair — access- и refresh-токены, выдаваемые при входе и обновлении сессии
+type TokenPair struct {
+	AccessToken      string    `json:"token"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
+
+type AuthService interface {
+	Login(identifier, password string) (*TokenPair, error)
+	Refresh(refreshToken string) (*TokenPair, error)
+	Logout(userID int, refreshToken, accessJTI string, accessExpiresAt time.Time) error
+	RevokeUserSessions(userID int) error
+	IsTokenRevoked(jti string) (bool, error)
+}
+
+type authService struct {
+	userService UserService
+	tokens      repositories.TokenRepository
+}
+
+func NewAuthService(userService UserService, tokens repositories.TokenRepository) AuthService {
+	return &authService{
+		userService: userService,
+		tokens:      tokens,
+	}
+}
+
+func (s *authService) Login(identifier, password string) (*TokenPair, error) {
+	user, err := s.userService.AuthenticateUser(identifier, password)
+	if err != nil {
+		return nil, err
+	}
+
+	familyID, err := auth.NewTokenID()
+	if err != nil {
+		return nil, err
+	}
+
+	pair, refresh, err := issueTokens(user, familyID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.tokens.CreateRefreshToken(refresh); err != nil {
+		return nil, err
+	}
+	return pair, nil
+}
+
+// Refresh обменивает refresh-токен на новую пару токенов. Использованный токен отзывается;
+// повторное предъявление уже отозванного токена считается кражей и завершает всю сессию.
+func (s *authService) Refresh(refreshToken string) (*TokenPair, error) {
+	current, err := s.tokens.GetRefreshTokenByHash(auth.HashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, repositories.ErrRefreshTokenNotFound) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+
+	if current.RevokedAt != nil {
+		if err := s.tokens.RevokeFamily(current.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+	if time.Now().After(current.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	// Роль берется из актуальной записи сотрудника
+	user, err := s.userService.GetUserByID(current.UserID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrUserNotFound) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+
+	pair, next, err := issueTokens(user, current.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.tokens.RotateRefreshToken(current.ID, next); err != nil {
+		if errors.Is(err, repositories.ErrRefreshTokenRevoked) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+	return pair, nil
+}
+
+// Logout отзывает сессию, к которой относится refresh-токен, и текущий access-токен
+func (s *authService) Logout(userID int, refreshToken, accessJTI string, accessExpiresAt time.Time) error {
+	if refreshToken != "" {
+		current, err := s.tokens.GetRefreshTokenByHash(auth.HashToken(refreshToken))
+		if err != nil && !errors.Is(err, repositories.ErrRefreshTokenNotFound) {
+			return err
+		}
+		if current != nil && current.UserID == userID {
+			if err := s.tokens.RevokeFamily(current.FamilyID); err != nil {
+				return err
+			}
+		}
+	}
+
+	return s.tokens.RevokeAccessToken(accessJTI, accessExpiresAt)
+}
+
+func (s *authService) RevokeUserSessions(userID int) error {
+	return s.tokens.RevokeUserTokens(userID)
+}
+
+func (s *authService) IsTokenRevoked(jti string) (bool, error) {
+	return s.tokens.IsAccessTokenRevoked(jti)
+}
+
+// issueTokens выпускает access-токен и refresh-токен в цепочке familyID
+func issueTokens(user *models.User, familyID string) (*TokenPair, *models.RefreshToken, error) {
+	accessToken, claims, err := auth.GenerateToken(user.ID, user.TenantID, user.Role)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	refreshToken, refreshHash, err := auth.NewRefreshToken()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	accessExpiresAt := time.Unix(claims.ExpiresAt, 0)
+	refreshExpiresAt := time.Now().Add(auth.RefreshTokenTTL())
+
+	record := &models.RefreshToken{
+		UserID:          user.ID,
+		FamilyID:        familyID,
+		TokenHash:       refreshHash,
+		AccessJTI:       claims.Id,
+		AccessExpiresAt: accessExpiresAt,
+		ExpiresAt:       refreshExpiresAt,
+	}
+	pair := &TokenPair{
+		AccessToken:      accessToken,
+		ExpiresAt:        accessExpiresAt,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: refreshExpiresAt,
+	}
+	return pair, record, nil
+}
@@ -4,80 +4,389 @@ import (
 	"errors"
 	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
 	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/repositories"
+	"log"
+	"sort"
+	"time"
 )
 
+// slotStep — шаг, с которым перебираются возможные времена начала бронирования
+const slotStep = 15 * time.Minute
+
 var (
-	ErrBookingNotFound  = errors.New("бронирование не найдено")
-	ErrTimeSlotOccupied = errors.New("временной слот уже занят")
+	ErrBookingNotFound     = errors.New("бронирование не найдено")
+	ErrTimeSlotOccupied    = repositories.ErrTimeSlotOccupied
+	ErrOutsideWorkingHours = errors.New("бронирование вне рабочих часов сотрудника")
+	ErrDuringBreak         = errors.New("бронирование попадает на перерыв сотрудника")
+	ErrInvalidTransition   = errors.New("недопустимый переход статуса бронирования")
+	// ErrBookingClosed возвращается при попытке изменить завершенное, отмененное или пропущенное бронирование
+	ErrBookingClosed       = errors.New("бронирование в конечном статусе нельзя изменить")
+	ErrBarberNotAssigned   = errors.New("сотрудник не выполняет эту услугу")
+	ErrInvalidRevenueRange = errors.New("начало периода должно быть раньше конца")
 )
 
+// bookingTransitions описывает жизненный цикл бронирования: из какого статуса в какие возможен переход
+var bookingTransitions = map[string][]string{
+	models.BookingStatusPending:    {models.BookingStatusConfirmed, models.BookingStatusCancelled, models.BookingStatusNoShow},
+	models.BookingStatusConfirmed:  {models.BookingStatusInProgress, models.BookingStatusCancelled, models.BookingStatusNoShow},
+	models.BookingStatusInProgress: {models.BookingStatusCompleted},
+}
+
+// isClosed сообщает, что статус конечный: из него нет переходов, и бронирование больше не меняется
+func isClosed(status string) bool {
+	return len(bookingTransitions[status]) == 0
+}
+
+// BarberSlots — свободные времена начала бронирования для одного сотрудника
+type BarberSlots struct {
+	UserID int         `json:"user_id"`
+	Slots  []time.Time `json:"slots"`
+}
+
+// interval — полуоткрытый интервал времени [start, end)
+type interval struct {
+	start time.Time
+	end   time.Time
+}
+
+func (i interval) overlaps(other interval) bool {
+	return i.start.Before(other.end) && other.start.Before(i.end)
+}
+
+// BookingConflictError возвращается, когда интервал бронирования пересекается
+// с другими активными бронированиями того же сотрудника.
+type BookingConflictError = repositories.BookingConflictError
+
 type BookingService interface {
-	CreateBooking(booking *models.Bookings) error
+	CreateBooking(actorID int, booking *models.Bookings) error
 	GetBookingByID(id int) (*models.Bookings, error)
-	GetAllBookings() ([]models.Bookings, error)
-	UpdateBooking(id int, input *models.Bookings) error
-	DeleteBooking(id int) error
-	CheckAvailability(userID int, bookingTime string) (bool, error)
+	GetAllBookings(query repositories.ListQuery) ([]models.Bookings, int64, error)
+	UpdateBooking(actorID, id int, input *models.Bookings) error
+	DeleteBooking(actorID, id int) error
+	// CheckAvailability сообщает, свободен ли сотрудник для визита из услуг serviceIDs, начинающегося в bookingTime
+	CheckAvailability(locationID, userID int, serviceIDs []int, bookingTime time.Time) (bool, error)
 	GetBookingsByClientID(clientID int) ([]models.Bookings, error)
 	GetBookingsByServiceID(serviceID int) ([]models.Bookings, error)
 	GetBookingsByUserID(userID int) ([]models.Bookings, error)
+	// FindFreeSlots ищет время для визита из услуг serviceIDs, выполняемых подряд
+	FindFreeSlots(locationID, userID int, serviceIDs []int, date time.Time) ([]BarberSlots, error)
+	ChangeStatus(id int, status string, changedBy int) (*models.Bookings, error)
+	// RevenueByBarber возвращает выручку сотрудников по завершенным бронированиям с from по to включительно
+	RevenueByBarber(locationID, userID int, from, to time.Time) ([]repositories.BarberRevenue, error)
 }
 
 type bookingService struct {
-	repo repositories.BookingRepository
+	repo        repositories.BookingRepository
+	serviceRepo repositories.ServiceRepository
+	schedules   ScheduleService
+	breaks      BreakService
+	history     HistoryService
+	reminders   ReminderService
+	locations   LocationService
+	staff       staffEligibility
 }
 
-func NewBookingService(repo repositories.BookingRepository) BookingService {
+func NewBookingService(
+	repo repositories.BookingRepository,
+	serviceRepo repositories.ServiceRepository,
+	schedules ScheduleService,
+	breaks BreakService,
+	history HistoryService,
+	reminders ReminderService,
+	locations LocationService,
+	userRepo repositories.UserRepository,
+	allowUnassigned bool,
+) BookingService {
 	return &bookingService{
-		repo: repo,
+		repo:        repo,
+		serviceRepo: serviceRepo,
+		schedules:   schedules,
+		breaks:      breaks,
+		history:     history,
+		reminders:   reminders,
+		locations:   locations,
+		staff:       staffEligibility{serviceRepo: serviceRepo, userRepo: userRepo, allowUnassigned: allowUnassigned},
+	}
+}
+
+func (s *bookingService) CreateBooking(actorID int, booking *models.Bookings) error {
+	// Новое бронирование всегда начинает жизненный цикл с pending, статус меняется только через переходы
+	booking.Status = models.BookingStatusPending
+	booking.BookingTime = booking.BookingTime.UTC()
+	if err := s.applyTerms(booking); err != nil {
+		return err
+	}
+	if err := s.validateBooking(booking); err != nil {
+		return err
+	}
+	if err := s.repo.CreateBooking(booking); err != nil {
+		return err
+	}
+
+	s.history.Record(actorID, models.HistoryActionCreate, models.EntityBooking, booking.ID, nil, booking)
+	s.scheduleReminders(booking)
+	return nil
+}
+
+// scheduleReminders пересоздает напоминания клиенту. Ошибка не отменяет изменение бронирования и только логируется.
+func (s *bookingService) scheduleReminders(booking *models.Bookings) {
+	if err := s.reminders.ScheduleBookingReminders(booking); err != nil {
+		log.Printf("reminders: failed to schedule for booking #%d: %v", booking.ID, err)
 	}
 }
 
-func (s *bookingService) CreateBooking(booking *models.Bookings) error {
-	occupied, err := s.repo.IsTimeSlotOccupied(booking.UserID, booking.BookingTime.String())
+func (s *bookingService) cancelReminders(bookingID int) {
+	if err := s.reminders.CancelBookingReminders(bookingID); err != nil {
+		log.Printf("reminders: failed to cancel for booking #%d: %v", bookingID, err)
+	}
+}
+
+func (s *bookingService) notifyClient(bookingID int, templateCode string) {
+	if err := s.reminders.EnqueueBookingNotification(bookingID, templateCode); err != nil {
+		log.Printf("reminders: failed to enqueue %s for booking #%d: %v", templateCode, bookingID, err)
+	}
+}
+
+// applyTerms фиксирует в позициях бронирования цены и длительности услуг у сотрудника в филиале
+// бронирования, а в самом бронировании — их суммы. Сотрудник должен выполнять каждую услугу визита.
+func (s *bookingService) applyTerms(booking *models.Bookings) error {
+	serviceIDs := booking.ServiceIDs()
+	items := make([]models.BookingItem, 0, len(serviceIDs))
+	prices := make([]models.Money, 0, len(serviceIDs))
+	duration := 0
+	for position, serviceID := range serviceIDs {
+		service, err := s.serviceRepo.GetServiceByID(serviceID)
+		if err != nil {
+			return err
+		}
+		assignment, err := s.staff.staffTerms(serviceID, booking.UserID)
+		if err != nil {
+			return err
+		}
+
+		price, minutes := assignment.Terms(*service, booking.LocationID)
+		items = append(items, models.BookingItem{ServiceID: serviceID, Position: position, Price: price, Duration: minutes, Service: *service})
+		prices = append(prices, price)
+		duration += minutes
+	}
+
+	total, err := models.SumMoney(prices...)
 	if err != nil {
 		return err
 	}
-	if occupied {
-		return ErrTimeSlotOccupied
+	booking.Items = items
+	booking.ServiceID = serviceIDs[0]
+	booking.Price, booking.Duration = total, duration
+	return nil
+}
+
+// sameServices сообщает, что визиты состоят из одних и тех же услуг в том же порядке
+func sameServices(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
 	}
-	return s.repo.CreateBooking(booking)
+	return true
+}
+
+// validateBooking проверяет, что интервал бронирования (BookingTime + длительность визита)
+// укладывается в рабочие часы сотрудника в филиале бронирования и не попадает на перерыв.
+// Пересечение с другими бронированиями репозиторий проверяет в транзакции сохранения.
+func (s *bookingService) validateBooking(booking *models.Bookings) error {
+	slot := interval{
+		start: booking.BookingTime,
+		end:   booking.BookingTime.Add(booking.Length()),
+	}
+
+	if err := s.checkWorkingHours(booking.LocationID, booking.UserID, slot); err != nil {
+		return err
+	}
+
+	breaks, err := s.breaks.Occurrences(booking.UserID, slot.start, slot.end)
+	if err != nil {
+		return err
+	}
+	if len(breaks) > 0 {
+		return ErrDuringBreak
+	}
+	return nil
+}
+
+// checkWorkingHours проверяет, что интервал целиком лежит внутри одного из рабочих интервалов сотрудника
+// в филиале с учетом исключений из расписания на этот день
+func (s *bookingService) checkWorkingHours(locationID, userID int, slot interval) error {
+	zone, err := s.locations.Zone(locationID)
+	if err != nil {
+		return err
+	}
+	work, err := s.schedules.WorkingIntervals(locationID, userID, slot.start.In(zone))
+	if err != nil {
+		return err
+	}
+
+	for _, w := range work {
+		if !slot.start.Before(w.Start) && !slot.end.After(w.End) {
+			return nil
+		}
+	}
+	return ErrOutsideWorkingHours
+}
+
+// checkOverlap проверяет, что интервал не пересекается с другими активными бронированиями сотрудника
+func (s *bookingService) checkOverlap(userID int, slot interval, excludeID int) error {
+	overlapping, err := s.repo.FindOverlappingBookings(userID, slot.start, slot.end, excludeID)
+	if err != nil {
+		return err
+	}
+	if len(overlapping) == 0 {
+		return nil
+	}
+
+	conflict := &BookingConflictError{ConflictingIDs: make([]int, 0, len(overlapping))}
+	for _, b := range overlapping {
+		conflict.ConflictingIDs = append(conflict.ConflictingIDs, b.ID)
+	}
+	return conflict
 }
 
 func (s *bookingService) GetBookingByID(id int) (*models.Bookings, error) {
 	return s.repo.GetBookingByID(id)
 }
 
-func (s *bookingService) GetAllBookings() ([]models.Bookings, error) {
-	return s.repo.GetAllBookings()
+func (s *bookingService) GetAllBookings(query repositories.ListQuery) ([]models.Bookings, int64, error) {
+	return s.repo.GetAllBookings(query)
 }
 
-func (s *bookingService) UpdateBooking(id int, input *models.Bookings) error {
+func (s *bookingService) UpdateBooking(actorID, id int, input *models.Bookings) error {
 	booking, err := s.repo.GetBookingByID(id)
 	if err != nil {
 		return err
 	}
+	if isClosed(booking.Status) {
+		return ErrBookingClosed
+	}
+	before := *booking
 
 	// Обновляем поля
 	booking.UserID = input.UserID
+	booking.LocationID = input.LocationID
 	booking.ClientID = input.ClientID
-	booking.ServiceID = input.ServiceID
-	booking.BookingTime = input.BookingTime
-	// Другие поля...
+	booking.BookingTime = input.BookingTime.UTC()
+	// Состав визита задается позициями, а без них — услугой service_id. Прежняя первая услуга без позиций
+	// и те же услуги в позициях сохраняют прежние позиции с зафиксированными ценами
+	requested := models.Bookings{ServiceID: input.ServiceID, Items: input.Items}
+	changing := len(input.Items) > 0 || input.ServiceID != before.ServiceID
+	if changing && !sameServices(before.ServiceIDs(), requested.ServiceIDs()) {
+		booking.ServiceID, booking.Items = requested.ServiceID, requested.Items
+	}
+	servicesChanged := !sameServices(before.ServiceIDs(), booking.ServiceIDs())
+
+	// Цены и длительности пересчитываются только при смене услуг, сотрудника или филиала
+	reassigned := servicesChanged || before.UserID != booking.UserID || before.LocationID != booking.LocationID
+	if reassigned || booking.Duration == 0 || len(booking.Items) == 0 {
+		if err := s.applyTerms(booking); err != nil {
+			return err
+		}
+	}
+	if err := s.validateBooking(booking); err != nil {
+		return err
+	}
+
+	if err := s.repo.UpdateBooking(booking); err != nil {
+		return err
+	}
+
+	s.history.Record(actorID, models.HistoryActionUpdate, models.EntityBooking, booking.ID, &before, booking)
 
-	return s.repo.UpdateBooking(booking)
+	// Перенос визита или смена клиента/услуг меняют время и текст напоминаний
+	moved := !before.BookingTime.Equal(booking.BookingTime) || before.ClientID != booking.ClientID ||
+		servicesChanged || before.LocationID != booking.LocationID
+	if moved {
+		s.scheduleReminders(booking)
+	}
+	return nil
+}
+
+// ChangeStatus переводит бронирование в новый статус, если переход допустим, и фиксирует автора перехода
+func (s *bookingService) ChangeStatus(id int, status string, changedBy int) (*models.Bookings, error) {
+	booking, err := s.repo.GetBookingByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !canTransition(booking.Status, status) {
+		return nil, ErrInvalidTransition
+	}
+
+	change := &models.BookingStatusChange{
+		BookingID:  booking.ID,
+		FromStatus: booking.Status,
+		ToStatus:   status,
+		ChangedBy:  changedBy,
+	}
+	if err := s.repo.ChangeBookingStatus(change); err != nil {
+		return nil, err
+	}
+
+	updated, err := s.repo.GetBookingByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.history.Record(changedBy, models.HistoryActionUpdate, models.EntityBooking, id, booking, updated)
+	switch status {
+	case models.BookingStatusConfirmed:
+		s.notifyClient(id, models.TemplateBookingConfirmed)
+	case models.BookingStatusCancelled:
+		s.cancelReminders(id)
+		s.notifyClient(id, models.TemplateBookingCancelled)
+	}
+	return updated, nil
+}
+
+func canTransition(from, to string) bool {
+	for _, allowed := range bookingTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
 }
 
-func (s *bookingService) DeleteBooking(id int) error {
-	return s.repo.DeleteBooking(id)
+func (s *bookingService) DeleteBooking(actorID, id int) error {
+	booking, err := s.repo.GetBookingByID(id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteBooking(id); err != nil {
+		return err
+	}
+
+	s.history.Record(actorID, models.HistoryActionDelete, models.EntityBooking, id, booking, nil)
+	s.cancelReminders(id)
+	return nil
 }
 
-func (s *bookingService) CheckAvailability(userID int, bookingTime string) (bool, error) {
-	occupied, err := s.repo.IsTimeSlotOccupied(userID, bookingTime)
+// CheckAvailability проверяет пересечение визита с активными бронированиями сотрудника так же, как CreateBooking:
+// длительность визита — сумма длительностей услуг у сотрудника в филиале, отмененные бронирования не учитываются
+func (s *bookingService) CheckAvailability(locationID, userID int, serviceIDs []int, bookingTime time.Time) (bool, error) {
+	durations, err := s.visitDurations(locationID, userID, serviceIDs)
 	if err != nil {
 		return false, err
 	}
-	return !occupied, nil
+
+	start := bookingTime.UTC()
+	err = s.checkOverlap(userID, interval{start: start, end: start.Add(durations[userID])}, 0)
+	if errors.Is(err, ErrTimeSlotOccupied) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
 }
 
 func (s *bookingService) GetBookingsByClientID(clientID int) ([]models.Bookings, error) {
@@ -91,3 +400,172 @@ func (s *bookingService) GetBookingsByServiceID(serviceID int) ([]models.Booking
 func (s *bookingService) GetBookingsByUserID(userID int) ([]models.Bookings, error) {
 	return s.repo.GetBookingsByUserID(userID)
 }
+
+// FindFreeSlots возвращает все времена начала, в которые можно записаться на услуги в указанный день.
+// От date берется только календарная дата, день отсчитывается в часовом поясе филиала locationID.
+// Учитываются рабочие часы в филиале с исключениями на эту дату, перерывы, существующие бронирования
+// и суммарная длительность услуг у каждого сотрудника. Если userID равен 0, слоты считаются для всех
+// сотрудников, которые выполняют все услуги и работают в этот день; если locationID равен 0 —
+// во всех филиалах.
+func (s *bookingService) FindFreeSlots(locationID, userID int, serviceIDs []int, date time.Time) ([]BarberSlots, error) {
+	durations, err := s.visitDurations(locationID, userID, serviceIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	zone, err := s.locations.Zone(locationID)
+	if err != nil {
+		return nil, err
+	}
+	dayStart := calendarDay(date, zone)
+	dayEnd := dayStart.AddDate(0, 0, 1)
+
+	workByUser := make(map[int][]WorkingInterval)
+	if userID != 0 {
+		work, err := s.schedules.WorkingIntervals(locationID, userID, dayStart)
+		if err != nil {
+			return nil, err
+		}
+		if len(work) > 0 {
+			workByUser[userID] = work
+		}
+	} else if workByUser, err = s.schedules.WorkingIntervalsByUser(locationID, dayStart); err != nil {
+		return nil, err
+	}
+
+	userIDs := make([]int, 0, len(workByUser))
+	for id := range workByUser {
+		if _, assigned := durations[id]; assigned {
+			userIDs = append(userIDs, id)
+		}
+	}
+	sort.Ints(userIDs)
+
+	now := time.Now()
+	result := make([]BarberSlots, 0, len(userIDs))
+	for _, id := range userIDs {
+		busy, err := s.busyIntervals(id, dayStart, dayEnd)
+		if err != nil {
+			return nil, err
+		}
+
+		duration := durations[id]
+		slots := make([]time.Time, 0)
+		for _, work := range workByUser[id] {
+			for start := work.Start; !start.Add(duration).After(work.End); start = start.Add(slotStep) {
+				if start.Before(now) {
+					continue
+				}
+				if !overlapsAny(interval{start: start, end: start.Add(duration)}, busy) {
+					slots = append(slots, start)
+				}
+			}
+		}
+		result = append(result, BarberSlots{UserID: id, Slots: slots})
+	}
+
+	return result, nil
+}
+
+// visitDurations возвращает суммарную длительность услуг у сотрудников, которые выполняют все услуги.
+// Если userID не равен 0, учитывается только он.
+func (s *bookingService) visitDurations(locationID, userID int, serviceIDs []int) (map[int]time.Duration, error) {
+	var total map[int]time.Duration
+	for i, serviceID := range serviceIDs {
+		durations, err := s.serviceDurations(locationID, userID, serviceID)
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			total = durations
+			continue
+		}
+		for id, duration := range total {
+			if extra, assigned := durations[id]; assigned {
+				total[id] = duration + extra
+			} else {
+				delete(total, id)
+			}
+		}
+	}
+	return total, nil
+}
+
+// serviceDurations возвращает длительность услуги у сотрудников, которые ее выполняют.
+// Если userID не равен 0, учитывается только он; если он не выполняет услугу — ErrBarberNotAssigned.
+func (s *bookingService) serviceDurations(locationID, userID, serviceID int) (map[int]time.Duration, error) {
+	service, err := s.serviceRepo.GetServiceByID(serviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	var assignments []models.BarberService
+	if userID != 0 {
+		assignment, err := s.staff.staffTerms(serviceID, userID)
+		if err != nil {
+			return nil, err
+		}
+		assignments = append(assignments, *assignment)
+	} else if assignments, err = s.staff.serviceStaff(serviceID); err != nil {
+		return nil, err
+	}
+
+	durations := make(map[int]time.Duration, len(assignments))
+	for _, assignment := range assignments {
+		_, minutes := assignment.Terms(*service, locationID)
+		durations[assignment.UserID] = time.Duration(minutes) * time.Minute
+	}
+	return durations, nil
+}
+
+// busyIntervals собирает перерывы и активные бронирования сотрудника в интервале [from, to)
+func (s *bookingService) busyIntervals(userID int, from, to time.Time) ([]interval, error) {
+	breaks, err := s.breaks.Occurrences(userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	bookings, err := s.repo.FindOverlappingBookings(userID, from, to, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	busy := make([]interval, 0, len(breaks)+len(bookings))
+	for _, b := range breaks {
+		busy = append(busy, interval{start: b.Start, end: b.End})
+	}
+	for _, b := range bookings {
+		busy = append(busy, interval{
+			start: b.BookingTime,
+			end:   b.BookingTime.Add(b.Length()),
+		})
+	}
+	return busy, nil
+}
+
+func overlapsAny(candidate interval, busy []interval) bool {
+	for _, b := range busy {
+		if candidate.overlaps(b) {
+			return true
+		}
+	}
+	return false
+}
+
+// RevenueByBarber считает от from и to только календарные даты; дни отсчитываются в часовом поясе
+// филиала locationID. Нулевые locationID и userID означают все филиалы и всех сотрудников.
+func (s *bookingService) RevenueByBarber(locationID, userID int, from, to time.Time) ([]repositories.BarberRevenue, error) {
+	zone, err := s.locations.Zone(locationID)
+	if err != nil {
+		return nil, err
+	}
+	filter := repositories.RevenueFilter{
+		From:       calendarDay(from, zone),
+		To:         calendarDay(to, zone).AddDate(0, 0, 1),
+		LocationID: locationID,
+		UserID:     userID,
+	}
+	if !filter.From.Before(filter.To) {
+		return nil, ErrInvalidRevenueRange
+	}
+	return s.repo.GetRevenueByBarber(filter)
+}
@@ -1,55 +1,359 @@
 package services
 
 import (
+	"errors"
 	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
 	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/repositories"
+	"sort"
+	"time"
+)
+
+var (
+	ErrInvalidBreakTime      = errors.New("окончание перерыва должно быть позже начала")
+	ErrInvalidRecurrence     = errors.New("некорректное правило повторения перерыва")
+	ErrNotRecurringBreak     = errors.New("перерыв не повторяется")
+	ErrNotBreakOccurrence    = errors.New("в эту дату нет вхождения перерыва")
+	ErrInvalidBreakDate      = errors.New("некорректная дата вхождения, ожидается ГГГГ-ММ-ДД")
+	ErrRecurringBreakTooLong = errors.New("повторяющийся перерыв не может длиться дольше суток")
 )
 
 type BreakService interface {
-	CreateBreak(breaks *models.Break) error
+	CreateBreak(actorID int, breaks *models.Break) error
+	// CreateBreaks создает несколько перерывов сразу: либо все, либо ни одного
+	CreateBreaks(actorID int, breaks []models.Break) error
 	GetBreakByID(id int) (*models.Break, error)
-	GetAllBreaks() ([]models.Break, error)
-	UpdateBreak(id int, input *models.Break) error
-	DeleteBreak(id int) error
+	GetAllBreaks(query repositories.ListQuery) ([]models.Break, int64, error)
+	// UpdateBreak и DeleteBreak для повторяющегося перерыва изменяют всю серию
+	UpdateBreak(actorID, id int, input *models.Break) error
+	DeleteBreak(actorID, id int) error
+	GetBreaksByUserID(userID int) ([]models.Break, error)
+
+	// Occurrences возвращает вхождения перерывов сотрудника, пересекающиеся с интервалом [from, to),
+	// с раскрытыми повторениями
+	Occurrences(userID int, from, to time.Time) ([]models.BreakOccurrence, error)
+	// UpdateOccurrence переносит одно вхождение серии: оно исключается из серии
+	// и заменяется отдельным перерывом, который возвращается
+	UpdateOccurrence(actorID, id int, date string, input *models.Break) (*models.Break, error)
+	// DeleteOccurrence удаляет одно вхождение серии
+	DeleteOccurrence(actorID, id int, date string) error
 }
 
 type breakService struct {
-	repo repositories.BreakRepository
+	repo      repositories.BreakRepository
+	history   HistoryService
+	locations LocationService
 }
 
-func NewBreakService(repo repositories.BreakRepository) BreakService {
+func NewBreakService(repo repositories.BreakRepository, history HistoryService, locations LocationService) BreakService {
 	return &breakService{
-		repo: repo,
+		repo:      repo,
+		history:   history,
+		locations: locations,
 	}
 }
 
-func (s *breakService) CreateBreak(breaks *models.Break) error {
-	return s.repo.CreateBreak(breaks)
+func (s *breakService) CreateBreak(actorID int, breaks *models.Break) error {
+	if err := s.validateBreak(breaks); err != nil {
+		return err
+	}
+	if err := s.repo.CreateBreak(breaks); err != nil {
+		return err
+	}
+
+	s.history.Record(actorID, models.HistoryActionCreate, models.EntityBreak, breaks.ID, nil, breaks)
+	return nil
+}
+
+func (s *breakService) CreateBreaks(actorID int, breaks []models.Break) error {
+	for i := range breaks {
+		if err := s.validateBreak(&breaks[i]); err != nil {
+			return err
+		}
+	}
+	if err := s.repo.CreateBreaks(breaks); err != nil {
+		return err
+	}
+
+	for i := range breaks {
+		s.history.Record(actorID, models.HistoryActionCreate, models.EntityBreak, breaks[i].ID, nil, &breaks[i])
+	}
+	return nil
 }
 
 func (s *breakService) GetBreakByID(id int) (*models.Break, error) {
 	return s.repo.GetBreakByID(id)
 }
 
-func (s *breakService) GetAllBreaks() ([]models.Break, error) {
-	return s.repo.GetAllBreaks()
+func (s *breakService) GetAllBreaks(query repositories.ListQuery) ([]models.Break, int64, error) {
+	return s.repo.GetAllBreaks(query)
 }
 
-func (s *breakService) UpdateBreak(id int, input *models.Break) error {
+func (s *breakService) UpdateBreak(actorID, id int, input *models.Break) error {
 	existingBreak, err := s.repo.GetBreakByID(id)
 	if err != nil {
 		return err
 	}
+	before := *existingBreak
 
 	// Обновляем поля
 	existingBreak.UserID = input.UserID
+	existingBreak.LocationID = input.LocationID
 	existingBreak.BreakStart = input.BreakStart
 	existingBreak.BreakEnd = input.BreakEnd
-	// Другие поля, если есть
+	existingBreak.Recurrence = input.Recurrence
+	existingBreak.RecurrenceDays = input.RecurrenceDays
+	existingBreak.RecurrenceUntil = input.RecurrenceUntil
+
+	if err := s.validateBreak(existingBreak); err != nil {
+		return err
+	}
+	if err := s.repo.UpdateBreak(existingBreak); err != nil {
+		return err
+	}
+
+	s.history.Record(actorID, models.HistoryActionUpdate, models.EntityBreak, existingBreak.ID, &before, existingBreak)
+	return nil
+}
+
+func (s *breakService) DeleteBreak(actorID, id int) error {
+	existingBreak, err := s.repo.GetBreakByID(id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteBreak(id); err != nil {
+		return err
+	}
+
+	s.history.Record(actorID, models.HistoryActionDelete, models.EntityBreak, id, existingBreak, nil)
+	return nil
+}
+
+func (s *breakService) GetBreaksByUserID(userID int) ([]models.Break, error) {
+	return s.repo.GetBreaksByUserID(userID)
+}
+
+func (s *breakService) Occurrences(userID int, from, to time.Time) ([]models.BreakOccurrence, error) {
+	breaks, err := s.repo.GetBreaksByUserInRange(userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	occurrences := make([]models.BreakOccurrence, 0, len(breaks))
+	zones := make(map[int]*time.Location)
+	for _, b := range breaks {
+		zone, ok := zones[b.LocationID]
+		if !ok {
+			if zone, err = s.locations.Zone(b.LocationID); err != nil {
+				return nil, err
+			}
+			zones[b.LocationID] = zone
+		}
+		occurrences = append(occurrences, expandBreak(b, from, to, zone)...)
+	}
+	sort.Slice(occurrences, func(i, j int) bool {
+		return occurrences[i].Start.Before(occurrences[j].Start)
+	})
+	return occurrences, nil
+}
+
+func (s *breakService) UpdateOccurrence(actorID, id int, date string, input *models.Break) (*models.Break, error) {
+	series, exception, err := s.occurrence(id, date)
+	if err != nil {
+		return nil, err
+	}
+
+	replacement := &models.Break{
+		UserID:     series.UserID,
+		LocationID: series.LocationID,
+		BreakStart: input.BreakStart,
+		BreakEnd:   input.BreakEnd,
+		SeriesID:   &series.ID,
+	}
+	if err := s.validateBreak(replacement); err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateBreakException(exception, replacement); err != nil {
+		return nil, err
+	}
+
+	s.history.Record(actorID, models.HistoryActionCreate, models.EntityBreak, replacement.ID, nil, replacement)
+	s.recordSeriesChange(actorID, series)
+	return replacement, nil
+}
+
+func (s *breakService) DeleteOccurrence(actorID, id int, date string) error {
+	series, exception, err := s.occurrence(id, date)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.CreateBreakException(exception, nil); err != nil {
+		return err
+	}
+
+	s.recordSeriesChange(actorID, series)
+	return nil
+}
+
+// occurrence проверяет, что у серии id есть вхождение в дату date, и готовит исключение для него
+func (s *breakService) occurrence(id int, date string) (*models.Break, *models.BreakException, error) {
+	series, err := s.repo.GetBreakByID(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !series.IsRecurring() {
+		return nil, nil, ErrNotRecurringBreak
+	}
+
+	zone, err := s.locations.Zone(series.LocationID)
+	if err != nil {
+		return nil, nil, err
+	}
+	day, err := time.ParseInLocation(dateLayout, date, zone)
+	if err != nil {
+		return nil, nil, ErrInvalidBreakDate
+	}
+	// Уже исключенные вхождения тоже учитываются: повторное исключение отклонит репозиторий
+	rule := *series
+	rule.Exceptions = nil
+	found := false
+	for _, occurrence := range expandBreak(rule, day, day.AddDate(0, 0, 1), zone) {
+		if occurrence.Start.In(zone).Format(dateLayout) == date {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, nil, ErrNotBreakOccurrence
+	}
 
-	return s.repo.UpdateBreak(existingBreak)
+	return series, &models.BreakException{BreakID: series.ID, OccurrenceDate: date}, nil
+}
+
+// recordSeriesChange записывает в журнал изменение списка исключений серии
+func (s *breakService) recordSeriesChange(actorID int, before *models.Break) {
+	after, err := s.repo.GetBreakByID(before.ID)
+	if err != nil {
+		return
+	}
+	s.history.Record(actorID, models.HistoryActionUpdate, models.EntityBreak, before.ID, before, after)
 }
 
-func (s *breakService) DeleteBreak(id int) error {
-	return s.repo.DeleteBreak(id)
+// expandBreak возвращает вхождения перерыва, пересекающиеся с [from, to).
+// Вхождения повторяющегося перерыва начинаются в то же время суток, что и первое,
+// в часовом поясе филиала loc, поэтому переход на летнее время не сдвигает перерыв.
+func expandBreak(b models.Break, from, to time.Time, loc *time.Location) []models.BreakOccurrence {
+	if !b.IsRecurring() {
+		if b.BreakStart.Before(to) && b.BreakEnd.After(from) {
+			return []models.BreakOccurrence{{BreakID: b.ID, UserID: b.UserID, Start: b.BreakStart, End: b.BreakEnd}}
+		}
+		return nil
+	}
+
+	first := b.BreakStart.In(loc)
+	duration := b.BreakEnd.Sub(b.BreakStart)
+	skipped := make(map[string]bool, len(b.Exceptions))
+	for _, exception := range b.Exceptions {
+		skipped[exception.OccurrenceDate] = true
+	}
+
+	// Вхождение, начавшееся накануне from, может еще продолжаться
+	day := startOfDay(from.In(loc)).AddDate(0, 0, -1)
+	if firstDay := startOfDay(first); day.Before(firstDay) {
+		day = firstDay
+	}
+	var until time.Time
+	if b.RecurrenceUntil != nil {
+		until = startOfDay(b.RecurrenceUntil.In(loc))
+	}
+
+	var occurrences []models.BreakOccurrence
+	for ; day.Before(to); day = day.AddDate(0, 0, 1) {
+		if !until.IsZero() && day.After(until) {
+			break
+		}
+		if !recurrenceMatches(b, models.WeekdayOf(day)) || skipped[day.Format(dateLayout)] {
+			continue
+		}
+
+		start := time.Date(day.Year(), day.Month(), day.Day(), first.Hour(), first.Minute(), first.Second(), 0, loc)
+		end := start.Add(duration)
+		if start.Before(to) && end.After(from) {
+			occurrences = append(occurrences, models.BreakOccurrence{BreakID: b.ID, UserID: b.UserID, Start: start, End: end})
+		}
+	}
+	return occurrences
+}
+
+func recurrenceMatches(b models.Break, day models.Weekday) bool {
+	switch b.Recurrence {
+	case models.BreakRecurrenceDaily:
+		return true
+	case models.BreakRecurrenceWeekdays:
+		return day >= models.Monday && day <= models.Friday
+	case models.BreakRecurrenceWeekly:
+		for _, d := range b.RecurrenceDays {
+			if d == day {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// validateBreak проверяет перерыв в часовом поясе его филиала
+func (s *breakService) validateBreak(b *models.Break) error {
+	loc, err := s.locations.Zone(b.LocationID)
+	if err != nil {
+		return err
+	}
+	return validateBreak(b, loc)
+}
+
+// validateBreak проверяет интервал и правило повторения и приводит поля к виду для хранения:
+// время перерыва — в UTC, дата окончания повторения — полночь этой даты в часовом поясе филиала loc
+func validateBreak(b *models.Break, loc *time.Location) error {
+	if !b.BreakEnd.After(b.BreakStart) {
+		return ErrInvalidBreakTime
+	}
+	b.BreakStart = b.BreakStart.UTC()
+	b.BreakEnd = b.BreakEnd.UTC()
+	if !models.IsValidBreakRecurrence(b.Recurrence) {
+		return ErrInvalidRecurrence
+	}
+
+	if !b.IsRecurring() {
+		b.RecurrenceDays = nil
+		b.RecurrenceUntil = nil
+		return nil
+	}
+	if b.BreakEnd.Sub(b.BreakStart) > 24*time.Hour {
+		return ErrRecurringBreakTooLong
+	}
+	firstDay := startOfDay(b.BreakStart.In(loc))
+	if b.RecurrenceUntil != nil {
+		until := startOfDay(b.RecurrenceUntil.In(loc)).UTC()
+		if until.Before(firstDay) {
+			return ErrInvalidRecurrence
+		}
+		b.RecurrenceUntil = &until
+	}
+
+	if b.Recurrence != models.BreakRecurrenceWeekly {
+		b.RecurrenceDays = nil
+		return nil
+	}
+	if len(b.RecurrenceDays) == 0 {
+		// По умолчанию перерыв повторяется в тот же день недели, что и первый
+		b.RecurrenceDays = []models.Weekday{models.WeekdayOf(firstDay)}
+	}
+	for _, day := range b.RecurrenceDays {
+		if !day.IsValid() {
+			return ErrInvalidRecurrence
+		}
+	}
+	return nil
 }
@@ -0,0 +1,202 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/repositories"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	ErrUnknownCustomField  = errors.New("неизвестное дополнительное поле")
+	ErrRequiredCustomField = errors.New("не заполнено обязательное дополнительное поле")
+	ErrInvalidNote         = errors.New("заметка должна содержать от 1 до 2000 символов")
+)
+
+const maxNoteLength = 2000
+
+// normalizeClientDetails приводит теги клиента к единому виду, а значения дополнительных полей —
+// к типам полей арендатора. Поля без значения удаляются; при requireAll обязательные поля должны быть заполнены.
+// stored — ранее сохраненные значения, которые не передавались заново: значения удаленных
+// или измененных с тех пор полей отбрасываются без ошибки.
+func (s *clientService) normalizeClientDetails(client *models.Client, stored map[string]interface{}, requireAll bool) error {
+	tags, err := models.NormalizeTags(client.Tags)
+	if err != nil {
+		return err
+	}
+	client.Tags = tags
+
+	fields, err := s.fieldRepo.GetAllClientFields()
+	if err != nil {
+		return err
+	}
+	byKey := make(map[string]*models.ClientField, len(fields))
+	for i := range fields {
+		byKey[fields[i].Key] = &fields[i]
+	}
+
+	values := make(map[string]interface{}, len(client.CustomFields)+len(stored))
+	for key, value := range stored {
+		if field, ok := byKey[key]; ok && value != nil {
+			if normalized, err := field.NormalizeValue(value); err == nil {
+				values[key] = normalized
+			}
+		}
+	}
+	for key, value := range client.CustomFields {
+		field, ok := byKey[key]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownCustomField, key)
+		}
+		if value == nil {
+			delete(values, key)
+			continue
+		}
+		if values[key], err = field.NormalizeValue(value); err != nil {
+			return err
+		}
+	}
+	if requireAll {
+		for _, field := range fields {
+			if _, ok := values[field.Key]; field.Required && !ok {
+				return fmt.Errorf("%w: %s", ErrRequiredCustomField, field.Key)
+			}
+		}
+	}
+	client.CustomFields = values
+	return nil
+}
+
+func (s *clientService) GetTagUsage() ([]repositories.TagUsage, error) {
+	return s.repo.GetTagUsage()
+}
+
+func (s *clientService) GetClientNotes(clientID int) ([]models.ClientNote, error) {
+	if _, err := s.repo.GetClientByID(clientID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetClientNotes(clientID)
+}
+
+func (s *clientService) AddClientNote(actorID, clientID int, text string) (*models.ClientNote, error) {
+	text, err := normalizeNote(text)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.repo.GetClientByID(clientID); err != nil {
+		return nil, err
+	}
+
+	note := &models.ClientNote{ClientID: clientID, AuthorID: actorID, Text: text}
+	if err := s.repo.CreateClientNote(note); err != nil {
+		return nil, err
+	}
+
+	s.history.Record(actorID, models.HistoryActionCreate, models.EntityClientNote, note.ID, nil, note)
+	return note, nil
+}
+
+func (s *clientService) UpdateClientNote(actorID, clientID, noteID int, text string) (*models.ClientNote, error) {
+	text, err := normalizeNote(text)
+	if err != nil {
+		return nil, err
+	}
+	note, err := s.repo.GetClientNote(clientID, noteID)
+	if err != nil {
+		return nil, err
+	}
+	before := *note
+
+	note.Text = text
+	note.AuthorID = actorID
+	if err := s.repo.UpdateClientNote(note); err != nil {
+		return nil, err
+	}
+
+	s.history.Record(actorID, models.HistoryActionUpdate, models.EntityClientNote, note.ID, &before, note)
+	return note, nil
+}
+
+func (s *clientService) DeleteClientNote(actorID, clientID, noteID int) error {
+	note, err := s.repo.GetClientNote(clientID, noteID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteClientNote(clientID, noteID); err != nil {
+		return err
+	}
+
+	s.history.Record(actorID, models.HistoryActionDelete, models.EntityClientNote, noteID, note, nil)
+	return nil
+}
+
+// normalizeNote убирает пробелы по краям и проверяет длину заметки
+func normalizeNote(text string) (string, error) {
+	text = strings.TrimSpace(text)
+	if text == "" || utf8.RuneCountInString(text) > maxNoteLength {
+		return "", ErrInvalidNote
+	}
+	return text, nil
+}
+
+func (s *clientService) GetClientFields() ([]models.ClientField, error) {
+	return s.fieldRepo.GetAllClientFields()
+}
+
+func (s *clientService) CreateClientField(actorID int, field *models.ClientField) error {
+	field.Label = strings.TrimSpace(field.Label)
+	if err := field.Validate(); err != nil {
+		return err
+	}
+	if _, err := s.fieldRepo.GetClientFieldByKey(field.Key); err == nil {
+		return repositories.ErrClientFieldExists
+	} else if !errors.Is(err, repositories.ErrClientFieldNotFound) {
+		return err
+	}
+	if err := s.fieldRepo.CreateClientField(field); err != nil {
+		return err
+	}
+
+	s.history.Record(actorID, models.HistoryActionCreate, models.EntityClientField, field.ID, nil, field)
+	return nil
+}
+
+// UpdateClientField меняет название, варианты, обязательность и порядок поля.
+// Ключ и тип не меняются: по ним уже сохранены значения у клиентов.
+func (s *clientService) UpdateClientField(actorID, id int, input *models.ClientField) (*models.ClientField, error) {
+	field, err := s.fieldRepo.GetClientFieldByID(id)
+	if err != nil {
+		return nil, err
+	}
+	before := *field
+
+	field.Label = strings.TrimSpace(input.Label)
+	field.Options = input.Options
+	field.Required = input.Required
+	field.Position = input.Position
+	if err := field.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.fieldRepo.UpdateClientField(field); err != nil {
+		return nil, err
+	}
+
+	s.history.Record(actorID, models.HistoryActionUpdate, models.EntityClientField, field.ID, &before, field)
+	return field, nil
+}
+
+// DeleteClientField удаляет поле; сохраненные у клиентов значения отбрасываются при следующем изменении клиента
+func (s *clientService) DeleteClientField(actorID, id int) error {
+	field, err := s.fieldRepo.GetClientFieldByID(id)
+	if err != nil {
+		return err
+	}
+	if err := s.fieldRepo.DeleteClientField(id); err != nil {
+		return err
+	}
+
+	s.history.Record(actorID, models.HistoryActionDelete, models.EntityClientField, id, field, nil)
+	return nil
+}
@@ -0,0 +1,299 @@
+package services
+
+import (
+	"errors"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/repositories"
+	"math"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrMergeSameClient = errors.New("нельзя объединить клиента с самим собой")
+)
+
+// Вклад признаков в оценку сходства клиентов. Совпадение телефона, email или имени по отдельности
+// дает порог по умолчанию; разные телефоны или email у обоих клиентов снижают оценку.
+const (
+	duplicatePhoneWeight    = 0.5
+	duplicateEmailWeight    = 0.5
+	duplicateNameWeight     = 0.5
+	duplicateConflictWeight = 0.25
+	// minNameSimilarity — наименьшее сходство имен, которое учитывается в оценке
+	minNameSimilarity = 0.75
+
+	DefaultDuplicateScore = 0.5
+)
+
+// Ограничения работы FindDuplicates за один запрос
+const (
+	// maxDuplicateBlock — наибольшая группа клиентов с общим телефоном, email или ключом имени,
+	// внутри которой сравниваются все пары. Более крупные группы (общий служебный номер,
+	// частое имя без фамилии) не отличают дубликаты и пропускаются.
+	maxDuplicateBlock = 50
+	// maxDuplicateComparisons — наибольшее число сравниваемых пар
+	maxDuplicateComparisons = 20000
+	// duplicateBlockPage — число блоков, загружаемых из базы за раз
+	duplicateBlockPage = 200
+)
+
+// Признаки, по которым клиенты признаны возможными дубликатами
+const (
+	DuplicateReasonPhone = "phone"
+	DuplicateReasonEmail = "email"
+	DuplicateReasonName  = "name"
+)
+
+// DuplicateCandidate — пара клиентов, которые, вероятно, являются одним человеком
+type DuplicateCandidate struct {
+	Client    models.Client `json:"client"`
+	Duplicate models.Client `json:"duplicate"` // Клиент, созданный позже
+	Score     float64       `json:"score"`     // От 0 до 1
+	Reasons   []string      `json:"reasons"`   // phone, email, name
+}
+
+// DuplicateSearch — найденные пары возможных дубликатов
+type DuplicateSearch struct {
+	Candidates []DuplicateCandidate `json:"candidates"`
+	// Truncated сообщает, что сравнены не все клиенты: группа оказалась больше допустимой
+	// или достигнут предел сравнений за запрос
+	Truncated bool `json:"truncated"`
+}
+
+// ClientMerge — результат объединения: сохраненный клиент и число перенесенных записей
+type ClientMerge struct {
+	Client models.Client                  `json:"client"`
+	Moved  repositories.ClientMergeResult `json:"moved"`
+}
+
+// clientMergeAudit — состояние сохраненного клиента после объединения для журнала
+type clientMergeAudit struct {
+	models.Client
+	MergedFrom int `json:"merged_from"` // Удаленный дубликат
+}
+
+// duplicateMergeAudit — состояние удаленного дубликата после объединения для журнала
+type duplicateMergeAudit struct {
+	MergedInto int `json:"merged_into"` // Сохраненный клиент
+}
+
+// FindDuplicates оценивает пары клиентов по нормализованному телефону, email и сходству имен
+// и возвращает пары с оценкой не ниже minScore, начиная с наиболее вероятных.
+// Сравниваются только клиенты из одного блока — с общим телефоном, email или ключом имени, — блоки
+// читаются из базы постранично. Блоки больше maxDuplicateBlock пропускаются, а всего сравнивается
+// не более maxDuplicateComparisons пар; в обоих случаях результат помечается как неполный.
+func (s *clientService) FindDuplicates(minScore float64, limit int) (*DuplicateSearch, error) {
+	search := &DuplicateSearch{Candidates: make([]DuplicateCandidate, 0)}
+	compared := make(map[[2]int]bool)
+	for offset := 0; ; offset += duplicateBlockPage {
+		blocks, err := s.repo.FindDuplicateBlocks(maxDuplicateBlock, offset, duplicateBlockPage)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.compareBlocks(blocks, minScore, compared, search); err != nil {
+			return nil, err
+		}
+		if len(blocks) < duplicateBlockPage {
+			break
+		}
+		if len(compared) >= maxDuplicateComparisons {
+			search.Truncated = true
+			break
+		}
+	}
+
+	candidates := search.Candidates
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Score != candidates[j].Score {
+			return candidates[i].Score > candidates[j].Score
+		}
+		if candidates[i].Client.ID != candidates[j].Client.ID {
+			return candidates[i].Client.ID < candidates[j].Client.ID
+		}
+		return candidates[i].Duplicate.ID < candidates[j].Duplicate.ID
+	})
+	if limit > 0 && len(candidates) > limit {
+		search.Candidates = candidates[:limit]
+	}
+	return search, nil
+}
+
+// compareBlocks оценивает еще не сравненные пары клиентов внутри каждого блока страницы
+// и добавляет подходящие в search
+func (s *clientService) compareBlocks(blocks []repositories.DuplicateBlock, minScore float64, compared map[[2]int]bool, search *DuplicateSearch) error {
+	ids := make([]int, 0)
+	for _, block := range blocks {
+		ids = append(ids, block.ClientIDs...)
+	}
+	clients, err := s.repo.GetClientsByIDs(ids)
+	if err != nil {
+		return err
+	}
+	byID := make(map[int]models.Client, len(clients))
+	for _, client := range clients {
+		byID[client.ID] = client
+	}
+
+	for _, block := range blocks {
+		if len(block.ClientIDs) == 0 {
+			search.Truncated = true
+			continue
+		}
+		for i := 0; i < len(block.ClientIDs); i++ {
+			for j := i + 1; j < len(block.ClientIDs); j++ {
+				pair := [2]int{block.ClientIDs[i], block.ClientIDs[j]}
+				if compared[pair] {
+					continue
+				}
+				if len(compared) >= maxDuplicateComparisons {
+					search.Truncated = true
+					return nil
+				}
+				compared[pair] = true
+
+				first, second := byID[pair[0]], byID[pair[1]]
+				score, reasons := duplicateScore(first, second)
+				if len(reasons) == 0 || score < minScore {
+					continue
+				}
+				search.Candidates = append(search.Candidates, DuplicateCandidate{Client: first, Duplicate: second, Score: score, Reasons: reasons})
+			}
+		}
+	}
+	return nil
+}
+
+// MergeClients объединяет дубликат с клиентом primaryID: бронирования, уведомления и заметки переходят
+// к primaryID, пустые контакты и дополнительные поля primaryID заполняются значениями дубликата,
+// теги объединяются, дубликат удаляется. Клиенты читаются и журнал пишется в транзакции объединения.
+func (s *clientService) MergeClients(actorID, primaryID, duplicateID int) (*ClientMerge, error) {
+	if primaryID == duplicateID {
+		return nil, ErrMergeSameClient
+	}
+	primary, moved, err := s.repo.MergeClients(primaryID, duplicateID, func(primary, duplicate *models.Client) ([]models.HistoryLogs, error) {
+		before := *primary
+		mergeClientData(primary, duplicate)
+
+		merged, err := s.history.Entry(actorID, models.HistoryActionMerge, models.EntityClient, primaryID,
+			&before, &clientMergeAudit{Client: *primary, MergedFrom: duplicateID})
+		if err != nil {
+			return nil, err
+		}
+		removed, err := s.history.Entry(actorID, models.HistoryActionMerge, models.EntityClient, duplicateID,
+			duplicate, &duplicateMergeAudit{MergedInto: primaryID})
+		if err != nil {
+			return nil, err
+		}
+		return []models.HistoryLogs{*merged, *removed}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ClientMerge{Client: *primary, Moved: *moved}, nil
+}
+
+// mergeClientData заполняет пустые контакты и дополнительные поля primary значениями дубликата и объединяет теги
+func mergeClientData(primary, duplicate *models.Client) {
+	fillString := func(target *string, value string) {
+		if strings.TrimSpace(*target) == "" {
+			*target = value
+		}
+	}
+	fillString(&primary.FirstName, duplicate.FirstName)
+	fillString(&primary.LastName, duplicate.LastName)
+	fillString(&primary.Email, duplicate.Email)
+	fillString(&primary.PhoneNumber, duplicate.PhoneNumber)
+	fillString(&primary.TgNickname, duplicate.TgNickname)
+	if primary.TgID == 0 {
+		primary.TgID = duplicate.TgID
+	}
+	primary.Tags, _ = models.NormalizeTags(append(append([]string{}, primary.Tags...), duplicate.Tags...))
+	for key, value := range duplicate.CustomFields {
+		if _, ok := primary.CustomFields[key]; !ok {
+			if primary.CustomFields == nil {
+				primary.CustomFields = make(map[string]interface{})
+			}
+			primary.CustomFields[key] = value
+		}
+	}
+}
+
+// duplicateScore оценивает сходство двух клиентов и возвращает совпавшие признаки
+func duplicateScore(a, b models.Client) (float64, []string) {
+	score := 0.0
+	reasons := make([]string, 0, 3)
+
+	phoneA, phoneB := comparablePhone(a.PhoneNumber), comparablePhone(b.PhoneNumber)
+	switch {
+	case phoneA != "" && phoneA == phoneB:
+		score += duplicatePhoneWeight
+		reasons = append(reasons, DuplicateReasonPhone)
+	case phoneA != "" && phoneB != "":
+		score -= duplicateConflictWeight
+	}
+
+	emailA, emailB := strings.ToLower(strings.TrimSpace(a.Email)), strings.ToLower(strings.TrimSpace(b.Email))
+	switch {
+	case emailA != "" && emailA == emailB:
+		score += duplicateEmailWeight
+		reasons = append(reasons, DuplicateReasonEmail)
+	case emailA != "" && emailB != "":
+		score -= duplicateConflictWeight
+	}
+
+	if similarity := nameSimilarity(a, b); similarity >= minNameSimilarity {
+		score += duplicateNameWeight * similarity
+		reasons = append(reasons, DuplicateReasonName)
+	}
+
+	score = math.Round(math.Min(math.Max(score, 0), 1)*100) / 100
+	return score, reasons
+}
+
+// comparablePhone приводит номер к E.164; номер, который не удалось разобрать, не сравнивается
+func comparablePhone(phone string) string {
+	normalized, err := models.NormalizePhone(phone)
+	if err != nil {
+		return ""
+	}
+	return normalized
+}
+
+// nameTokens возвращает части имени и фамилии в нижнем регистре по алфавиту,
+// чтобы «Иван Петров» и «Петров Иван» совпадали
+func nameTokens(client models.Client) []string {
+	tokens := strings.Fields(strings.ToLower(client.FirstName + " " + client.LastName))
+	sort.Strings(tokens)
+	return tokens
+}
+
+// nameSimilarity сравнивает имена по расстоянию Левенштейна: 1 — совпадают, 0 — ничего общего
+func nameSimilarity(a, b models.Client) float64 {
+	nameA, nameB := []rune(strings.Join(nameTokens(a), " ")), []rune(strings.Join(nameTokens(b), " "))
+	if len(nameA) == 0 || len(nameB) == 0 {
+		return 0
+	}
+	longest := math.Max(float64(len(nameA)), float64(len(nameB)))
+	return 1 - float64(levenshtein(nameA, nameB))/longest
+}
+
+func levenshtein(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"errors"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/repositories"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+var (
+	ErrEmptySearchQuery = errors.New("поисковый запрос пуст")
+)
+
+// Оценка совпадения слова запроса с полем клиента
+const (
+	searchExactWeight     = 1.0
+	searchPrefixWeight    = 0.8
+	searchSubstringWeight = 0.6
+	// searchFuzzyWeight умножается на сходство имени с опечаткой
+	searchFuzzyWeight = 0.7
+	// minSearchPhoneDigits — с какого числа цифр запрос считается фрагментом телефона
+	minSearchPhoneDigits = 3
+	// minFuzzyTermLength — опечатки ищутся только в словах не короче
+	minFuzzyTermLength = 4
+)
+
+// ClientSearchResult — найденный клиент и релевантность от 0 до 1
+type ClientSearchResult struct {
+	Client models.Client `json:"client"`
+	Score  float64       `json:"score"`
+}
+
+// SearchClients ищет клиентов по строке q: части имени и фамилии в любом порядке (с учетом опечаток),
+// фрагмент телефона, ник Telegram (с @ или без) и email. Каждое слово запроса должно совпасть
+// хотя бы с одним полем; клиенты упорядочены по убыванию релевантности.
+func (s *clientService) SearchClients(q string, limit int) ([]ClientSearchResult, error) {
+	search := parseClientSearch(q)
+	if len(search.Terms) == 0 && search.Phone == "" {
+		return nil, ErrEmptySearchQuery
+	}
+
+	clients, err := s.repo.SearchClients(search)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]ClientSearchResult, 0)
+	for _, client := range clients {
+		if score := clientSearchScore(search, client); score > 0 {
+			results = append(results, ClientSearchResult{Client: client, Score: score})
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Client.ID < results[j].Client.ID
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// parseClientSearch разбирает запрос: строка из цифр и знаков номера («+7 999 12», «(999) 123») ищется
+// в телефоне, иначе запрос делится на слова в нижнем регистре
+func parseClientSearch(q string) repositories.ClientSearch {
+	q = strings.TrimSpace(q)
+	digits := 0
+	phoneLike := q != ""
+	for _, r := range q {
+		switch {
+		case unicode.IsDigit(r):
+			digits++
+		case strings.ContainsRune("+()- .", r):
+		default:
+			phoneLike = false
+		}
+	}
+	if phoneLike && digits >= minSearchPhoneDigits {
+		// Полный номер в любом формате сравнивается в E.164, фрагмент — как есть
+		if phone, err := models.NormalizePhone(q); err == nil {
+			return repositories.ClientSearch{Phone: strings.TrimPrefix(phone, "+")}
+		}
+		return repositories.ClientSearch{Phone: strings.Map(keepDigits, q)}
+	}
+
+	var terms []string
+	for _, field := range strings.Fields(strings.ToLower(q)) {
+		if term := strings.TrimLeft(field, "@"); term != "" {
+			terms = append(terms, term)
+		}
+	}
+	return repositories.ClientSearch{Terms: terms}
+}
+
+// clientSearchScore — средняя оценка слов запроса; 0, если хотя бы одно слово не совпало
+func clientSearchScore(search repositories.ClientSearch, client models.Client) float64 {
+	if search.Phone != "" {
+		return phoneSearchScore(search.Phone, client.PhoneNumber)
+	}
+
+	names := nameTokens(client)
+	nickname := strings.ToLower(client.TgNickname)
+	email := strings.ToLower(client.Email)
+	localPart, _, _ := strings.Cut(email, "@")
+
+	total := 0.0
+	for _, term := range search.Terms {
+		best := 0.0
+		for _, name := range names {
+			best = math.Max(best, termScore(term, name, true))
+		}
+		best = math.Max(best, termScore(term, nickname, false))
+		best = math.Max(best, termScore(term, email, false))
+		best = math.Max(best, termScore(term, localPart, false))
+		if isDigits(term) && len(term) >= minSearchPhoneDigits {
+			best = math.Max(best, phoneSearchScore(term, client.PhoneNumber))
+		}
+		if best == 0 {
+			return 0
+		}
+		total += best
+	}
+	return math.Round(total/float64(len(search.Terms))*100) / 100
+}
+
+// termScore оценивает совпадение слова с полем: целиком, началом, подстрокой или, для имен, с опечаткой
+func termScore(term, field string, fuzzy bool) float64 {
+	switch {
+	case field == "":
+		return 0
+	case field == term:
+		return searchExactWeight
+	case strings.HasPrefix(field, term):
+		return searchPrefixWeight
+	case strings.Contains(field, term):
+		return searchSubstringWeight
+	}
+	if !fuzzy || len([]rune(term)) < minFuzzyTermLength {
+		return 0
+	}
+	// Сравнивается с началом имени той же длины, чтобы «алекс» с опечаткой находил «александра»
+	termRunes, fieldRunes := []rune(term), []rune(field)
+	if len(fieldRunes) > len(termRunes) {
+		fieldRunes = fieldRunes[:len(termRunes)]
+	}
+	longest := math.Max(float64(len(termRunes)), float64(len(fieldRunes)))
+	similarity := 1 - float64(levenshtein(termRunes, fieldRunes))/longest
+	if similarity < minNameSimilarity {
+		return 0
+	}
+	return searchFuzzyWeight * similarity
+}
+
+// phoneSearchScore оценивает фрагмент номера: весь номер — 1, иначе тем выше, чем длиннее фрагмент
+func phoneSearchScore(fragment, phone string) float64 {
+	digits := strings.Map(keepDigits, phone)
+	switch {
+	case digits == "" || !strings.Contains(digits, fragment):
+		return 0
+	case digits == fragment:
+		return searchExactWeight
+	}
+	score := searchSubstringWeight + (searchPrefixWeight-searchSubstringWeight)*float64(len(fragment))/float64(len(digits))
+	return math.Round(score*100) / 100
+}
+
+func isDigits(value string) bool {
+	return strings.IndexFunc(value, func(r rune) bool { return !unicode.IsDigit(r) }) < 0
+}
+
+func keepDigits(r rune) rune {
+	if unicode.IsDigit(r) {
+		return r
+	}
+	return -1
+}
@@ -3,48 +3,119 @@ package services
 import (
 	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
 	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/repositories"
+	"math"
+	"time"
 )
 
+// favouriteLimit — сколько любимых услуг и сотрудников показывается в профиле клиента
+const favouriteLimit = 3
+
+// ClientProfile — сводка по истории визитов клиента. Суммы, любимые услуги, сотрудники
+// и интервалы считаются по завершенным визитам.
+type ClientProfile struct {
+	Client              models.Client                `json:"client"`
+	TotalVisits         int64                        `json:"total_visits"` // Все бронирования клиента независимо от статуса
+	Completed           int64                        `json:"completed"`
+	Cancelled           int64                        `json:"cancelled"`
+	NoShow              int64                        `json:"no_show"`
+	LifetimeSpend       []models.Money               `json:"lifetime_spend"` // По одной сумме на валюту
+	FavouriteServices   []repositories.ServiceVisits `json:"favourite_services"`
+	FavouriteBarbers    []repositories.BarberVisits  `json:"favourite_barbers"`
+	LastVisit           *time.Time                   `json:"last_visit"`
+	NextVisit           *time.Time                   `json:"next_visit"`            // Ближайшее ожидающее или подтвержденное бронирование
+	AverageIntervalDays *float64                     `json:"average_interval_days"` // Не менее двух визитов; с точностью до 0.1 дня
+}
+
 type ClientService interface {
-	CreateClient(client *models.Client) error
+	CreateClient(actorID int, client *models.Client) error
 	GetClientByID(id int) (*models.Client, error)
-	GetAllClients() ([]models.Client, error)
-	UpdateClient(id int, input *models.Client) error
-	DeleteClient(id int) error
+	GetAllClients(query repositories.ListQuery) ([]models.Client, int64, error)
+	UpdateClient(actorID, id int, input *models.Client) error
+	DeleteClient(actorID, id int) error
 	GetClientByTelegramID(tgID int64) (*models.Client, error)
 	FilterClientsByName(name string) ([]models.Client, error)
-	QuickAddClient(client *models.Client) error
+	QuickAddClient(actorID int, client *models.Client) error
 	SearchClientByEmailOrPhone(email, phone string) (*models.Client, error)
 	CheckClientExistence(phoneNumber string, tgID int64) (bool, error)
+	// GetClientProfile возвращает статистику визитов клиента
+	GetClientProfile(id int) (*ClientProfile, error)
+	// SearchClients ищет клиентов по имени, фрагменту телефона, нику Telegram и email с ранжированием; limit 0 — все
+	SearchClients(q string, limit int) ([]ClientSearchResult, error)
+	// FindDuplicates возвращает пары возможных дубликатов с оценкой не ниже minScore; limit 0 — все
+	FindDuplicates(minScore float64, limit int) (*DuplicateSearch, error)
+	// MergeClients объединяет дубликат duplicateID с клиентом primaryID
+	MergeClients(actorID, primaryID, duplicateID int) (*ClientMerge, error)
+
+	// GetTagUsage возвращает теги клиентов с числом клиентов
+	GetTagUsage() ([]repositories.TagUsage, error)
+	GetClientNotes(clientID int) ([]models.ClientNote, error)
+	AddClientNote(actorID, clientID int, text string) (*models.ClientNote, error)
+	UpdateClientNote(actorID, clientID, noteID int, text string) (*models.ClientNote, error)
+	DeleteClientNote(actorID, clientID, noteID int) error
+
+	// Дополнительные поля карточки клиента, которые настраивает арендатор
+	GetClientFields() ([]models.ClientField, error)
+	CreateClientField(actorID int, field *models.ClientField) error
+	UpdateClientField(actorID, id int, input *models.ClientField) (*models.ClientField, error)
+	DeleteClientField(actorID, id int) error
 }
 
 type clientService struct {
-	repo repositories.ClientRepository
+	repo        repositories.ClientRepository
+	bookingRepo repositories.BookingRepository
+	fieldRepo   repositories.ClientFieldRepository
+	history     HistoryService
 }
 
-func NewClientService(repo repositories.ClientRepository) ClientService {
+func NewClientService(repo repositories.ClientRepository, bookingRepo repositories.BookingRepository, fieldRepo repositories.ClientFieldRepository, history HistoryService) ClientService {
 	return &clientService{
-		repo: repo,
+		repo:        repo,
+		bookingRepo: bookingRepo,
+		fieldRepo:   fieldRepo,
+		history:     history,
 	}
 }
 
-func (s *clientService) CreateClient(client *models.Client) error {
-	return s.repo.CreateClient(client)
+func (s *clientService) CreateClient(actorID int, client *models.Client) error {
+	if err := normalizeClientLanguage(client); err != nil {
+		return err
+	}
+	if err := normalizeClientContacts(client); err != nil {
+		return err
+	}
+	if err := s.normalizeClientDetails(client, nil, true); err != nil {
+		return err
+	}
+	if err := s.repo.CreateClient(client); err != nil {
+		return err
+	}
+
+	s.history.Record(actorID, models.HistoryActionCreate, models.EntityClient, client.ID, nil, client)
+	return nil
 }
 
+// GetClientByID возвращает клиента вместе с заметками сотрудников
 func (s *clientService) GetClientByID(id int) (*models.Client, error) {
-	return s.repo.GetClientByID(id)
+	client, err := s.repo.GetClientByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if client.Notes, err = s.repo.GetClientNotes(id); err != nil {
+		return nil, err
+	}
+	return client, nil
 }
 
-func (s *clientService) GetAllClients() ([]models.Client, error) {
-	return s.repo.GetAllClients()
+func (s *clientService) GetAllClients(query repositories.ListQuery) ([]models.Client, int64, error) {
+	return s.repo.GetAllClients(query)
 }
 
-func (s *clientService) UpdateClient(id int, input *models.Client) error {
+func (s *clientService) UpdateClient(actorID, id int, input *models.Client) error {
 	client, err := s.repo.GetClientByID(id)
 	if err != nil {
 		return err
 	}
+	before := *client
 
 	// Обновляем поля
 	client.FirstName = input.FirstName
@@ -53,12 +124,48 @@ func (s *clientService) UpdateClient(id int, input *models.Client) error {
 	client.PhoneNumber = input.PhoneNumber
 	client.TgID = input.TgID
 	client.TgNickname = input.TgNickname
+	if input.Language != "" {
+		client.Language = input.Language
+	}
+	// Теги и дополнительные поля заменяются целиком, если переданы
+	if input.Tags != nil {
+		client.Tags = input.Tags
+	}
+	var stored map[string]interface{}
+	if input.CustomFields == nil {
+		stored, client.CustomFields = client.CustomFields, nil
+	} else {
+		client.CustomFields = input.CustomFields
+	}
+	if err := normalizeClientLanguage(client); err != nil {
+		return err
+	}
+	if err := normalizeClientContacts(client); err != nil {
+		return err
+	}
+	if err := s.normalizeClientDetails(client, stored, true); err != nil {
+		return err
+	}
 
-	return s.repo.UpdateClient(client)
+	if err := s.repo.UpdateClient(client); err != nil {
+		return err
+	}
+
+	s.history.Record(actorID, models.HistoryActionUpdate, models.EntityClient, client.ID, &before, client)
+	return nil
 }
 
-func (s *clientService) DeleteClient(id int) error {
-	return s.repo.DeleteClient(id)
+func (s *clientService) DeleteClient(actorID, id int) error {
+	client, err := s.repo.GetClientByID(id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteClient(id); err != nil {
+		return err
+	}
+
+	s.history.Record(actorID, models.HistoryActionDelete, models.EntityClient, id, client, nil)
+	return nil
 }
 
 func (s *clientService) GetClientByTelegramID(tgID int64) (*models.Client, error) {
@@ -69,14 +176,104 @@ func (s *clientService) FilterClientsByName(name string) ([]models.Client, error
 	return s.repo.FilterClientsByName(name)
 }
 
-func (s *clientService) QuickAddClient(client *models.Client) error {
-	return s.repo.QuickAddClient(client)
+func (s *clientService) QuickAddClient(actorID int, client *models.Client) error {
+	if err := normalizeClientLanguage(client); err != nil {
+		return err
+	}
+	if err := normalizeClientContacts(client); err != nil {
+		return err
+	}
+	// Обязательные дополнительные поля заполняются позже, в полной карточке клиента
+	if err := s.normalizeClientDetails(client, nil, false); err != nil {
+		return err
+	}
+	if err := s.repo.QuickAddClient(client); err != nil {
+		return err
+	}
+
+	s.history.Record(actorID, models.HistoryActionCreate, models.EntityClient, client.ID, nil, client)
+	return nil
 }
 
+// SearchClientByEmailOrPhone ищет клиента по нормализованным email и телефону:
+// «+7 (999) 123-45-67» и «89991234567» находят одного и того же клиента
 func (s *clientService) SearchClientByEmailOrPhone(email, phone string) (*models.Client, error) {
+	email, err := models.NormalizeEmail(email)
+	if err != nil {
+		return nil, err
+	}
+	phone, err = models.NormalizePhone(phone)
+	if err != nil {
+		return nil, err
+	}
 	return s.repo.SearchClientByEmailOrPhone(email, phone)
 }
 
 func (s *clientService) CheckClientExistence(phoneNumber string, tgID int64) (bool, error) {
+	phoneNumber, err := models.NormalizePhone(phoneNumber)
+	if err != nil {
+		return false, err
+	}
 	return s.repo.CheckClientExistence(phoneNumber, tgID)
 }
+
+func (s *clientService) GetClientProfile(id int) (*ClientProfile, error) {
+	client, err := s.GetClientByID(id)
+	if err != nil {
+		return nil, err
+	}
+	stats, err := s.bookingRepo.GetClientVisitStats(id, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	profile := &ClientProfile{
+		Client:            *client,
+		Completed:         stats.StatusCounts[models.BookingStatusCompleted],
+		Cancelled:         stats.StatusCounts[models.BookingStatusCancelled],
+		NoShow:            stats.StatusCounts[models.BookingStatusNoShow],
+		LifetimeSpend:     append([]models.Money{}, stats.Spend...),
+		FavouriteServices: append([]repositories.ServiceVisits{}, stats.Services[:min(len(stats.Services), favouriteLimit)]...),
+		FavouriteBarbers:  append([]repositories.BarberVisits{}, stats.Barbers[:min(len(stats.Barbers), favouriteLimit)]...),
+		NextVisit:         stats.NextVisit,
+	}
+	for _, count := range stats.StatusCounts {
+		profile.TotalVisits += count
+	}
+
+	if visits := len(stats.VisitTimes); visits > 0 {
+		first, last := stats.VisitTimes[0], stats.VisitTimes[visits-1]
+		profile.LastVisit = &last
+		if visits > 1 {
+			days := last.Sub(first).Hours() / 24 / float64(visits-1)
+			days = math.Round(days*10) / 10
+			profile.AverageIntervalDays = &days
+		}
+	}
+	return profile, nil
+}
+
+// normalizeClientContacts приводит телефон клиента к E.164, а email — к нижнему регистру и проверяет их формат
+func normalizeClientContacts(client *models.Client) error {
+	phone, err := models.NormalizePhone(client.PhoneNumber)
+	if err != nil {
+		return err
+	}
+	email, err := models.NormalizeEmail(client.Email)
+	if err != nil {
+		return err
+	}
+	client.PhoneNumber, client.Email = phone, email
+	return nil
+}
+
+// normalizeClientLanguage подставляет язык по умолчанию и проверяет, что язык поддерживается
+func normalizeClientLanguage(client *models.Client) error {
+	if client.Language == "" {
+		client.Language = models.DefaultLanguage
+	}
+	if !models.IsValidLanguage(client.Language) {
+		return ErrInvalidLanguage
+	}
+	return nil
+}
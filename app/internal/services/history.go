@@ -0,0 +1,136 @@
+package services
+
+import (
+	"encoding/json"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/repositories"
+	"log"
+	"reflect"
+)
+
+// auditIgnoredFields не попадают в журнал: они меняются при каждом сохранении
+var auditIgnoredFields = map[string]bool{
+	"created_at": true,
+	"updated_at": true,
+}
+
+// auditedCollections — вложенные значения, которые являются данными самой сущности, а не связанными
+// сущностями, и поэтому попадают в журнал: теги и дополнительные поля клиента
+var auditedCollections = map[string]bool{
+	"tags":          true,
+	"custom_fields": true,
+}
+
+type HistoryService interface {
+	Record(actorID int, action, entityType string, entityID int, before, after interface{})
+	// Entry собирает запись журнала, не сохраняя ее, чтобы изменение и запись о нем сохранились в одной транзакции.
+	// Для обновления без изменений возвращает nil.
+	Entry(actorID int, action, entityType string, entityID int, before, after interface{}) (*models.HistoryLogs, error)
+	GetHistory(query repositories.ListQuery) ([]models.HistoryLogs, int64, error)
+}
+
+type historyService struct {
+	repo repositories.HistoryRepository
+}
+
+func NewHistoryService(repo repositories.HistoryRepository) HistoryService {
+	return &historyService{
+		repo: repo,
+	}
+}
+
+// Record добавляет запись в журнал изменений. before равен nil при создании, after — при удалении.
+// Ошибка записи журнала не откатывает уже выполненное изменение и только логируется.
+func (s *historyService) Record(actorID int, action, entityType string, entityID int, before, after interface{}) {
+	entry, err := s.Entry(actorID, action, entityType, entityID, before, after)
+	if err != nil {
+		log.Printf("audit: failed to diff %s #%d: %v", entityType, entityID, err)
+		return
+	}
+	if entry == nil {
+		return
+	}
+	if err := s.repo.CreateLog(entry); err != nil {
+		log.Printf("audit: failed to record %s of %s #%d: %v", action, entityType, entityID, err)
+	}
+}
+
+func (s *historyService) Entry(actorID int, action, entityType string, entityID int, before, after interface{}) (*models.HistoryLogs, error) {
+	changes, err := diffSnapshots(before, after)
+	if err != nil {
+		return nil, err
+	}
+	if action == models.HistoryActionUpdate && len(changes) == 0 {
+		return nil, nil
+	}
+	return &models.HistoryLogs{
+		UserID:     actorID,
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Changes:    changes,
+	}, nil
+}
+
+func (s *historyService) GetHistory(query repositories.ListQuery) ([]models.HistoryLogs, int64, error) {
+	return s.repo.FindLogs(query)
+}
+
+// diffSnapshots сравнивает JSON-представления сущности до и после изменения.
+// Вложенные объекты и списки (связанные сущности) не учитываются, кроме auditedCollections.
+func diffSnapshots(before, after interface{}) (map[string]models.FieldChange, error) {
+	beforeFields, err := snapshot(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := snapshot(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]models.FieldChange)
+	for key, value := range beforeFields {
+		if !reflect.DeepEqual(value, afterFields[key]) {
+			changes[key] = models.FieldChange{Before: value, After: afterFields[key]}
+		}
+	}
+	for key, value := range afterFields {
+		if _, ok := beforeFields[key]; !ok {
+			changes[key] = models.FieldChange{After: value}
+		}
+	}
+	return changes, nil
+}
+
+func snapshot(entity interface{}) (map[string]interface{}, error) {
+	fields := make(map[string]interface{})
+	if entity == nil {
+		return fields, nil
+	}
+	if v := reflect.ValueOf(entity); v.Kind() == reflect.Ptr && v.IsNil() {
+		return fields, nil
+	}
+
+	data, err := json.Marshal(entity)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	for key, value := range raw {
+		if auditIgnoredFields[key] {
+			continue
+		}
+		switch value.(type) {
+		case map[string]interface{}, []interface{}:
+			if !auditedCollections[key] {
+				continue
+			}
+		}
+		fields[key] = value
+	}
+	return fields, nil
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/repositories"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidLocation     = errors.New("некорректные данные филиала: обязательны name и timezone")
+	ErrInvalidTimezone     = errors.New("неизвестный часовой пояс, ожидается имя IANA, например Europe/Moscow")
+	ErrInvalidOpeningHours = errors.New("некорректные часы работы филиала: нужен день недели и открытие раньше закрытия")
+)
+
+type LocationService interface {
+	CreateLocation(actorID int, location *models.Location) error
+	GetLocationByID(id int) (*models.Location, error)
+	GetAllLocations(query repositories.ListQuery) ([]models.Location, int64, error)
+	UpdateLocation(actorID, id int, input *models.Location) error
+	DeleteLocation(actorID, id int) error
+
+	// Resolve возвращает филиал и его часовой пояс. Для locationID 0 и несуществующего филиала
+	// возвращается nil и часовой пояс салона по умолчанию.
+	Resolve(locationID int) (*models.Location, *time.Location, error)
+	// Zone возвращает часовой пояс филиала, см. Resolve
+	Zone(locationID int) (*time.Location, error)
+}
+
+type locationService struct {
+	repo       repositories.LocationRepository
+	history    HistoryService
+	defaultLoc *time.Location // Часовой пояс салона из настроек, если филиал не указан
+}
+
+func NewLocationService(repo repositories.LocationRepository, history HistoryService, defaultLoc *time.Location) LocationService {
+	return &locationService{
+		repo:       repo,
+		history:    history,
+		defaultLoc: defaultLoc,
+	}
+}
+
+func (s *locationService) CreateLocation(actorID int, location *models.Location) error {
+	if err := validateLocation(location); err != nil {
+		return err
+	}
+	if err := s.repo.CreateLocation(location); err != nil {
+		return err
+	}
+
+	s.history.Record(actorID, models.HistoryActionCreate, models.EntityLocation, location.ID, nil, location)
+	return nil
+}
+
+func (s *locationService) GetLocationByID(id int) (*models.Location, error) {
+	return s.repo.GetLocationByID(id)
+}
+
+func (s *locationService) GetAllLocations(query repositories.ListQuery) ([]models.Location, int64, error) {
+	return s.repo.GetAllLocations(query)
+}
+
+func (s *locationService) UpdateLocation(actorID, id int, input *models.Location) error {
+	location, err := s.repo.GetLocationByID(id)
+	if err != nil {
+		return err
+	}
+	before := *location
+
+	// Обновляем поля
+	location.Name = input.Name
+	location.Address = input.Address
+	location.Timezone = input.Timezone
+	location.OpeningHours = input.OpeningHours
+	location.IsActive = input.IsActive
+
+	if err := validateLocation(location); err != nil {
+		return err
+	}
+	if err := s.repo.UpdateLocation(location); err != nil {
+		return err
+	}
+
+	s.history.Record(actorID, models.HistoryActionUpdate, models.EntityLocation, location.ID, &before, location)
+	return nil
+}
+
+func (s *locationService) DeleteLocation(actorID, id int) error {
+	location, err := s.repo.GetLocationByID(id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteLocation(id); err != nil {
+		return err
+	}
+
+	s.history.Record(actorID, models.HistoryActionDelete, models.EntityLocation, id, location, nil)
+	return nil
+}
+
+func (s *locationService) Resolve(locationID int) (*models.Location, *time.Location, error) {
+	if locationID == 0 {
+		return nil, s.defaultLoc, nil
+	}
+
+	location, err := s.repo.GetLocationByID(locationID)
+	if errors.Is(err, repositories.ErrLocationNotFound) {
+		return nil, s.defaultLoc, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	zone, err := location.TimeLocation()
+	if err != nil {
+		return nil, nil, fmt.Errorf("филиал #%d: %w", location.ID, ErrInvalidTimezone)
+	}
+	return location, zone, nil
+}
+
+func (s *locationService) Zone(locationID int) (*time.Location, error) {
+	_, zone, err := s.Resolve(locationID)
+	return zone, err
+}
+
+func validateLocation(location *models.Location) error {
+	location.Name = strings.TrimSpace(location.Name)
+	location.Timezone = strings.TrimSpace(location.Timezone)
+	if location.Name == "" || location.Timezone == "" {
+		return ErrInvalidLocation
+	}
+	if _, err := location.TimeLocation(); err != nil {
+		return ErrInvalidTimezone
+	}
+
+	for _, hours := range location.OpeningHours {
+		if !hours.Day.IsValid() || validateTimeRange(hours.Open, hours.Close) != nil {
+			return ErrInvalidOpeningHours
+		}
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/repositories"
+	"strings"
+	"text/template"
+	"time"
+)
+
+var (
+	ErrInvalidTemplate = errors.New("некорректный шаблон")
+	ErrInvalidLanguage = errors.New("недопустимый язык: ru или en")
+)
+
+// TemplateData — данные, доступные в шаблоне: {{.Client.FirstName}}, {{.Service.Name}},
+// {{.Barber.Username}}, {{datetime .Booking.BookingTime}}. Service — первая услуга визита,
+// все услуги перечисляются через {{range .Services}}{{.Name}} {{end}}
+type TemplateData struct {
+	Client   models.Client
+	Booking  models.Bookings
+	Service  models.Service
+	Services []models.Service
+	Barber   models.User
+}
+
+// templateFuncs — функции форматирования, доступные в шаблонах. Время выводится в часовом поясе филиала loc.
+func templateFuncs(loc *time.Location) template.FuncMap {
+	return template.FuncMap{
+		"date":     func(t time.Time) string { return t.In(loc).Format("02.01.2006") },
+		"time":     func(t time.Time) string { return t.In(loc).Format("15:04") },
+		"datetime": func(t time.Time) string { return t.In(loc).Format("02.01.2006 15:04") },
+	}
+}
+
+type NotificationTemplateService interface {
+	CreateTemplate(template *models.NotificationTemplate) error
+	GetTemplateByID(id int) (*models.NotificationTemplate, error)
+	GetAllTemplates(query repositories.ListQuery) ([]models.NotificationTemplate, int64, error)
+	UpdateTemplate(id int, input *models.NotificationTemplate) error
+	DeleteTemplate(id int) error
+	Render(code, language string, data TemplateData) (string, error)
+	Preview(id, bookingID int) (string, error)
+}
+
+type notificationTemplateService struct {
+	repo        repositories.NotificationTemplateRepository
+	bookingRepo repositories.BookingRepository
+	locations   LocationService
+}
+
+func NewNotificationTemplateService(
+	repo repositories.NotificationTemplateRepository,
+	bookingRepo repositories.BookingRepository,
+	locations LocationService,
+) NotificationTemplateService {
+	return &notificationTemplateService{
+		repo:        repo,
+		bookingRepo: bookingRepo,
+		locations:   locations,
+	}
+}
+
+func (s *notificationTemplateService) CreateTemplate(tmpl *models.NotificationTemplate) error {
+	if err := validateTemplate(tmpl); err != nil {
+		return err
+	}
+	return s.repo.CreateTemplate(tmpl)
+}
+
+func (s *notificationTemplateService) GetTemplateByID(id int) (*models.NotificationTemplate, error) {
+	return s.repo.GetTemplateByID(id)
+}
+
+func (s *notificationTemplateService) GetAllTemplates(query repositories.ListQuery) ([]models.NotificationTemplate, int64, error) {
+	return s.repo.GetAllTemplates(query)
+}
+
+func (s *notificationTemplateService) UpdateTemplate(id int, input *models.NotificationTemplate) error {
+	tmpl, err := s.repo.GetTemplateByID(id)
+	if err != nil {
+		return err
+	}
+
+	// Обновляем поля
+	tmpl.Code = input.Code
+	tmpl.Language = input.Language
+	tmpl.Body = input.Body
+
+	if err := validateTemplate(tmpl); err != nil {
+		return err
+	}
+	return s.repo.UpdateTemplate(tmpl)
+}
+
+func (s *notificationTemplateService) DeleteTemplate(id int) error {
+	return s.repo.DeleteTemplate(id)
+}
+
+// Render подставляет данные в шаблон code на языке language.
+// Если шаблона на этом языке нет, используется язык по умолчанию.
+func (s *notificationTemplateService) Render(code, language string, data TemplateData) (string, error) {
+	if language == "" {
+		language = models.DefaultLanguage
+	}
+
+	tmpl, err := s.repo.GetTemplateByCode(code, language)
+	if errors.Is(err, repositories.ErrTemplateNotFound) && language != models.DefaultLanguage {
+		tmpl, err = s.repo.GetTemplateByCode(code, models.DefaultLanguage)
+	}
+	if err != nil {
+		return "", err
+	}
+	return s.render(tmpl.Body, data)
+}
+
+// Preview отображает шаблон на данных бронирования bookingID или, если он не указан, на примере бронирования
+func (s *notificationTemplateService) Preview(id, bookingID int) (string, error) {
+	tmpl, err := s.repo.GetTemplateByID(id)
+	if err != nil {
+		return "", err
+	}
+
+	data := sampleTemplateData()
+	if bookingID != 0 {
+		booking, err := s.bookingRepo.GetBookingByID(bookingID)
+		if err != nil {
+			return "", err
+		}
+		data = bookingTemplateData(booking)
+	}
+	return s.render(tmpl.Body, data)
+}
+
+// render отображает шаблон, выводя время в часовом поясе филиала бронирования
+func (s *notificationTemplateService) render(body string, data TemplateData) (string, error) {
+	zone, err := s.locations.Zone(data.Booking.LocationID)
+	if err != nil {
+		return "", err
+	}
+	return renderTemplate(body, data, zone)
+}
+
+func validateTemplate(tmpl *models.NotificationTemplate) error {
+	tmpl.Code = strings.TrimSpace(tmpl.Code)
+	if tmpl.Code == "" || strings.TrimSpace(tmpl.Body) == "" {
+		return fmt.Errorf("%w: обязательные поля code и body", ErrInvalidTemplate)
+	}
+	if !models.IsValidLanguage(tmpl.Language) {
+		return ErrInvalidLanguage
+	}
+	// Шаблон должен отображаться на примере данных, иначе ошибка обнаружится только при отправке
+	if _, err := renderTemplate(tmpl.Body, sampleTemplateData(), time.UTC); err != nil {
+		return err
+	}
+	return nil
+}
+
+func renderTemplate(body string, data TemplateData, loc *time.Location) (string, error) {
+	tmpl, err := template.New("notification").Funcs(templateFuncs(loc)).Option("missingkey=error").Parse(body)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+	return out.String(), nil
+}
+
+func bookingTemplateData(booking *models.Bookings) TemplateData {
+	services := []models.Service{booking.Service}
+	if len(booking.Items) > 0 {
+		services = make([]models.Service, 0, len(booking.Items))
+		for _, item := range booking.Items {
+			services = append(services, item.Service)
+		}
+	}
+	return TemplateData{
+		Client:   booking.Client,
+		Booking:  *booking,
+		Service:  booking.Service,
+		Services: services,
+		Barber:   booking.User,
+	}
+}
+
+// sampleTemplateData — пример бронирования для предпросмотра и проверки шаблонов
+func sampleTemplateData() TemplateData {
+	client := models.Client{ID: 1, FirstName: "Иван", LastName: "Петров", Email: "ivan@example.com", PhoneNumber: "+79991234567", Language: models.DefaultLanguage}
+	service := models.Service{ID: 1, Name: "Мужская стрижка", Price: models.NewMoney(150000, "RUB"), Duration: 60, IsActive: true}
+	barber := models.User{ID: 1, Username: "barber", Role: models.RoleBarber}
+	booking := models.Bookings{
+		ID:          1,
+		ClientID:    client.ID,
+		ServiceID:   service.ID,
+		UserID:      barber.ID,
+		BookingTime: time.Date(2025, 3, 14, 15, 30, 0, 0, time.UTC),
+		Status:      models.BookingStatusConfirmed,
+		Client:      client,
+		Service:     service,
+		User:        barber,
+	}
+	return bookingTemplateData(&booking)
+}
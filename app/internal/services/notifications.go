@@ -1,14 +1,20 @@
 package services
 
 import (
+	"errors"
 	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
 	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/repositories"
 )
 
+var (
+	ErrInvalidNotificationType   = errors.New("недопустимый тип уведомления: telegram, sms или email")
+	ErrInvalidNotificationStatus = errors.New("недопустимый статус уведомления: pending, sent, failed или cancelled")
+)
+
 type NotificationService interface {
 	CreateNotification(notification *models.Notification) error
 	GetNotificationByID(id int) (*models.Notification, error)
-	GetAllNotifications() ([]models.Notification, error)
+	GetAllNotifications(query repositories.ListQuery) ([]models.Notification, int64, error)
 	UpdateNotification(id int, input *models.Notification) error
 	DeleteNotification(id int) error
 }
@@ -24,6 +30,16 @@ func NewNotificationService(repo repositories.NotificationRepository) Notificati
 }
 
 func (s *notificationService) CreateNotification(notification *models.Notification) error {
+	if !models.IsValidNotificationType(notification.NotificationType) {
+		return ErrInvalidNotificationType
+	}
+
+	// Поля доставки заполняет диспетчер
+	notification.Status = models.NotificationStatusPending
+	notification.Attempts = 0
+	notification.NextAttemptAt = nil
+	notification.LastError = ""
+	notification.SentAt = nil
 	return s.repo.CreateNotification(notification)
 }
 
@@ -31,8 +47,8 @@ func (s *notificationService) GetNotificationByID(id int) (*models.Notification,
 	return s.repo.GetNotificationByID(id)
 }
 
-func (s *notificationService) GetAllNotifications() ([]models.Notification, error) {
-	return s.repo.GetAllNotifications()
+func (s *notificationService) GetAllNotifications(query repositories.ListQuery) ([]models.Notification, int64, error) {
+	return s.repo.GetAllNotifications(query)
 }
 
 func (s *notificationService) UpdateNotification(id int, input *models.Notification) error {
@@ -41,6 +57,22 @@ func (s *notificationService) UpdateNotification(id int, input *models.Notificat
 		return err
 	}
 
+	if !models.IsValidNotificationType(input.NotificationType) {
+		return ErrInvalidNotificationType
+	}
+	switch input.Status {
+	case models.NotificationStatusPending, models.NotificationStatusSent, models.NotificationStatusFailed, models.NotificationStatusCancelled:
+	default:
+		return ErrInvalidNotificationStatus
+	}
+
+	// Возврат неотправленного уведомления в pending — повторная отправка с первой попытки
+	if input.Status == models.NotificationStatusPending && notification.Status != models.NotificationStatusPending {
+		notification.Attempts = 0
+		notification.NextAttemptAt = nil
+		notification.LastError = ""
+	}
+
 	// Обновляем поля
 	notification.Message = input.Message
 	notification.NotificationType = input.NotificationType
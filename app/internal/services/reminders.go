@@ -0,0 +1,156 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/repositories"
+	"time"
+)
+
+// Напоминания по умолчанию: за сутки и за два часа до визита; канал — первый доступный у клиента
+var (
+	defaultReminderOffsets  = []time.Duration{24 * time.Hour, 2 * time.Hour}
+	defaultReminderChannels = []string{models.NotificationTypeTelegram, models.NotificationTypeSMS, models.NotificationTypeEmail}
+)
+
+// ReminderService ставит в очередь уведомления клиенту о бронировании: напоминания перед визитом
+// и сообщения о смене статуса. Тексты берутся из шаблонов на языке клиента.
+type ReminderService interface {
+	ScheduleBookingReminders(booking *models.Bookings) error
+	CancelBookingReminders(bookingID int) error
+	EnqueueBookingNotification(bookingID int, templateCode string) error
+}
+
+type reminderService struct {
+	notificationRepo repositories.NotificationRepository
+	bookingRepo      repositories.BookingRepository
+	templates        NotificationTemplateService
+	offsets          []time.Duration
+	channels         []string
+	now              func() time.Time
+}
+
+// NewReminderService создает сервис напоминаний. offsets — за сколько до BookingTime отправлять
+// напоминания, channels — каналы в порядке предпочтения; пустые значения заменяются значениями по умолчанию.
+func NewReminderService(
+	notificationRepo repositories.NotificationRepository,
+	bookingRepo repositories.BookingRepository,
+	templates NotificationTemplateService,
+	offsets []time.Duration,
+	channels []string,
+) ReminderService {
+	if len(offsets) == 0 {
+		offsets = defaultReminderOffsets
+	}
+	if len(channels) == 0 {
+		channels = defaultReminderChannels
+	}
+	return &reminderService{
+		notificationRepo: notificationRepo,
+		bookingRepo:      bookingRepo,
+		templates:        templates,
+		offsets:          offsets,
+		channels:         channels,
+		now:              time.Now,
+	}
+}
+
+// ScheduleBookingReminders заменяет неотправленные напоминания бронирования новыми,
+// рассчитанными от текущего BookingTime. Напоминания, время которых уже прошло, не создаются.
+func (s *reminderService) ScheduleBookingReminders(booking *models.Bookings) error {
+	// Для текста нужны клиент, услуга и сотрудник
+	full, err := s.bookingRepo.GetBookingByID(booking.ID)
+	if err != nil {
+		return err
+	}
+
+	channel := s.channelFor(&full.Client)
+	if channel == "" {
+		// Связаться с клиентом нельзя: старые напоминания все равно отменяются
+		return s.notificationRepo.ReplaceBookingReminders(full.ID, nil)
+	}
+
+	data := bookingTemplateData(full)
+	now := s.now()
+	reminders := make([]models.Notification, 0, len(s.offsets))
+	for _, offset := range s.offsets {
+		scheduledFor := full.BookingTime.Add(-offset)
+		if !scheduledFor.After(now) {
+			continue
+		}
+
+		text, err := s.renderReminder(offset, full.Client.Language, data)
+		if err != nil {
+			return err
+		}
+		reminders = append(reminders, s.notification(full, channel, text, &scheduledFor))
+	}
+
+	return s.notificationRepo.ReplaceBookingReminders(full.ID, reminders)
+}
+
+// CancelBookingReminders отменяет все неотправленные уведомления бронирования
+func (s *reminderService) CancelBookingReminders(bookingID int) error {
+	return s.notificationRepo.CancelBookingNotifications(bookingID)
+}
+
+// EnqueueBookingNotification ставит в очередь немедленное уведомление по шаблону templateCode
+func (s *reminderService) EnqueueBookingNotification(bookingID int, templateCode string) error {
+	booking, err := s.bookingRepo.GetBookingByID(bookingID)
+	if err != nil {
+		return err
+	}
+
+	channel := s.channelFor(&booking.Client)
+	if channel == "" {
+		return nil
+	}
+
+	text, err := s.templates.Render(templateCode, booking.Client.Language, bookingTemplateData(booking))
+	if err != nil {
+		return err
+	}
+	notification := s.notification(booking, channel, text, nil)
+	return s.notificationRepo.CreateNotification(&notification)
+}
+
+// renderReminder использует шаблон для конкретного интервала (reminder_24h, reminder_2h),
+// а если его нет — общий шаблон reminder
+func (s *reminderService) renderReminder(offset time.Duration, language string, data TemplateData) (string, error) {
+	text, err := s.templates.Render(reminderTemplateCode(offset), language, data)
+	if errors.Is(err, repositories.ErrTemplateNotFound) {
+		return s.templates.Render(models.TemplateReminder, language, data)
+	}
+	return text, err
+}
+
+func (s *reminderService) notification(booking *models.Bookings, channel, text string, scheduledFor *time.Time) models.Notification {
+	bookingID := booking.ID
+	return models.Notification{
+		ClientID:         booking.ClientID,
+		BookingID:        &bookingID,
+		Message:          text,
+		NotificationType: channel,
+		Status:           models.NotificationStatusPending,
+		ScheduledFor:     scheduledFor,
+	}
+}
+
+// channelFor выбирает первый канал из настроенных, по которому у клиента есть адрес
+func (s *reminderService) channelFor(client *models.Client) string {
+	for _, channel := range s.channels {
+		if client.ContactFor(channel) != "" {
+			return channel
+		}
+	}
+	return ""
+}
+
+// reminderTemplateCode — код шаблона для интервала: 24h → reminder_24h, 30m → reminder_30m
+func reminderTemplateCode(offset time.Duration) string {
+	if offset%time.Hour == 0 {
+		return fmt.Sprintf("%s_%dh", models.TemplateReminder, int(offset/time.Hour))
+	}
+	return fmt.Sprintf("%s_%dm", models.TemplateReminder, int(offset/time.Minute))
+}
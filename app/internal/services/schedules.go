@@ -1,60 +1,417 @@
 package services
 
 import (
+	"errors"
 	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
 	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/repositories"
+	"sort"
+	"time"
 )
 
+var (
+	ErrInvalidScheduleTime  = errors.New("некорректное время в расписании: начало должно быть раньше конца")
+	ErrInvalidScheduleDay   = errors.New("некорректный день недели в расписании")
+	ErrInvalidOverrideDate  = errors.New("некорректная дата исключения, ожидается ГГГГ-ММ-ДД")
+	ErrInvalidOverrideKind  = errors.New("некорректный вид исключения: допустимы day_off, hours, extra")
+	ErrInvalidOverrideHours = errors.New("для выходного (day_off) время не указывается")
+)
+
+// dateLayout — формат дат (ГГГГ-ММ-ДД) в исключениях из расписания и вхождениях перерывов
+const dateLayout = "2006-01-02"
+
+// calendarDay возвращает полночь календарной даты date в часовом поясе loc
+func calendarDay(date time.Time, loc *time.Location) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, loc)
+}
+
+// WorkingInterval — рабочий интервал сотрудника [Start, End)
+type WorkingInterval struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
 type ScheduleService interface {
-	CreateSchedule(schedule *models.Schedule) error
+	CreateSchedule(actorID int, schedule *models.Schedule) error
 	GetScheduleByID(id int) (*models.Schedule, error)
-	GetAllSchedules() ([]models.Schedule, error)
-	UpdateSchedule(id int, input *models.Schedule) error
-	DeleteSchedule(id int) error
+	GetAllSchedules(query repositories.ListQuery) ([]models.Schedule, int64, error)
+	UpdateSchedule(actorID, id int, input *models.Schedule) error
+	DeleteSchedule(actorID, id int) error
 	FilterSchedulesByUser(userID int) ([]models.Schedule, error)
+
+	CreateOverride(actorID int, override *models.ScheduleOverride) error
+	GetOverrideByID(id int) (*models.ScheduleOverride, error)
+	GetAllOverrides(query repositories.ListQuery) ([]models.ScheduleOverride, int64, error)
+	UpdateOverride(actorID, id int, input *models.ScheduleOverride) error
+	DeleteOverride(actorID, id int) error
+
+	// WorkingIntervals возвращает рабочие интервалы сотрудника в филиале locationID в день date
+	// с учетом исключений и часов работы филиала; locationID 0 — во всех филиалах.
+	// От date берется только календарная дата, часы расписания отсчитываются в часовом поясе филиала.
+	WorkingIntervals(locationID, userID int, date time.Time) ([]WorkingInterval, error)
+	// WorkingIntervalsByUser возвращает рабочие интервалы всех сотрудников, работающих в филиале в день date
+	WorkingIntervalsByUser(locationID int, date time.Time) (map[int][]WorkingInterval, error)
 }
 
 type scheduleService struct {
-	repo repositories.ScheduleRepository
+	repo         repositories.ScheduleRepository
+	overrideRepo repositories.ScheduleOverrideRepository
+	history      HistoryService
+	locations    LocationService
 }
 
-func NewScheduleService(repo repositories.ScheduleRepository) ScheduleService {
+func NewScheduleService(
+	repo repositories.ScheduleRepository,
+	overrideRepo repositories.ScheduleOverrideRepository,
+	history HistoryService,
+	locations LocationService,
+) ScheduleService {
 	return &scheduleService{
-		repo: repo,
+		repo:         repo,
+		overrideRepo: overrideRepo,
+		history:      history,
+		locations:    locations,
 	}
 }
 
-func (s *scheduleService) CreateSchedule(schedule *models.Schedule) error {
-	// Дополнительная бизнес-логика перед созданием расписания (если требуется)
-	return s.repo.CreateSchedule(schedule)
+func (s *scheduleService) CreateSchedule(actorID int, schedule *models.Schedule) error {
+	if err := validateSchedule(schedule); err != nil {
+		return err
+	}
+	if err := s.repo.CreateSchedule(schedule); err != nil {
+		return err
+	}
+
+	s.history.Record(actorID, models.HistoryActionCreate, models.EntitySchedule, schedule.ID, nil, schedule)
+	return nil
 }
 
 func (s *scheduleService) GetScheduleByID(id int) (*models.Schedule, error) {
 	return s.repo.GetScheduleByID(id)
 }
 
-func (s *scheduleService) GetAllSchedules() ([]models.Schedule, error) {
-	return s.repo.GetAllSchedules()
+func (s *scheduleService) GetAllSchedules(query repositories.ListQuery) ([]models.Schedule, int64, error) {
+	return s.repo.GetAllSchedules(query)
 }
 
-func (s *scheduleService) UpdateSchedule(id int, input *models.Schedule) error {
+func (s *scheduleService) UpdateSchedule(actorID, id int, input *models.Schedule) error {
 	schedule, err := s.repo.GetScheduleByID(id)
 	if err != nil {
 		return err
 	}
+	before := *schedule
 
 	// Обновляем поля
+	schedule.LocationID = input.LocationID
 	schedule.ScheduleDay = input.ScheduleDay
 	schedule.StartTime = input.StartTime
 	schedule.EndTime = input.EndTime
 
-	return s.repo.UpdateSchedule(schedule)
+	if err := validateSchedule(schedule); err != nil {
+		return err
+	}
+	if err := s.repo.UpdateSchedule(schedule); err != nil {
+		return err
+	}
+
+	s.history.Record(actorID, models.HistoryActionUpdate, models.EntitySchedule, schedule.ID, &before, schedule)
+	return nil
 }
 
-func (s *scheduleService) DeleteSchedule(id int) error {
-	return s.repo.DeleteSchedule(id)
+func (s *scheduleService) DeleteSchedule(actorID, id int) error {
+	schedule, err := s.repo.GetScheduleByID(id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteSchedule(id); err != nil {
+		return err
+	}
+
+	s.history.Record(actorID, models.HistoryActionDelete, models.EntitySchedule, id, schedule, nil)
+	return nil
 }
 
 func (s *scheduleService) FilterSchedulesByUser(userID int) ([]models.Schedule, error) {
 	return s.repo.FilterSchedulesByUser(userID)
 }
+
+func (s *scheduleService) CreateOverride(actorID int, override *models.ScheduleOverride) error {
+	if err := validateOverride(override); err != nil {
+		return err
+	}
+	if err := s.overrideRepo.CreateOverride(override); err != nil {
+		return err
+	}
+
+	s.history.Record(actorID, models.HistoryActionCreate, models.EntityScheduleOverride, override.ID, nil, override)
+	return nil
+}
+
+func (s *scheduleService) GetOverrideByID(id int) (*models.ScheduleOverride, error) {
+	return s.overrideRepo.GetOverrideByID(id)
+}
+
+func (s *scheduleService) GetAllOverrides(query repositories.ListQuery) ([]models.ScheduleOverride, int64, error) {
+	return s.overrideRepo.GetAllOverrides(query)
+}
+
+func (s *scheduleService) UpdateOverride(actorID, id int, input *models.ScheduleOverride) error {
+	override, err := s.overrideRepo.GetOverrideByID(id)
+	if err != nil {
+		return err
+	}
+	before := *override
+
+	override.Date = input.Date
+	override.LocationID = input.LocationID
+	override.Kind = input.Kind
+	override.StartTime = input.StartTime
+	override.EndTime = input.EndTime
+	override.Reason = input.Reason
+
+	if err := validateOverride(override); err != nil {
+		return err
+	}
+	if err := s.overrideRepo.UpdateOverride(override); err != nil {
+		return err
+	}
+
+	s.history.Record(actorID, models.HistoryActionUpdate, models.EntityScheduleOverride, override.ID, &before, override)
+	return nil
+}
+
+func (s *scheduleService) DeleteOverride(actorID, id int) error {
+	override, err := s.overrideRepo.GetOverrideByID(id)
+	if err != nil {
+		return err
+	}
+	if err := s.overrideRepo.DeleteOverride(id); err != nil {
+		return err
+	}
+
+	s.history.Record(actorID, models.HistoryActionDelete, models.EntityScheduleOverride, id, override, nil)
+	return nil
+}
+
+func (s *scheduleService) WorkingIntervals(locationID, userID int, date time.Time) ([]WorkingInterval, error) {
+	weekly, err := s.repo.FilterSchedulesByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := s.overrideRepo.GetOverridesByDate(userID, date.Format(dateLayout))
+	if err != nil {
+		return nil, err
+	}
+
+	day := models.WeekdayOf(date)
+	sameDay := make([]models.Schedule, 0, len(weekly))
+	for _, schedule := range weekly {
+		if schedule.ScheduleDay == day {
+			sameDay = append(sameDay, schedule)
+		}
+	}
+	return s.resolveAtLocations(locationID, sameDay, overrides, date)
+}
+
+func (s *scheduleService) WorkingIntervalsByUser(locationID int, date time.Time) (map[int][]WorkingInterval, error) {
+	weekly, err := s.repo.GetSchedulesByDay(models.WeekdayOf(date))
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := s.overrideRepo.GetOverridesByDate(0, date.Format(dateLayout))
+	if err != nil {
+		return nil, err
+	}
+
+	weeklyByUser := make(map[int][]models.Schedule)
+	for _, schedule := range weekly {
+		weeklyByUser[schedule.UserID] = append(weeklyByUser[schedule.UserID], schedule)
+	}
+	overridesByUser := make(map[int][]models.ScheduleOverride)
+	for _, override := range overrides {
+		overridesByUser[override.UserID] = append(overridesByUser[override.UserID], override)
+	}
+
+	userIDs := make(map[int]bool, len(weeklyByUser)+len(overridesByUser))
+	for userID := range weeklyByUser {
+		userIDs[userID] = true
+	}
+	for userID := range overridesByUser {
+		userIDs[userID] = true
+	}
+
+	result := make(map[int][]WorkingInterval)
+	for userID := range userIDs {
+		work, err := s.resolveAtLocations(locationID, weeklyByUser[userID], overridesByUser[userID], date)
+		if err != nil {
+			return nil, err
+		}
+		if len(work) > 0 {
+			result[userID] = work
+		}
+	}
+	return result, nil
+}
+
+// resolveAtLocations вычисляет рабочие интервалы сотрудника отдельно по каждому филиалу:
+// в часовом поясе филиала, с его исключениями и часами работы. Исключения без филиала
+// действуют во всех филиалах. Если locationID не 0, учитывается только этот филиал.
+func (s *scheduleService) resolveAtLocations(locationID int, weekly []models.Schedule, overrides []models.ScheduleOverride, date time.Time) ([]WorkingInterval, error) {
+	weeklyByLocation := make(map[int][]models.Schedule)
+	for _, schedule := range weekly {
+		if locationID == 0 || schedule.LocationID == locationID {
+			weeklyByLocation[schedule.LocationID] = append(weeklyByLocation[schedule.LocationID], schedule)
+		}
+	}
+
+	var common []models.ScheduleOverride
+	overridesByLocation := make(map[int][]models.ScheduleOverride)
+	for _, override := range overrides {
+		switch {
+		case override.LocationID == 0:
+			common = append(common, override)
+		case locationID == 0 || override.LocationID == locationID:
+			overridesByLocation[override.LocationID] = append(overridesByLocation[override.LocationID], override)
+		}
+	}
+
+	locationIDs := make(map[int]bool)
+	for id := range weeklyByLocation {
+		locationIDs[id] = true
+	}
+	for id := range overridesByLocation {
+		locationIDs[id] = true
+	}
+	if len(locationIDs) == 0 && len(common) > 0 {
+		locationIDs[locationID] = true
+	}
+
+	var work []WorkingInterval
+	for id := range locationIDs {
+		location, zone, err := s.locations.Resolve(id)
+		if err != nil {
+			return nil, err
+		}
+		day := calendarDay(date, zone)
+
+		dayOverrides := append(append([]models.ScheduleOverride(nil), overridesByLocation[id]...), common...)
+		intervals := resolveWorkingIntervals(weeklyByLocation[id], dayOverrides, day)
+		work = append(work, clipToOpeningHours(intervals, location, day)...)
+	}
+	return mergeIntervals(work), nil
+}
+
+// resolveWorkingIntervals применяет исключения на дату к еженедельным часам этого дня недели:
+// day_off отменяет еженедельные часы, hours заменяет их, extra добавляет смену.
+// Пересекающиеся и смежные интервалы объединяются.
+func resolveWorkingIntervals(weekly []models.Schedule, overrides []models.ScheduleOverride, date time.Time) []WorkingInterval {
+	var dayOff bool
+	var hours, extra []WorkingInterval
+	for _, override := range overrides {
+		switch override.Kind {
+		case models.ScheduleOverrideDayOff:
+			dayOff = true
+		case models.ScheduleOverrideHours:
+			hours = append(hours, timeRange(override.StartTime, override.EndTime, date))
+		case models.ScheduleOverrideExtra:
+			extra = append(extra, timeRange(override.StartTime, override.EndTime, date))
+		}
+	}
+
+	var work []WorkingInterval
+	switch {
+	case dayOff:
+	case len(hours) > 0:
+		work = hours
+	default:
+		for _, schedule := range weekly {
+			work = append(work, timeRange(schedule.StartTime, schedule.EndTime, date))
+		}
+	}
+	return mergeIntervals(append(work, extra...))
+}
+
+// clipToOpeningHours оставляет от рабочих интервалов только время, когда филиал открыт в день day.
+// Если часы работы филиала не заданы, интервалы не меняются.
+func clipToOpeningHours(work []WorkingInterval, location *models.Location, day time.Time) []WorkingInterval {
+	if location == nil || len(location.OpeningHours) == 0 {
+		return work
+	}
+
+	weekday := models.WeekdayOf(day)
+	var clipped []WorkingInterval
+	for _, hours := range location.OpeningHours {
+		if hours.Day != weekday {
+			continue
+		}
+		open := timeRange(hours.Open, hours.Close, day)
+		for _, w := range work {
+			start, end := w.Start, w.End
+			if open.Start.After(start) {
+				start = open.Start
+			}
+			if open.End.Before(end) {
+				end = open.End
+			}
+			if start.Before(end) {
+				clipped = append(clipped, WorkingInterval{Start: start, End: end})
+			}
+		}
+	}
+	return clipped
+}
+
+func timeRange(start, end models.TimeOfDay, date time.Time) WorkingInterval {
+	return WorkingInterval{Start: start.On(date), End: end.On(date)}
+}
+
+func mergeIntervals(intervals []WorkingInterval) []WorkingInterval {
+	if len(intervals) == 0 {
+		return nil
+	}
+	sort.Slice(intervals, func(i, j int) bool {
+		return intervals[i].Start.Before(intervals[j].Start)
+	})
+
+	merged := []WorkingInterval{intervals[0]}
+	for _, next := range intervals[1:] {
+		last := &merged[len(merged)-1]
+		if next.Start.After(last.End) {
+			merged = append(merged, next)
+			continue
+		}
+		if next.End.After(last.End) {
+			last.End = next.End
+		}
+	}
+	return merged
+}
+
+func validateSchedule(schedule *models.Schedule) error {
+	if !schedule.ScheduleDay.IsValid() {
+		return ErrInvalidScheduleDay
+	}
+	return validateTimeRange(schedule.StartTime, schedule.EndTime)
+}
+
+func validateOverride(override *models.ScheduleOverride) error {
+	if _, err := time.Parse(dateLayout, override.Date); err != nil {
+		return ErrInvalidOverrideDate
+	}
+	if !models.IsValidScheduleOverrideKind(override.Kind) {
+		return ErrInvalidOverrideKind
+	}
+	if override.Kind == models.ScheduleOverrideDayOff {
+		if override.StartTime != 0 || override.EndTime != 0 {
+			return ErrInvalidOverrideHours
+		}
+		return nil
+	}
+	return validateTimeRange(override.StartTime, override.EndTime)
+}
+
+func validateTimeRange(start, end models.TimeOfDay) error {
+	if !start.IsValid() || !end.IsValid() || start >= end {
+		return ErrInvalidScheduleTime
+	}
+	return nil
+}
@@ -1,47 +1,97 @@
 package services
 
 import (
+	"errors"
 	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
 	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/repositories"
+	"sort"
 )
 
+var (
+	ErrInvalidServicePrice    = errors.New("цена должна быть больше нуля")
+	ErrInvalidServiceDuration = errors.New("длительность должна быть от 1 минуты до суток")
+)
+
+// maxServiceDuration — наибольшая длительность услуги у сотрудника в минутах
+const maxServiceDuration = 24 * 60
+
 type ServiceService interface {
-	CreateService(service *models.Service) error
+	CreateService(actorID int, service *models.Service) error
 	GetServiceByID(id int) (*models.Service, error)
-	GetAllServices() ([]models.Service, error)
-	UpdateService(id int, input *models.Service) error
-	DeleteService(id int) error
-	DeactivateService(id int) error
+	GetAllServices(query repositories.ListQuery) ([]models.Service, int64, error)
+	UpdateService(actorID, id int, input *models.Service) error
+	DeleteService(actorID, id int) error
+	DeactivateService(actorID, id int) error
+
+	// SetLocationPrice задает цену услуги в филиале и возвращает услугу с обновленными ценами
+	// Цена филиала задается в валюте услуги
+	SetLocationPrice(actorID, serviceID, locationID int, price models.Money) (*models.Service, error)
+	// DeleteLocationPrice возвращает услуге в филиале базовую цену
+	DeleteLocationPrice(actorID, serviceID, locationID int) error
+
+	// AssignBarber назначает услугу сотруднику с необязательными индивидуальными ценой и длительностью
+	AssignBarber(actorID int, assignment *models.BarberService) error
+	UnassignBarber(actorID, serviceID, userID int) error
+	// GetServiceBarbers возвращает сотрудников, выполняющих услугу, с их ценами и длительностями
+	GetServiceBarbers(serviceID int) ([]models.BarberService, error)
 }
 
 type serviceService struct {
-	repo repositories.ServiceRepository
+	repo         repositories.ServiceRepository
+	locationRepo repositories.LocationRepository
+	userRepo     repositories.UserRepository
+	history      HistoryService
+	staff        staffEligibility
 }
 
-func NewServiceService(repo repositories.ServiceRepository) ServiceService {
+// NewServiceService создает сервис услуг. allowUnassigned разрешает барберам без назначения
+// выполнять любую услугу по ее цене и длительности (см. staffEligibility).
+func NewServiceService(
+	repo repositories.ServiceRepository,
+	locationRepo repositories.LocationRepository,
+	userRepo repositories.UserRepository,
+	history HistoryService,
+	allowUnassigned bool,
+) ServiceService {
 	return &serviceService{
-		repo: repo,
+		repo:         repo,
+		locationRepo: locationRepo,
+		userRepo:     userRepo,
+		history:      history,
+		staff:        staffEligibility{serviceRepo: repo, userRepo: userRepo, allowUnassigned: allowUnassigned},
 	}
 }
 
-func (s *serviceService) CreateService(service *models.Service) error {
+func (s *serviceService) CreateService(actorID int, service *models.Service) error {
 	// Дополнительная бизнес-логика перед созданием услуги (если требуется)
-	return s.repo.CreateService(service)
+	if err := s.repo.CreateService(service); err != nil {
+		return err
+	}
+
+	s.history.Record(actorID, models.HistoryActionCreate, models.EntityService, service.ID, nil, service)
+	return nil
 }
 
 func (s *serviceService) GetServiceByID(id int) (*models.Service, error) {
 	return s.repo.GetServiceByID(id)
 }
 
-func (s *serviceService) GetAllServices() ([]models.Service, error) {
-	return s.repo.GetAllServices()
+func (s *serviceService) GetAllServices(query repositories.ListQuery) ([]models.Service, int64, error) {
+	return s.repo.GetAllServices(query)
 }
 
-func (s *serviceService) UpdateService(id int, input *models.Service) error {
+func (s *serviceService) UpdateService(actorID, id int, input *models.Service) error {
 	service, err := s.repo.GetServiceByID(id)
 	if err != nil {
 		return err
 	}
+	before := *service
+
+	if input.Price.Currency != service.Price.Currency {
+		if err := s.ensureNoCustomPrices(service); err != nil {
+			return err
+		}
+	}
 
 	// Обновляем поля
 	service.Name = input.Name
@@ -50,13 +100,215 @@ func (s *serviceService) UpdateService(id int, input *models.Service) error {
 	service.Duration = input.Duration
 	service.IsActive = input.IsActive
 
-	return s.repo.UpdateService(service)
+	if err := s.repo.UpdateService(service); err != nil {
+		return err
+	}
+
+	s.history.Record(actorID, models.HistoryActionUpdate, models.EntityService, service.ID, &before, service)
+	return nil
 }
 
-func (s *serviceService) DeleteService(id int) error {
-	return s.repo.DeleteService(id)
+func (s *serviceService) DeleteService(actorID, id int) error {
+	service, err := s.repo.GetServiceByID(id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteService(id); err != nil {
+		return err
+	}
+
+	s.history.Record(actorID, models.HistoryActionDelete, models.EntityService, id, service, nil)
+	return nil
+}
+
+func (s *serviceService) DeactivateService(actorID, id int) error {
+	service, err := s.repo.GetServiceByID(id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeactivateService(id); err != nil {
+		return err
+	}
+
+	after := *service
+	after.IsActive = false
+	s.history.Record(actorID, models.HistoryActionUpdate, models.EntityService, id, service, &after)
+	return nil
+}
+
+func (s *serviceService) SetLocationPrice(actorID, serviceID, locationID int, price models.Money) (*models.Service, error) {
+	if !price.IsPositive() {
+		return nil, ErrInvalidServicePrice
+	}
+	service, err := s.repo.GetServiceByID(serviceID)
+	if err != nil {
+		return nil, err
+	}
+	if price.Currency != service.Price.Currency {
+		return nil, models.ErrCurrencyMismatch
+	}
+	if _, err := s.locationRepo.GetLocationByID(locationID); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.SetLocationPrice(&models.ServiceLocationPrice{ServiceID: serviceID, LocationID: locationID, Price: price}); err != nil {
+		return nil, err
+	}
+	return s.recordPriceChange(actorID, service)
+}
+
+func (s *serviceService) DeleteLocationPrice(actorID, serviceID, locationID int) error {
+	service, err := s.repo.GetServiceByID(serviceID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteLocationPrice(serviceID, locationID); err != nil {
+		return err
+	}
+
+	_, err = s.recordPriceChange(actorID, service)
+	return err
+}
+
+// ensureNoCustomPrices запрещает смену валюты услуги, пока заданы цены филиалов или сотрудников в прежней валюте
+func (s *serviceService) ensureNoCustomPrices(service *models.Service) error {
+	if len(service.LocationPrices) > 0 {
+		return models.ErrCurrencyMismatch
+	}
+	assignments, err := s.repo.GetServiceBarbers(service.ID)
+	if err != nil {
+		return err
+	}
+	for _, assignment := range assignments {
+		if assignment.Price != nil {
+			return models.ErrCurrencyMismatch
+		}
+	}
+	return nil
 }
 
-func (s *serviceService) DeactivateService(id int) error {
-	return s.repo.DeactivateService(id)
+// recordPriceChange записывает в журнал изменение цен услуги в филиалах и возвращает услугу после изменения
+func (s *serviceService) recordPriceChange(actorID int, before *models.Service) (*models.Service, error) {
+	after, err := s.repo.GetServiceByID(before.ID)
+	if err != nil {
+		return nil, err
+	}
+	s.history.Record(actorID, models.HistoryActionUpdate, models.EntityService, before.ID, before, after)
+	return after, nil
+}
+
+func (s *serviceService) AssignBarber(actorID int, assignment *models.BarberService) error {
+	if assignment.Price != nil && !assignment.Price.IsPositive() {
+		return ErrInvalidServicePrice
+	}
+	if assignment.Duration != nil && (*assignment.Duration <= 0 || *assignment.Duration > maxServiceDuration) {
+		return ErrInvalidServiceDuration
+	}
+	service, err := s.repo.GetServiceByID(assignment.ServiceID)
+	if err != nil {
+		return err
+	}
+	if assignment.Price != nil && assignment.Price.Currency != service.Price.Currency {
+		return models.ErrCurrencyMismatch
+	}
+	if _, err := s.userRepo.GetUserByID(assignment.UserID); err != nil {
+		return err
+	}
+
+	before, err := s.repo.GetBarberService(assignment.ServiceID, assignment.UserID)
+	if err != nil && !errors.Is(err, repositories.ErrBarberServiceNotFound) {
+		return err
+	}
+	if err := s.repo.AssignBarber(assignment); err != nil {
+		return err
+	}
+
+	after, err := s.repo.GetBarberService(assignment.ServiceID, assignment.UserID)
+	if err != nil {
+		return err
+	}
+	*assignment = *after
+	if before == nil {
+		s.history.Record(actorID, models.HistoryActionCreate, models.EntityBarberService, after.ID, nil, after)
+	} else {
+		s.history.Record(actorID, models.HistoryActionUpdate, models.EntityBarberService, after.ID, before, after)
+	}
+	return nil
+}
+
+func (s *serviceService) UnassignBarber(actorID, serviceID, userID int) error {
+	assignment, err := s.repo.GetBarberService(serviceID, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.UnassignBarber(serviceID, userID); err != nil {
+		return err
+	}
+
+	s.history.Record(actorID, models.HistoryActionDelete, models.EntityBarberService, assignment.ID, assignment, nil)
+	return nil
+}
+
+func (s *serviceService) GetServiceBarbers(serviceID int) ([]models.BarberService, error) {
+	if _, err := s.repo.GetServiceByID(serviceID); err != nil {
+		return nil, err
+	}
+	return s.staff.serviceStaff(serviceID)
+}
+
+// staffEligibility определяет, какие сотрудники выполняют услугу. Услугу выполняют сотрудники,
+// которым она назначена (barber_services). Если allowUnassigned включен (app.allow_unassigned_staff),
+// ее выполняют и барберы без назначения — по цене и длительности самой услуги; владельцы,
+// администраторы и ресепшн без назначения услугу не выполняют.
+type staffEligibility struct {
+	serviceRepo     repositories.ServiceRepository
+	userRepo        repositories.UserRepository
+	allowUnassigned bool
+}
+
+// serviceStaff возвращает условия услуги у всех сотрудников, которые ее выполняют, с загруженными сотрудниками
+func (e staffEligibility) serviceStaff(serviceID int) ([]models.BarberService, error) {
+	assignments, err := e.serviceRepo.GetServiceBarbers(serviceID)
+	if err != nil || !e.allowUnassigned {
+		return assignments, err
+	}
+
+	assigned := make(map[int]bool, len(assignments))
+	for _, assignment := range assignments {
+		assigned[assignment.UserID] = true
+	}
+	barbers, err := e.userRepo.GetUsersByRole(models.RoleBarber)
+	if err != nil {
+		return nil, err
+	}
+	for i := range barbers {
+		if !assigned[barbers[i].ID] {
+			assignments = append(assignments, models.BarberService{ServiceID: serviceID, UserID: barbers[i].ID, User: &barbers[i]})
+		}
+	}
+	sort.Slice(assignments, func(i, j int) bool { return assignments[i].UserID < assignments[j].UserID })
+	return assignments, nil
+}
+
+// staffTerms возвращает условия услуги у сотрудника userID; если он ее не выполняет — ErrBarberNotAssigned
+func (e staffEligibility) staffTerms(serviceID, userID int) (*models.BarberService, error) {
+	assignment, err := e.serviceRepo.GetBarberService(serviceID, userID)
+	if !errors.Is(err, repositories.ErrBarberServiceNotFound) {
+		return assignment, err
+	}
+	if !e.allowUnassigned {
+		return nil, ErrBarberNotAssigned
+	}
+
+	user, err := e.userRepo.GetUserByID(userID)
+	if errors.Is(err, repositories.ErrUserNotFound) {
+		return nil, ErrBarberNotAssigned
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user.Role != models.RoleBarber {
+		return nil, ErrBarberNotAssigned
+	}
+	return &models.BarberService{ServiceID: serviceID, UserID: userID}, nil
 }
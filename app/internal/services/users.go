@@ -4,35 +4,66 @@ import (
 	"errors"
 	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
 	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/repositories"
+	"strings"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
+var (
+	ErrInvalidRole        = errors.New("недопустимая роль: owner, admin, barber или receptionist")
+	ErrInvalidCredentials = errors.New("неверные учетные данные")
+	ErrRegistrationClosed = errors.New("регистрация закрыта: сотрудников создает администратор")
+)
+
 type UserService interface {
-	CreateUser(user *models.User) error
+	CreateUser(actorID int, user *models.User) error
 	GetUserByID(id int) (*models.User, error)
-	GetAllUsers() ([]models.User, error)
-	UpdateUser(id int, input *models.User) error
-	DeleteUser(id int) error
+	GetAllUsers(query repositories.ListQuery) ([]models.User, int64, error)
+	UpdateUser(actorID, id int, input *models.User) error
+	DeleteUser(actorID, id int) error
 	AuthenticateUser(identifier, password string) (*models.User, error)
+	RegisterOwner(user *models.User) error
 }
 
 type userService struct {
-	repo repositories.UserRepository
+	repo    repositories.UserRepository
+	history HistoryService
 }
 
-func NewUserService(repo repositories.UserRepository) UserService {
+func NewUserService(repo repositories.UserRepository, history HistoryService) UserService {
 	return &userService{
-		repo: repo,
+		repo:    repo,
+		history: history,
+	}
+}
+
+func (s *userService) CreateUser(actorID int, user *models.User) error {
+	if err := s.prepareUser(user); err != nil {
+		return err
 	}
+	if err := s.repo.CreateUser(user); err != nil {
+		return err
+	}
+
+	s.history.Record(actorID, models.HistoryActionCreate, models.EntityUser, user.ID, nil, user)
+	return nil
 }
 
-func (s *userService) CreateUser(user *models.User) error {
+// prepareUser проверяет нового сотрудника, нормализует контакты и хеширует пароль
+func (s *userService) prepareUser(user *models.User) error {
 	// Проверка обязательных полей
 	if user.Username == "" || user.PasswordHash == "" || user.Role == "" {
 		return errors.New("обязательные поля: Username, Password и Role")
 	}
 
+	if !models.IsValidRole(user.Role) {
+		return ErrInvalidRole
+	}
+	if err := normalizeUserContacts(user); err != nil {
+		return err
+	}
+
 	// Проверка уникальности Username и Email
 	if _, err := s.repo.GetUserByUsername(user.Username); err == nil {
 		return errors.New("пользователь с таким Username уже существует")
@@ -50,23 +81,26 @@ func (s *userService) CreateUser(user *models.User) error {
 		return errors.New("не удалось хешировать пароль")
 	}
 	user.PasswordHash = string(hashedPassword)
-
-	return s.repo.CreateUser(user)
+	return nil
 }
 
 func (s *userService) GetUserByID(id int) (*models.User, error) {
 	return s.repo.GetUserByID(id)
 }
 
-func (s *userService) GetAllUsers() ([]models.User, error) {
-	return s.repo.GetAllUsers()
+func (s *userService) GetAllUsers(query repositories.ListQuery) ([]models.User, int64, error) {
+	return s.repo.GetAllUsers(query)
 }
 
-func (s *userService) UpdateUser(id int, input *models.User) error {
+func (s *userService) UpdateUser(actorID, id int, input *models.User) error {
 	user, err := s.repo.GetUserByID(id)
 	if err != nil {
 		return err
 	}
+	before := *user
+	if err := normalizeUserContacts(input); err != nil {
+		return err
+	}
 
 	// Обновление полей
 	if input.Username != "" && input.Username != user.Username {
@@ -94,6 +128,9 @@ func (s *userService) UpdateUser(id int, input *models.User) error {
 	}
 
 	if input.Role != "" {
+		if !models.IsValidRole(input.Role) {
+			return ErrInvalidRole
+		}
 		user.Role = input.Role
 	}
 
@@ -101,31 +138,89 @@ func (s *userService) UpdateUser(id int, input *models.User) error {
 		user.PhoneNumber = input.PhoneNumber
 	}
 
-	return s.repo.UpdateUser(user)
+	if input.LocationID != 0 {
+		user.LocationID = input.LocationID
+	}
+
+	if err := s.repo.UpdateUser(user); err != nil {
+		return err
+	}
+
+	s.history.Record(actorID, models.HistoryActionUpdate, models.EntityUser, user.ID, &before, user)
+	return nil
 }
 
-func (s *userService) DeleteUser(id int) error {
-	return s.repo.DeleteUser(id)
+func (s *userService) DeleteUser(actorID, id int) error {
+	user, err := s.repo.GetUserByID(id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteUser(id); err != nil {
+		return err
+	}
+
+	s.history.Record(actorID, models.HistoryActionDelete, models.EntityUser, id, user, nil)
+	return nil
 }
 
 func (s *userService) AuthenticateUser(identifier, password string) (*models.User, error) {
 	var user *models.User
 	var err error
 
-	if user, err = s.repo.GetUserByEmail(identifier); err != nil {
+	// Email хранится в нижнем регистре; username ищется как введен
+	if user, err = s.repo.GetUserByEmail(strings.ToLower(strings.TrimSpace(identifier))); err != nil {
 		if err != repositories.ErrUserNotFound {
 			return nil, err
 		}
 		// Если не найден по email, пробуем по username
 		if user, err = s.repo.GetUserByUsername(identifier); err != nil {
-			return nil, errors.New("неверные учетные данные")
+			return nil, ErrInvalidCredentials
 		}
 	}
 
 	// Проверка пароля
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
-		return nil, errors.New("неверные учетные данные")
+		return nil, ErrInvalidCredentials
+	}
+
+	now := time.Now()
+	user.LastLoginAt = &now
+	if err := s.repo.UpdateUser(user); err != nil {
+		return nil, err
 	}
 
 	return user, nil
 }
+
+// RegisterOwner создает первого сотрудника с ролью owner.
+// Пока в системе нет ни одного сотрудника, это единственный способ войти;
+// дальнейшие сотрудники создаются через /users.
+func (s *userService) RegisterOwner(user *models.User) error {
+	user.Role = models.RoleOwner
+	if err := s.prepareUser(user); err != nil {
+		return err
+	}
+	if err := s.repo.CreateOwner(user); err != nil {
+		if errors.Is(err, repositories.ErrOwnerExists) {
+			return ErrRegistrationClosed
+		}
+		return err
+	}
+
+	s.history.Record(0, models.HistoryActionCreate, models.EntityUser, user.ID, nil, user)
+	return nil
+}
+
+// normalizeUserContacts приводит телефон сотрудника к E.164, а email — к нижнему регистру и проверяет их формат
+func normalizeUserContacts(user *models.User) error {
+	phone, err := models.NormalizePhone(user.PhoneNumber)
+	if err != nil {
+		return err
+	}
+	email, err := models.NormalizeEmail(user.Email)
+	if err != nil {
+		return err
+	}
+	user.PhoneNumber, user.Email = phone, email
+	return nil
+}
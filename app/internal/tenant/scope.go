@@ -0,0 +1,122 @@
+// Package tenant ограничивает запросы GORM данными одного арендатора (барбершопа).
+// Ограничение задается на уровне соединения: репозитории, созданные поверх Scope(db, id),
+// видят и изменяют только записи арендатора id, а новые записи получают его tenant_id.
+package tenant
+
+import (
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// DefaultID — арендатор, к которому относятся данные однотенантной установки
+const DefaultID = 1
+
+const (
+	settingKey   = "tenant:id"
+	callbackName = "tenant:scope"
+	fieldName    = "TenantID"
+)
+
+// Register подключает к db callbacks, которые применяют ограничение Scope ко всем
+// запросам моделей с полем TenantID. Вызывается один раз после открытия соединения.
+func Register(db *gorm.DB) error {
+	callbacks := db.Callback()
+	if err := callbacks.Create().Before("gorm:create").Register(callbackName, assignTenant); err != nil {
+		return err
+	}
+	if err := callbacks.Query().Before("gorm:query").Register(callbackName, restrictToTenant); err != nil {
+		return err
+	}
+	if err := callbacks.Row().Before("gorm:row").Register(callbackName, restrictToTenant); err != nil {
+		return err
+	}
+	if err := callbacks.Update().Before("gorm:update").Register(callbackName, restrictUpdate); err != nil {
+		return err
+	}
+	return callbacks.Delete().Before("gorm:delete").Register(callbackName, restrictToTenant)
+}
+
+// Scope возвращает соединение, ограниченное данными арендатора tenantID.
+// Результат можно переиспользовать: условие применяется к каждому запросу заново.
+func Scope(db *gorm.DB, tenantID int) *gorm.DB {
+	return db.Set(settingKey, tenantID).Session(&gorm.Session{})
+}
+
+// FromDB возвращает арендатора, которым ограничено соединение
+func FromDB(db *gorm.DB) (int, bool) {
+	value, ok := db.Get(settingKey)
+	if !ok {
+		return 0, false
+	}
+	tenantID, ok := value.(int)
+	return tenantID, ok
+}
+
+// tenantField возвращает поле TenantID модели запроса, если соединение ограничено арендатором
+func tenantField(db *gorm.DB) (*schema.Field, int, bool) {
+	tenantID, ok := FromDB(db)
+	if !ok || db.Statement.Schema == nil {
+		return nil, 0, false
+	}
+	field := db.Statement.Schema.LookUpField(fieldName)
+	if field == nil {
+		return nil, 0, false
+	}
+	return field, tenantID, true
+}
+
+// restrictToTenant добавляет условие tenant_id к выборке, обновлению и удалению
+func restrictToTenant(db *gorm.DB) {
+	field, tenantID, ok := tenantField(db)
+	if !ok {
+		return
+	}
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{condition(field, tenantID)}})
+}
+
+func condition(field *schema.Field, tenantID int) clause.Expression {
+	return clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: tenantID}
+}
+
+// restrictUpdate ограничивает обновление арендатором и не дает Save перенести запись
+// к другому арендатору, если tenant_id в обновляемой структуре не заполнен
+func restrictUpdate(db *gorm.DB) {
+	restrictToTenant(db)
+	assignTenant(db)
+}
+
+// assignTenant проставляет tenant_id создаваемым записям; значение из запроса не учитывается.
+// Upsert (в том числе Save записи, не найденной у арендатора) не перезаписывает чужую запись при конфликте.
+func assignTenant(db *gorm.DB) {
+	field, tenantID, ok := tenantField(db)
+	if !ok {
+		return
+	}
+
+	if c, ok := db.Statement.Clauses["ON CONFLICT"]; ok {
+		if onConflict, ok := c.Expression.(clause.OnConflict); ok && !onConflict.DoNothing {
+			onConflict.Where.Exprs = append(onConflict.Where.Exprs, condition(field, tenantID))
+			c.Expression = onConflict
+			db.Statement.Clauses["ON CONFLICT"] = c
+		}
+	}
+
+	ctx := db.Statement.Context
+	value := reflect.Indirect(db.Statement.ReflectValue)
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			if err := field.Set(ctx, reflect.Indirect(value.Index(i)), tenantID); err != nil {
+				db.AddError(err)
+				return
+			}
+		}
+	case reflect.Struct:
+		if err := field.Set(ctx, value, tenantID); err != nil {
+			db.AddError(err)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+// Package currency описывает поддерживаемые валюты ISO 4217. Пакет не зависит от моделей,
+// поэтому им пользуются и модели, и проверка конфигурации.
+package currency
+
+import "errors"
+
+// Default — валюта установки, если app.currency не задана
+const Default = "RUB"
+
+var ErrUnknown = errors.New("неизвестный код валюты ISO 4217")
+
+// exponents — число знаков после запятой в поддерживаемых валютах
+var exponents = map[string]int{
+	"RUB": 2, "USD": 2, "EUR": 2, "GBP": 2, "CHF": 2, "CNY": 2, "TRY": 2, "AED": 2,
+	"KZT": 2, "BYN": 2, "UAH": 2, "UZS": 2, "KGS": 2, "AMD": 2, "GEL": 2, "AZN": 2,
+	"PLN": 2, "CZK": 2, "RSD": 2, "JPY": 0, "KRW": 0, "KWD": 3, "BHD": 3,
+}
+
+// IsValid проверяет, что код валюты поддерживается
+func IsValid(code string) bool {
+	_, ok := exponents[code]
+	return ok
+}
+
+// Exponent возвращает число знаков после запятой в валюте; для неизвестной валюты — 2
+func Exponent(code string) int {
+	if exponent, ok := exponents[code]; ok {
+		return exponent
+	}
+	return 2
+}
@@ -2,6 +2,7 @@ package db
 
 import (
 	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/tenant"
 	"log"
 
 	"gorm.io/driver/postgres"
@@ -10,7 +11,11 @@ import (
 
 var DB *gorm.DB
 
-func InitDB(dsn string) error {
+// InitDB подключается к базе и применяет миграции. defaultLocation — филиал,
+// который создается при первом запуске и к которому привязываются данные единственного салона;
+// он и существующие данные относятся к арендатору по умолчанию. currency — валюта цен,
+// сохраненных до появления валют.
+func InitDB(dsn string, defaultLocation models.Location, currency string) error {
 	var err error
 
 	// Открываем соединение с базой данных
@@ -19,22 +24,105 @@ func InitDB(dsn string) error {
 		return err
 	}
 
+	// Ограничение запросов арендатором (tenant.Scope)
+	if err := tenant.Register(DB); err != nil {
+		return err
+	}
+
+	// Устаревший журнал history_logs несовместим с новой схемой и пересоздается
+	if err := DropLegacyHistoryLogs(DB); err != nil {
+		return err
+	}
+
+	if err := DropGlobalUniqueConstraints(DB); err != nil {
+		return err
+	}
+
+	// Старые расписания приводятся к формату новых колонок до изменения их типов
+	if err := NormalizeSchedules(DB); err != nil {
+		return err
+	}
+
+	// Назначения услуг появились позже услуг: при создании таблицы барберы получают все услуги
+	assignServices := !DB.Migrator().HasTable(&models.BarberService{})
+	// Позиции бронирований появились позже бронирований: каждое бронирование получает позицию своей услуги
+	fillBookingItems := !DB.Migrator().HasTable(&models.BookingItem{})
+
 	// Выполняем миграции
 	err = DB.AutoMigrate(
 		&models.Bookings{},
+		&models.BookingItem{},
+		&models.BookingStatusChange{},
 		&models.User{},
 		&models.Client{},
+		&models.ClientNote{},
+		&models.ClientField{},
 		&models.Schedule{},
+		&models.ScheduleOverride{},
 		&models.Service{},
+		&models.ServiceLocationPrice{},
+		&models.BarberService{},
+		&models.Location{},
 		&models.HistoryLogs{},
 		&models.Notification{},
+		&models.NotificationTemplate{},
 		&models.Break{},
-		&models.AuthUser{},
+		&models.BreakException{},
+		&models.RefreshToken{},
+		&models.RevokedToken{},
+		&models.Tenant{},
+		&models.OwnerRegistration{},
 	)
 	if err != nil {
 		return err
 	}
 
+	// Переносим учетные записи из устаревшей таблицы auth_users в users
+	if err := MigrateAuthUsers(DB); err != nil {
+		return err
+	}
+
+	// Пустые и устаревшие роли запрещают доступ ко всем маршрутам
+	if err := NormalizeRoles(DB); err != nil {
+		return err
+	}
+
+	if err := MigrateMoneyColumns(DB, currency); err != nil {
+		return err
+	}
+
+	if err := CreateClientSearchIndexes(DB); err != nil {
+		return err
+	}
+
+	if err := MigrateNotificationTimestamps(DB); err != nil {
+		return err
+	}
+
+	if err := EnsureDefaultTenant(DB, defaultLocation.Name); err != nil {
+		return err
+	}
+
+	if err := AssignDefaultLocation(tenant.Scope(DB, tenant.DefaultID), defaultLocation); err != nil {
+		return err
+	}
+
+	if assignServices {
+		if err := AssignServicesToBarbers(DB); err != nil {
+			return err
+		}
+	}
+
+	if fillBookingItems {
+		if err := CreateBookingItems(DB); err != nil {
+			return err
+		}
+	}
+
+	if err := SeedNotificationTemplates(DB); err != nil {
+		return err
+	}
+
 	log.Println("Database connection established and migrations applied successfully.")
 	return nil
 }
@@ -0,0 +1,100 @@
+package db
+
+import (
+	"fmt"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// ContactIssue — запись, контакт которой не удалось нормализовать или сохранить
+type ContactIssue struct {
+	Table  string
+	ID     int
+	Field  string
+	Value  string
+	Reason string
+}
+
+func (i ContactIssue) String() string {
+	return fmt.Sprintf("%s #%d %s %q: %s", i.Table, i.ID, i.Field, i.Value, i.Reason)
+}
+
+// ContactBackfill — итог нормализации контактов
+type ContactBackfill struct {
+	Checked int
+	Updated int
+	Issues  []ContactIssue
+}
+
+// contactRow — телефон и email клиента или сотрудника; Email — NULL у сотрудников без email
+type contactRow struct {
+	ID          int
+	Email       *string
+	PhoneNumber string
+}
+
+// NormalizeContacts приводит телефоны клиентов и сотрудников всех арендаторов к E.164, а email —
+// к нижнему регистру, как это делается при сохранении через API. Значения, которые не удалось
+// разобрать, и записи, которые после нормализации совпали с существующими (уникальный email),
+// не изменяются и попадают в Issues. При dryRun изменения только подсчитываются.
+// Выполняется однократно командой cmd/normalize_contacts.
+func NormalizeContacts(db *gorm.DB, dryRun bool) (*ContactBackfill, error) {
+	result := &ContactBackfill{}
+	tables := []struct {
+		name  string
+		model interface{}
+	}{
+		{"clients", &models.Client{}},
+		{"users", &models.User{}},
+	}
+
+	for _, table := range tables {
+		var rows []contactRow
+		if err := db.Model(table.model).Select("id", "email", "phone_number").Order("id").Find(&rows).Error; err != nil {
+			return nil, fmt.Errorf("не удалось прочитать контакты таблицы %s: %w", table.name, err)
+		}
+
+		for _, row := range rows {
+			result.Checked++
+			changes := make(map[string]interface{})
+
+			phone, err := models.NormalizePhone(row.PhoneNumber)
+			if err != nil {
+				result.Issues = append(result.Issues, ContactIssue{table.name, row.ID, "phone_number", row.PhoneNumber, err.Error()})
+			} else if phone != row.PhoneNumber {
+				changes["phone_number"] = phone
+			}
+
+			if row.Email != nil {
+				email, err := models.NormalizeEmail(*row.Email)
+				if err != nil {
+					result.Issues = append(result.Issues, ContactIssue{table.name, row.ID, "email", *row.Email, err.Error()})
+				} else if email != *row.Email {
+					changes["email"] = email
+				}
+			}
+
+			if len(changes) == 0 {
+				continue
+			}
+			if !dryRun {
+				// UpdateColumns не меняет updated_at: контакты по сути не изменились
+				if err := db.Model(table.model).Where("id = ?", row.ID).UpdateColumns(changes).Error; err != nil {
+					// Чаще всего email после приведения к нижнему регистру уже занят другой записью
+					issue := ContactIssue{table.name, row.ID, "phone_number", row.PhoneNumber, err.Error()}
+					if _, ok := changes["email"]; ok {
+						issue.Field, issue.Value = "email", *row.Email
+					}
+					result.Issues = append(result.Issues, issue)
+					continue
+				}
+			}
+			result.Updated++
+		}
+	}
+
+	log.Printf("Normalized contacts: %d checked, %d updated, %d issues.", result.Checked, result.Updated, len(result.Issues))
+	return result, nil
+}
@@ -0,0 +1,514 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/tenant"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// legacyAuthUser — запись устаревшей таблицы auth_users, которая использовалась для входа до объединения с users
+type legacyAuthUser struct {
+	ID       uint
+	Username string
+	Password string
+	UserID   *int
+}
+
+func (legacyAuthUser) TableName() string {
+	return "auth_users"
+}
+
+// MigrateAuthUsers переносит учетные записи из auth_users в users и удаляет устаревшую таблицу.
+// Связанный сотрудник (auth_users.user_id) или сотрудник с тем же username получает пароль
+// учетной записи; для остальных создается сотрудник без роли — ее назначает NormalizeRoles.
+func MigrateAuthUsers(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&legacyAuthUser{}) {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		var accounts []legacyAuthUser
+		if err := tx.Find(&accounts).Error; err != nil {
+			return err
+		}
+
+		for _, account := range accounts {
+			if err := mergeAuthUser(tx, account); err != nil {
+				return fmt.Errorf("не удалось перенести учетную запись %s: %w", account.Username, err)
+			}
+		}
+
+		log.Printf("Migrated %d auth_users rows into users.", len(accounts))
+		return tx.Migrator().DropTable(&legacyAuthUser{})
+	})
+}
+
+func mergeAuthUser(tx *gorm.DB, account legacyAuthUser) error {
+	var user models.User
+	err := gorm.ErrRecordNotFound
+	if account.UserID != nil {
+		err = tx.First(&user, *account.UserID).Error
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = tx.Where("username = ?", account.Username).First(&user).Error
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		user = models.User{
+			Username:     account.Username,
+			PasswordHash: account.Password,
+		}
+		// Email не задан: сохраняем NULL, чтобы не нарушать уникальность
+		return tx.Omit("Email").Create(&user).Error
+	}
+	if err != nil {
+		return err
+	}
+
+	return tx.Model(&user).Update("password_hash", account.Password).Error
+}
+
+// legacyRoles — роли, которые записывались в users.role до появления фиксированного списка ролей
+var legacyRoles = map[string]string{
+	"owner":         models.RoleOwner,
+	"владелец":      models.RoleOwner,
+	"admin":         models.RoleAdmin,
+	"administrator": models.RoleAdmin,
+	"администратор": models.RoleAdmin,
+	"manager":       models.RoleAdmin,
+	"менеджер":      models.RoleAdmin,
+	"управляющий":   models.RoleAdmin,
+	"barber":        models.RoleBarber,
+	"барбер":        models.RoleBarber,
+	"master":        models.RoleBarber,
+	"мастер":        models.RoleBarber,
+	"stylist":       models.RoleBarber,
+	"стилист":       models.RoleBarber,
+	"парикмахер":    models.RoleBarber,
+	"receptionist":  models.RoleReceptionist,
+	"reception":     models.RoleReceptionist,
+	"ресепшн":       models.RoleReceptionist,
+	"ресепшен":      models.RoleReceptionist,
+}
+
+// roleRow — роль сотрудника; роль может быть пустой или произвольной строкой
+type roleRow struct {
+	ID       int
+	TenantID int
+	Role     string
+}
+
+// NormalizeRoles приводит роли сотрудников к поддерживаемым: известные старые названия заменяются
+// соответствующей ролью, пустая или неизвестная роль — наименее привилегированной ролью barber.
+// Если у арендатора нет ни владельца, ни администратора, владельцем становится самая старая учетная запись,
+// иначе управлять сотрудниками было бы некому: регистрация закрыта, пока есть хотя бы один сотрудник.
+func NormalizeRoles(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var users []roleRow
+		if err := tx.Model(&models.User{}).Select("id", "tenant_id", "role").Order("created_at, id").Find(&users).Error; err != nil {
+			return err
+		}
+
+		managed := make(map[int]bool)
+		oldest := make(map[int]int)
+		for _, user := range users {
+			role, ok := legacyRoles[strings.ToLower(strings.TrimSpace(user.Role))]
+			if !ok {
+				role = models.RoleBarber
+			}
+			if role != user.Role {
+				if err := tx.Model(&models.User{}).Where("id = ?", user.ID).UpdateColumn("role", role).Error; err != nil {
+					return err
+				}
+				log.Printf("Changed role of user #%d from %q to %q.", user.ID, user.Role, role)
+			}
+
+			if role == models.RoleOwner || role == models.RoleAdmin {
+				managed[user.TenantID] = true
+			}
+			if _, ok := oldest[user.TenantID]; !ok {
+				oldest[user.TenantID] = user.ID
+			}
+		}
+
+		for tenantID, userID := range oldest {
+			if managed[tenantID] {
+				continue
+			}
+			if err := tx.Model(&models.User{}).Where("id = ?", userID).UpdateColumn("role", models.RoleOwner).Error; err != nil {
+				return err
+			}
+			log.Printf("Tenant #%d has no owner or admin: user #%d became the owner.", tenantID, userID)
+		}
+		return nil
+	})
+}
+
+// DropLegacyHistoryLogs удаляет таблицу history_logs старого формата (без entity_type):
+// в ней created_at хранился строкой, и записи не привязаны к сущностям.
+// Таблица создается заново при AutoMigrate.
+func DropLegacyHistoryLogs(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasTable(&models.HistoryLogs{}) || migrator.HasColumn(&models.HistoryLogs{}, "EntityType") {
+		return nil
+	}
+
+	log.Println("Dropping legacy history_logs table.")
+	return migrator.DropTable(&models.HistoryLogs{})
+}
+
+// MigrateNotificationTimestamps заполняет created_at у уведомлений, созданных до появления колонки.
+// Раньше sent_at заполнялся при создании записи, поэтому он переносится в created_at
+// и сохраняется только у действительно отправленных уведомлений.
+func MigrateNotificationTimestamps(db *gorm.DB) error {
+	return db.Exec(`UPDATE notifications
+		SET created_at = COALESCE(sent_at, CURRENT_TIMESTAMP),
+			sent_at = CASE WHEN status = ? THEN sent_at ELSE NULL END
+		WHERE created_at IS NULL`, models.NotificationStatusSent).Error
+}
+
+// legacySchedule — строка расписания до типизации: день недели и время хранились произвольными строками
+type legacySchedule struct {
+	ID          int
+	TenantID    int
+	UserID      int
+	LocationID  *int
+	ScheduleDay string
+	StartTime   string
+	EndTime     string
+}
+
+func (legacySchedule) TableName() string {
+	return "schedules"
+}
+
+// UnparsedSchedule — строка расписания, которую NormalizeSchedules не смогла разобрать. Строки хранятся
+// без изменений под прежним ID, чтобы администратор перенес их в расписание вручную.
+type UnparsedSchedule struct {
+	ID          int `gorm:"primaryKey;autoIncrement:false"` // ID строки в schedules
+	TenantID    int `gorm:"index"`
+	UserID      int
+	LocationID  *int
+	ScheduleDay string
+	StartTime   string
+	EndTime     string
+	Reason      string
+	MovedAt     time.Time `gorm:"autoCreateTime"`
+}
+
+func (UnparsedSchedule) TableName() string {
+	return "schedules_unparsed"
+}
+
+// NormalizeSchedules приводит день недели и время в расписаниях к каноническому виду ("monday", "09:00").
+// Строки, которые не удается разобрать (день не по-английски, время без формата ЧЧ:ММ, смена через полночь),
+// переносятся без изменений в schedules_unparsed: ничего не удаляется, а расписание остается читаемым.
+// Выполняется до AutoMigrate: после нормализации все значения помещаются в колонки модели Schedule.
+func NormalizeSchedules(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&legacySchedule{}) {
+		return nil
+	}
+	if err := db.AutoMigrate(&UnparsedSchedule{}); err != nil {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		var schedules []legacySchedule
+		if err := tx.Find(&schedules).Error; err != nil {
+			return err
+		}
+
+		var unparsed []UnparsedSchedule
+		for _, schedule := range schedules {
+			day, dayErr := models.ParseWeekday(schedule.ScheduleDay)
+			start, startErr := models.ParseTimeOfDay(schedule.StartTime)
+			end, endErr := models.ParseTimeOfDay(schedule.EndTime)
+			if reason := unparsedScheduleReason(dayErr, startErr, endErr, start, end); reason != "" {
+				// Расписания, созданные до появления арендаторов, относятся к арендатору по умолчанию
+				tenantID := schedule.TenantID
+				if tenantID == 0 {
+					tenantID = tenant.DefaultID
+				}
+				unparsed = append(unparsed, UnparsedSchedule{
+					ID:          schedule.ID,
+					TenantID:    tenantID,
+					UserID:      schedule.UserID,
+					LocationID:  schedule.LocationID,
+					ScheduleDay: schedule.ScheduleDay,
+					StartTime:   schedule.StartTime,
+					EndTime:     schedule.EndTime,
+					Reason:      reason,
+				})
+				continue
+			}
+
+			if day.String() == schedule.ScheduleDay && start.String() == schedule.StartTime && end.String() == schedule.EndTime {
+				continue
+			}
+			err := tx.Model(&legacySchedule{}).Where("id = ?", schedule.ID).Updates(map[string]interface{}{
+				"schedule_day": day.String(),
+				"start_time":   start.String(),
+				"end_time":     end.String(),
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+		if len(unparsed) == 0 {
+			return nil
+		}
+
+		ids := make([]int, 0, len(unparsed))
+		for _, schedule := range unparsed {
+			ids = append(ids, schedule.ID)
+		}
+		if err := tx.Create(&unparsed).Error; err != nil {
+			return fmt.Errorf("не удалось сохранить неразобранные расписания: %w", err)
+		}
+		if err := tx.Where("id IN ?", ids).Delete(&legacySchedule{}).Error; err != nil {
+			return err
+		}
+		log.Printf("Moved %d unparsable schedules to schedules_unparsed for manual repair: ids %v.", len(ids), ids)
+		return nil
+	})
+}
+
+// unparsedScheduleReason описывает, почему строку расписания не удалось разобрать; пустая строка — строка корректна
+func unparsedScheduleReason(dayErr, startErr, endErr error, start, end models.TimeOfDay) string {
+	switch {
+	case dayErr != nil:
+		return dayErr.Error()
+	case startErr != nil:
+		return startErr.Error()
+	case endErr != nil:
+		return endErr.Error()
+	case start == end:
+		return "время начала совпадает с временем окончания"
+	case start > end:
+		return "смена переходит через полночь: разделите ее на два дня"
+	}
+	return ""
+}
+
+// locationScopedTables — таблицы, записи которых до появления филиалов относились к единственному салону
+var locationScopedTables = []string{"users", "schedules", "breaks", "bookings"}
+
+// AssignDefaultLocation создает филиал defaults, если филиалов еще нет, и привязывает к первому филиалу
+// сотрудников, расписания, перерывы и бронирования, созданные до появления филиалов (location_id IS NULL).
+// Исключения из расписания не привязываются: без филиала они действуют во всех филиалах.
+func AssignDefaultLocation(db *gorm.DB, defaults models.Location) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var location models.Location
+		err := tx.Order("id").First(&location).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			location = defaults
+			if err := tx.Create(&location).Error; err != nil {
+				return err
+			}
+			log.Printf("Created default location #%d %q (%s).", location.ID, location.Name, location.Timezone)
+		} else if err != nil {
+			return err
+		}
+
+		for _, table := range locationScopedTables {
+			result := tx.Table(table).Where("location_id IS NULL").Update("location_id", location.ID)
+			if result.Error != nil {
+				return fmt.Errorf("не удалось привязать %s к филиалу: %w", table, result.Error)
+			}
+			if result.RowsAffected > 0 {
+				log.Printf("Assigned %d %s rows to location #%d.", result.RowsAffected, table, location.ID)
+			}
+		}
+		return nil
+	})
+}
+
+// EnsureDefaultTenant создает арендатора tenant.DefaultID, если арендаторов еще нет.
+// Записи, созданные до появления арендаторов, получают tenant_id по умолчанию и относятся к нему.
+func EnsureDefaultTenant(db *gorm.DB, name string) error {
+	var count int64
+	if err := db.Model(&models.Tenant{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	// Первая запись таблицы получает ID 1 — tenant.DefaultID
+	defaults := models.Tenant{Name: name, IsActive: true}
+	if err := db.Create(&defaults).Error; err != nil {
+		return err
+	}
+	log.Printf("Created default tenant #%d %q.", defaults.ID, defaults.Name)
+	return nil
+}
+
+// globalUniqueConstraints — ограничения уникальности, которые до появления арендаторов действовали
+// на всю таблицу; теперь уникальность проверяется в пределах арендатора составными индексами
+var globalUniqueConstraints = map[string][]string{
+	"users":                  {"users_username_key", "uni_users_username", "users_email_key", "uni_users_email"},
+	"clients":                {"clients_email_key", "uni_clients_email", "clients_tg_id_key", "uni_clients_tg_id"},
+	"notification_templates": {"idx_template_code_language"},
+}
+
+// DropGlobalUniqueConstraints удаляет ограничения уникальности на всю таблицу, чтобы у разных арендаторов
+// могли совпадать логины, email и коды шаблонов. Выполняется до AutoMigrate: индекс
+// idx_template_code_language пересоздается с tenant_id. Нужна только в PostgreSQL.
+func DropGlobalUniqueConstraints(db *gorm.DB) error {
+	if db.Dialector.Name() != "postgres" {
+		return nil
+	}
+
+	for table, constraints := range globalUniqueConstraints {
+		if !db.Migrator().HasTable(table) || db.Migrator().HasColumn(table, "tenant_id") {
+			continue
+		}
+		for _, name := range constraints {
+			statements := []string{
+				fmt.Sprintf("ALTER TABLE %q DROP CONSTRAINT IF EXISTS %q", table, name),
+				fmt.Sprintf("DROP INDEX IF EXISTS %q", name),
+			}
+			for _, statement := range statements {
+				if err := db.Exec(statement).Error; err != nil {
+					return fmt.Errorf("не удалось удалить ограничение %s: %w", name, err)
+				}
+			}
+		}
+		log.Printf("Dropped table-wide unique constraints of %s.", table)
+	}
+	return nil
+}
+
+// AssignServicesToBarbers назначает каждому барберу все услуги его арендатора без индивидуальных цен
+// и длительностей. Выполняется один раз при появлении таблицы barber_services, чтобы после перехода
+// на назначения услуг барберы могли принимать записи как раньше.
+func AssignServicesToBarbers(db *gorm.DB) error {
+	var barbers []models.User
+	if err := db.Where("role = ?", models.RoleBarber).Order("id").Find(&barbers).Error; err != nil {
+		return err
+	}
+	var services []models.Service
+	if err := db.Order("id").Find(&services).Error; err != nil {
+		return err
+	}
+
+	assignments := make([]models.BarberService, 0, len(barbers)*len(services))
+	for _, barber := range barbers {
+		for _, service := range services {
+			if service.TenantID == barber.TenantID {
+				assignments = append(assignments, models.BarberService{TenantID: barber.TenantID, UserID: barber.ID, ServiceID: service.ID})
+			}
+		}
+	}
+	if len(assignments) == 0 {
+		return nil
+	}
+
+	if err := db.CreateInBatches(&assignments, 500).Error; err != nil {
+		return err
+	}
+	log.Printf("Assigned services to barbers: %d assignments.", len(assignments))
+	return nil
+}
+
+// clientSearchColumns — колонки клиентов, по которым идет поиск GET /clients/search
+var clientSearchColumns = []string{"first_name", "last_name", "tg_nickname", "email", "phone_number"}
+
+// CreateClientSearchIndexes подключает расширение pg_trgm и создает GIN-индексы триграмм для поиска
+// клиентов по подстроке (ILIKE) и по сходству (оператор %). Нужна только в PostgreSQL.
+func CreateClientSearchIndexes(db *gorm.DB) error {
+	if db.Dialector.Name() != "postgres" {
+		return nil
+	}
+
+	if err := db.Exec("CREATE EXTENSION IF NOT EXISTS pg_trgm").Error; err != nil {
+		return fmt.Errorf("не удалось подключить расширение pg_trgm: %w", err)
+	}
+	for _, column := range clientSearchColumns {
+		statement := fmt.Sprintf("CREATE INDEX IF NOT EXISTS %q ON clients USING gin (%q gin_trgm_ops)", "idx_clients_"+column+"_trgm", column)
+		if err := db.Exec(statement).Error; err != nil {
+			return fmt.Errorf("не удалось создать индекс поиска по %s: %w", column, err)
+		}
+	}
+	return nil
+}
+
+// moneyTables — таблицы, в которых цена хранилась числом с плавающей точкой в колонке price
+var moneyTables = []string{"services", "service_location_prices", "barber_services", "bookings"}
+
+// MigrateMoneyColumns переносит цены из устаревшей колонки price в минорные единицы price_amount
+// с валютой currency и удаляет колонку. Бронирования без зафиксированной цены (price = 0)
+// получают базовую цену услуги. Выполняется после AutoMigrate, создающего новые колонки.
+func MigrateMoneyColumns(db *gorm.DB, currency string) error {
+	scale := 1
+	for i := 0; i < models.CurrencyExponent(currency); i++ {
+		scale *= 10
+	}
+
+	for _, table := range moneyTables {
+		if !db.Migrator().HasTable(table) || !db.Migrator().HasColumn(table, "price") {
+			continue
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			err := tx.Exec(fmt.Sprintf("UPDATE %q SET price_amount = ROUND(price * ?), price_currency = ? "+
+				"WHERE price IS NOT NULL AND price_amount IS NULL", table), scale, currency).Error
+			if err != nil {
+				return err
+			}
+			if table == "bookings" {
+				err = tx.Exec("UPDATE bookings SET price_amount = (SELECT services.price_amount FROM services WHERE services.id = bookings.service_id), " +
+					"price_currency = (SELECT services.price_currency FROM services WHERE services.id = bookings.service_id) " +
+					"WHERE price_amount = 0 OR price_amount IS NULL").Error
+				if err != nil {
+					return err
+				}
+			}
+			return tx.Exec(fmt.Sprintf("ALTER TABLE %q DROP COLUMN price", table)).Error
+		})
+		if err != nil {
+			return fmt.Errorf("не удалось перенести цены таблицы %s: %w", table, err)
+		}
+		log.Printf("Migrated %s.price to minor units in %s.", table, currency)
+	}
+	return nil
+}
+
+// CreateBookingItems создает каждому бронированию без позиций позицию его услуги с зафиксированными
+// в бронировании ценой и длительностью (для бронирований без длительности — длительностью услуги).
+// Выполняется после MigrateMoneyColumns при появлении таблицы booking_items.
+func CreateBookingItems(db *gorm.DB) error {
+	var bookings []models.Bookings
+	err := db.Preload("Service").
+		Where("NOT EXISTS (SELECT 1 FROM booking_items WHERE booking_items.booking_id = bookings.id)").
+		Order("id").Find(&bookings).Error
+	if err != nil {
+		return err
+	}
+
+	items := make([]models.BookingItem, 0, len(bookings))
+	for _, booking := range bookings {
+		items = append(items, models.BookingItem{
+			TenantID:  booking.TenantID,
+			BookingID: booking.ID,
+			ServiceID: booking.ServiceID,
+			Price:     booking.Price,
+			Duration:  int(booking.Length() / time.Minute),
+		})
+	}
+	if len(items) == 0 {
+		return nil
+	}
+
+	if err := db.Omit(clause.Associations).CreateInBatches(&items, 500).Error; err != nil {
+		return err
+	}
+	log.Printf("Created booking items for %d bookings.", len(items))
+	return nil
+}
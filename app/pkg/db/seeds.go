@@ -0,0 +1,50 @@
+package db
+
+import (
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// defaultNotificationTemplates — шаблоны, которые создаются при первом запуске.
+// Отредактированные администратором шаблоны не перезаписываются.
+var defaultNotificationTemplates = []models.NotificationTemplate{
+	{Code: models.TemplateBookingConfirmed, Language: models.LanguageRU, Body: `{{.Client.FirstName}}, ваша запись на «{{.Service.Name}}» {{date .Booking.BookingTime}} в {{time .Booking.BookingTime}} подтверждена.`},
+	{Code: models.TemplateBookingConfirmed, Language: models.LanguageEN, Body: `{{.Client.FirstName}}, your {{.Service.Name}} appointment on {{date .Booking.BookingTime}} at {{time .Booking.BookingTime}} is confirmed.`},
+	{Code: models.TemplateBookingCancelled, Language: models.LanguageRU, Body: `{{.Client.FirstName}}, ваша запись на «{{.Service.Name}}» {{date .Booking.BookingTime}} в {{time .Booking.BookingTime}} отменена.`},
+	{Code: models.TemplateBookingCancelled, Language: models.LanguageEN, Body: `{{.Client.FirstName}}, your {{.Service.Name}} appointment on {{date .Booking.BookingTime}} at {{time .Booking.BookingTime}} has been cancelled.`},
+	{Code: models.TemplateReminder24h, Language: models.LanguageRU, Body: `{{.Client.FirstName}}, напоминаем: завтра в {{time .Booking.BookingTime}} вас ждет мастер {{.Barber.Username}} — «{{.Service.Name}}».`},
+	{Code: models.TemplateReminder24h, Language: models.LanguageEN, Body: `{{.Client.FirstName}}, a reminder: tomorrow at {{time .Booking.BookingTime}} {{.Barber.Username}} is expecting you for {{.Service.Name}}.`},
+	{Code: models.TemplateReminder2h, Language: models.LanguageRU, Body: `{{.Client.FirstName}}, через 2 часа, в {{time .Booking.BookingTime}}, ваша запись на «{{.Service.Name}}».`},
+	{Code: models.TemplateReminder2h, Language: models.LanguageEN, Body: `{{.Client.FirstName}}, your {{.Service.Name}} appointment is in 2 hours, at {{time .Booking.BookingTime}}.`},
+	{Code: models.TemplateReminder, Language: models.LanguageRU, Body: `{{.Client.FirstName}}, напоминаем о записи на «{{.Service.Name}}» {{date .Booking.BookingTime}} в {{time .Booking.BookingTime}}.`},
+	{Code: models.TemplateReminder, Language: models.LanguageEN, Body: `{{.Client.FirstName}}, a reminder about your {{.Service.Name}} appointment on {{date .Booking.BookingTime}} at {{time .Booking.BookingTime}}.`},
+	{Code: models.TemplateBirthdayGreeting, Language: models.LanguageRU, Body: `{{.Client.FirstName}}, поздравляем с днем рождения! Ждем вас в гости.`},
+	{Code: models.TemplateBirthdayGreeting, Language: models.LanguageEN, Body: `Happy birthday, {{.Client.FirstName}}! We look forward to seeing you.`},
+}
+
+// SeedNotificationTemplates добавляет недостающие шаблоны уведомлений по умолчанию каждому арендатору
+func SeedNotificationTemplates(db *gorm.DB) error {
+	var tenantIDs []int
+	if err := db.Model(&models.Tenant{}).Order("id").Pluck("id", &tenantIDs).Error; err != nil {
+		return err
+	}
+
+	for _, tenantID := range tenantIDs {
+		templates := make([]models.NotificationTemplate, len(defaultNotificationTemplates))
+		copy(templates, defaultNotificationTemplates)
+		for i := range templates {
+			templates[i].TenantID = tenantID
+		}
+
+		err := db.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "code"}, {Name: "language"}},
+			DoNothing: true,
+		}).Create(&templates).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
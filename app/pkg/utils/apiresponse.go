@@ -3,9 +3,18 @@ package utils
 type APIResponse struct {
 	Success bool        `json:"success"`
 	Data    interface{} `json:"data,omitempty"`
+	Meta    *ListMeta   `json:"meta,omitempty"`
 	Error   string      `json:"error,omitempty"`
 }
 
+// ListMeta — сведения о странице списка
+type ListMeta struct {
+	Total      int64  `json:"total"`
+	Limit      int    `json:"limit"`
+	Offset     int    `json:"offset"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
 func SuccessResponse(data interface{}) APIResponse {
 	return APIResponse{
 		Success: true,
@@ -13,9 +22,25 @@ func SuccessResponse(data interface{}) APIResponse {
 	}
 }
 
+func ListResponse(data interface{}, meta ListMeta) APIResponse {
+	return APIResponse{
+		Success: true,
+		Data:    data,
+		Meta:    &meta,
+	}
+}
+
 func ErrorResponse(err string) APIResponse {
 	return APIResponse{
 		Success: false,
 		Error:   err,
 	}
 }
+
+func ErrorResponseWithData(err string, data interface{}) APIResponse {
+	return APIResponse{
+		Success: false,
+		Data:    data,
+		Error:   err,
+	}
+}
@@ -0,0 +1,467 @@
+package db
+
+import (
+	"fmt"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/pkg/db"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// authUser повторяет структуру устаревшей таблицы auth_users
+type authUser struct {
+	ID       uint   `gorm:"primaryKey"`
+	Username string `gorm:"unique;not null"`
+	Password string `gorm:"not null"`
+	UserID   *int
+}
+
+func TestMigrateAuthUsers(t *testing.T) {
+	database, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, database.AutoMigrate(&models.User{}, &authUser{}))
+
+	linked := &models.User{Username: "barber", PasswordHash: "old-hash", Role: models.RoleBarber, Email: "barber@example.com"}
+	sameName := &models.User{Username: "admin", PasswordHash: "old-hash", Role: models.RoleAdmin, Email: "admin@example.com"}
+	require.NoError(t, database.Create(linked).Error)
+	require.NoError(t, database.Create(sameName).Error)
+
+	accounts := []authUser{
+		{Username: "barber-login", Password: "linked-hash", UserID: &linked.ID},
+		{Username: "admin", Password: "admin-hash"},
+		{Username: "newcomer", Password: "newcomer-hash"},
+		{Username: "another", Password: "another-hash"},
+	}
+	require.NoError(t, database.Create(&accounts).Error)
+
+	require.NoError(t, db.MigrateAuthUsers(database))
+
+	var linkedUser, sameNameUser, newcomer models.User
+	require.NoError(t, database.First(&linkedUser, linked.ID).Error)
+	assert.Equal(t, "linked-hash", linkedUser.PasswordHash)
+
+	require.NoError(t, database.First(&sameNameUser, sameName.ID).Error)
+	assert.Equal(t, "admin-hash", sameNameUser.PasswordHash)
+
+	require.NoError(t, database.Where("username = ?", "newcomer").First(&newcomer).Error)
+	assert.Equal(t, "newcomer-hash", newcomer.PasswordHash)
+	assert.Empty(t, newcomer.Role)
+
+	var count int64
+	require.NoError(t, database.Model(&models.User{}).Count(&count).Error)
+	assert.Equal(t, int64(4), count)
+	assert.False(t, database.Migrator().HasTable("auth_users"))
+
+	// Повторный запуск ничего не делает
+	require.NoError(t, db.MigrateAuthUsers(database))
+}
+
+func TestNormalizeRoles(t *testing.T) {
+	database, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, database.AutoMigrate(&models.User{}, &authUser{}))
+
+	// Арендатор 1: учетные записи из auth_users без роли и сотрудники с произвольными ролями
+	created := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	users := []models.User{
+		{Username: "master", Role: "Мастер", Email: "master@example.com", TenantID: 1, CreatedAt: created.Add(time.Hour)},
+		{Username: "intern", Role: "стажер", Email: "intern@example.com", TenantID: 1, CreatedAt: created.Add(2 * time.Hour)},
+		{Username: "manager", Role: " Администратор ", Email: "manager@example.com", TenantID: 2, CreatedAt: created},
+		{Username: "desk", Role: "reception", Email: "desk@example.com", TenantID: 2, CreatedAt: created.Add(time.Hour)},
+	}
+	for i := range users {
+		users[i].PasswordHash = "hash"
+	}
+	require.NoError(t, database.Create(&users).Error)
+	require.NoError(t, database.Create(&authUser{Username: "founder", Password: "founder-hash"}).Error)
+	require.NoError(t, db.MigrateAuthUsers(database))
+	require.NoError(t, database.Model(&models.User{}).Where("username = ?", "founder").Update("created_at", created).Error)
+
+	require.NoError(t, db.NormalizeRoles(database))
+	// Повторный запуск ничего не меняет
+	require.NoError(t, db.NormalizeRoles(database))
+
+	roles := make(map[string]string)
+	var stored []models.User
+	require.NoError(t, database.Find(&stored).Error)
+	for _, user := range stored {
+		roles[user.Username] = user.Role
+	}
+	assert.Equal(t, map[string]string{
+		// Без владельца и администратора самая старая учетная запись становится владельцем
+		"founder": models.RoleOwner,
+		"master":  models.RoleBarber,
+		"intern":  models.RoleBarber,
+		// У второго арендатора есть администратор, поэтому владелец не назначается
+		"manager": models.RoleAdmin,
+		"desk":    models.RoleReceptionist,
+	}, roles)
+}
+
+// legacyHistoryLog повторяет устаревшую структуру history_logs
+type legacyHistoryLog struct {
+	ID        uint `gorm:"primaryKey"`
+	UserID    uint
+	Action    string
+	CreatedAt string
+}
+
+func (legacyHistoryLog) TableName() string {
+	return "history_logs"
+}
+
+func TestDropLegacyHistoryLogs(t *testing.T) {
+	database, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, database.AutoMigrate(&legacyHistoryLog{}))
+
+	require.NoError(t, db.DropLegacyHistoryLogs(database))
+	assert.False(t, database.Migrator().HasTable("history_logs"))
+
+	// Таблица нового формата не затрагивается
+	require.NoError(t, database.AutoMigrate(&models.HistoryLogs{}))
+	require.NoError(t, database.Create(&models.HistoryLogs{Action: models.HistoryActionCreate, EntityType: models.EntityUser, EntityID: 1}).Error)
+	require.NoError(t, db.DropLegacyHistoryLogs(database))
+
+	var count int64
+	require.NoError(t, database.Model(&models.HistoryLogs{}).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+}
+
+// legacyNotification повторяет устаревшую структуру notifications: sent_at заполнялся при создании
+type legacyNotification struct {
+	ID               int `gorm:"primaryKey"`
+	ClientID         int
+	Message          string
+	NotificationType string
+	SentAt           time.Time
+	Status           string
+}
+
+func (legacyNotification) TableName() string {
+	return "notifications"
+}
+
+func TestMigrateNotificationTimestamps(t *testing.T) {
+	database, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, database.AutoMigrate(&legacyNotification{}))
+
+	created := time.Date(2025, 1, 10, 10, 0, 0, 0, time.UTC)
+	legacy := []legacyNotification{
+		{ClientID: 1, Message: "pending", NotificationType: models.NotificationTypeSMS, SentAt: created, Status: models.NotificationStatusPending},
+		{ClientID: 1, Message: "sent", NotificationType: models.NotificationTypeSMS, SentAt: created, Status: models.NotificationStatusSent},
+	}
+	require.NoError(t, database.Create(&legacy).Error)
+
+	require.NoError(t, database.AutoMigrate(&models.Notification{}))
+	require.NoError(t, db.MigrateNotificationTimestamps(database))
+
+	var notifications []models.Notification
+	require.NoError(t, database.Order("id").Find(&notifications).Error)
+	require.Len(t, notifications, 2)
+	assert.True(t, notifications[0].CreatedAt.Equal(created))
+	assert.Nil(t, notifications[0].SentAt)
+	require.NotNil(t, notifications[1].SentAt)
+	assert.True(t, notifications[1].SentAt.Equal(created))
+}
+
+func TestSeedNotificationTemplates(t *testing.T) {
+	database, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, database.AutoMigrate(&models.NotificationTemplate{}, &models.Tenant{}))
+	require.NoError(t, db.EnsureDefaultTenant(database, "GoBarberCRM"))
+
+	require.NoError(t, db.SeedNotificationTemplates(database))
+
+	var seeded int64
+	require.NoError(t, database.Model(&models.NotificationTemplate{}).Count(&seeded).Error)
+	require.NotZero(t, seeded)
+
+	// Повторный запуск не создает дубликатов и не перезаписывает отредактированный текст
+	require.NoError(t, database.Model(&models.NotificationTemplate{}).
+		Where("code = ? AND language = ?", models.TemplateReminder, models.LanguageRU).
+		Update("body", "Свой текст").Error)
+	require.NoError(t, db.SeedNotificationTemplates(database))
+
+	var total int64
+	require.NoError(t, database.Model(&models.NotificationTemplate{}).Count(&total).Error)
+	assert.Equal(t, seeded, total)
+
+	var edited models.NotificationTemplate
+	require.NoError(t, database.Where("code = ? AND language = ?", models.TemplateReminder, models.LanguageRU).First(&edited).Error)
+	assert.Equal(t, "Свой текст", edited.Body)
+
+	// Новый арендатор получает собственный набор шаблонов
+	require.NoError(t, database.Create(&models.Tenant{Name: "Второй барбершоп", IsActive: true}).Error)
+	require.NoError(t, db.SeedNotificationTemplates(database))
+	var secondTenant int64
+	require.NoError(t, database.Model(&models.NotificationTemplate{}).Where("tenant_id = ?", 2).Count(&secondTenant).Error)
+	assert.Equal(t, seeded, secondTenant)
+}
+
+// legacySchedule повторяет структуру таблицы schedules со строковыми днем недели и временем
+type legacySchedule struct {
+	ID          int `gorm:"primaryKey"`
+	UserID      int
+	ScheduleDay string `gorm:"size:10"`
+	StartTime   string
+	EndTime     string
+}
+
+func (legacySchedule) TableName() string {
+	return "schedules"
+}
+
+func TestNormalizeSchedules(t *testing.T) {
+	database, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, database.AutoMigrate(&legacySchedule{}))
+
+	legacy := []legacySchedule{
+		{UserID: 1, ScheduleDay: "Monday", StartTime: "9:00", EndTime: "18:00"},
+		{UserID: 1, ScheduleDay: "TUE", StartTime: "10:00:00", EndTime: "19:30"},
+		{UserID: 2, ScheduleDay: "Пн", StartTime: "09:00", EndTime: "18:00"},
+		{UserID: 2, ScheduleDay: "friday", StartTime: "утро", EndTime: "18:00"},
+		{UserID: 3, ScheduleDay: "saturday", StartTime: "22:00", EndTime: "02:00"},
+	}
+	require.NoError(t, database.Create(&legacy).Error)
+
+	// Как в InitDB: нормализация до изменения типов колонок
+	require.NoError(t, db.NormalizeSchedules(database))
+	require.NoError(t, database.AutoMigrate(&models.Schedule{}))
+	// Повторный запуск ничего не меняет
+	require.NoError(t, db.NormalizeSchedules(database))
+
+	var raw []legacySchedule
+	require.NoError(t, database.Order("id").Find(&raw).Error)
+	require.Len(t, raw, 2)
+	assert.Equal(t, legacySchedule{ID: 1, UserID: 1, ScheduleDay: "monday", StartTime: "09:00", EndTime: "18:00"}, raw[0])
+	assert.Equal(t, legacySchedule{ID: 2, UserID: 1, ScheduleDay: "tuesday", StartTime: "10:00", EndTime: "19:30"}, raw[1])
+
+	var schedules []models.Schedule
+	require.NoError(t, database.Order("id").Find(&schedules).Error)
+	require.Len(t, schedules, 2)
+	assert.Equal(t, models.Tuesday, schedules[1].ScheduleDay)
+	assert.Equal(t, models.NewTimeOfDay(19, 30), schedules[1].EndTime)
+
+	// Неразобранные строки, включая смену через полночь, сохраняются без изменений
+	var unparsed []db.UnparsedSchedule
+	require.NoError(t, database.Order("id").Find(&unparsed).Error)
+	require.Len(t, unparsed, 3)
+	assert.Equal(t, []int{3, 4, 5}, []int{unparsed[0].ID, unparsed[1].ID, unparsed[2].ID})
+	assert.Equal(t, "Пн", unparsed[0].ScheduleDay)
+	assert.Equal(t, "утро", unparsed[1].StartTime)
+	assert.Equal(t, 3, unparsed[2].UserID)
+	assert.Equal(t, "22:00", unparsed[2].StartTime)
+	assert.Equal(t, "02:00", unparsed[2].EndTime)
+	assert.Contains(t, unparsed[2].Reason, "полночь")
+}
+
+// Дни недели и время хранятся строками: в PostgreSQL колонки не должны получить числовой тип
+func TestScheduleColumnTypes(t *testing.T) {
+	dialector := postgres.Dialector{Config: &postgres.Config{}}
+	cache := &sync.Map{}
+	for model, columns := range map[interface{}]map[string]string{
+		&models.Schedule{}:         {"ScheduleDay": "varchar(10)", "StartTime": "varchar(8)", "EndTime": "varchar(8)"},
+		&models.ScheduleOverride{}: {"StartTime": "varchar(8)", "EndTime": "varchar(8)"},
+	} {
+		parsed, err := schema.Parse(model, cache, schema.NamingStrategy{})
+		require.NoError(t, err)
+		for name, expected := range columns {
+			assert.Equal(t, expected, dialector.DataTypeOf(parsed.LookUpField(name)), "%s.%s", parsed.Name, name)
+		}
+	}
+
+	// В SQLite колонки создаются с тем же объявленным типом
+	database, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, database.AutoMigrate(&models.Schedule{}))
+	columnTypes, err := database.Migrator().ColumnTypes(&models.Schedule{})
+	require.NoError(t, err)
+	declared := make(map[string]string)
+	for _, column := range columnTypes {
+		length, _ := column.Length()
+		declared[column.Name()] = fmt.Sprintf("%s(%d)", strings.ToLower(column.DatabaseTypeName()), length)
+	}
+	assert.Equal(t, "varchar(10)", declared["schedule_day"])
+	assert.Equal(t, "varchar(8)", declared["start_time"])
+	assert.Equal(t, "varchar(8)", declared["end_time"])
+}
+
+func TestAssignDefaultLocation(t *testing.T) {
+	database, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, database.AutoMigrate(&models.Location{}, &models.User{}, &models.Schedule{}, &models.Break{}, &models.Bookings{}))
+
+	// Записи, созданные до появления филиалов, не имеют location_id
+	barber := &models.User{Username: "barber", PasswordHash: "hash", Role: models.RoleBarber, Email: "barber@example.com"}
+	require.NoError(t, database.Omit("LocationID").Create(barber).Error)
+	schedule := &models.Schedule{UserID: barber.ID, ScheduleDay: models.Monday, StartTime: models.NewTimeOfDay(9, 0), EndTime: models.NewTimeOfDay(18, 0)}
+	require.NoError(t, database.Omit("LocationID").Create(schedule).Error)
+	start := time.Date(2025, 1, 6, 10, 0, 0, 0, time.UTC)
+	require.NoError(t, database.Omit("LocationID").Create(&models.Bookings{ClientID: 1, ServiceID: 1, UserID: barber.ID, BookingTime: start}).Error)
+
+	defaults := models.Location{Name: "GoBarberCRM", Timezone: "Europe/Moscow", IsActive: true}
+	require.NoError(t, db.AssignDefaultLocation(database, defaults))
+	// Повторный запуск не создает второй филиал
+	require.NoError(t, db.AssignDefaultLocation(database, defaults))
+
+	var locations []models.Location
+	require.NoError(t, database.Find(&locations).Error)
+	require.Len(t, locations, 1)
+	assert.Equal(t, "Europe/Moscow", locations[0].Timezone)
+
+	for _, table := range []string{"users", "schedules", "bookings"} {
+		var unassigned int64
+		require.NoError(t, database.Table(table).Where("location_id IS NULL OR location_id <> ?", locations[0].ID).Count(&unassigned).Error)
+		assert.Zero(t, unassigned, table)
+	}
+}
+
+func TestAssignServicesToBarbers(t *testing.T) {
+	database, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, database.AutoMigrate(&models.User{}, &models.Service{}, &models.BarberService{}))
+
+	users := []models.User{
+		{Username: "barber", PasswordHash: "hash", Role: models.RoleBarber, Email: "barber@example.com", TenantID: 1},
+		{Username: "admin", PasswordHash: "hash", Role: models.RoleAdmin, Email: "admin@example.com", TenantID: 1},
+		{Username: "other", PasswordHash: "hash", Role: models.RoleBarber, Email: "other@example.com", TenantID: 2},
+	}
+	require.NoError(t, database.Create(&users).Error)
+	services := []models.Service{
+		{Name: "Стрижка", Price: models.NewMoney(100000, "RUB"), Duration: 60, TenantID: 1},
+		{Name: "Борода", Price: models.NewMoney(50000, "RUB"), Duration: 30, TenantID: 1},
+		{Name: "Стрижка", Price: models.NewMoney(120000, "RUB"), Duration: 60, TenantID: 2},
+	}
+	require.NoError(t, database.Create(&services).Error)
+
+	require.NoError(t, db.AssignServicesToBarbers(database))
+
+	var assignments []models.BarberService
+	require.NoError(t, database.Order("user_id, service_id").Find(&assignments).Error)
+	require.Len(t, assignments, 3)
+	assert.Equal(t, []int{users[0].ID, users[0].ID, users[2].ID}, []int{assignments[0].UserID, assignments[1].UserID, assignments[2].UserID})
+	assert.Equal(t, services[2].ID, assignments[2].ServiceID)
+	assert.Equal(t, 2, assignments[2].TenantID)
+	assert.Nil(t, assignments[0].Price)
+}
+
+func TestMigrateMoneyColumns(t *testing.T) {
+	database, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, database.AutoMigrate(&models.Service{}, &models.Bookings{}))
+	// Колонки price из схемы до перехода на минорные единицы
+	require.NoError(t, database.Exec("ALTER TABLE services ADD COLUMN price real").Error)
+	require.NoError(t, database.Exec("ALTER TABLE bookings ADD COLUMN price real DEFAULT 0").Error)
+
+	require.NoError(t, database.Exec("INSERT INTO services (id, name, price, duration, is_active) VALUES (1, 'Стрижка', 1499.99, 60, true)").Error)
+	require.NoError(t, database.Exec("INSERT INTO bookings (id, client_id, service_id, user_id, booking_time, status, price) VALUES "+
+		"(1, 1, 1, 1, ?, 'completed', 1200.5), (2, 1, 1, 1, ?, 'completed', 0)", time.Now(), time.Now()).Error)
+
+	require.NoError(t, db.MigrateMoneyColumns(database, "RUB"))
+
+	var service models.Service
+	require.NoError(t, database.First(&service, 1).Error)
+	assert.Equal(t, models.NewMoney(149999, "RUB"), service.Price)
+
+	var bookings []models.Bookings
+	require.NoError(t, database.Order("id").Find(&bookings).Error)
+	require.Len(t, bookings, 2)
+	assert.Equal(t, models.NewMoney(120050, "RUB"), bookings[0].Price)
+	// Цена не была зафиксирована — берется цена услуги
+	assert.Equal(t, models.NewMoney(149999, "RUB"), bookings[1].Price)
+
+	assert.False(t, database.Migrator().HasColumn("services", "price"))
+	assert.False(t, database.Migrator().HasColumn("bookings", "price"))
+	// Повторный запуск ничего не меняет
+	require.NoError(t, db.MigrateMoneyColumns(database, "RUB"))
+}
+
+func TestCreateBookingItems(t *testing.T) {
+	database, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, database.AutoMigrate(&models.Service{}, &models.Bookings{}, &models.BookingItem{}))
+
+	service := &models.Service{Name: "Стрижка", Price: models.NewMoney(100000, "RUB"), Duration: 60, TenantID: 2}
+	require.NoError(t, database.Create(service).Error)
+	bookings := []models.Bookings{
+		{ClientID: 1, ServiceID: service.ID, UserID: 1, BookingTime: time.Now(), Price: models.NewMoney(150000, "RUB"), Duration: 90, TenantID: 2},
+		// Бронирование без зафиксированной длительности получает длительность услуги
+		{ClientID: 1, ServiceID: service.ID, UserID: 1, BookingTime: time.Now(), Price: models.NewMoney(100000, "RUB"), TenantID: 2},
+	}
+	require.NoError(t, database.Omit("Items").Create(&bookings).Error)
+
+	require.NoError(t, db.CreateBookingItems(database))
+	require.NoError(t, db.CreateBookingItems(database))
+
+	var items []models.BookingItem
+	require.NoError(t, database.Order("booking_id").Find(&items).Error)
+	require.Len(t, items, 2)
+	assert.Equal(t, bookings[0].ID, items[0].BookingID)
+	assert.Equal(t, service.ID, items[0].ServiceID)
+	assert.Equal(t, models.NewMoney(150000, "RUB"), items[0].Price)
+	assert.Equal(t, 90, items[0].Duration)
+	assert.Equal(t, 60, items[1].Duration)
+	assert.Equal(t, 2, items[1].TenantID)
+}
+
+func TestNormalizeContacts(t *testing.T) {
+	database, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, database.AutoMigrate(&models.Client{}, &models.User{}))
+
+	clients := []models.Client{
+		{FirstName: "Иван", PhoneNumber: "+7 (999) 123-45-67", Email: "Ivan@Example.com", TgID: 1},
+		{FirstName: "Петр", PhoneNumber: "89997654321", Email: "petr@example.com", TgID: 2},
+		{FirstName: "Анна", PhoneNumber: "не помню", Email: "anna@example.com", TgID: 3, TenantID: 2},
+		// После приведения к нижнему регистру email совпадет с уже существующим
+		{FirstName: "Мария", PhoneNumber: "+79990000003", Email: "PETR@example.com", TgID: 4},
+	}
+	require.NoError(t, database.Create(&clients).Error)
+	user := &models.User{Username: "barber", PasswordHash: "hash", Role: models.RoleBarber, PhoneNumber: "8 (999) 000-00-01"}
+	require.NoError(t, database.Omit("Email").Create(user).Error)
+
+	result, err := db.NormalizeContacts(database, true)
+	require.NoError(t, err)
+	assert.Equal(t, 5, result.Checked)
+	assert.Equal(t, 4, result.Updated)
+	var unchanged models.Client
+	require.NoError(t, database.First(&unchanged, clients[0].ID).Error)
+	assert.Equal(t, "+7 (999) 123-45-67", unchanged.PhoneNumber)
+
+	result, err = db.NormalizeContacts(database, false)
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Updated)
+	require.Len(t, result.Issues, 2)
+	assert.Equal(t, db.ContactIssue{Table: "clients", ID: clients[2].ID, Field: "phone_number", Value: "не помню", Reason: models.ErrInvalidPhone.Error()}, result.Issues[0])
+	assert.Equal(t, clients[3].ID, result.Issues[1].ID)
+	assert.Equal(t, "email", result.Issues[1].Field)
+
+	var stored []models.Client
+	require.NoError(t, database.Order("id").Find(&stored).Error)
+	assert.Equal(t, "+79991234567", stored[0].PhoneNumber)
+	assert.Equal(t, "ivan@example.com", stored[0].Email)
+	assert.Equal(t, "+79997654321", stored[1].PhoneNumber)
+	assert.Equal(t, "не помню", stored[2].PhoneNumber)
+	assert.Equal(t, "PETR@example.com", stored[3].Email)
+
+	var barber models.User
+	require.NoError(t, database.First(&barber, user.ID).Error)
+	assert.Equal(t, "+79990000001", barber.PhoneNumber)
+
+	// Повторный запуск ничего не меняет
+	result, err = db.NormalizeContacts(database, false)
+	require.NoError(t, err)
+	assert.Zero(t, result.Updated)
+}
@@ -0,0 +1,281 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/configs"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/app"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/auth"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/middleware"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/tenant"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupRouterDB создает базу со всеми таблицами API. Одно соединение: у каждого соединения
+// с :memory: своя база
+func setupRouterDB(t *testing.T) *gorm.DB {
+	configs.AppConfigInstance = &configs.Config{App: configs.AppConfig{JWTSecret: "secret"}}
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	require.NoError(t, tenant.Register(db))
+	require.NoError(t, db.AutoMigrate(
+		&models.Bookings{}, &models.BookingItem{}, &models.BookingStatusChange{}, &models.User{}, &models.Client{},
+		&models.ClientNote{}, &models.ClientField{}, &models.Schedule{}, &models.ScheduleOverride{}, &models.Service{},
+		&models.ServiceLocationPrice{}, &models.BarberService{}, &models.Location{}, &models.HistoryLogs{},
+		&models.Notification{}, &models.NotificationTemplate{}, &models.Break{}, &models.BreakException{},
+		&models.RefreshToken{}, &models.RevokedToken{}, &models.Tenant{}, &models.OwnerRegistration{},
+	))
+	return db
+}
+
+// request выполняет запрос к роутеру; body кодируется в JSON
+func request(t *testing.T, router http.Handler, method, path, token string, body interface{}, headers ...string) *httptest.ResponseRecorder {
+	var payload bytes.Buffer
+	if body != nil {
+		require.NoError(t, json.NewEncoder(&payload).Encode(body))
+	}
+	r := httptest.NewRequest(method, path, &payload)
+	r.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		r.Header.Set(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	return w
+}
+
+func TestRegisterOwner(t *testing.T) {
+	db := setupRouterDB(t)
+	router := app.SetupRouter(tenant.Scope(db, tenant.DefaultID))
+
+	owner := map[string]string{"username": "owner", "password": "secret", "email": "owner@example.com"}
+	assert.Equal(t, http.StatusCreated, request(t, router, http.MethodPost, "/api/auth/register", "", owner).Code)
+	intruder := map[string]string{"username": "intruder", "password": "secret"}
+	assert.Equal(t, http.StatusForbidden, request(t, router, http.MethodPost, "/api/auth/register", "", intruder).Code)
+
+	var stored models.User
+	require.NoError(t, db.Where("username = ?", "owner").First(&stored).Error)
+	assert.Equal(t, models.RoleOwner, stored.Role)
+
+	// В многотенантном режиме регистрация закрыта даже у арендатора без сотрудников
+	require.NoError(t, db.Create(&models.Tenant{Name: "Первый", IsActive: true}).Error)
+	shop := &models.Tenant{Name: "Новый барбершоп", IsActive: true}
+	require.NoError(t, db.Create(shop).Error)
+	tenants := app.NewTenantRouter(db)
+	w := request(t, tenants, http.MethodPost, "/api/auth/register", "", intruder, middleware.TenantHeader, strconv.Itoa(shop.ID))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	var count int64
+	require.NoError(t, db.Model(&models.User{}).Where("tenant_id = ?", shop.ID).Count(&count).Error)
+	assert.Zero(t, count)
+}
+
+// staff — сотрудники арендатора по умолчанию и их токены
+type staff struct {
+	ids    map[string]int
+	tokens map[string]string
+}
+
+// seedStaff создает владельца, администратора, двух барберов и администратора ресепшн, услугу «Стрижка»
+// на 60 минут, назначенную обоим барберам, клиента и расписание барберов по понедельникам с 10:00 до 13:00
+func seedStaff(t *testing.T, db *gorm.DB) *staff {
+	scoped := tenant.Scope(db, tenant.DefaultID)
+	s := &staff{ids: make(map[string]int), tokens: make(map[string]string)}
+	for _, user := range []models.User{
+		{Username: "owner", Role: models.RoleOwner},
+		{Username: "admin", Role: models.RoleAdmin},
+		{Username: "barber", Role: models.RoleBarber},
+		{Username: "other_barber", Role: models.RoleBarber},
+		{Username: "desk", Role: models.RoleReceptionist},
+	} {
+		user.PasswordHash = "hash"
+		require.NoError(t, scoped.Omit("Email").Create(&user).Error)
+		token, _, err := auth.GenerateToken(user.ID, tenant.DefaultID, user.Role)
+		require.NoError(t, err)
+		s.ids[user.Username], s.tokens[user.Username] = user.ID, token
+	}
+
+	require.NoError(t, scoped.Create(&models.Service{Name: "Стрижка", Price: models.NewMoney(100000, "RUB"), Duration: 60, IsActive: true}).Error)
+	require.NoError(t, scoped.Create(&models.Client{FirstName: "Иван", PhoneNumber: "+79990000001"}).Error)
+	for _, barber := range []string{"barber", "other_barber"} {
+		require.NoError(t, scoped.Omit("User").Create(&models.BarberService{ServiceID: 1, UserID: s.ids[barber]}).Error)
+		require.NoError(t, scoped.Create(&models.Schedule{UserID: s.ids[barber], ScheduleDay: models.Monday,
+			StartTime: models.NewTimeOfDay(10, 0), EndTime: models.NewTimeOfDay(13, 0)}).Error)
+	}
+	return s
+}
+
+// nextMonday возвращает понедельник не ранее чем через неделю
+func nextMonday() time.Time {
+	day := time.Now().UTC().AddDate(0, 0, 7)
+	for day.Weekday() != time.Monday {
+		day = day.AddDate(0, 0, 1)
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func TestBookingErrorStatuses(t *testing.T) {
+	db := setupRouterDB(t)
+	s := seedStaff(t, db)
+	router := app.SetupRouter(tenant.Scope(db, tenant.DefaultID))
+	day := nextMonday()
+	require.NoError(t, tenant.Scope(db, tenant.DefaultID).Omit("User").Create(&models.Break{UserID: s.ids["barber"],
+		BreakStart: day.Add(11 * time.Hour), BreakEnd: day.Add(11*time.Hour + 30*time.Minute)}).Error)
+
+	book := func(start time.Duration) int {
+		booking := map[string]interface{}{"client_id": 1, "service_id": 1, "user_id": s.ids["barber"], "booking_time": day.Add(start).Format(time.RFC3339)}
+		return request(t, router, http.MethodPost, "/api/bookings/", s.tokens["owner"], booking).Code
+	}
+	assert.Equal(t, http.StatusUnprocessableEntity, book(9*time.Hour), "до начала смены")
+	assert.Equal(t, http.StatusUnprocessableEntity, book(12*time.Hour+30*time.Minute), "после конца смены")
+	assert.Equal(t, http.StatusConflict, book(10*time.Hour+45*time.Minute), "на перерыв")
+	assert.Equal(t, http.StatusCreated, book(10*time.Hour))
+	assert.Equal(t, http.StatusConflict, book(10*time.Hour+15*time.Minute), "пересечение с бронированием")
+
+	// Завершенное бронирование не редактируется
+	require.NoError(t, db.Model(&models.Bookings{}).Where("id = ?", 1).Update("status", models.BookingStatusCompleted).Error)
+	moved := map[string]interface{}{"client_id": 1, "service_id": 1, "user_id": s.ids["barber"], "booking_time": day.Add(12 * time.Hour).Format(time.RFC3339)}
+	assert.Equal(t, http.StatusConflict, request(t, router, http.MethodPut, "/api/bookings/1", s.tokens["owner"], moved).Code)
+
+	// Услуга не назначена сотруднику
+	unassigned := map[string]interface{}{"client_id": 1, "service_id": 1, "user_id": s.ids["admin"], "booking_time": day.Add(10 * time.Hour).Format(time.RFC3339)}
+	assert.Equal(t, http.StatusUnprocessableEntity, request(t, router, http.MethodPost, "/api/bookings/", s.tokens["owner"], unassigned).Code)
+}
+
+func TestRolePermissions(t *testing.T) {
+	db := setupRouterDB(t)
+	s := seedStaff(t, db)
+	scoped := tenant.Scope(db, tenant.DefaultID)
+	router := app.SetupRouter(scoped)
+	day := nextMonday()
+
+	other := s.ids["other_barber"]
+	booking := &models.Bookings{ClientID: 1, UserID: other, ServiceID: 1, BookingTime: day.Add(10 * time.Hour), Status: models.BookingStatusPending}
+	require.NoError(t, scoped.Omit("Client", "User", "Service").Create(booking).Error)
+	var schedule models.Schedule
+	require.NoError(t, scoped.Where("user_id = ?", other).First(&schedule).Error)
+	breakModel := &models.Break{UserID: other, BreakStart: day.Add(11 * time.Hour), BreakEnd: day.Add(11*time.Hour + 30*time.Minute)}
+	require.NoError(t, scoped.Omit("User").Create(breakModel).Error)
+
+	barber := s.tokens["barber"]
+	assert.Equal(t, http.StatusForbidden, request(t, router, http.MethodDelete, "/api/users/"+strconv.Itoa(s.ids["desk"]), barber, nil).Code)
+	assert.Equal(t, http.StatusForbidden, request(t, router, http.MethodPost, "/api/services/", barber,
+		map[string]interface{}{"name": "Бритье", "price": 50000, "duration": 30}).Code)
+
+	// Чужие записи барбер не читает, не меняет и не удаляет
+	foreign := map[string]string{
+		"/api/bookings/" + strconv.Itoa(booking.ID):   `{"client_id":1,"service_id":1,"user_id":` + strconv.Itoa(other) + `}`,
+		"/api/schedules/" + strconv.Itoa(schedule.ID): `{"user_id":` + strconv.Itoa(other) + `,"schedule_day":"Monday","start_time":"09:00","end_time":"18:00"}`,
+		"/api/breaks/" + strconv.Itoa(breakModel.ID):  `{"user_id":` + strconv.Itoa(other) + `}`,
+	}
+	for path, body := range foreign {
+		for _, method := range []string{http.MethodGet, http.MethodPut, http.MethodDelete} {
+			w := request(t, router, method, path, barber, json.RawMessage(body))
+			assert.Contains(t, []int{http.StatusForbidden, http.StatusNotFound}, w.Code, "%s %s", method, path)
+		}
+	}
+	var stored models.Bookings
+	require.NoError(t, scoped.First(&stored, booking.ID).Error)
+	assert.Equal(t, other, stored.UserID)
+	assert.True(t, booking.BookingTime.Equal(stored.BookingTime))
+	var storedSchedule models.Schedule
+	require.NoError(t, scoped.First(&storedSchedule, schedule.ID).Error)
+	assert.Equal(t, schedule.StartTime, storedSchedule.StartTime)
+	require.NoError(t, scoped.First(&models.Break{}, breakModel.ID).Error)
+
+	// В общем списке барбер видит только свои бронирования
+	w := request(t, router, http.MethodGet, "/api/bookings/", barber, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var list struct {
+		Data []models.Bookings `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Empty(t, list.Data)
+
+	// Ресепшн читает бронирования, но не управляет сотрудниками
+	desk := s.tokens["desk"]
+	assert.Equal(t, http.StatusOK, request(t, router, http.MethodGet, "/api/bookings/", desk, nil).Code)
+	assert.Equal(t, http.StatusOK, request(t, router, http.MethodGet, "/api/bookings/"+strconv.Itoa(booking.ID), desk, nil).Code)
+	assert.Equal(t, http.StatusForbidden, request(t, router, http.MethodDelete, "/api/users/"+strconv.Itoa(s.ids["barber"]), desk, nil).Code)
+
+	// Пустая или неизвестная роль не дает доступа ни к чему
+	for _, role := range []string{"", "стажер"} {
+		token, _, err := auth.GenerateToken(s.ids["barber"], tenant.DefaultID, role)
+		require.NoError(t, err)
+		for _, path := range []string{"/api/bookings/", "/api/clients/", "/api/schedules/"} {
+			assert.Equal(t, http.StatusForbidden, request(t, router, http.MethodGet, path, token, nil).Code, "роль %q: %s", role, path)
+		}
+	}
+}
+
+func TestFindDuplicatesRequiresLimit(t *testing.T) {
+	db := setupRouterDB(t)
+	s := seedStaff(t, db)
+	router := app.SetupRouter(tenant.Scope(db, tenant.DefaultID))
+
+	assert.Equal(t, http.StatusBadRequest, request(t, router, http.MethodGet, "/api/clients/duplicates", s.tokens["admin"], nil).Code)
+	assert.Equal(t, http.StatusBadRequest, request(t, router, http.MethodGet, "/api/clients/duplicates?limit=0", s.tokens["admin"], nil).Code)
+	w := request(t, router, http.MethodGet, "/api/clients/duplicates?limit=10", s.tokens["admin"], nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var search struct {
+		Data struct {
+			Candidates []json.RawMessage `json:"candidates"`
+			Truncated  *bool             `json:"truncated"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &search))
+	assert.NotNil(t, search.Data.Candidates)
+	require.NotNil(t, search.Data.Truncated)
+	assert.False(t, *search.Data.Truncated)
+}
+
+func TestCreateBookingIgnoresStatusHistory(t *testing.T) {
+	db := setupRouterDB(t)
+	s := seedStaff(t, db)
+	router := app.SetupRouter(tenant.Scope(db, tenant.DefaultID))
+
+	booking := map[string]interface{}{
+		"client_id": 1, "service_id": 1, "user_id": s.ids["barber"], "booking_time": nextMonday().Add(10 * time.Hour).Format(time.RFC3339),
+		"status":         models.BookingStatusCompleted,
+		"status_history": []map[string]interface{}{{"from_status": "pending", "to_status": "completed", "changed_by": s.ids["owner"]}},
+		"client":         map[string]interface{}{"id": 1, "first_name": "Подмена"},
+		"user":           map[string]interface{}{"id": s.ids["barber"], "username": "barber", "role": models.RoleOwner},
+	}
+	w := request(t, router, http.MethodPost, "/api/bookings/", s.tokens["desk"], booking)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var created struct {
+		Data struct {
+			Status        string            `json:"status"`
+			StatusHistory []json.RawMessage `json:"status_history"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, models.BookingStatusPending, created.Data.Status)
+	assert.Empty(t, created.Data.StatusHistory)
+
+	var changes int64
+	require.NoError(t, db.Model(&models.BookingStatusChange{}).Count(&changes).Error)
+	assert.Zero(t, changes)
+	var client models.Client
+	require.NoError(t, db.First(&client, 1).Error)
+	assert.Equal(t, "Иван", client.FirstName)
+	var barber models.User
+	require.NoError(t, db.First(&barber, s.ids["barber"]).Error)
+	assert.Equal(t, models.RoleBarber, barber.Role)
+}
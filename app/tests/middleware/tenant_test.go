@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"github.com/0sokrat0/GoGRAFFApi.git/app/configs"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/auth"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/middleware"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveTenant(t *testing.T) {
+	configs.AppConfigInstance = &configs.Config{App: configs.AppConfig{JWTSecret: "secret"}}
+	token, _, err := auth.GenerateToken(1, 2, "admin")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		header   string
+		token    string
+		expected int
+		err      error
+	}{
+		{name: "header", header: "3", expected: 3},
+		{name: "token", token: token, expected: 2},
+		{name: "token and matching header", header: "2", token: token, expected: 2},
+		{name: "token and foreign header", header: "3", token: token, err: middleware.ErrTenantMismatch},
+		{name: "invalid token falls back to header", header: "3", token: "broken", expected: 3},
+		{name: "invalid header", header: "shop", err: middleware.ErrInvalidTenant},
+		{name: "nothing", err: middleware.ErrTenantRequired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/clients", nil)
+			if tt.header != "" {
+				r.Header.Set(middleware.TenantHeader, tt.header)
+			}
+			if tt.token != "" {
+				r.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+
+			tenantID, err := middleware.ResolveTenant(r)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, tenantID)
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package models
+
+import (
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientField_Validate(t *testing.T) {
+	valid := []models.ClientField{
+		{Key: "birthday", Label: "День рождения", Type: models.ClientFieldDate},
+		{Key: "hair_type", Label: "Тип волос", Type: models.ClientFieldSelect, Options: []string{"прямые", "вьющиеся"}},
+	}
+	for _, field := range valid {
+		assert.NoError(t, field.Validate(), field.Key)
+	}
+
+	invalid := []models.ClientField{
+		{Key: "Birthday", Label: "День рождения", Type: models.ClientFieldDate},
+		{Key: "день", Label: "День", Type: models.ClientFieldText},
+		{Key: "note", Label: " ", Type: models.ClientFieldText},
+		{Key: "color", Label: "Цвет", Type: "color"},
+		{Key: "hair_type", Label: "Тип волос", Type: models.ClientFieldSelect},
+		{Key: "visits", Label: "Визиты", Type: models.ClientFieldNumber, Options: []string{"1"}},
+	}
+	for _, field := range invalid {
+		assert.ErrorIs(t, field.Validate(), models.ErrInvalidClientField, field.Key)
+	}
+}
+
+func TestClientField_NormalizeValue(t *testing.T) {
+	tests := []struct {
+		field models.ClientField
+		value interface{}
+		want  interface{}
+		ok    bool
+	}{
+		{models.ClientField{Type: models.ClientFieldText}, "  насадка №2 ", "насадка №2", true},
+		{models.ClientField{Type: models.ClientFieldText}, 42.0, nil, false},
+		{models.ClientField{Type: models.ClientFieldNumber}, 42.5, 42.5, true},
+		{models.ClientField{Type: models.ClientFieldNumber}, "42", nil, false},
+		{models.ClientField{Type: models.ClientFieldBoolean}, true, true, true},
+		{models.ClientField{Type: models.ClientFieldBoolean}, "true", nil, false},
+		{models.ClientField{Type: models.ClientFieldDate}, "1990-05-17", "1990-05-17", true},
+		{models.ClientField{Type: models.ClientFieldDate}, "17.05.1990", nil, false},
+		{models.ClientField{Type: models.ClientFieldSelect, Options: []string{"прямые"}}, "прямые", "прямые", true},
+		{models.ClientField{Type: models.ClientFieldSelect, Options: []string{"прямые"}}, "кудрявые", nil, false},
+	}
+	for _, tt := range tests {
+		got, err := tt.field.NormalizeValue(tt.value)
+		if tt.ok {
+			require.NoError(t, err, tt.field.Type)
+		} else {
+			assert.ErrorIs(t, err, models.ErrInvalidFieldValue, tt.field.Type)
+		}
+		assert.Equal(t, tt.want, got, tt.field.Type)
+	}
+}
+
+func TestNormalizeTags(t *testing.T) {
+	tags, err := models.NormalizeTags([]string{" VIP ", "студент", "vip", "Проблемный   клиент"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"vip", "студент", "проблемный клиент"}, tags)
+
+	_, err = models.NormalizeTags([]string{"vip", " "})
+	assert.ErrorIs(t, err, models.ErrInvalidTag)
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizePhone(t *testing.T) {
+	tests := []struct {
+		phone string
+		want  string
+		err   error
+	}{
+		{"+7 (999) 123-45-67", "+79991234567", nil},
+		{"89991234567", "+79991234567", nil},
+		{"8 999 123 45 67", "+79991234567", nil},
+		{"9991234567", "+79991234567", nil},
+		{"79991234567", "+79991234567", nil},
+		{"+375 29 123-45-67", "+375291234567", nil},
+		{"00 44 20 7946 0958", "+442079460958", nil},
+		{"", "", nil},
+		{"  ", "", nil},
+		{"12345", "", models.ErrInvalidPhone},
+		{"+7 999 abc", "", models.ErrInvalidPhone},
+		{"+1234567890123456", "", models.ErrInvalidPhone},
+	}
+	for _, tt := range tests {
+		got, err := models.NormalizePhone(tt.phone)
+		assert.ErrorIs(t, err, tt.err, tt.phone)
+		assert.Equal(t, tt.want, got, tt.phone)
+	}
+}
+
+func TestNormalizeEmail(t *testing.T) {
+	tests := []struct {
+		email string
+		want  string
+		err   error
+	}{
+		{" Ivan.Petrov@Example.COM ", "ivan.petrov@example.com", nil},
+		{"", "", nil},
+		{"ivan", "", models.ErrInvalidEmail},
+		{"ivan@localhost", "", models.ErrInvalidEmail},
+		{"Иван <ivan@example.com>", "", models.ErrInvalidEmail},
+		{"ivan@@example.com", "", models.ErrInvalidEmail},
+		{"ivan@example.", "", models.ErrInvalidEmail},
+	}
+	for _, tt := range tests {
+		got, err := models.NormalizeEmail(tt.email)
+		assert.ErrorIs(t, err, tt.err, tt.email)
+		assert.Equal(t, tt.want, got, tt.email)
+	}
+}
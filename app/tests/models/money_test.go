@@ -0,0 +1,68 @@
+package models
+
+import (
+	"encoding/json"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		amount   string
+		currency string
+		want     models.Money
+		err      error
+	}{
+		{"1500", "RUB", models.NewMoney(150000, "RUB"), nil},
+		{"0.1", "USD", models.NewMoney(10, "USD"), nil},
+		{"1500.5", "RUB", models.NewMoney(150050, "RUB"), nil},
+		{"1500", "JPY", models.NewMoney(1500, "JPY"), nil},
+		{"1.005", "KWD", models.NewMoney(1005, "KWD"), nil},
+		{"1500.555", "RUB", models.Money{}, models.ErrInvalidMoney},
+		{"1500.5", "JPY", models.Money{}, models.ErrInvalidMoney},
+		{"abc", "RUB", models.Money{}, models.ErrInvalidMoney},
+		{"1500", "XXX", models.Money{}, models.ErrInvalidCurrency},
+	}
+	for _, tt := range tests {
+		got, err := models.ParseMoney(tt.amount, tt.currency)
+		assert.ErrorIs(t, err, tt.err, tt.amount+" "+tt.currency)
+		assert.Equal(t, tt.want, got, tt.amount+" "+tt.currency)
+	}
+}
+
+func TestMoney_Arithmetic(t *testing.T) {
+	// 0.1 + 0.2 во float дает 0.30000000000000004, в минорных единицах — ровно 0.30
+	total, err := models.SumMoney(models.NewMoney(10, "USD"), models.NewMoney(20, "USD"))
+	require.NoError(t, err)
+	assert.Equal(t, "0.30", total.Decimal())
+
+	total, err = models.SumMoney()
+	require.NoError(t, err)
+	assert.True(t, total.IsZero())
+
+	_, err = models.SumMoney(models.NewMoney(100, "USD"), models.NewMoney(100, "EUR"))
+	assert.ErrorIs(t, err, models.ErrCurrencyMismatch)
+
+	assert.Equal(t, models.NewMoney(450000, "RUB"), models.NewMoney(150000, "RUB").Mul(3))
+	assert.Equal(t, "-12.05 RUB", models.NewMoney(-1205, "RUB").String())
+	assert.Equal(t, "1500 JPY", models.NewMoney(1500, "JPY").String())
+}
+
+func TestMoney_JSON(t *testing.T) {
+	data, err := json.Marshal(models.NewMoney(150050, "RUB"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"amount":"1500.50","currency":"RUB"}`, string(data))
+
+	var money models.Money
+	require.NoError(t, json.Unmarshal([]byte(`{"amount":"1500.50","currency":"RUB"}`), &money))
+	assert.Equal(t, models.NewMoney(150050, "RUB"), money)
+	require.NoError(t, json.Unmarshal([]byte(`{"amount":19.99,"currency":"usd"}`), &money))
+	assert.Equal(t, models.NewMoney(1999, "USD"), money)
+
+	assert.ErrorIs(t, json.Unmarshal([]byte(`{"amount":"1500"}`), &money), models.ErrInvalidCurrency)
+	assert.ErrorIs(t, json.Unmarshal([]byte(`{"amount":"15.001","currency":"RUB"}`), &money), models.ErrInvalidMoney)
+	assert.ErrorIs(t, json.Unmarshal([]byte(`1500`), &money), models.ErrInvalidMoney)
+}
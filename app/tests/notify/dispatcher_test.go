@@ -0,0 +1,113 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/configs"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/notify"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/repositories"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupDispatcher(t *testing.T, senders map[string]notify.Sender) (*gorm.DB, *notify.Dispatcher, *time.Time) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Client{}, &models.Notification{}))
+
+	dispatcher := notify.NewDispatcher(
+		repositories.NewNotificationRepository(db),
+		repositories.NewClientRepository(db),
+		senders,
+		configs.NotificationsConfig{MaxAttempts: 3, RetryBaseDelay: time.Minute, RetryMaxDelay: 10 * time.Minute},
+	)
+	now := time.Date(2025, 1, 10, 10, 0, 0, 0, time.UTC)
+	dispatcher.SetClock(func() time.Time { return now })
+	return db, dispatcher, &now
+}
+
+func TestDispatcher_RoutesByNotificationType(t *testing.T) {
+	telegram := notify.NewFakeSender()
+	email := notify.NewFakeSender()
+	db, dispatcher, _ := setupDispatcher(t, map[string]notify.Sender{
+		models.NotificationTypeTelegram: telegram,
+		models.NotificationTypeEmail:    email,
+	})
+
+	client := &models.Client{FirstName: "Ivan", Email: "ivan@example.com", TgID: 12345, PhoneNumber: "+79990000000"}
+	require.NoError(t, db.Create(client).Error)
+
+	notifications := []models.Notification{
+		{ClientID: client.ID, Message: "tg", NotificationType: models.NotificationTypeTelegram},
+		{ClientID: client.ID, Message: "mail", NotificationType: models.NotificationTypeEmail},
+		{ClientID: client.ID, Message: "sms", NotificationType: models.NotificationTypeSMS},
+	}
+	require.NoError(t, db.Create(&notifications).Error)
+
+	sent, err := dispatcher.DispatchPending(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, sent)
+
+	require.Len(t, telegram.Sent(), 1)
+	assert.Equal(t, "12345", telegram.Sent()[0].To)
+	require.Len(t, email.Sent(), 1)
+	assert.Equal(t, "ivan@example.com", email.Sent()[0].To)
+
+	var stored []models.Notification
+	require.NoError(t, db.Order("id").Find(&stored).Error)
+	assert.Equal(t, models.NotificationStatusSent, stored[0].Status)
+	assert.NotNil(t, stored[0].SentAt)
+	// Канал SMS не настроен — повторять бессмысленно
+	assert.Equal(t, models.NotificationStatusFailed, stored[2].Status)
+	assert.NotEmpty(t, stored[2].LastError)
+}
+
+func TestDispatcher_RetriesWithBackoff(t *testing.T) {
+	sms := notify.NewFakeSender()
+	db, dispatcher, now := setupDispatcher(t, map[string]notify.Sender{models.NotificationTypeSMS: sms})
+
+	client := &models.Client{FirstName: "Anna", Email: "anna@example.com", TgID: 1, PhoneNumber: "+79991111111"}
+	require.NoError(t, db.Create(client).Error)
+	notification := &models.Notification{ClientID: client.ID, Message: "hi", NotificationType: models.NotificationTypeSMS}
+	require.NoError(t, db.Create(notification).Error)
+
+	sms.Fail(errors.New("gateway timeout"), errors.New("gateway timeout"), errors.New("gateway timeout"))
+
+	// Первая ошибка: повтор через минуту
+	_, err := dispatcher.DispatchPending(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, db.First(notification, notification.ID).Error)
+	assert.Equal(t, models.NotificationStatusPending, notification.Status)
+	assert.Equal(t, 1, notification.Attempts)
+	require.NotNil(t, notification.NextAttemptAt)
+	assert.True(t, notification.NextAttemptAt.Equal(now.Add(time.Minute)))
+
+	// До наступления времени повтора уведомление не выбирается
+	sent, err := dispatcher.DispatchPending(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, sent)
+	require.NoError(t, db.First(notification, notification.ID).Error)
+	assert.Equal(t, 1, notification.Attempts)
+
+	// Вторая ошибка: задержка удваивается
+	*now = now.Add(time.Minute)
+	_, err = dispatcher.DispatchPending(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, db.First(notification, notification.ID).Error)
+	assert.True(t, notification.NextAttemptAt.Equal(now.Add(2*time.Minute)))
+
+	// Третья ошибка исчерпывает попытки
+	*now = now.Add(2 * time.Minute)
+	_, err = dispatcher.DispatchPending(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, db.First(notification, notification.ID).Error)
+	assert.Equal(t, models.NotificationStatusFailed, notification.Status)
+	assert.Equal(t, 3, notification.Attempts)
+	assert.Empty(t, sms.Sent())
+}
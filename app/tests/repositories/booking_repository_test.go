@@ -0,0 +1,227 @@
+package repositories
+
+import (
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/repositories"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestBookingRepository_FindOverlappingBookings(t *testing.T) {
+	db := setupTestDB(t, &models.Bookings{}, &models.BookingItem{}, &models.Service{})
+	repo := repositories.NewBookingRepository(db)
+
+	haircut := &models.Service{Name: "Haircut", Price: models.NewMoney(10000, "RUB"), Duration: 60, IsActive: true}
+	require.NoError(t, db.Create(haircut).Error)
+
+	base := time.Date(2025, 1, 10, 10, 0, 0, 0, time.UTC)
+	bookings := []models.Bookings{
+		{ClientID: 1, ServiceID: haircut.ID, UserID: 1, BookingTime: base},
+		{ClientID: 2, ServiceID: haircut.ID, UserID: 1, BookingTime: base.Add(2 * time.Hour)},
+		{ClientID: 3, ServiceID: haircut.ID, UserID: 2, BookingTime: base},
+		{ClientID: 4, ServiceID: haircut.ID, UserID: 1, BookingTime: base.Add(30 * time.Minute), Status: models.BookingStatusCancelled},
+	}
+	for i := range bookings {
+		require.NoError(t, repo.CreateBooking(&bookings[i]))
+	}
+
+	// 10:30–11:00 пересекается с 10:00–11:00
+	overlapping, err := repo.FindOverlappingBookings(1, base.Add(30*time.Minute), base.Add(time.Hour), 0)
+	require.NoError(t, err)
+	require.Len(t, overlapping, 1)
+	assert.Equal(t, bookings[0].ID, overlapping[0].ID)
+
+	// 11:00–12:00 начинается ровно в момент окончания первого бронирования
+	overlapping, err = repo.FindOverlappingBookings(1, base.Add(time.Hour), base.Add(2*time.Hour), 0)
+	require.NoError(t, err)
+	assert.Empty(t, overlapping)
+
+	// Исключение собственного бронирования при обновлении
+	overlapping, err = repo.FindOverlappingBookings(1, base, base.Add(time.Hour), bookings[0].ID)
+	require.NoError(t, err)
+	assert.Empty(t, overlapping)
+}
+
+func TestBookingRepository_SaveRejectsOverlap(t *testing.T) {
+	db := setupTestDB(t, &models.Bookings{}, &models.BookingItem{}, &models.User{})
+	repo := repositories.NewBookingRepository(db)
+
+	// Запоминаем запросы, которые блокируют строку сотрудника
+	var locks []string
+	require.NoError(t, db.Callback().Query().Before("gorm:query").Register("test:locks", func(tx *gorm.DB) {
+		if _, ok := tx.Statement.Clauses["FOR"]; ok {
+			locks = append(locks, tx.Statement.Table)
+		}
+	}))
+
+	start := time.Date(2025, 1, 10, 10, 0, 0, 0, time.UTC)
+	first := &models.Bookings{ClientID: 1, ServiceID: 1, UserID: 1, BookingTime: start, Duration: 60}
+	require.NoError(t, repo.CreateBooking(first))
+	assert.Equal(t, []string{"users"}, locks)
+
+	// 10:30–11:30 пересекается с 10:00–11:00 и не сохраняется
+	second := &models.Bookings{ClientID: 2, ServiceID: 1, UserID: 1, BookingTime: start.Add(30 * time.Minute), Duration: 60}
+	err := repo.CreateBooking(second)
+	var conflict *repositories.BookingConflictError
+	require.ErrorAs(t, err, &conflict)
+	assert.ErrorIs(t, err, repositories.ErrTimeSlotOccupied)
+	assert.Equal(t, []int{first.ID}, conflict.ConflictingIDs)
+	var count int64
+	require.NoError(t, db.Model(&models.Bookings{}).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+
+	// Отмененное бронирование и другой сотрудник не конфликтуют
+	require.NoError(t, repo.CreateBooking(&models.Bookings{ClientID: 2, ServiceID: 1, UserID: 1, BookingTime: start, Duration: 60, Status: models.BookingStatusCancelled}))
+	require.NoError(t, repo.CreateBooking(&models.Bookings{ClientID: 2, ServiceID: 1, UserID: 2, BookingTime: start, Duration: 60}))
+
+	// Перенос на занятое время тоже отклоняется, а собственный интервал не мешает сдвигу
+	later := &models.Bookings{ClientID: 3, ServiceID: 1, UserID: 1, BookingTime: start.Add(2 * time.Hour), Duration: 60}
+	require.NoError(t, repo.CreateBooking(later))
+	later.BookingTime = start.Add(45 * time.Minute)
+	assert.ErrorIs(t, repo.UpdateBooking(later), repositories.ErrTimeSlotOccupied)
+	later.BookingTime = start.Add(90 * time.Minute)
+	require.NoError(t, repo.UpdateBooking(later))
+}
+
+func TestBookingRepository_CreateBookingIgnoresAssociations(t *testing.T) {
+	db := setupTestDB(t, &models.Bookings{}, &models.BookingItem{}, &models.BookingStatusChange{}, &models.Service{}, &models.Client{}, &models.User{})
+	repo := repositories.NewBookingRepository(db)
+
+	haircut := &models.Service{Name: "Haircut", Price: models.NewMoney(10000, "RUB"), Duration: 60, IsActive: true}
+	require.NoError(t, db.Create(haircut).Error)
+	client := &models.Client{FirstName: "Иван", PhoneNumber: "+79990000001"}
+	require.NoError(t, db.Create(client).Error)
+
+	booking := &models.Bookings{
+		ClientID: client.ID, ServiceID: haircut.ID, UserID: 1, BookingTime: time.Date(2025, 1, 10, 10, 0, 0, 0, time.UTC),
+		Client:        models.Client{ID: client.ID, FirstName: "Подмена"},
+		Service:       models.Service{ID: haircut.ID, Name: "Подмена", Price: models.NewMoney(1, "RUB"), Duration: 1},
+		User:          models.User{ID: 1, Username: "intruder", PasswordHash: "hash", Role: models.RoleOwner},
+		StatusHistory: []models.BookingStatusChange{{FromStatus: models.BookingStatusPending, ToStatus: models.BookingStatusCompleted, ChangedBy: 1}},
+	}
+	require.NoError(t, repo.CreateBooking(booking))
+
+	var changes int64
+	require.NoError(t, db.Model(&models.BookingStatusChange{}).Count(&changes).Error)
+	assert.Zero(t, changes)
+	var users int64
+	require.NoError(t, db.Model(&models.User{}).Count(&users).Error)
+	assert.Zero(t, users)
+
+	var storedClient models.Client
+	require.NoError(t, db.First(&storedClient, client.ID).Error)
+	assert.Equal(t, "Иван", storedClient.FirstName)
+	var storedService models.Service
+	require.NoError(t, db.First(&storedService, haircut.ID).Error)
+	assert.Equal(t, "Haircut", storedService.Name)
+}
+
+func TestBookingRepository_ChangeBookingStatus(t *testing.T) {
+	db := setupTestDB(t, &models.Bookings{}, &models.BookingItem{}, &models.BookingStatusChange{})
+	repo := repositories.NewBookingRepository(db)
+
+	booking := &models.Bookings{
+		ClientID:    1,
+		ServiceID:   1,
+		UserID:      1,
+		BookingTime: time.Date(2025, 1, 10, 10, 0, 0, 0, time.UTC),
+	}
+	require.NoError(t, repo.CreateBooking(booking))
+
+	change := &models.BookingStatusChange{
+		BookingID:  booking.ID,
+		FromStatus: models.BookingStatusPending,
+		ToStatus:   models.BookingStatusConfirmed,
+		ChangedBy:  7,
+	}
+	require.NoError(t, repo.ChangeBookingStatus(change))
+	require.NotZero(t, change.ID)
+
+	var updated models.Bookings
+	require.NoError(t, db.Preload("StatusHistory").First(&updated, booking.ID).Error)
+	assert.Equal(t, models.BookingStatusConfirmed, updated.Status)
+	require.Len(t, updated.StatusHistory, 1)
+	assert.Equal(t, 7, updated.StatusHistory[0].ChangedBy)
+
+	// Повторный переход из устаревшего статуса отклоняется
+	stale := &models.BookingStatusChange{
+		BookingID:  booking.ID,
+		FromStatus: models.BookingStatusPending,
+		ToStatus:   models.BookingStatusCancelled,
+		ChangedBy:  7,
+	}
+	err := repo.ChangeBookingStatus(stale)
+	assert.Equal(t, repositories.ErrBookingStatusChanged, err)
+}
+
+func TestBookingRepository_GetAllBookings(t *testing.T) {
+	db := setupTestDB(t, &models.Bookings{}, &models.BookingItem{}, &models.Service{}, &models.Client{}, &models.ClientNote{}, &models.User{})
+	repo := repositories.NewBookingRepository(db)
+
+	base := time.Date(2025, 1, 10, 10, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		booking := &models.Bookings{ClientID: 1, ServiceID: 1, UserID: 1 + i%2, BookingTime: base.Add(time.Duration(i) * time.Hour)}
+		require.NoError(t, repo.CreateBooking(booking))
+	}
+
+	// Первая страница: сортировка по времени в обратном порядке
+	page, total, err := repo.GetAllBookings(repositories.ListQuery{Limit: 2, Sort: "-booking_time"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), total)
+	require.Len(t, page, 2)
+	assert.True(t, page[0].BookingTime.Equal(base.Add(4*time.Hour)))
+
+	// Курсор указывает на следующую страницу, на последней странице его нет
+	query := repositories.ListQuery{Limit: 2, Sort: "-booking_time"}
+	next, err := repositories.DecodeCursor(query.NextCursor(total))
+	require.NoError(t, err)
+	assert.Equal(t, 2, next)
+	assert.Empty(t, repositories.ListQuery{Limit: 2, Offset: 4}.NextCursor(total))
+
+	// Фильтр по сотруднику и периоду
+	page, total, err = repo.GetAllBookings(repositories.ListQuery{
+		Filters: map[string]string{"user_id": "1"},
+		From:    base.Add(time.Hour),
+		To:      base.Add(5 * time.Hour),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	require.Len(t, page, 2)
+
+	// Неизвестные поля сортировки и фильтров отклоняются
+	_, _, err = repo.GetAllBookings(repositories.ListQuery{Sort: "password"})
+	assert.ErrorIs(t, err, repositories.ErrInvalidListQuery)
+	_, _, err = repo.GetAllBookings(repositories.ListQuery{Filters: map[string]string{"user_id": "abc"}})
+	assert.ErrorIs(t, err, repositories.ErrInvalidListQuery)
+}
+
+func TestBookingRepository_FindOverlappingBookings_PartialOverlap(t *testing.T) {
+	db := setupTestDB(t, &models.Bookings{}, &models.BookingItem{})
+	repo := repositories.NewBookingRepository(db)
+
+	start := time.Date(2025, 1, 10, 10, 0, 0, 0, time.UTC)
+	booking := &models.Bookings{ClientID: 1, ServiceID: 1, UserID: 1, BookingTime: start, Duration: 60}
+	require.NoError(t, repo.CreateBooking(booking))
+	cancelled := &models.Bookings{ClientID: 2, ServiceID: 1, UserID: 1, BookingTime: start.Add(2 * time.Hour), Duration: 60, Status: models.BookingStatusCancelled}
+	require.NoError(t, repo.CreateBooking(cancelled))
+
+	// 10:15–10:45 лежит внутри бронирования 10:00–11:00, хотя время начала не совпадает
+	overlapping, err := repo.FindOverlappingBookings(1, start.Add(15*time.Minute), start.Add(45*time.Minute), 0)
+	require.NoError(t, err)
+	require.Len(t, overlapping, 1)
+	assert.Equal(t, booking.ID, overlapping[0].ID)
+
+	// 9:30–10:15 заходит на начало бронирования
+	overlapping, err = repo.FindOverlappingBookings(1, start.Add(-30*time.Minute), start.Add(15*time.Minute), 0)
+	require.NoError(t, err)
+	assert.Len(t, overlapping, 1)
+
+	// Отмененное бронирование не занимает время
+	overlapping, err = repo.FindOverlappingBookings(1, start.Add(2*time.Hour), start.Add(3*time.Hour), 0)
+	require.NoError(t, err)
+	assert.Empty(t, overlapping)
+}
@@ -0,0 +1,101 @@
+package repositories
+
+import (
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/repositories"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBreakRepository_CreateBreak(t *testing.T) {
+	db := setupTestDB(t, &models.Break{}, &models.BreakException{})
+	repo := repositories.NewBreakRepository(db)
+
+	start := time.Date(2025, 1, 10, 13, 0, 0, 0, time.UTC)
+	breakModel := &models.Break{
+		UserID:     1,
+		BreakStart: start,
+		BreakEnd:   start.Add(time.Hour),
+	}
+
+	err := repo.CreateBreak(breakModel)
+	require.NoError(t, err)
+	require.NotZero(t, breakModel.ID)
+}
+
+func TestBreakRepository_GetBreaksByUserInRange(t *testing.T) {
+	db := setupTestDB(t, &models.Break{}, &models.BreakException{})
+	repo := repositories.NewBreakRepository(db)
+
+	day := time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC)
+	ended := day.AddDate(0, 0, -5)
+	breaks := []models.Break{
+		{UserID: 1, BreakStart: day.Add(13 * time.Hour), BreakEnd: day.Add(14 * time.Hour)},
+		{UserID: 1, BreakStart: day.Add(37 * time.Hour), BreakEnd: day.Add(38 * time.Hour)},
+		{UserID: 2, BreakStart: day.Add(13 * time.Hour), BreakEnd: day.Add(14 * time.Hour)},
+		// Серия, начатая раньше периода, попадает в выборку, а закончившаяся до него — нет
+		{UserID: 1, BreakStart: day.AddDate(0, 0, -9).Add(16 * time.Hour), BreakEnd: day.AddDate(0, 0, -9).Add(17 * time.Hour), Recurrence: models.BreakRecurrenceDaily},
+		{UserID: 1, BreakStart: day.AddDate(0, 0, -9).Add(18 * time.Hour), BreakEnd: day.AddDate(0, 0, -9).Add(19 * time.Hour), Recurrence: models.BreakRecurrenceDaily, RecurrenceUntil: &ended},
+	}
+
+	for i := range breaks {
+		err := repo.CreateBreak(&breaks[i])
+		require.NoError(t, err)
+	}
+
+	userBreaks, err := repo.GetBreaksByUserInRange(1, day, day.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	require.Len(t, userBreaks, 2)
+	assert.ElementsMatch(t, []int{breaks[0].ID, breaks[3].ID}, []int{userBreaks[0].ID, userBreaks[1].ID})
+}
+
+func TestBreakRepository_BreakExceptions(t *testing.T) {
+	db := setupTestDB(t, &models.Break{}, &models.BreakException{})
+	repo := repositories.NewBreakRepository(db)
+
+	start := time.Date(2025, 1, 6, 13, 0, 0, 0, time.UTC)
+	series := &models.Break{UserID: 1, BreakStart: start, BreakEnd: start.Add(time.Hour), Recurrence: models.BreakRecurrenceDaily}
+	require.NoError(t, repo.CreateBreak(series))
+
+	moved := start.AddDate(0, 0, 1).Add(time.Hour)
+	replacement := &models.Break{UserID: 1, BreakStart: moved, BreakEnd: moved.Add(time.Hour), SeriesID: &series.ID}
+	require.NoError(t, repo.CreateBreakException(&models.BreakException{BreakID: series.ID, OccurrenceDate: "2025-01-07"}, replacement))
+	require.NotZero(t, replacement.ID)
+
+	err := repo.CreateBreakException(&models.BreakException{BreakID: series.ID, OccurrenceDate: "2025-01-07"}, nil)
+	assert.ErrorIs(t, err, repositories.ErrBreakExceptionConflict)
+
+	fetched, err := repo.GetBreakByID(series.ID)
+	require.NoError(t, err)
+	require.Len(t, fetched.Exceptions, 1)
+	assert.Equal(t, "2025-01-07", fetched.Exceptions[0].OccurrenceDate)
+
+	// Удаление серии удаляет и выделенные из нее вхождения
+	require.NoError(t, repo.DeleteBreak(series.ID))
+	_, err = repo.GetBreakByID(replacement.ID)
+	assert.ErrorIs(t, err, repositories.ErrBreakNotFound)
+}
+
+func TestBreakRepository_GetBreaksByUserID(t *testing.T) {
+	db := setupTestDB(t, &models.Break{}, &models.BreakException{})
+	repo := repositories.NewBreakRepository(db)
+
+	start := time.Date(2025, 1, 10, 13, 0, 0, 0, time.UTC)
+	breaks := []models.Break{
+		{UserID: 1, BreakStart: start, BreakEnd: start.Add(time.Hour)},
+		{UserID: 1, BreakStart: start.AddDate(0, 0, 1), BreakEnd: start.AddDate(0, 0, 1).Add(time.Hour)},
+		{UserID: 2, BreakStart: start, BreakEnd: start.Add(time.Hour)},
+	}
+
+	for i := range breaks {
+		err := repo.CreateBreak(&breaks[i])
+		require.NoError(t, err)
+	}
+
+	userBreaks, err := repo.GetBreaksByUserID(1)
+	require.NoError(t, err)
+	assert.Len(t, userBreaks, 2)
+}
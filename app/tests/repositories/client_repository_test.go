@@ -1,12 +1,16 @@
 package repositories
 
 import (
+	"errors"
+	"fmt"
 	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
 	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/repositories"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/tenant"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
 )
 
 func TestClientRepository_CreateClient(t *testing.T) {
@@ -64,7 +68,7 @@ func TestClientRepository_UpdateClient(t *testing.T) {
 }
 
 func TestClientRepository_DeleteClient(t *testing.T) {
-	db := setupTestDB(t, &models.Client{})
+	db := setupTestDB(t, &models.Client{}, &models.ClientNote{})
 	repo := repositories.NewClientRepository(db)
 
 	client := &models.Client{
@@ -82,3 +86,99 @@ func TestClientRepository_DeleteClient(t *testing.T) {
 	assert.Error(t, err)
 	assert.Equal(t, repositories.ErrClientNotFound, err)
 }
+
+func TestClientRepository_MergeClients(t *testing.T) {
+	db := setupTestDB(t, &models.Client{}, &models.ClientNote{}, &models.Bookings{}, &models.Notification{}, &models.HistoryLogs{})
+	repo := repositories.NewClientRepository(db)
+
+	// Запоминаем таблицы, строки которых блокируются
+	var locks []string
+	require.NoError(t, db.Callback().Query().Before("gorm:query").Register("test:locks", func(tx *gorm.DB) {
+		if _, ok := tx.Statement.Clauses["FOR"]; ok {
+			locks = append(locks, tx.Statement.Table)
+		}
+	}))
+
+	primary := &models.Client{FirstName: "Петр", PhoneNumber: "+79990000001", TgID: 1001}
+	duplicate := &models.Client{FirstName: "Петр", Email: "petr@example.com", TgID: 1002}
+	require.NoError(t, db.Create(primary).Error)
+	require.NoError(t, db.Create(duplicate).Error)
+	require.NoError(t, db.Create(&models.ClientNote{ClientID: duplicate.ID, Text: "Аллергия на лак"}).Error)
+
+	// Ошибка при объединении откатывает всю транзакцию
+	failure := errors.New("merge failed")
+	_, _, err := repo.MergeClients(duplicate.ID, primary.ID, func(primary, duplicate *models.Client) ([]models.HistoryLogs, error) {
+		return nil, failure
+	})
+	assert.ErrorIs(t, err, failure)
+
+	_, _, err = repo.MergeClients(primary.ID, 42, func(primary, duplicate *models.Client) ([]models.HistoryLogs, error) {
+		t.Fatal("merge вызван без дубликата")
+		return nil, nil
+	})
+	assert.ErrorIs(t, err, repositories.ErrClientNotFound)
+
+	merged, moved, err := repo.MergeClients(primary.ID, duplicate.ID, func(p, d *models.Client) ([]models.HistoryLogs, error) {
+		assert.Equal(t, primary.ID, p.ID)
+		assert.Equal(t, duplicate.ID, d.ID)
+		p.Email = d.Email
+		return []models.HistoryLogs{{UserID: 1, Action: models.HistoryActionMerge, EntityType: models.EntityClient, EntityID: p.ID}}, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"clients", "clients", "clients"}, locks)
+	assert.Equal(t, "petr@example.com", merged.Email)
+	assert.Equal(t, int64(1), moved.Notes)
+
+	stored, err := repo.GetClientByID(primary.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "petr@example.com", stored.Email)
+	_, err = repo.GetClientByID(duplicate.ID)
+	assert.ErrorIs(t, err, repositories.ErrClientNotFound)
+	var logs []models.HistoryLogs
+	require.NoError(t, db.Find(&logs).Error)
+	require.Len(t, logs, 1)
+	assert.Equal(t, primary.ID, logs[0].EntityID)
+}
+
+func TestClientRepository_FindDuplicateBlocks(t *testing.T) {
+	db := setupTestDB(t, &models.Client{})
+	require.NoError(t, tenant.Register(db))
+	repo := repositories.NewClientRepository(tenant.Scope(db, 1))
+
+	clients := []*models.Client{
+		{FirstName: "Анна", LastName: "Петрова", PhoneNumber: "+79990000001", Email: "anna@example.com", TgID: 1},
+		{FirstName: "Петрова", LastName: "Аня", PhoneNumber: "+79990000001", TgID: 2},
+		{FirstName: "Алла", LastName: "Пестова", Email: "alla@example.com", TgID: 3},
+	}
+	// Три клиента с одинаковым именем без фамилии образуют блок больше допустимого
+	for i := 1; i <= 3; i++ {
+		clients = append(clients, &models.Client{FirstName: "Иван", TgID: int64(10 + i), Email: fmt.Sprintf("ivan%d@example.com", i)})
+	}
+	for _, client := range clients {
+		require.NoError(t, repo.CreateClient(client))
+	}
+	// Клиент другого арендатора с тем же телефоном в блок не попадает
+	require.NoError(t, repositories.NewClientRepository(tenant.Scope(db, 2)).CreateClient(&models.Client{FirstName: "Анна", PhoneNumber: "+79990000001", TgID: 1}))
+
+	blocks, err := repo.FindDuplicateBlocks(2, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []repositories.DuplicateBlock{
+		{Key: "name:Иван", Size: 3},
+		{Key: "name:Петрова А", Size: 2, ClientIDs: []int{clients[0].ID, clients[1].ID}},
+		{Key: "phone:+79990000001", Size: 2, ClientIDs: []int{clients[0].ID, clients[1].ID}},
+	}, blocks)
+
+	// Блоки читаются постранично по возрастанию ключа
+	page, err := repo.FindDuplicateBlocks(2, 1, 1)
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	assert.Equal(t, "name:Петрова А", page[0].Key)
+	page, err = repo.FindDuplicateBlocks(2, 3, 1)
+	require.NoError(t, err)
+	assert.Empty(t, page)
+
+	found, err := repo.GetClientsByIDs([]int{clients[1].ID, clients[0].ID, 999})
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+	assert.Equal(t, clients[0].ID, found[0].ID)
+}
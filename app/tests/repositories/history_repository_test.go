@@ -0,0 +1,52 @@
+package repositories
+
+import (
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/repositories"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistoryRepository_FindLogs(t *testing.T) {
+	db := setupTestDB(t, &models.HistoryLogs{})
+	repo := repositories.NewHistoryRepository(db)
+
+	base := time.Date(2025, 1, 10, 10, 0, 0, 0, time.UTC)
+	logs := []models.HistoryLogs{
+		{UserID: 1, Action: models.HistoryActionCreate, EntityType: models.EntityBooking, EntityID: 5, CreatedAt: base},
+		{
+			UserID:     2,
+			Action:     models.HistoryActionUpdate,
+			EntityType: models.EntityBooking,
+			EntityID:   5,
+			Changes:    map[string]models.FieldChange{"status": {Before: "pending", After: "confirmed"}},
+			CreatedAt:  base.Add(time.Hour),
+		},
+		{UserID: 1, Action: models.HistoryActionDelete, EntityType: models.EntityClient, EntityID: 3, CreatedAt: base.Add(48 * time.Hour)},
+	}
+	for i := range logs {
+		require.NoError(t, repo.CreateLog(&logs[i]))
+	}
+
+	// Без фильтра: все записи, новые первыми
+	found, total, err := repo.FindLogs(repositories.ListQuery{})
+	require.NoError(t, err)
+	require.Len(t, found, 3)
+	assert.Equal(t, int64(3), total)
+	assert.Equal(t, logs[2].ID, found[0].ID)
+
+	// Фильтр по сущности сохраняет diff
+	found, _, err = repo.FindLogs(repositories.ListQuery{Filters: map[string]string{"entity_type": models.EntityBooking, "entity_id": "5"}})
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+	assert.Equal(t, "confirmed", found[0].Changes["status"].After)
+
+	// Фильтр по автору и периоду
+	found, _, err = repo.FindLogs(repositories.ListQuery{Filters: map[string]string{"actor_id": "1"}, From: base, To: base.Add(24 * time.Hour)})
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, logs[0].ID, found[0].ID)
+}
@@ -0,0 +1,54 @@
+package repositories
+
+import (
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/repositories"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocationRepository_CreateAndGet(t *testing.T) {
+	db := setupTestDB(t, &models.Location{})
+	repo := repositories.NewLocationRepository(db)
+
+	location := &models.Location{
+		Name: "Центр", Address: "ул. Ленина, 1", Timezone: "Europe/Moscow", IsActive: true,
+		OpeningHours: []models.OpeningHours{{Day: models.Monday, Open: models.NewTimeOfDay(10, 0), Close: models.NewTimeOfDay(21, 0)}},
+	}
+	require.NoError(t, repo.CreateLocation(location))
+
+	fetched, err := repo.GetLocationByID(location.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Центр", fetched.Name)
+	assert.Equal(t, location.OpeningHours, fetched.OpeningHours)
+
+	_, err = repo.GetLocationByID(location.ID + 1)
+	assert.ErrorIs(t, err, repositories.ErrLocationNotFound)
+}
+
+func TestLocationRepository_DeleteLocation(t *testing.T) {
+	db := setupTestDB(t, &models.Location{}, &models.User{}, &models.Schedule{}, &models.ScheduleOverride{},
+		&models.Break{}, &models.Bookings{}, &models.ServiceLocationPrice{})
+	repo := repositories.NewLocationRepository(db)
+
+	used := &models.Location{Name: "Центр", Timezone: "Europe/Moscow"}
+	empty := &models.Location{Name: "Север", Timezone: "Europe/Moscow"}
+	require.NoError(t, repo.CreateLocation(used))
+	require.NoError(t, repo.CreateLocation(empty))
+	require.NoError(t, db.Create(&models.Schedule{UserID: 1, LocationID: used.ID, ScheduleDay: models.Monday, StartTime: models.NewTimeOfDay(9, 0), EndTime: models.NewTimeOfDay(18, 0)}).Error)
+	require.NoError(t, db.Create(&models.ServiceLocationPrice{ServiceID: 1, LocationID: empty.ID, Price: models.NewMoney(10000, "RUB")}).Error)
+
+	err := repo.DeleteLocation(used.ID)
+	assert.ErrorIs(t, err, repositories.ErrLocationInUse)
+
+	// Цены услуг в удаляемом филиале удаляются вместе с ним
+	require.NoError(t, repo.DeleteLocation(empty.ID))
+	var prices int64
+	require.NoError(t, db.Model(&models.ServiceLocationPrice{}).Count(&prices).Error)
+	assert.Zero(t, prices)
+
+	err = repo.DeleteLocation(empty.ID)
+	assert.ErrorIs(t, err, repositories.ErrLocationNotFound)
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"testing"
+	"time"
 )
 
 func TestNotificationRepository_CreateNotification(t *testing.T) {
@@ -58,9 +59,10 @@ func TestNotificationRepository_GetAllNotifications(t *testing.T) {
 		require.NoError(t, err)
 	}
 
-	fetchedNotifications, err := repo.GetAllNotifications()
+	fetchedNotifications, total, err := repo.GetAllNotifications(repositories.ListQuery{})
 	require.NoError(t, err)
 	assert.Len(t, fetchedNotifications, 2)
+	assert.Equal(t, int64(2), total)
 }
 
 func TestNotificationRepository_UpdateNotification(t *testing.T) {
@@ -105,3 +107,46 @@ func TestNotificationRepository_DeleteNotification(t *testing.T) {
 	assert.Error(t, err)
 	assert.Equal(t, repositories.ErrNotificationNotFound, err)
 }
+
+func TestNotificationRepository_BookingReminders(t *testing.T) {
+	db := setupTestDB(t, &models.Notification{})
+	repo := repositories.NewNotificationRepository(db)
+
+	now := time.Date(2025, 1, 10, 10, 0, 0, 0, time.UTC)
+	bookingID := 7
+	reminder := func(at time.Time) models.Notification {
+		return models.Notification{
+			ClientID:         1,
+			BookingID:        &bookingID,
+			Message:          "reminder",
+			NotificationType: models.NotificationTypeSMS,
+			Status:           models.NotificationStatusPending,
+			ScheduledFor:     &at,
+		}
+	}
+
+	require.NoError(t, repo.ReplaceBookingReminders(bookingID, []models.Notification{
+		reminder(now.Add(-time.Minute)),
+		reminder(now.Add(time.Hour)),
+	}))
+
+	// Отправляются только напоминания, время которых наступило
+	due, err := repo.FindDueNotifications(now, 10)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.True(t, due[0].ScheduledFor.Equal(now.Add(-time.Minute)))
+
+	// Перенос бронирования заменяет неотправленные напоминания
+	require.NoError(t, repo.ReplaceBookingReminders(bookingID, []models.Notification{reminder(now.Add(2 * time.Hour))}))
+	var pending int64
+	require.NoError(t, db.Model(&models.Notification{}).Where("status = ?", models.NotificationStatusPending).Count(&pending).Error)
+	assert.Equal(t, int64(1), pending)
+
+	// Отмена бронирования отменяет оставшиеся напоминания
+	require.NoError(t, repo.CancelBookingNotifications(bookingID))
+	require.NoError(t, db.Model(&models.Notification{}).Where("status = ?", models.NotificationStatusPending).Count(&pending).Error)
+	assert.Zero(t, pending)
+	due, err = repo.FindDueNotifications(now.Add(24*time.Hour), 10)
+	require.NoError(t, err)
+	assert.Empty(t, due)
+}
@@ -0,0 +1,56 @@
+package repositories
+
+import (
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestNotificationTemplateRepository_CreateAndGetByCode(t *testing.T) {
+	db := setupTestDB(t, &models.NotificationTemplate{})
+	repo := repositories.NewNotificationTemplateRepository(db)
+
+	ru := &models.NotificationTemplate{Code: models.TemplateReminder, Language: models.LanguageRU, Body: "Напоминание"}
+	en := &models.NotificationTemplate{Code: models.TemplateReminder, Language: models.LanguageEN, Body: "Reminder"}
+	require.NoError(t, repo.CreateTemplate(ru))
+	require.NoError(t, repo.CreateTemplate(en))
+
+	duplicate := &models.NotificationTemplate{Code: models.TemplateReminder, Language: models.LanguageRU, Body: "Еще одно"}
+	assert.ErrorIs(t, repo.CreateTemplate(duplicate), repositories.ErrTemplateAlreadyExists)
+
+	fetched, err := repo.GetTemplateByCode(models.TemplateReminder, models.LanguageEN)
+	require.NoError(t, err)
+	assert.Equal(t, en.ID, fetched.ID)
+	assert.Equal(t, "Reminder", fetched.Body)
+
+	_, err = repo.GetTemplateByCode(models.TemplateBookingConfirmed, models.LanguageRU)
+	assert.ErrorIs(t, err, repositories.ErrTemplateNotFound)
+}
+
+func TestNotificationTemplateRepository_UpdateAndDelete(t *testing.T) {
+	db := setupTestDB(t, &models.NotificationTemplate{})
+	repo := repositories.NewNotificationTemplateRepository(db)
+
+	ru := &models.NotificationTemplate{Code: models.TemplateReminder, Language: models.LanguageRU, Body: "Напоминание"}
+	en := &models.NotificationTemplate{Code: models.TemplateReminder, Language: models.LanguageEN, Body: "Reminder"}
+	require.NoError(t, repo.CreateTemplate(ru))
+	require.NoError(t, repo.CreateTemplate(en))
+
+	ru.Body = "Новый текст"
+	require.NoError(t, repo.UpdateTemplate(ru))
+	fetched, err := repo.GetTemplateByID(ru.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Новый текст", fetched.Body)
+
+	// Смена языка на уже занятый конфликтует с другим шаблоном
+	en.Language = models.LanguageRU
+	assert.ErrorIs(t, repo.UpdateTemplate(en), repositories.ErrTemplateAlreadyExists)
+
+	require.NoError(t, repo.DeleteTemplate(ru.ID))
+	_, err = repo.GetTemplateByID(ru.ID)
+	assert.ErrorIs(t, err, repositories.ErrTemplateNotFound)
+	assert.ErrorIs(t, repo.DeleteTemplate(ru.ID), repositories.ErrTemplateNotFound)
+}
@@ -0,0 +1,38 @@
+package repositories
+
+import (
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/repositories"
+
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduleOverrideRepository_GetOverridesByDate(t *testing.T) {
+	db := setupTestDB(t, &models.ScheduleOverride{})
+	repo := repositories.NewScheduleOverrideRepository(db)
+
+	overrides := []models.ScheduleOverride{
+		{UserID: 1, Date: "2025-01-06", Kind: models.ScheduleOverrideDayOff},
+		{UserID: 2, Date: "2025-01-06", Kind: models.ScheduleOverrideExtra, StartTime: models.NewTimeOfDay(18, 0), EndTime: models.NewTimeOfDay(20, 0)},
+		{UserID: 1, Date: "2025-01-07", Kind: models.ScheduleOverrideHours, StartTime: models.NewTimeOfDay(10, 0), EndTime: models.NewTimeOfDay(14, 0)},
+	}
+	for i := range overrides {
+		require.NoError(t, repo.CreateOverride(&overrides[i]))
+	}
+
+	all, err := repo.GetOverridesByDate(0, "2025-01-06")
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+
+	own, err := repo.GetOverridesByDate(1, "2025-01-07")
+	require.NoError(t, err)
+	require.Len(t, own, 1)
+	assert.Equal(t, models.NewTimeOfDay(14, 0), own[0].EndTime)
+
+	require.NoError(t, repo.DeleteOverride(own[0].ID))
+	_, err = repo.GetOverrideByID(own[0].ID)
+	assert.ErrorIs(t, err, repositories.ErrScheduleOverrideNotFound)
+}
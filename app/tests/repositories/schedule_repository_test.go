@@ -16,9 +16,9 @@ func TestScheduleRepository_CreateSchedule(t *testing.T) {
 
 	schedule := &models.Schedule{
 		UserID:      1,
-		ScheduleDay: "Monday",
-		StartTime:   "09:00",
-		EndTime:     "18:00",
+		ScheduleDay: models.Monday,
+		StartTime:   models.NewTimeOfDay(9, 0),
+		EndTime:     models.NewTimeOfDay(18, 0),
 	}
 
 	err := repo.CreateSchedule(schedule)
@@ -32,9 +32,9 @@ func TestScheduleRepository_GetScheduleByID(t *testing.T) {
 
 	schedule := &models.Schedule{
 		UserID:      1,
-		ScheduleDay: "Monday",
-		StartTime:   "09:00",
-		EndTime:     "18:00",
+		ScheduleDay: models.Monday,
+		StartTime:   models.NewTimeOfDay(9, 0),
+		EndTime:     models.NewTimeOfDay(18, 0),
 	}
 	err := repo.CreateSchedule(schedule)
 	require.NoError(t, err)
@@ -50,8 +50,8 @@ func TestScheduleRepository_GetAllSchedules(t *testing.T) {
 	repo := repositories.NewScheduleRepository(db)
 
 	schedules := []models.Schedule{
-		{UserID: 1, ScheduleDay: "Monday", StartTime: "09:00", EndTime: "18:00"},
-		{UserID: 2, ScheduleDay: "Tuesday", StartTime: "10:00", EndTime: "19:00"},
+		{UserID: 1, ScheduleDay: models.Monday, StartTime: models.NewTimeOfDay(9, 0), EndTime: models.NewTimeOfDay(18, 0)},
+		{UserID: 2, ScheduleDay: models.Tuesday, StartTime: models.NewTimeOfDay(10, 0), EndTime: models.NewTimeOfDay(19, 0)},
 	}
 
 	for i := range schedules {
@@ -59,9 +59,10 @@ func TestScheduleRepository_GetAllSchedules(t *testing.T) {
 		require.NoError(t, err)
 	}
 
-	fetchedSchedules, err := repo.GetAllSchedules()
+	fetchedSchedules, total, err := repo.GetAllSchedules(repositories.ListQuery{})
 	require.NoError(t, err)
 	assert.Len(t, fetchedSchedules, 2)
+	assert.Equal(t, int64(2), total)
 }
 
 func TestScheduleRepository_UpdateSchedule(t *testing.T) {
@@ -70,20 +71,20 @@ func TestScheduleRepository_UpdateSchedule(t *testing.T) {
 
 	schedule := &models.Schedule{
 		UserID:      1,
-		ScheduleDay: "Monday",
-		StartTime:   "09:00",
-		EndTime:     "18:00",
+		ScheduleDay: models.Monday,
+		StartTime:   models.NewTimeOfDay(9, 0),
+		EndTime:     models.NewTimeOfDay(18, 0),
 	}
 	err := repo.CreateSchedule(schedule)
 	require.NoError(t, err)
 
-	schedule.EndTime = "17:00"
+	schedule.EndTime = models.NewTimeOfDay(17, 0)
 	err = repo.UpdateSchedule(schedule)
 	require.NoError(t, err)
 
 	updatedSchedule, err := repo.GetScheduleByID(schedule.ID)
 	require.NoError(t, err)
-	assert.Equal(t, "17:00", updatedSchedule.EndTime)
+	assert.Equal(t, models.NewTimeOfDay(17, 0), updatedSchedule.EndTime)
 }
 
 func TestScheduleRepository_DeleteSchedule(t *testing.T) {
@@ -92,9 +93,9 @@ func TestScheduleRepository_DeleteSchedule(t *testing.T) {
 
 	schedule := &models.Schedule{
 		UserID:      1,
-		ScheduleDay: "Monday",
-		StartTime:   "09:00",
-		EndTime:     "18:00",
+		ScheduleDay: models.Monday,
+		StartTime:   models.NewTimeOfDay(9, 0),
+		EndTime:     models.NewTimeOfDay(18, 0),
 	}
 	err := repo.CreateSchedule(schedule)
 	require.NoError(t, err)
@@ -112,9 +113,9 @@ func TestScheduleRepository_FilterSchedulesByUser(t *testing.T) {
 	repo := repositories.NewScheduleRepository(db)
 
 	schedules := []models.Schedule{
-		{UserID: 1, ScheduleDay: "Monday", StartTime: "09:00", EndTime: "18:00"},
-		{UserID: 1, ScheduleDay: "Tuesday", StartTime: "09:00", EndTime: "18:00"},
-		{UserID: 2, ScheduleDay: "Monday", StartTime: "10:00", EndTime: "19:00"},
+		{UserID: 1, ScheduleDay: models.Monday, StartTime: models.NewTimeOfDay(9, 0), EndTime: models.NewTimeOfDay(18, 0)},
+		{UserID: 1, ScheduleDay: models.Tuesday, StartTime: models.NewTimeOfDay(9, 0), EndTime: models.NewTimeOfDay(18, 0)},
+		{UserID: 2, ScheduleDay: models.Monday, StartTime: models.NewTimeOfDay(10, 0), EndTime: models.NewTimeOfDay(19, 0)},
 	}
 
 	for i := range schedules {
@@ -126,3 +127,23 @@ func TestScheduleRepository_FilterSchedulesByUser(t *testing.T) {
 	require.NoError(t, err)
 	assert.Len(t, userSchedules, 2)
 }
+
+func TestScheduleRepository_GetSchedulesByDay(t *testing.T) {
+	db := setupTestDB(t, &models.Schedule{})
+	repo := repositories.NewScheduleRepository(db)
+
+	schedules := []models.Schedule{
+		{UserID: 1, ScheduleDay: models.Monday, StartTime: models.NewTimeOfDay(9, 0), EndTime: models.NewTimeOfDay(18, 0)},
+		{UserID: 2, ScheduleDay: models.Monday, StartTime: models.NewTimeOfDay(10, 0), EndTime: models.NewTimeOfDay(19, 0)},
+		{UserID: 1, ScheduleDay: models.Tuesday, StartTime: models.NewTimeOfDay(9, 0), EndTime: models.NewTimeOfDay(18, 0)},
+	}
+
+	for i := range schedules {
+		err := repo.CreateSchedule(&schedules[i])
+		require.NoError(t, err)
+	}
+
+	mondaySchedules, err := repo.GetSchedulesByDay(models.Monday)
+	require.NoError(t, err)
+	assert.Len(t, mondaySchedules, 2)
+}
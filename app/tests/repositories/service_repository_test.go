@@ -10,13 +10,13 @@ import (
 )
 
 func TestServiceRepository_CreateService(t *testing.T) {
-	db := setupTestDB(t, &models.Service{})
+	db := setupTestDB(t, &models.Service{}, &models.ServiceLocationPrice{}, &models.BarberService{})
 	repo := repositories.NewServiceRepository(db)
 
 	service := &models.Service{
 		Name:        "Test Service",
 		Description: "Description",
-		Price:       100.0,
+		Price:       models.NewMoney(10000, "RUB"),
 		Duration:    60,
 		IsActive:    true,
 	}
@@ -27,13 +27,13 @@ func TestServiceRepository_CreateService(t *testing.T) {
 }
 
 func TestServiceRepository_GetServiceByID(t *testing.T) {
-	db := setupTestDB(t, &models.Service{})
+	db := setupTestDB(t, &models.Service{}, &models.ServiceLocationPrice{}, &models.BarberService{})
 	repo := repositories.NewServiceRepository(db)
 
 	service := &models.Service{
 		Name:        "Test Service",
 		Description: "Description",
-		Price:       100.0,
+		Price:       models.NewMoney(10000, "RUB"),
 		Duration:    60,
 		IsActive:    true,
 	}
@@ -47,12 +47,12 @@ func TestServiceRepository_GetServiceByID(t *testing.T) {
 }
 
 func TestServiceRepository_GetAllServices(t *testing.T) {
-	db := setupTestDB(t, &models.Service{})
+	db := setupTestDB(t, &models.Service{}, &models.ServiceLocationPrice{}, &models.BarberService{})
 	repo := repositories.NewServiceRepository(db)
 
 	services := []models.Service{
-		{Name: "Service 1", Price: 50.0, Duration: 30, IsActive: true},
-		{Name: "Service 2", Price: 80.0, Duration: 45, IsActive: true},
+		{Name: "Service 1", Price: models.NewMoney(5000, "RUB"), Duration: 30, IsActive: true},
+		{Name: "Service 2", Price: models.NewMoney(8000, "RUB"), Duration: 45, IsActive: true},
 	}
 
 	for i := range services {
@@ -60,19 +60,20 @@ func TestServiceRepository_GetAllServices(t *testing.T) {
 		require.NoError(t, err)
 	}
 
-	fetchedServices, err := repo.GetAllServices()
+	fetchedServices, total, err := repo.GetAllServices(repositories.ListQuery{})
 	require.NoError(t, err)
 	assert.Len(t, fetchedServices, 2)
+	assert.Equal(t, int64(2), total)
 }
 
 func TestServiceRepository_UpdateService(t *testing.T) {
-	db := setupTestDB(t, &models.Service{})
+	db := setupTestDB(t, &models.Service{}, &models.ServiceLocationPrice{}, &models.BarberService{})
 	repo := repositories.NewServiceRepository(db)
 
 	service := &models.Service{
 		Name:        "Old Service",
 		Description: "Old Description",
-		Price:       100.0,
+		Price:       models.NewMoney(10000, "RUB"),
 		Duration:    60,
 		IsActive:    true,
 	}
@@ -89,12 +90,12 @@ func TestServiceRepository_UpdateService(t *testing.T) {
 }
 
 func TestServiceRepository_DeleteService(t *testing.T) {
-	db := setupTestDB(t, &models.Service{})
+	db := setupTestDB(t, &models.Service{}, &models.ServiceLocationPrice{}, &models.BarberService{})
 	repo := repositories.NewServiceRepository(db)
 
 	service := &models.Service{
 		Name:     "Test Service",
-		Price:    100.0,
+		Price:    models.NewMoney(10000, "RUB"),
 		Duration: 60,
 		IsActive: true,
 	}
@@ -110,12 +111,12 @@ func TestServiceRepository_DeleteService(t *testing.T) {
 }
 
 func TestServiceRepository_DeactivateService(t *testing.T) {
-	db := setupTestDB(t, &models.Service{})
+	db := setupTestDB(t, &models.Service{}, &models.ServiceLocationPrice{}, &models.BarberService{})
 	repo := repositories.NewServiceRepository(db)
 
 	service := &models.Service{
 		Name:     "Test Service",
-		Price:    100.0,
+		Price:    models.NewMoney(10000, "RUB"),
 		Duration: 60,
 		IsActive: true,
 	}
@@ -129,3 +130,64 @@ func TestServiceRepository_DeactivateService(t *testing.T) {
 	require.NoError(t, err)
 	assert.False(t, updatedService.IsActive)
 }
+
+func TestServiceRepository_LocationPrices(t *testing.T) {
+	db := setupTestDB(t, &models.Service{}, &models.ServiceLocationPrice{}, &models.BarberService{})
+	repo := repositories.NewServiceRepository(db)
+
+	service := &models.Service{Name: "Haircut", Price: models.NewMoney(10000, "RUB"), Duration: 60, IsActive: true}
+	require.NoError(t, repo.CreateService(service))
+
+	require.NoError(t, repo.SetLocationPrice(&models.ServiceLocationPrice{ServiceID: service.ID, LocationID: 2, Price: models.NewMoney(12000, "RUB")}))
+	// Повторная установка заменяет цену, а не добавляет вторую
+	require.NoError(t, repo.SetLocationPrice(&models.ServiceLocationPrice{ServiceID: service.ID, LocationID: 2, Price: models.NewMoney(15000, "RUB")}))
+
+	fetched, err := repo.GetServiceByID(service.ID)
+	require.NoError(t, err)
+	require.Len(t, fetched.LocationPrices, 1)
+	assert.Equal(t, models.NewMoney(15000, "RUB"), fetched.PriceAt(2))
+	assert.Equal(t, models.NewMoney(10000, "RUB"), fetched.PriceAt(1))
+
+	require.NoError(t, repo.DeleteLocationPrice(service.ID, 2))
+	err = repo.DeleteLocationPrice(service.ID, 2)
+	assert.ErrorIs(t, err, repositories.ErrServiceLocationPriceNotFound)
+}
+
+func TestServiceRepository_BarberAssignments(t *testing.T) {
+	db := setupTestDB(t, &models.Service{}, &models.ServiceLocationPrice{}, &models.BarberService{}, &models.User{})
+	repo := repositories.NewServiceRepository(db)
+
+	service := &models.Service{Name: "Haircut", Price: models.NewMoney(10000, "RUB"), Duration: 60, IsActive: true}
+	require.NoError(t, repo.CreateService(service))
+	senior := &models.User{Username: "senior", PasswordHash: "hash", Role: models.RoleBarber, Email: "senior@example.com"}
+	require.NoError(t, db.Create(senior).Error)
+
+	require.NoError(t, repo.AssignBarber(&models.BarberService{ServiceID: service.ID, UserID: senior.ID}))
+	// Повторное назначение заменяет индивидуальные цену и длительность
+	price, duration := models.NewMoney(15000, "RUB"), 90
+	require.NoError(t, repo.AssignBarber(&models.BarberService{ServiceID: service.ID, UserID: senior.ID, Price: &price, Duration: &duration}))
+	require.NoError(t, repo.AssignBarber(&models.BarberService{ServiceID: service.ID, UserID: 99}))
+
+	barbers, err := repo.GetServiceBarbers(service.ID)
+	require.NoError(t, err)
+	require.Len(t, barbers, 2)
+	require.NotNil(t, barbers[0].User)
+	assert.Equal(t, "senior", barbers[0].User.Username)
+	termsPrice, termsDuration := barbers[0].Terms(*service, 0)
+	assert.Equal(t, models.NewMoney(15000, "RUB"), termsPrice)
+	assert.Equal(t, 90, termsDuration)
+	termsPrice, termsDuration = barbers[1].Terms(*service, 0)
+	assert.Equal(t, models.NewMoney(10000, "RUB"), termsPrice)
+	assert.Equal(t, 60, termsDuration)
+
+	require.NoError(t, repo.UnassignBarber(service.ID, 99))
+	_, err = repo.GetBarberService(service.ID, 99)
+	assert.ErrorIs(t, err, repositories.ErrBarberServiceNotFound)
+	assert.ErrorIs(t, repo.UnassignBarber(service.ID, 99), repositories.ErrBarberServiceNotFound)
+
+	// Удаление услуги удаляет и ее назначения
+	require.NoError(t, repo.DeleteService(service.ID))
+	var left int64
+	require.NoError(t, db.Model(&models.BarberService{}).Count(&left).Error)
+	assert.Zero(t, left)
+}
@@ -0,0 +1,121 @@
+package repositories
+
+import (
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/repositories"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/tenant"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// setupTenantDB возвращает общую базу и соединения двух арендаторов поверх нее
+func setupTenantDB(t *testing.T, models ...interface{}) (*gorm.DB, *gorm.DB, *gorm.DB) {
+	db := setupTestDB(t, models...)
+	require.NoError(t, tenant.Register(db))
+	return db, tenant.Scope(db, 1), tenant.Scope(db, 2)
+}
+
+func TestTenantIsolation_Clients(t *testing.T) {
+	db, first, second := setupTenantDB(t, &models.Client{}, &models.ClientNote{})
+	firstRepo := repositories.NewClientRepository(first)
+	secondRepo := repositories.NewClientRepository(second)
+
+	// Email и Telegram ID уникальны только в пределах арендатора
+	own := &models.Client{FirstName: "Иван", Email: "ivan@example.com", PhoneNumber: "+79990000001", TgID: 100}
+	require.NoError(t, firstRepo.CreateClient(own))
+	foreign := &models.Client{FirstName: "Иван", Email: "ivan@example.com", PhoneNumber: "+79990000001", TgID: 100, TenantID: 1}
+	require.NoError(t, secondRepo.CreateClient(foreign))
+	assert.Equal(t, 1, own.TenantID)
+	assert.Equal(t, 2, foreign.TenantID, "tenant_id задается соединением, а не данными запроса")
+
+	_, err := secondRepo.GetClientByID(own.ID)
+	assert.ErrorIs(t, err, repositories.ErrClientNotFound)
+
+	clients, total, err := secondRepo.GetAllClients(repositories.ListQuery{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	require.Len(t, clients, 1)
+	assert.Equal(t, foreign.ID, clients[0].ID)
+
+	found, err := secondRepo.GetClientByTelegramID(100)
+	require.NoError(t, err)
+	assert.Equal(t, foreign.ID, found.ID)
+	found, err = secondRepo.SearchClientByEmailOrPhone("ivan@example.com", "")
+	require.NoError(t, err)
+	assert.Equal(t, foreign.ID, found.ID)
+	byName, err := secondRepo.FilterClientsByName("Иван")
+	require.NoError(t, err)
+	assert.Len(t, byName, 1)
+
+	// Изменение и удаление чужого клиента не затрагивают его
+	hijacked := *own
+	hijacked.FirstName = "Взломан"
+	require.NoError(t, secondRepo.UpdateClient(&hijacked))
+	require.NoError(t, secondRepo.DeleteClient(own.ID))
+
+	var stored models.Client
+	require.NoError(t, db.First(&stored, own.ID).Error)
+	assert.Equal(t, "Иван", stored.FirstName)
+	assert.Equal(t, 1, stored.TenantID)
+
+	var count int64
+	require.NoError(t, db.Model(&models.Client{}).Count(&count).Error)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestTenantIsolation_Bookings(t *testing.T) {
+	db, first, second := setupTenantDB(t, &models.Bookings{}, &models.BookingItem{}, &models.BookingStatusChange{}, &models.Client{}, &models.ClientNote{}, &models.Service{}, &models.User{})
+	firstRepo := repositories.NewBookingRepository(first)
+	secondRepo := repositories.NewBookingRepository(second)
+
+	service := &models.Service{Name: "Стрижка", Price: models.NewMoney(100000, "RUB"), Duration: 60, IsActive: true}
+	require.NoError(t, repositories.NewServiceRepository(first).CreateService(service))
+
+	start := time.Date(2025, 1, 6, 10, 0, 0, 0, time.UTC)
+	own := &models.Bookings{ClientID: 1, ServiceID: service.ID, UserID: 1, BookingTime: start, Status: models.BookingStatusPending,
+		Items: []models.BookingItem{{ServiceID: service.ID, Duration: 60}}}
+	require.NoError(t, firstRepo.CreateBooking(own))
+
+	_, err := secondRepo.GetBookingByID(own.ID)
+	assert.ErrorIs(t, err, repositories.ErrBookingNotFound)
+
+	bookings, total, err := secondRepo.GetAllBookings(repositories.ListQuery{})
+	require.NoError(t, err)
+	assert.Zero(t, total)
+	assert.Empty(t, bookings)
+
+	overlapping, err := secondRepo.FindOverlappingBookings(1, start, start.Add(time.Hour), 0)
+	require.NoError(t, err)
+	assert.Empty(t, overlapping)
+	byUser, err := secondRepo.GetBookingsByUserID(1)
+	require.NoError(t, err)
+	assert.Empty(t, byUser)
+
+	// Смена статуса чужого бронирования не проходит
+	err = secondRepo.ChangeBookingStatus(&models.BookingStatusChange{
+		BookingID: own.ID, FromStatus: models.BookingStatusPending, ToStatus: models.BookingStatusCancelled, ChangedAt: start,
+	})
+	assert.ErrorIs(t, err, repositories.ErrBookingStatusChanged)
+	require.NoError(t, secondRepo.DeleteBooking(own.ID))
+
+	fetched, err := firstRepo.GetBookingByID(own.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.BookingStatusPending, fetched.Status)
+	assert.Equal(t, service.Name, fetched.Service.Name)
+	// Позиции получают арендатора бронирования и не удаляются чужим арендатором
+	require.Len(t, fetched.Items, 1)
+	assert.Equal(t, fetched.TenantID, fetched.Items[0].TenantID)
+	assert.Equal(t, service.Name, fetched.Items[0].Service.Name)
+
+	overlapping, err = firstRepo.FindOverlappingBookings(1, start, start.Add(time.Hour), 0)
+	require.NoError(t, err)
+	assert.Len(t, overlapping, 1)
+
+	var count int64
+	require.NoError(t, db.Model(&models.Bookings{}).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+}
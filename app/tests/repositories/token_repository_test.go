@@ -0,0 +1,114 @@
+package repositories
+
+import (
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/repositories"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRefreshToken(userID int, familyID, hash, jti string) *models.RefreshToken {
+	return &models.RefreshToken{
+		UserID:          userID,
+		FamilyID:        familyID,
+		TokenHash:       hash,
+		AccessJTI:       jti,
+		AccessExpiresAt: time.Now().Add(15 * time.Minute),
+		ExpiresAt:       time.Now().Add(24 * time.Hour),
+	}
+}
+
+func TestTokenRepository_RotateRefreshToken(t *testing.T) {
+	db := setupTestDB(t, &models.RefreshToken{}, &models.RevokedToken{})
+	repo := repositories.NewTokenRepository(db)
+
+	first := newRefreshToken(1, "family", "hash-1", "jti-1")
+	require.NoError(t, repo.CreateRefreshToken(first))
+
+	second := newRefreshToken(1, "family", "hash-2", "jti-2")
+	require.NoError(t, repo.RotateRefreshToken(first.ID, second))
+
+	rotated, err := repo.GetRefreshTokenByHash("hash-1")
+	require.NoError(t, err)
+	assert.NotNil(t, rotated.RevokedAt)
+
+	// Повторная ротация того же токена отклоняется
+	third := newRefreshToken(1, "family", "hash-3", "jti-3")
+	err = repo.RotateRefreshToken(first.ID, third)
+	assert.Equal(t, repositories.ErrRefreshTokenRevoked, err)
+
+	_, err = repo.GetRefreshTokenByHash("hash-3")
+	assert.Equal(t, repositories.ErrRefreshTokenNotFound, err)
+}
+
+func TestTokenRepository_RevokeUserTokens(t *testing.T) {
+	db := setupTestDB(t, &models.RefreshToken{}, &models.RevokedToken{})
+	repo := repositories.NewTokenRepository(db)
+
+	require.NoError(t, repo.CreateRefreshToken(newRefreshToken(1, "family-a", "hash-a", "jti-a")))
+	require.NoError(t, repo.CreateRefreshToken(newRefreshToken(1, "family-b", "hash-b", "jti-b")))
+	require.NoError(t, repo.CreateRefreshToken(newRefreshToken(2, "family-c", "hash-c", "jti-c")))
+
+	require.NoError(t, repo.RevokeUserTokens(1))
+
+	for _, jti := range []string{"jti-a", "jti-b"} {
+		revoked, err := repo.IsAccessTokenRevoked(jti)
+		require.NoError(t, err)
+		assert.True(t, revoked, jti)
+	}
+
+	revoked, err := repo.IsAccessTokenRevoked("jti-c")
+	require.NoError(t, err)
+	assert.False(t, revoked)
+
+	token, err := repo.GetRefreshTokenByHash("hash-c")
+	require.NoError(t, err)
+	assert.Nil(t, token.RevokedAt)
+
+	// Повторный отзыв не падает на уже отозванных jti
+	require.NoError(t, repo.RevokeUserTokens(1))
+}
+
+func TestTokenRepository_DeleteExpiredTokens(t *testing.T) {
+	db := setupTestDB(t, &models.RefreshToken{}, &models.RevokedToken{})
+	repo := repositories.NewTokenRepository(db)
+	now := time.Now()
+
+	// Действующая сессия: использованный токен нужен для обнаружения повторного предъявления
+	used := newRefreshToken(1, "live", "hash-used", "jti-used")
+	require.NoError(t, repo.CreateRefreshToken(used))
+	require.NoError(t, repo.RotateRefreshToken(used.ID, newRefreshToken(1, "live", "hash-live", "jti-live")))
+
+	// Завершенная сессия и истекший токен
+	require.NoError(t, repo.CreateRefreshToken(newRefreshToken(2, "ended", "hash-ended", "jti-ended")))
+	require.NoError(t, repo.RevokeFamily("ended"))
+	expired := newRefreshToken(3, "expired", "hash-expired", "jti-expired")
+	expired.ExpiresAt = now.Add(-time.Minute)
+	require.NoError(t, repo.CreateRefreshToken(expired))
+
+	require.NoError(t, repo.RevokeAccessToken("jti-old", now.Add(-time.Minute)))
+	require.NoError(t, repo.RevokeAccessToken("jti-fresh", now.Add(time.Minute)))
+
+	deleted, err := repo.DeleteExpiredTokens(now)
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, deleted)
+
+	for _, hash := range []string{"hash-used", "hash-live"} {
+		_, err := repo.GetRefreshTokenByHash(hash)
+		assert.NoError(t, err, hash)
+	}
+	for _, hash := range []string{"hash-ended", "hash-expired"} {
+		_, err := repo.GetRefreshTokenByHash(hash)
+		assert.Equal(t, repositories.ErrRefreshTokenNotFound, err, hash)
+	}
+
+	revoked, err := repo.IsAccessTokenRevoked("jti-old")
+	require.NoError(t, err)
+	assert.False(t, revoked)
+	revoked, err = repo.IsAccessTokenRevoked("jti-fresh")
+	require.NoError(t, err)
+	assert.True(t, revoked)
+}
@@ -3,6 +3,7 @@ package repositories
 import (
 	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
 	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/repositories"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/tenant"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -10,7 +11,7 @@ import (
 )
 
 func TestUserRepository_CreateUser(t *testing.T) {
-	db := setupTestDB(t, &models.User{})
+	db := setupTestDB(t, &models.User{}, &models.BarberService{})
 	repo := repositories.NewUserRepository(db)
 
 	user := &models.User{
@@ -26,7 +27,7 @@ func TestUserRepository_CreateUser(t *testing.T) {
 }
 
 func TestUserRepository_GetUserByID(t *testing.T) {
-	db := setupTestDB(t, &models.User{})
+	db := setupTestDB(t, &models.User{}, &models.BarberService{})
 	repo := repositories.NewUserRepository(db)
 
 	user := &models.User{
@@ -45,7 +46,7 @@ func TestUserRepository_GetUserByID(t *testing.T) {
 }
 
 func TestUserRepository_GetAllUsers(t *testing.T) {
-	db := setupTestDB(t, &models.User{})
+	db := setupTestDB(t, &models.User{}, &models.BarberService{})
 	repo := repositories.NewUserRepository(db)
 
 	users := []models.User{
@@ -58,13 +59,14 @@ func TestUserRepository_GetAllUsers(t *testing.T) {
 		require.NoError(t, err)
 	}
 
-	fetchedUsers, err := repo.GetAllUsers()
+	fetchedUsers, total, err := repo.GetAllUsers(repositories.ListQuery{})
 	require.NoError(t, err)
 	assert.Len(t, fetchedUsers, 2)
+	assert.Equal(t, int64(2), total)
 }
 
 func TestUserRepository_UpdateUser(t *testing.T) {
-	db := setupTestDB(t, &models.User{})
+	db := setupTestDB(t, &models.User{}, &models.BarberService{})
 	repo := repositories.NewUserRepository(db)
 
 	user := &models.User{
@@ -86,7 +88,7 @@ func TestUserRepository_UpdateUser(t *testing.T) {
 }
 
 func TestUserRepository_DeleteUser(t *testing.T) {
-	db := setupTestDB(t, &models.User{})
+	db := setupTestDB(t, &models.User{}, &models.BarberService{})
 	repo := repositories.NewUserRepository(db)
 
 	user := &models.User{
@@ -105,3 +107,50 @@ func TestUserRepository_DeleteUser(t *testing.T) {
 	assert.Error(t, err)
 	assert.Equal(t, repositories.ErrUserNotFound, err)
 }
+
+func TestUserRepository_CountUsers(t *testing.T) {
+	db := setupTestDB(t, &models.User{}, &models.BarberService{})
+	repo := repositories.NewUserRepository(db)
+
+	count, err := repo.CountUsers()
+	require.NoError(t, err)
+	assert.Zero(t, count)
+
+	// Сотрудники без Email не конфликтуют по уникальности
+	users := []models.User{
+		{Username: "first", PasswordHash: "hash", Role: models.RoleOwner},
+		{Username: "second", PasswordHash: "hash", Role: models.RoleBarber},
+	}
+	for i := range users {
+		require.NoError(t, repo.CreateUser(&users[i]))
+	}
+
+	count, err = repo.CountUsers()
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestUserRepository_CreateOwner(t *testing.T) {
+	db, first, second := setupTenantDB(t, &models.User{}, &models.OwnerRegistration{})
+	firstRepo := repositories.NewUserRepository(first)
+
+	owner := &models.User{Username: "owner", PasswordHash: "hash", Role: models.RoleOwner}
+	require.NoError(t, firstRepo.CreateOwner(owner))
+	var registration models.OwnerRegistration
+	require.NoError(t, db.Where("tenant_id = ?", 1).First(&registration).Error)
+	assert.Equal(t, owner.ID, registration.UserID)
+
+	// Повторная регистрация у того же арендатора не проходит, у другого — проходит
+	err := firstRepo.CreateOwner(&models.User{Username: "intruder", PasswordHash: "hash", Role: models.RoleOwner})
+	assert.ErrorIs(t, err, repositories.ErrOwnerExists)
+	require.NoError(t, repositories.NewUserRepository(second).CreateOwner(&models.User{Username: "owner", PasswordHash: "hash", Role: models.RoleOwner}))
+
+	// Сотрудники, созданные до появления регистраций, тоже закрывают регистрацию
+	third := repositories.NewUserRepository(tenant.Scope(db, 3))
+	require.NoError(t, third.CreateUser(&models.User{Username: "legacy", PasswordHash: "hash", Role: models.RoleAdmin}))
+	err = third.CreateOwner(&models.User{Username: "intruder", PasswordHash: "hash", Role: models.RoleOwner})
+	assert.ErrorIs(t, err, repositories.ErrOwnerExists)
+	var registrations int64
+	require.NoError(t, db.Model(&models.OwnerRegistration{}).Count(&registrations).Error)
+	assert.Equal(t, int64(2), registrations, "неудачная регистрация откатывается")
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"github.com/0sokrat0/GoGRAFFApi.git/app/configs"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/auth"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/repositories"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/services"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupAuthService создает сотрудника «barber» с паролем «secret»
+func setupAuthService(t *testing.T) (*gorm.DB, services.AuthService) {
+	configs.AppConfigInstance = &configs.Config{App: configs.AppConfig{JWTSecret: "secret"}}
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.User{}, &models.RefreshToken{}, &models.RevokedToken{}, &models.HistoryLogs{}))
+
+	hash, err := auth.HashPassword("secret")
+	require.NoError(t, err)
+	require.NoError(t, db.Create(&models.User{Username: "barber", Email: "barber@example.com", PasswordHash: hash, Role: models.RoleBarber}).Error)
+
+	users := services.NewUserService(repositories.NewUserRepository(db), services.NewHistoryService(repositories.NewHistoryRepository(db)))
+	return db, services.NewAuthService(users, repositories.NewTokenRepository(db))
+}
+
+// accessJTI возвращает идентификатор access-токена пары
+func accessJTI(t *testing.T, pair *services.TokenPair) string {
+	claims, err := auth.ValidateToken(pair.AccessToken)
+	require.NoError(t, err)
+	return claims.Id
+}
+
+func TestAuthService_RefreshReuseRevokesFamily(t *testing.T) {
+	_, authService := setupAuthService(t)
+
+	first, err := authService.Login("barber", "secret")
+	require.NoError(t, err)
+	second, err := authService.Refresh(first.RefreshToken)
+	require.NoError(t, err)
+	third, err := authService.Refresh(second.RefreshToken)
+	require.NoError(t, err)
+
+	// Другая сессия того же сотрудника не затрагивается
+	other, err := authService.Login("barber@example.com", "secret")
+	require.NoError(t, err)
+
+	// Повторное предъявление уже использованного токена завершает всю цепочку
+	_, err = authService.Refresh(first.RefreshToken)
+	assert.Equal(t, services.ErrInvalidRefreshToken, err)
+	_, err = authService.Refresh(third.RefreshToken)
+	assert.Equal(t, services.ErrInvalidRefreshToken, err)
+
+	revoked, err := authService.IsTokenRevoked(accessJTI(t, third))
+	require.NoError(t, err)
+	assert.True(t, revoked)
+
+	revoked, err = authService.IsTokenRevoked(accessJTI(t, other))
+	require.NoError(t, err)
+	assert.False(t, revoked)
+	_, err = authService.Refresh(other.RefreshToken)
+	assert.NoError(t, err)
+
+	_, err = authService.Refresh("unknown")
+	assert.Equal(t, services.ErrInvalidRefreshToken, err)
+}
+
+func TestAuthService_Logout(t *testing.T) {
+	db, authService := setupAuthService(t)
+
+	pair, err := authService.Login("barber", "secret")
+	require.NoError(t, err)
+	rotated, err := authService.Refresh(pair.RefreshToken)
+	require.NoError(t, err)
+
+	var user models.User
+	require.NoError(t, db.Where("username = ?", "barber").First(&user).Error)
+
+	// Чужой refresh-токен не завершает сессию, но текущий access-токен отзывается
+	jti := accessJTI(t, rotated)
+	require.NoError(t, authService.Logout(user.ID+1, rotated.RefreshToken, jti, rotated.ExpiresAt))
+	revoked, err := authService.IsTokenRevoked(jti)
+	require.NoError(t, err)
+	assert.True(t, revoked)
+
+	next, err := authService.Refresh(rotated.RefreshToken)
+	require.NoError(t, err)
+
+	require.NoError(t, authService.Logout(user.ID, next.RefreshToken, accessJTI(t, next), next.ExpiresAt))
+	_, err = authService.Refresh(next.RefreshToken)
+	assert.Equal(t, services.ErrInvalidRefreshToken, err)
+
+	// После выхода записи сессии удаляются очисткой
+	deleted, err := repositories.NewTokenRepository(db).DeleteExpiredTokens(time.Now())
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, deleted)
+}
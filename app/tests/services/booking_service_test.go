@@ -0,0 +1,391 @@
+package services
+
+import (
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/repositories"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/services"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type bookingFixture struct {
+	db       *gorm.DB
+	bookings services.BookingService
+	catalog  services.ServiceService
+	service  *models.Service
+}
+
+// setupBookingService создает услугу «Стрижка» на 60 минут за 1000 и двух барберов,
+// работающих по понедельникам с 10:00 до 13:00
+func setupBookingService(t *testing.T) *bookingFixture {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&models.Bookings{}, &models.BookingItem{}, &models.BookingStatusChange{}, &models.Client{}, &models.ClientNote{}, &models.ClientField{}, &models.User{},
+		&models.Service{}, &models.ServiceLocationPrice{}, &models.BarberService{},
+		&models.Schedule{}, &models.ScheduleOverride{}, &models.Break{}, &models.BreakException{},
+		&models.Location{}, &models.HistoryLogs{}, &models.Notification{}, &models.NotificationTemplate{},
+	))
+
+	bookings, catalog := newBookingServices(db, false)
+	f := &bookingFixture{
+		db:       db,
+		bookings: bookings,
+		catalog:  catalog,
+		service:  &models.Service{Name: "Стрижка", Price: models.NewMoney(100000, "RUB"), Duration: 60, IsActive: true},
+	}
+	require.NoError(t, f.catalog.CreateService(1, f.service))
+	require.NoError(t, db.Create(&models.Client{FirstName: "Иван", PhoneNumber: "+79990000001"}).Error)
+	for _, username := range []string{"junior", "senior"} {
+		barber := &models.User{Username: username, PasswordHash: "hash", Role: models.RoleBarber, Email: username + "@example.com"}
+		require.NoError(t, db.Create(barber).Error)
+		require.NoError(t, db.Create(&models.Schedule{
+			UserID: barber.ID, ScheduleDay: models.Monday, StartTime: models.NewTimeOfDay(10, 0), EndTime: models.NewTimeOfDay(13, 0),
+		}).Error)
+	}
+	return f
+}
+
+// newBookingServices создает сервисы бронирований и услуг поверх db.
+// allowUnassigned — значение настройки app.allow_unassigned_staff.
+func newBookingServices(db *gorm.DB, allowUnassigned bool) (services.BookingService, services.ServiceService) {
+	history := services.NewHistoryService(repositories.NewHistoryRepository(db))
+	locationRepo := repositories.NewLocationRepository(db)
+	locations := services.NewLocationService(locationRepo, history, time.UTC)
+	bookingRepo := repositories.NewBookingRepository(db)
+	serviceRepo := repositories.NewServiceRepository(db)
+	userRepo := repositories.NewUserRepository(db)
+	schedules := services.NewScheduleService(repositories.NewScheduleRepository(db), repositories.NewScheduleOverrideRepository(db), history, locations)
+	templates := services.NewNotificationTemplateService(repositories.NewNotificationTemplateRepository(db), bookingRepo, locations)
+	reminders := services.NewReminderService(repositories.NewNotificationRepository(db), bookingRepo, templates, nil, nil)
+	breaks := services.NewBreakService(repositories.NewBreakRepository(db), history, locations)
+
+	bookings := services.NewBookingService(bookingRepo, serviceRepo, schedules, breaks, history, reminders, locations, userRepo, allowUnassigned)
+	catalog := services.NewServiceService(serviceRepo, locationRepo, userRepo, history, allowUnassigned)
+	return bookings, catalog
+}
+
+// assignService назначает услугу барберам без индивидуальных цен и длительностей
+func (f *bookingFixture) assignService(t *testing.T, serviceID int, userIDs ...int) {
+	for _, userID := range userIDs {
+		require.NoError(t, f.catalog.AssignBarber(1, &models.BarberService{ServiceID: serviceID, UserID: userID}))
+	}
+}
+
+// nextMonday возвращает понедельник не ранее чем через неделю: свободные слоты ищутся только в будущем
+func nextMonday() time.Time {
+	day := time.Now().UTC().AddDate(0, 0, 7)
+	for day.Weekday() != time.Monday {
+		day = day.AddDate(0, 0, 1)
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func TestBookingService_BarberTerms(t *testing.T) {
+	f := setupBookingService(t)
+	day := nextMonday()
+
+	// Без назначения барбер не выполняет услугу
+	err := f.bookings.CreateBooking(1, &models.Bookings{ClientID: 1, ServiceID: f.service.ID, UserID: 1, BookingTime: at(day, 10, 0)})
+	assert.ErrorIs(t, err, services.ErrBarberNotAssigned)
+	_, err = f.bookings.FindFreeSlots(0, 1, []int{f.service.ID}, day)
+	assert.ErrorIs(t, err, services.ErrBarberNotAssigned)
+
+	price, duration := models.NewMoney(150000, "RUB"), 90
+	require.NoError(t, f.catalog.AssignBarber(1, &models.BarberService{ServiceID: f.service.ID, UserID: 1}))
+	require.NoError(t, f.catalog.AssignBarber(1, &models.BarberService{ServiceID: f.service.ID, UserID: 2, Price: &price, Duration: &duration}))
+	err = f.catalog.AssignBarber(1, &models.BarberService{ServiceID: f.service.ID, UserID: 42})
+	assert.ErrorIs(t, err, repositories.ErrUserNotFound)
+
+	barbers, err := f.catalog.GetServiceBarbers(f.service.ID)
+	require.NoError(t, err)
+	require.Len(t, barbers, 2)
+
+	// Слоты с шагом 15 минут: у старшего барбера визит длится 90 минут
+	slots, err := f.bookings.FindFreeSlots(0, 0, []int{f.service.ID}, day)
+	require.NoError(t, err)
+	require.Len(t, slots, 2)
+	assert.Len(t, slots[0].Slots, 9)
+	assert.Len(t, slots[1].Slots, 7)
+	assert.Equal(t, at(day, 11, 30), slots[1].Slots[6])
+
+	// Бронирование фиксирует цену и длительность барбера
+	booking := &models.Bookings{ClientID: 1, ServiceID: f.service.ID, UserID: 2, BookingTime: at(day, 11, 30)}
+	require.NoError(t, f.bookings.CreateBooking(1, booking))
+	assert.Equal(t, models.NewMoney(150000, "RUB"), booking.Price)
+	assert.Equal(t, 90, booking.Duration)
+
+	// Пересечение считается по длительности барбера: визит в 10:30 закончился бы в 12:00
+	err = f.bookings.CreateBooking(1, &models.Bookings{ClientID: 1, ServiceID: f.service.ID, UserID: 2, BookingTime: at(day, 10, 30)})
+	assert.ErrorIs(t, err, services.ErrTimeSlotOccupied)
+
+	// Изменение цены после записи не меняет ее цену
+	price = models.NewMoney(200000, "RUB")
+	require.NoError(t, f.catalog.AssignBarber(1, &models.BarberService{ServiceID: f.service.ID, UserID: 2, Price: &price, Duration: &duration}))
+	require.NoError(t, f.bookings.UpdateBooking(1, booking.ID, &models.Bookings{ClientID: 1, ServiceID: f.service.ID, UserID: 2, BookingTime: at(day, 11, 15)}))
+	updated, err := f.bookings.GetBookingByID(booking.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.NewMoney(150000, "RUB"), updated.Price)
+
+	require.NoError(t, f.catalog.UnassignBarber(1, f.service.ID, 2))
+	slots, err = f.bookings.FindFreeSlots(0, 0, []int{f.service.ID}, day)
+	require.NoError(t, err)
+	require.Len(t, slots, 1)
+	assert.Equal(t, 1, slots[0].UserID)
+}
+
+func TestBookingService_UnassignedStaff(t *testing.T) {
+	f := setupBookingService(t)
+	day := nextMonday()
+	// Администратор ресепшн с расписанием и назначенный только старший барбер
+	desk := &models.User{Username: "desk", PasswordHash: "hash", Role: models.RoleReceptionist, Email: "desk@example.com"}
+	require.NoError(t, f.db.Create(desk).Error)
+	require.NoError(t, f.db.Create(&models.Schedule{UserID: desk.ID, ScheduleDay: models.Monday,
+		StartTime: models.NewTimeOfDay(10, 0), EndTime: models.NewTimeOfDay(13, 0)}).Error)
+	f.assignService(t, f.service.ID, 2)
+
+	book := func(bookings services.BookingService, userID int) error {
+		return bookings.CreateBooking(1, &models.Bookings{ClientID: 1, ServiceID: f.service.ID, UserID: userID, BookingTime: at(day, 10, 0)})
+	}
+	staffWithSlots := func(bookings services.BookingService) []int {
+		slots, err := bookings.FindFreeSlots(0, 0, []int{f.service.ID}, day)
+		require.NoError(t, err)
+		ids := make([]int, 0, len(slots))
+		for _, barber := range slots {
+			ids = append(ids, barber.UserID)
+		}
+		return ids
+	}
+
+	// По умолчанию услугу выполняют только назначенные сотрудники
+	for _, userID := range []int{1, desk.ID} {
+		assert.ErrorIs(t, book(f.bookings, userID), services.ErrBarberNotAssigned)
+		_, err := f.bookings.CheckAvailability(0, userID, []int{f.service.ID}, at(day, 10, 0))
+		assert.ErrorIs(t, err, services.ErrBarberNotAssigned)
+		_, err = f.bookings.FindFreeSlots(0, userID, []int{f.service.ID}, day)
+		assert.ErrorIs(t, err, services.ErrBarberNotAssigned)
+	}
+	assert.Equal(t, []int{2}, staffWithSlots(f.bookings))
+	barbers, err := f.catalog.GetServiceBarbers(f.service.ID)
+	require.NoError(t, err)
+	require.Len(t, barbers, 1)
+	assert.Equal(t, 2, barbers[0].UserID)
+
+	// С app.allow_unassigned_staff барберы без назначения работают по условиям услуги, ресепшн — нет
+	bookings, catalog := newBookingServices(f.db, true)
+	assert.ErrorIs(t, book(bookings, desk.ID), services.ErrBarberNotAssigned)
+	assert.Equal(t, []int{1, 2}, staffWithSlots(bookings))
+	barbers, err = catalog.GetServiceBarbers(f.service.ID)
+	require.NoError(t, err)
+	require.Len(t, barbers, 2)
+	assert.Equal(t, "junior", barbers[0].User.Username)
+	assert.Nil(t, barbers[0].Price)
+
+	booking := &models.Bookings{ClientID: 1, ServiceID: f.service.ID, UserID: 1, BookingTime: at(day, 10, 0)}
+	require.NoError(t, bookings.CreateBooking(1, booking))
+	assert.Equal(t, f.service.Price, booking.Price)
+	assert.Equal(t, f.service.Duration, booking.Duration)
+}
+
+func TestBookingService_RevenueByBarber(t *testing.T) {
+	f := setupBookingService(t)
+	day := nextMonday()
+
+	price := models.NewMoney(150000, "RUB")
+	require.NoError(t, f.catalog.AssignBarber(1, &models.BarberService{ServiceID: f.service.ID, UserID: 1}))
+	require.NoError(t, f.catalog.AssignBarber(1, &models.BarberService{ServiceID: f.service.ID, UserID: 2, Price: &price}))
+
+	complete := func(booking *models.Bookings) {
+		require.NoError(t, f.bookings.CreateBooking(1, booking))
+		for _, status := range []string{models.BookingStatusConfirmed, models.BookingStatusInProgress, models.BookingStatusCompleted} {
+			_, err := f.bookings.ChangeStatus(booking.ID, status, 1)
+			require.NoError(t, err)
+		}
+	}
+	complete(&models.Bookings{ClientID: 1, ServiceID: f.service.ID, UserID: 1, BookingTime: at(day, 10, 0)})
+	complete(&models.Bookings{ClientID: 1, ServiceID: f.service.ID, UserID: 2, BookingTime: at(day, 10, 0)})
+	complete(&models.Bookings{ClientID: 1, ServiceID: f.service.ID, UserID: 2, BookingTime: at(day, 11, 0)})
+	// Незавершенные бронирования в выручку не входят
+	require.NoError(t, f.bookings.CreateBooking(1, &models.Bookings{ClientID: 1, ServiceID: f.service.ID, UserID: 1, BookingTime: at(day, 12, 0)}))
+	// Последний день периода входит в отчет
+	lastDay := &models.Bookings{ClientID: 1, ServiceID: f.service.ID, UserID: 1, BookingTime: at(day, 12, 0).AddDate(0, 0, 7),
+		Status: models.BookingStatusCompleted, Price: models.NewMoney(100000, "RUB"), Duration: 60}
+	require.NoError(t, f.db.Create(lastDay).Error)
+
+	revenue, err := f.bookings.RevenueByBarber(0, 0, day, day.AddDate(0, 0, 7))
+	require.NoError(t, err)
+	assert.Equal(t, []repositories.BarberRevenue{
+		{UserID: 1, Bookings: 2, Revenue: models.NewMoney(200000, "RUB")},
+		{UserID: 2, Bookings: 2, Revenue: models.NewMoney(300000, "RUB")},
+	}, revenue)
+
+	revenue, err = f.bookings.RevenueByBarber(0, 2, day, day)
+	require.NoError(t, err)
+	assert.Equal(t, []repositories.BarberRevenue{{UserID: 2, Bookings: 2, Revenue: models.NewMoney(300000, "RUB")}}, revenue)
+
+	_, err = f.bookings.RevenueByBarber(0, 0, day, day.AddDate(0, 0, -1))
+	assert.ErrorIs(t, err, services.ErrInvalidRevenueRange)
+}
+
+func TestBookingService_MultiServiceBooking(t *testing.T) {
+	f := setupBookingService(t)
+	day := nextMonday()
+
+	beard := &models.Service{Name: "Борода", Price: models.NewMoney(50000, "RUB"), Duration: 30, IsActive: true}
+	require.NoError(t, f.catalog.CreateService(1, beard))
+	price := models.NewMoney(70000, "RUB")
+	require.NoError(t, f.catalog.AssignBarber(1, &models.BarberService{ServiceID: f.service.ID, UserID: 1}))
+	require.NoError(t, f.catalog.AssignBarber(1, &models.BarberService{ServiceID: f.service.ID, UserID: 2}))
+	require.NoError(t, f.catalog.AssignBarber(1, &models.BarberService{ServiceID: beard.ID, UserID: 2, Price: &price}))
+
+	// Визит из двух услуг доступен только барберу, который выполняет обе, и длится 90 минут
+	slots, err := f.bookings.FindFreeSlots(0, 0, []int{f.service.ID, beard.ID}, day)
+	require.NoError(t, err)
+	require.Len(t, slots, 1)
+	assert.Equal(t, 2, slots[0].UserID)
+	assert.Len(t, slots[0].Slots, 7)
+
+	booking := &models.Bookings{ClientID: 1, UserID: 2, BookingTime: at(day, 10, 0),
+		Items: []models.BookingItem{{ServiceID: f.service.ID}, {ServiceID: beard.ID}}}
+	require.NoError(t, f.bookings.CreateBooking(1, booking))
+	assert.Equal(t, f.service.ID, booking.ServiceID)
+	assert.Equal(t, models.NewMoney(170000, "RUB"), booking.Price)
+	assert.Equal(t, 90, booking.Duration)
+
+	stored, err := f.bookings.GetBookingByID(booking.ID)
+	require.NoError(t, err)
+	require.Len(t, stored.Items, 2)
+	assert.Equal(t, []int{f.service.ID, beard.ID}, stored.ServiceIDs())
+	assert.Equal(t, models.NewMoney(70000, "RUB"), stored.Items[1].Price)
+	assert.Equal(t, 30, stored.Items[1].Duration)
+
+	// Интервал визита — сумма длительностей: 11:15 пересекается с визитом до 11:30
+	err = f.bookings.CreateBooking(1, &models.Bookings{ClientID: 1, ServiceID: f.service.ID, UserID: 2, BookingTime: at(day, 11, 15)})
+	assert.ErrorIs(t, err, services.ErrTimeSlotOccupied)
+	// Барбер без одной из услуг визит не выполняет
+	err = f.bookings.CreateBooking(1, &models.Bookings{ClientID: 1, UserID: 1, BookingTime: at(day, 10, 0),
+		Items: []models.BookingItem{{ServiceID: f.service.ID}, {ServiceID: beard.ID}}})
+	assert.ErrorIs(t, err, services.ErrBarberNotAssigned)
+
+	byService, err := f.bookings.GetBookingsByServiceID(beard.ID)
+	require.NoError(t, err)
+	require.Len(t, byService, 1)
+	assert.Equal(t, booking.ID, byService[0].ID)
+
+	// Перенос с прежней первой услугой сохраняет состав визита
+	require.NoError(t, f.bookings.UpdateBooking(1, booking.ID, &models.Bookings{ClientID: 1, ServiceID: f.service.ID, UserID: 2, BookingTime: at(day, 11, 0)}))
+	stored, err = f.bookings.GetBookingByID(booking.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []int{f.service.ID, beard.ID}, stored.ServiceIDs())
+	assert.Equal(t, 90, stored.Duration)
+
+	// Новый состав пересчитывает цену и длительность
+	require.NoError(t, f.bookings.UpdateBooking(1, booking.ID, &models.Bookings{ClientID: 1, UserID: 2, BookingTime: at(day, 11, 0),
+		Items: []models.BookingItem{{ServiceID: beard.ID}}}))
+	stored, err = f.bookings.GetBookingByID(booking.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []int{beard.ID}, stored.ServiceIDs())
+	assert.Equal(t, beard.ID, stored.ServiceID)
+	assert.Equal(t, models.NewMoney(70000, "RUB"), stored.Price)
+	assert.Equal(t, 30, stored.Duration)
+}
+
+func TestBookingService_CheckAvailability(t *testing.T) {
+	f := setupBookingService(t)
+	day := nextMonday()
+	require.NoError(t, f.catalog.AssignBarber(1, &models.BarberService{ServiceID: f.service.ID, UserID: 1}))
+
+	booking := &models.Bookings{ClientID: 1, ServiceID: f.service.ID, UserID: 1, BookingTime: at(day, 10, 0)}
+	require.NoError(t, f.bookings.CreateBooking(1, booking))
+
+	check := func(hour, minute int) bool {
+		available, err := f.bookings.CheckAvailability(0, 1, []int{f.service.ID}, at(day, hour, minute))
+		require.NoError(t, err)
+		return available
+	}
+	// Визит 10:15–11:15 пересекается с бронированием 10:00–11:00, а 9:15–10:15 заходит на его начало
+	assert.False(t, check(10, 15))
+	assert.False(t, check(9, 15))
+	assert.True(t, check(11, 0))
+	assert.True(t, check(9, 0))
+
+	// Отмененное бронирование освобождает время
+	_, err := f.bookings.ChangeStatus(booking.ID, models.BookingStatusCancelled, 1)
+	require.NoError(t, err)
+	assert.True(t, check(10, 0))
+}
+
+func TestBookingService_ClosedBookingsCannotBeEdited(t *testing.T) {
+	f := setupBookingService(t)
+	f.assignService(t, f.service.ID, 1)
+	day := nextMonday()
+
+	paths := map[string][]string{
+		models.BookingStatusCompleted: {models.BookingStatusConfirmed, models.BookingStatusInProgress, models.BookingStatusCompleted},
+		models.BookingStatusNoShow:    {models.BookingStatusNoShow},
+		models.BookingStatusCancelled: {models.BookingStatusCancelled},
+	}
+	hour := 10
+	for status, path := range paths {
+		booking := &models.Bookings{ClientID: 1, ServiceID: f.service.ID, UserID: 1, BookingTime: at(day, hour, 0)}
+		require.NoError(t, f.bookings.CreateBooking(1, booking))
+		for _, next := range path {
+			_, err := f.bookings.ChangeStatus(booking.ID, next, 1)
+			require.NoError(t, err)
+		}
+
+		// Закрытое бронирование нельзя перенести даже на свободное время
+		input := &models.Bookings{ClientID: 1, ServiceID: f.service.ID, UserID: 1, BookingTime: at(day, hour, 30)}
+		assert.ErrorIs(t, f.bookings.UpdateBooking(1, booking.ID, input), services.ErrBookingClosed, status)
+		stored, err := f.bookings.GetBookingByID(booking.ID)
+		require.NoError(t, err)
+		assert.True(t, stored.BookingTime.Equal(at(day, hour, 0)), status)
+		assert.Equal(t, status, stored.Status)
+		hour++
+	}
+
+	// Активное бронирование по-прежнему редактируется
+	nextWeek := day.AddDate(0, 0, 7)
+	active := &models.Bookings{ClientID: 1, ServiceID: f.service.ID, UserID: 1, BookingTime: at(nextWeek, 10, 0)}
+	require.NoError(t, f.bookings.CreateBooking(1, active))
+	require.NoError(t, f.bookings.UpdateBooking(1, active.ID, &models.Bookings{ClientID: 1, ServiceID: f.service.ID, UserID: 1, BookingTime: at(nextWeek, 10, 30)}))
+}
+
+func TestBookingService_WorkingHoursAndBreaks(t *testing.T) {
+	f := setupBookingService(t)
+	f.assignService(t, f.service.ID, 1, 2)
+	day := nextMonday()
+	book := func(userID int, when time.Time) error {
+		return f.bookings.CreateBooking(1, &models.Bookings{ClientID: 1, ServiceID: f.service.ID, UserID: userID, BookingTime: when})
+	}
+
+	// Смена 10:00–13:00: визит до ее начала и визит, заканчивающийся после ее конца
+	assert.ErrorIs(t, book(1, at(day, 9, 30)), services.ErrOutsideWorkingHours)
+	assert.ErrorIs(t, book(1, at(day, 12, 30)), services.ErrOutsideWorkingHours)
+
+	// Разовый перерыв 11:00–11:30 у первого барбера
+	require.NoError(t, f.db.Omit("User").Create(&models.Break{UserID: 1, BreakStart: at(day, 11, 0), BreakEnd: at(day, 11, 30)}).Error)
+	assert.ErrorIs(t, book(1, at(day, 10, 45)), services.ErrDuringBreak)
+	assert.ErrorIs(t, book(1, at(day, 11, 15)), services.ErrDuringBreak)
+
+	// Ежедневный перерыв 12:00–12:15 у второго барбера, начавшийся неделей раньше
+	require.NoError(t, f.db.Omit("User").Create(&models.Break{UserID: 2, Recurrence: models.BreakRecurrenceDaily,
+		BreakStart: at(day.AddDate(0, 0, -7), 12, 0), BreakEnd: at(day.AddDate(0, 0, -7), 12, 15)}).Error)
+	assert.ErrorIs(t, book(2, at(day, 11, 30)), services.ErrDuringBreak)
+	require.NoError(t, book(2, at(day, 10, 0)))
+
+	// Выходной по исключению из расписания
+	nextWeek := day.AddDate(0, 0, 7)
+	require.NoError(t, f.db.Create(&models.ScheduleOverride{UserID: 1, Date: nextWeek.Format("2006-01-02"), Kind: models.ScheduleOverrideDayOff}).Error)
+	assert.ErrorIs(t, book(1, at(nextWeek, 10, 0)), services.ErrOutsideWorkingHours)
+	require.NoError(t, book(2, at(nextWeek, 10, 0)))
+
+	// Визиты вплотную к перерыву допустимы
+	require.NoError(t, book(1, at(day, 10, 0)))
+	require.NoError(t, book(1, at(day, 11, 30)))
+}
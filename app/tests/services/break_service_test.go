@@ -0,0 +1,152 @@
+package services
+
+import (
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/repositories"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/services"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupBreakService(t *testing.T, loc *time.Location) services.BreakService {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Break{}, &models.BreakException{}, &models.Location{}, &models.HistoryLogs{}))
+
+	history := services.NewHistoryService(repositories.NewHistoryRepository(db))
+	return services.NewBreakService(
+		repositories.NewBreakRepository(db),
+		history,
+		services.NewLocationService(repositories.NewLocationRepository(db), history, loc),
+	)
+}
+
+func occurrenceStarts(occurrences []models.BreakOccurrence) []time.Time {
+	starts := make([]time.Time, 0, len(occurrences))
+	for _, o := range occurrences {
+		starts = append(starts, o.Start)
+	}
+	return starts
+}
+
+func TestBreakService_Occurrences(t *testing.T) {
+	service := setupBreakService(t, time.UTC)
+
+	// Обед по будням с 13:00 до 14:00 до 10 января включительно
+	until := time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC)
+	lunch := &models.Break{
+		UserID: 1, BreakStart: at(monday, 13, 0), BreakEnd: at(monday, 14, 0),
+		Recurrence: models.BreakRecurrenceWeekdays, RecurrenceUntil: &until,
+	}
+	require.NoError(t, service.CreateBreak(1, lunch))
+	// По средам дополнительный перерыв, разовый перерыв в четверг
+	require.NoError(t, service.CreateBreak(1, &models.Break{
+		UserID: 1, BreakStart: at(monday, 16, 0), BreakEnd: at(monday, 16, 30),
+		Recurrence: models.BreakRecurrenceWeekly, RecurrenceDays: []models.Weekday{models.Wednesday},
+	}))
+	thursday := monday.AddDate(0, 0, 3)
+	require.NoError(t, service.CreateBreak(1, &models.Break{UserID: 1, BreakStart: at(thursday, 11, 0), BreakEnd: at(thursday, 11, 15)}))
+
+	occurrences, err := service.Occurrences(1, monday, monday.AddDate(0, 0, 14))
+	require.NoError(t, err)
+	wednesday := monday.AddDate(0, 0, 2)
+	assert.Equal(t, []time.Time{
+		at(monday, 13, 0),
+		at(monday.AddDate(0, 0, 1), 13, 0),
+		at(wednesday, 13, 0),
+		at(wednesday, 16, 0),
+		at(thursday, 11, 0),
+		at(thursday, 13, 0),
+		at(monday.AddDate(0, 0, 4), 13, 0),
+		at(wednesday.AddDate(0, 0, 7), 16, 0),
+	}, occurrenceStarts(occurrences))
+
+	// Вхождение, начавшееся до начала периода, тоже учитывается
+	occurrences, err = service.Occurrences(1, at(monday, 13, 30), at(monday, 15, 0))
+	require.NoError(t, err)
+	assert.Equal(t, []time.Time{at(monday, 13, 0)}, occurrenceStarts(occurrences))
+}
+
+func TestBreakService_EditSingleOccurrence(t *testing.T) {
+	service := setupBreakService(t, time.UTC)
+
+	lunch := &models.Break{UserID: 1, BreakStart: at(monday, 13, 0), BreakEnd: at(monday, 14, 0), Recurrence: models.BreakRecurrenceDaily}
+	require.NoError(t, service.CreateBreak(1, lunch))
+
+	tuesday := monday.AddDate(0, 0, 1)
+	replacement, err := service.UpdateOccurrence(1, lunch.ID, "2025-01-07", &models.Break{BreakStart: at(tuesday, 15, 0), BreakEnd: at(tuesday, 16, 0)})
+	require.NoError(t, err)
+	require.NotNil(t, replacement.SeriesID)
+	assert.Equal(t, lunch.ID, *replacement.SeriesID)
+	require.NoError(t, service.DeleteOccurrence(1, lunch.ID, "2025-01-08"))
+
+	occurrences, err := service.Occurrences(1, monday, monday.AddDate(0, 0, 4))
+	require.NoError(t, err)
+	assert.Equal(t, []time.Time{
+		at(monday, 13, 0),
+		at(tuesday, 15, 0),
+		at(monday.AddDate(0, 0, 3), 13, 0),
+	}, occurrenceStarts(occurrences))
+
+	err = service.DeleteOccurrence(1, lunch.ID, "2025-01-05")
+	assert.ErrorIs(t, err, services.ErrNotBreakOccurrence)
+	err = service.DeleteOccurrence(1, replacement.ID, "2025-01-07")
+	assert.ErrorIs(t, err, services.ErrNotRecurringBreak)
+	err = service.DeleteOccurrence(1, lunch.ID, "2025-01-08")
+	assert.ErrorIs(t, err, repositories.ErrBreakExceptionConflict)
+}
+
+func TestBreakService_Validation(t *testing.T) {
+	service := setupBreakService(t, time.UTC)
+
+	err := service.CreateBreak(1, &models.Break{UserID: 1, BreakStart: at(monday, 14, 0), BreakEnd: at(monday, 13, 0)})
+	assert.ErrorIs(t, err, services.ErrInvalidBreakTime)
+
+	err = service.CreateBreak(1, &models.Break{UserID: 1, BreakStart: at(monday, 13, 0), BreakEnd: at(monday, 14, 0), Recurrence: "monthly"})
+	assert.ErrorIs(t, err, services.ErrInvalidRecurrence)
+
+	// Массовое создание не сохраняет ничего, если один из перерывов некорректен
+	err = service.CreateBreaks(1, []models.Break{
+		{UserID: 1, BreakStart: at(monday, 13, 0), BreakEnd: at(monday, 14, 0)},
+		{UserID: 2, BreakStart: at(monday, 14, 0), BreakEnd: at(monday, 13, 0)},
+	})
+	assert.ErrorIs(t, err, services.ErrInvalidBreakTime)
+	occurrences, err := service.Occurrences(1, monday, monday.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	assert.Empty(t, occurrences)
+}
+
+func TestBreakService_OccurrencesAcrossDST(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	service := setupBreakService(t, berlin)
+
+	// Обед в 13:00 по Берлину задан в UTC; 30 марта 2025 часы переводятся на летнее время
+	start := time.Date(2025, 3, 28, 12, 0, 0, 0, time.UTC)
+	lunch := &models.Break{UserID: 1, BreakStart: start, BreakEnd: start.Add(time.Hour), Recurrence: models.BreakRecurrenceDaily}
+	require.NoError(t, service.CreateBreak(1, lunch))
+
+	from := time.Date(2025, 3, 28, 0, 0, 0, 0, berlin)
+	to := from.AddDate(0, 0, 4)
+	occurrences, err := service.Occurrences(1, from, to)
+	require.NoError(t, err)
+	require.Len(t, occurrences, 4)
+	for i, occurrence := range occurrences {
+		local := occurrence.Start.In(berlin)
+		assert.Equal(t, 28+i, local.Day())
+		assert.Equal(t, 13, local.Hour())
+		assert.Equal(t, time.Hour, occurrence.End.Sub(occurrence.Start))
+	}
+	assert.Equal(t, 11, occurrences[2].Start.UTC().Hour())
+
+	// Дата вхождения понимается в часовом поясе салона
+	require.NoError(t, service.DeleteOccurrence(1, lunch.ID, "2025-03-30"))
+	occurrences, err = service.Occurrences(1, from, to)
+	require.NoError(t, err)
+	assert.Len(t, occurrences, 3)
+}
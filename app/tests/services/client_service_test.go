@@ -0,0 +1,392 @@
+package services
+
+import (
+	"fmt"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/repositories"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/services"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type clientFixture struct {
+	db      *gorm.DB
+	clients services.ClientService
+	history services.HistoryService
+}
+
+// setupClientService создает сервис клиентов над базой без клиентов. Бронирования, уведомления
+// и журнал изменений нужны профилю клиента и объединению дубликатов.
+func setupClientService(t *testing.T) *clientFixture {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&models.Client{}, &models.ClientNote{}, &models.ClientField{},
+		&models.Bookings{}, &models.BookingItem{}, &models.BookingStatusChange{}, &models.Service{}, &models.User{},
+		&models.Notification{}, &models.HistoryLogs{},
+	))
+
+	history := services.NewHistoryService(repositories.NewHistoryRepository(db))
+	return &clientFixture{
+		db:      db,
+		clients: services.NewClientService(repositories.NewClientRepository(db), repositories.NewBookingRepository(db), repositories.NewClientFieldRepository(db), history),
+		history: history,
+	}
+}
+
+func TestClientService_GetClientProfile(t *testing.T) {
+	f := setupClientService(t)
+	clients := f.clients
+
+	require.NoError(t, f.db.Create(&models.Client{FirstName: "Иван", PhoneNumber: "+79990000001"}).Error)
+	haircut := &models.Service{Name: "Стрижка", Price: models.NewMoney(100000, "RUB"), Duration: 60, IsActive: true}
+	beard := &models.Service{Name: "Борода", Price: models.NewMoney(50000, "RUB"), Duration: 30, IsActive: true}
+	require.NoError(t, f.db.Create([]*models.Service{haircut, beard}).Error)
+	for _, username := range []string{"junior", "senior"} {
+		require.NoError(t, f.db.Create(&models.User{Username: username, PasswordHash: "hash", Role: models.RoleBarber, Email: username + "@example.com"}).Error)
+	}
+
+	// Пустая история
+	profile, err := clients.GetClientProfile(1)
+	require.NoError(t, err)
+	assert.Zero(t, profile.TotalVisits)
+	assert.Empty(t, profile.LifetimeSpend)
+	assert.Nil(t, profile.LastVisit)
+	assert.Nil(t, profile.AverageIntervalDays)
+
+	start := time.Date(2025, 1, 6, 10, 0, 0, 0, time.UTC)
+	visit := func(userID int, when time.Time, status string, price int64, serviceIDs ...int) {
+		booking := &models.Bookings{ClientID: 1, UserID: userID, ServiceID: serviceIDs[0], BookingTime: when, Status: status,
+			Price: models.NewMoney(price, "RUB"), Duration: 60}
+		for position, serviceID := range serviceIDs {
+			booking.Items = append(booking.Items, models.BookingItem{ServiceID: serviceID, Position: position, Duration: 30})
+		}
+		require.NoError(t, repositories.NewBookingRepository(f.db).CreateBooking(booking))
+	}
+	visit(1, start, models.BookingStatusCompleted, 100000, haircut.ID)
+	visit(2, start.AddDate(0, 0, 10), models.BookingStatusCompleted, 150000, haircut.ID, beard.ID)
+	visit(2, start.AddDate(0, 0, 31), models.BookingStatusCompleted, 50000, beard.ID)
+	visit(2, start.AddDate(0, 0, 35), models.BookingStatusCancelled, 100000, haircut.ID)
+	visit(1, start.AddDate(0, 0, 40), models.BookingStatusNoShow, 100000, haircut.ID)
+	next := time.Now().UTC().AddDate(0, 0, 3).Truncate(time.Minute)
+	visit(1, next, models.BookingStatusConfirmed, 100000, haircut.ID)
+	visit(1, next.AddDate(0, 0, 7), models.BookingStatusPending, 100000, haircut.ID)
+
+	profile, err = clients.GetClientProfile(1)
+	require.NoError(t, err)
+	assert.Equal(t, "Иван", profile.Client.FirstName)
+	assert.Equal(t, int64(7), profile.TotalVisits)
+	assert.Equal(t, int64(3), profile.Completed)
+	assert.Equal(t, int64(1), profile.Cancelled)
+	assert.Equal(t, int64(1), profile.NoShow)
+	assert.Equal(t, []models.Money{models.NewMoney(300000, "RUB")}, profile.LifetimeSpend)
+	assert.Equal(t, []repositories.ServiceVisits{
+		{ServiceID: haircut.ID, Name: "Стрижка", Visits: 2},
+		{ServiceID: beard.ID, Name: "Борода", Visits: 2},
+	}, profile.FavouriteServices)
+	require.Len(t, profile.FavouriteBarbers, 2)
+	assert.Equal(t, repositories.BarberVisits{UserID: 2, Username: "senior", Visits: 2}, profile.FavouriteBarbers[0])
+
+	require.NotNil(t, profile.LastVisit)
+	assert.True(t, start.AddDate(0, 0, 31).Equal(*profile.LastVisit))
+	require.NotNil(t, profile.NextVisit)
+	assert.True(t, next.Equal(*profile.NextVisit))
+	require.NotNil(t, profile.AverageIntervalDays)
+	assert.Equal(t, 15.5, *profile.AverageIntervalDays)
+
+	_, err = clients.GetClientProfile(42)
+	assert.ErrorIs(t, err, repositories.ErrClientNotFound)
+}
+
+func TestClientService_DuplicatesAndMerge(t *testing.T) {
+	f := setupClientService(t)
+	clients := f.clients
+
+	// Email и Telegram ID уникальны, поэтому у каждого клиента без Telegram или email свой набор контактов
+	withEmail := &models.Client{FirstName: "Пётр", LastName: "Сидоров", Email: "petr@example.com", PhoneNumber: "+7 (999) 123-45-67"}
+	withTelegram := &models.Client{FirstName: "Сидоров", LastName: "Пётр", PhoneNumber: "89991234567", TgID: 1001, TgNickname: "petr"}
+	namesake := &models.Client{FirstName: "Пётр", LastName: "Сидоров", PhoneNumber: "+79995550000", Email: "other@example.com", TgID: 2002}
+	typo := &models.Client{FirstName: "Петр", LastName: "Сидоров", Email: "sidorov@example.com", TgID: 3003}
+	for _, client := range []*models.Client{withEmail, withTelegram, namesake, typo} {
+		require.NoError(t, clients.CreateClient(1, client))
+	}
+
+	search, err := clients.FindDuplicates(services.DefaultDuplicateScore, 0)
+	require.NoError(t, err)
+	assert.False(t, search.Truncated)
+	candidates := search.Candidates
+	require.Len(t, candidates, 1)
+	assert.Equal(t, withEmail.ID, candidates[0].Client.ID)
+	assert.Equal(t, withTelegram.ID, candidates[0].Duplicate.ID)
+	assert.Equal(t, 1.0, candidates[0].Score)
+	assert.Equal(t, []string{services.DuplicateReasonPhone, services.DuplicateReasonName}, candidates[0].Reasons)
+
+	// С низким порогом находятся тезки с другими контактами; тезка с другими телефоном и email получает 0
+	search, err = clients.FindDuplicates(0.1, 0)
+	require.NoError(t, err)
+	assert.Len(t, search.Candidates, 5)
+
+	booking := &models.Bookings{ClientID: withTelegram.ID, ServiceID: 1, UserID: 1, BookingTime: time.Now(), Status: models.BookingStatusCompleted}
+	require.NoError(t, f.db.Omit("Items").Create(booking).Error)
+	require.NoError(t, f.db.Create(&models.Notification{ClientID: withTelegram.ID, Message: "Напоминание"}).Error)
+
+	_, err = clients.MergeClients(1, withEmail.ID, withEmail.ID)
+	assert.ErrorIs(t, err, services.ErrMergeSameClient)
+	_, err = clients.MergeClients(1, withEmail.ID, 42)
+	assert.ErrorIs(t, err, repositories.ErrClientNotFound)
+
+	merge, err := clients.MergeClients(1, withEmail.ID, withTelegram.ID)
+	require.NoError(t, err)
+	assert.Equal(t, repositories.ClientMergeResult{Bookings: 1, Notifications: 1}, merge.Moved)
+	assert.Equal(t, int64(1001), merge.Client.TgID)
+	assert.Equal(t, "petr", merge.Client.TgNickname)
+	assert.Equal(t, "+79991234567", merge.Client.PhoneNumber)
+	assert.Equal(t, "petr@example.com", merge.Client.Email)
+
+	_, err = clients.GetClientByID(withTelegram.ID)
+	assert.ErrorIs(t, err, repositories.ErrClientNotFound)
+	moved, err := repositories.NewBookingRepository(f.db).GetBookingByID(booking.ID)
+	require.NoError(t, err)
+	assert.Equal(t, withEmail.ID, moved.ClientID)
+	var notification models.Notification
+	require.NoError(t, f.db.First(&notification).Error)
+	assert.Equal(t, withEmail.ID, notification.ClientID)
+
+	logs, _, err := f.history.GetHistory(repositories.ListQuery{Filters: map[string]string{"action": models.HistoryActionMerge}})
+	require.NoError(t, err)
+	require.Len(t, logs, 2)
+	entries := map[int]models.HistoryLogs{logs[0].EntityID: logs[0], logs[1].EntityID: logs[1]}
+	assert.Equal(t, float64(withTelegram.ID), entries[withEmail.ID].Changes["merged_from"].After)
+	assert.Equal(t, float64(withEmail.ID), entries[withTelegram.ID].Changes["merged_into"].After)
+	assert.Equal(t, float64(1001), entries[withEmail.ID].Changes["tg_id"].After)
+}
+
+func TestClientService_DuplicateBlocks(t *testing.T) {
+	f := setupClientService(t)
+	clients := f.clients
+
+	// Частое имя без фамилии не отличает дубликаты: такая группа не сравнивается
+	for i := 1; i <= 51; i++ {
+		require.NoError(t, f.db.Create(&models.Client{FirstName: "Иван", Email: fmt.Sprintf("ivan%d@example.com", i), TgID: int64(i)}).Error)
+	}
+	// Тезки сравниваются, если совпадает одна часть имени и первая буква другой
+	anna := &models.Client{FirstName: "Анна", LastName: "Петрова", Email: "anna@example.com", TgID: 100}
+	swapped := &models.Client{FirstName: "Петрова", LastName: "Аня", Email: "anya@example.com", TgID: 101}
+	other := &models.Client{FirstName: "Алла", LastName: "Пестова", Email: "alla@example.com", TgID: 102}
+	for _, client := range []*models.Client{anna, swapped, other} {
+		require.NoError(t, f.db.Create(client).Error)
+	}
+
+	search, err := clients.FindDuplicates(0.1, 0)
+	require.NoError(t, err)
+	assert.True(t, search.Truncated)
+	require.Len(t, search.Candidates, 1)
+	assert.Equal(t, anna.ID, search.Candidates[0].Client.ID)
+	assert.Equal(t, swapped.ID, search.Candidates[0].Duplicate.ID)
+	assert.Equal(t, []string{services.DuplicateReasonName}, search.Candidates[0].Reasons)
+}
+
+func TestClientService_NormalizesContacts(t *testing.T) {
+	f := setupClientService(t)
+	clients := f.clients
+
+	client := &models.Client{FirstName: "Петр", PhoneNumber: "+7 (999) 123-45-67", Email: " Petr@Example.com", TgID: 10}
+	require.NoError(t, clients.CreateClient(1, client))
+	assert.Equal(t, "+79991234567", client.PhoneNumber)
+	assert.Equal(t, "petr@example.com", client.Email)
+
+	// Поиск нормализует запрос так же, как запись
+	found, err := clients.SearchClientByEmailOrPhone("", "89991234567")
+	require.NoError(t, err)
+	assert.Equal(t, client.ID, found.ID)
+	found, err = clients.SearchClientByEmailOrPhone("PETR@example.com", "")
+	require.NoError(t, err)
+	assert.Equal(t, client.ID, found.ID)
+	exists, err := clients.CheckClientExistence("8 (999) 123-45-67", 0)
+	require.NoError(t, err)
+	assert.True(t, exists)
+	list, total, err := clients.GetAllClients(repositories.ListQuery{Filters: map[string]string{"phone_number": "9991234567"}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, client.ID, list[0].ID)
+
+	quick := &models.Client{FirstName: "Двойник", PhoneNumber: "8 999 123 45 67", TgID: 11}
+	assert.ErrorIs(t, clients.QuickAddClient(1, quick), repositories.ErrClientAlreadyExists)
+
+	_, err = clients.SearchClientByEmailOrPhone("", "12345")
+	assert.ErrorIs(t, err, models.ErrInvalidPhone)
+	_, _, err = clients.GetAllClients(repositories.ListQuery{Filters: map[string]string{"email": "не email"}})
+	assert.ErrorIs(t, err, repositories.ErrInvalidListQuery)
+	assert.ErrorIs(t, clients.UpdateClient(1, client.ID, &models.Client{FirstName: "Петр", Email: "petr@"}), models.ErrInvalidEmail)
+	assert.ErrorIs(t, clients.CreateClient(1, &models.Client{FirstName: "Анна", PhoneNumber: "звоните в офис"}), models.ErrInvalidPhone)
+}
+
+func TestClientService_SearchClients(t *testing.T) {
+	f := setupClientService(t)
+	clients := f.clients
+
+	petr := &models.Client{FirstName: "Пётр", LastName: "Сидоров", PhoneNumber: "+79991234567", Email: "petr@example.com", TgID: 1}
+	alexandra := &models.Client{FirstName: "Александра", LastName: "Петрова", PhoneNumber: "+79997654321", Email: "sasha@example.com", TgID: 2, TgNickname: "sasha_p"}
+	sidorenko := &models.Client{FirstName: "Анна", LastName: "Сидоренко", PhoneNumber: "+79161230000", Email: "anna@mail.ru", TgID: 3}
+	for _, client := range []*models.Client{petr, alexandra, sidorenko} {
+		require.NoError(t, clients.CreateClient(1, client))
+	}
+
+	ids := func(results []services.ClientSearchResult) []int {
+		found := make([]int, 0, len(results))
+		for _, result := range results {
+			found = append(found, result.Client.ID)
+		}
+		return found
+	}
+	search := func(q string) []int {
+		results, err := clients.SearchClients(q, 0)
+		require.NoError(t, err, q)
+		return ids(results)
+	}
+
+	// Имя и фамилия в любом порядке, в том числе частично
+	assert.Equal(t, []int{petr.ID}, search("Пётр Сидоров"))
+	assert.Equal(t, []int{petr.ID}, search("сидоров пётр"))
+	assert.Equal(t, []int{petr.ID, sidorenko.ID}, search("сидор"))
+	// Опечатка в имени
+	assert.Equal(t, []int{alexandra.ID}, search("алексадра"))
+	// Фрагмент телефона и номер в любом формате
+	assert.Equal(t, []int{petr.ID, sidorenko.ID}, search("123"))
+	assert.Equal(t, []int{petr.ID}, search("8 (999) 123-45-67"))
+	assert.Equal(t, []int{alexandra.ID}, search("+7 999 7"))
+	// Ник Telegram и email
+	assert.Equal(t, []int{alexandra.ID}, search("@sasha"))
+	assert.Equal(t, []int{sidorenko.ID}, search("anna@mail.ru"))
+	// Слова из разных полей должны совпасть все
+	assert.Equal(t, []int{sidorenko.ID}, search("анна 916"))
+	assert.Empty(t, search("пётр анна"))
+
+	// Точное совпадение выше частичного
+	results, err := clients.SearchClients("петров", 0)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, alexandra.ID, results[0].Client.ID)
+	results, err = clients.SearchClients("петр", 0)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, []int{alexandra.ID, petr.ID}, ids(results))
+	assert.Greater(t, results[0].Score, results[1].Score)
+
+	results, err = clients.SearchClients("сидор", 1)
+	require.NoError(t, err)
+	assert.Len(t, results, 1)
+	_, err = clients.SearchClients("  @ ", 0)
+	assert.ErrorIs(t, err, services.ErrEmptySearchQuery)
+}
+
+func TestClientService_NotesTagsAndCustomFields(t *testing.T) {
+	f := setupClientService(t)
+	clients := f.clients
+
+	birthday := &models.ClientField{Key: "birthday", Label: "День рождения", Type: models.ClientFieldDate}
+	hair := &models.ClientField{Key: "hair_type", Label: "Тип волос", Type: models.ClientFieldSelect, Options: []string{"прямые", "вьющиеся"}, Required: true}
+	require.NoError(t, clients.CreateClientField(1, birthday))
+	require.NoError(t, clients.CreateClientField(1, hair))
+	assert.ErrorIs(t, clients.CreateClientField(1, &models.ClientField{Key: "birthday", Label: "ДР", Type: models.ClientFieldText}), repositories.ErrClientFieldExists)
+	assert.ErrorIs(t, clients.CreateClientField(1, &models.ClientField{Key: "Birthday", Label: "ДР", Type: models.ClientFieldDate}), models.ErrInvalidClientField)
+
+	// Обязательное поле, неизвестное поле и значение не того типа
+	client := &models.Client{FirstName: "Пётр", PhoneNumber: "+79991234567", Email: "petr@example.com", TgID: 10, Tags: []string{" VIP ", "студент", "vip"},
+		CustomFields: map[string]interface{}{"birthday": "1990-05-17"}}
+	assert.ErrorIs(t, clients.CreateClient(1, client), services.ErrRequiredCustomField)
+	client.CustomFields["hair_type"] = "кудрявые"
+	assert.ErrorIs(t, clients.CreateClient(1, client), models.ErrInvalidFieldValue)
+	client.CustomFields["hair_type"] = "вьющиеся"
+	client.CustomFields["shoe_size"] = 42.0
+	assert.ErrorIs(t, clients.CreateClient(1, client), services.ErrUnknownCustomField)
+	delete(client.CustomFields, "shoe_size")
+	require.NoError(t, clients.CreateClient(1, client))
+	assert.Equal(t, []string{"vip", "студент"}, client.Tags)
+
+	// Без custom_fields сохраненные значения не меняются, теги заменяются целиком
+	require.NoError(t, clients.UpdateClient(1, client.ID, &models.Client{FirstName: "Пётр", PhoneNumber: "+79991234567", Email: "petr@example.com", TgID: 10, Tags: []string{"vip"}}))
+	stored, err := clients.GetClientByID(client.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"vip"}, stored.Tags)
+	assert.Equal(t, map[string]interface{}{"birthday": "1990-05-17", "hair_type": "вьющиеся"}, stored.CustomFields)
+
+	hair.Options = []string{"прямые", "вьющиеся", "окрашенные"}
+	hair.Required = false
+	updated, err := clients.UpdateClientField(1, hair.ID, hair)
+	require.NoError(t, err)
+	assert.False(t, updated.Required)
+	fields, err := clients.GetClientFields()
+	require.NoError(t, err)
+	assert.Len(t, fields, 2)
+
+	// Фильтр по тегу и использование тегов
+	other := &models.Client{FirstName: "Анна", PhoneNumber: "+79161230000", Email: "anna@example.com", TgID: 11, Tags: []string{"VIP", "новый"}}
+	require.NoError(t, clients.CreateClient(1, other))
+	list, total, err := clients.GetAllClients(repositories.ListQuery{Filters: map[string]string{"tag": "Vip"}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Len(t, list, 2)
+	usage, err := clients.GetTagUsage()
+	require.NoError(t, err)
+	assert.Equal(t, []repositories.TagUsage{{Name: "vip", Clients: 2}, {Name: "новый", Clients: 1}}, usage)
+
+	// Заметки видны в карточке клиента и при открытии бронирования
+	_, err = clients.AddClientNote(1, client.ID, "   ")
+	assert.ErrorIs(t, err, services.ErrInvalidNote)
+	first, err := clients.AddClientNote(1, client.ID, "Аллергия на лак для волос")
+	require.NoError(t, err)
+	second, err := clients.AddClientNote(2, client.ID, "Предпочитает мастера Олега")
+	require.NoError(t, err)
+	_, err = clients.UpdateClientNote(1, other.ID, first.ID, "Чужая заметка")
+	assert.ErrorIs(t, err, repositories.ErrClientNoteNotFound)
+	edited, err := clients.UpdateClientNote(1, client.ID, first.ID, "Аллергия на лак для волос и воск")
+	require.NoError(t, err)
+	assert.Equal(t, "Аллергия на лак для волос и воск", edited.Text)
+
+	stored, err = clients.GetClientByID(client.ID)
+	require.NoError(t, err)
+	require.Len(t, stored.Notes, 2)
+	assert.Equal(t, second.ID, stored.Notes[0].ID)
+
+	booking := &models.Bookings{ClientID: client.ID, ServiceID: 1, UserID: 1, BookingTime: time.Now(), Status: models.BookingStatusPending}
+	require.NoError(t, f.db.Omit("Items").Create(booking).Error)
+	opened, err := repositories.NewBookingRepository(f.db).GetBookingByID(booking.ID)
+	require.NoError(t, err)
+	require.Len(t, opened.Client.Notes, 2)
+	assert.Equal(t, []string{"vip"}, opened.Client.Tags)
+
+	require.NoError(t, clients.DeleteClientNote(1, client.ID, second.ID))
+	notes, err := clients.GetClientNotes(client.ID)
+	require.NoError(t, err)
+	require.Len(t, notes, 1)
+	assert.Equal(t, first.ID, notes[0].ID)
+
+	// Объединение переносит заметки и объединяет теги
+	require.NoError(t, clients.CreateClientField(1, &models.ClientField{Key: "visits_before", Label: "Визиты до CRM", Type: models.ClientFieldNumber}))
+	_, err = clients.AddClientNote(1, other.ID, "Пришла по рекомендации")
+	require.NoError(t, err)
+	merge, err := clients.MergeClients(1, client.ID, other.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), merge.Moved.Notes)
+	assert.Equal(t, []string{"vip", "новый"}, merge.Client.Tags)
+	notes, err = clients.GetClientNotes(client.ID)
+	require.NoError(t, err)
+	assert.Len(t, notes, 2)
+
+	// Значения удаленного поля отбрасываются при следующем изменении клиента
+	require.NoError(t, clients.DeleteClientField(1, birthday.ID))
+	require.NoError(t, clients.UpdateClient(1, client.ID, &models.Client{FirstName: "Пётр", PhoneNumber: "+79991234567", Email: "petr@example.com", TgID: 10}))
+	stored, err = clients.GetClientByID(client.ID)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"hair_type": "вьющиеся"}, stored.CustomFields)
+
+	logs, _, err := f.history.GetHistory(repositories.ListQuery{Filters: map[string]string{"entity_type": models.EntityClientNote}})
+	require.NoError(t, err)
+	assert.Len(t, logs, 5)
+}
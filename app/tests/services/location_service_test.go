@@ -0,0 +1,65 @@
+package services
+
+import (
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/repositories"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/services"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupLocationService(t *testing.T, defaultLoc *time.Location) services.LocationService {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Location{}, &models.HistoryLogs{}))
+
+	return services.NewLocationService(
+		repositories.NewLocationRepository(db),
+		services.NewHistoryService(repositories.NewHistoryRepository(db)),
+		defaultLoc,
+	)
+}
+
+func TestLocationService_Resolve(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	require.NoError(t, err)
+	service := setupLocationService(t, moscow)
+
+	branch := &models.Location{Name: "Филиал", Timezone: "Asia/Yekaterinburg"}
+	require.NoError(t, service.CreateLocation(1, branch))
+
+	location, zone, err := service.Resolve(branch.ID)
+	require.NoError(t, err)
+	require.NotNil(t, location)
+	assert.Equal(t, "Asia/Yekaterinburg", zone.String())
+
+	// Без филиала и для неизвестного филиала используется часовой пояс по умолчанию
+	location, zone, err = service.Resolve(0)
+	require.NoError(t, err)
+	assert.Nil(t, location)
+	assert.Equal(t, moscow, zone)
+	zone, err = service.Zone(branch.ID + 1)
+	require.NoError(t, err)
+	assert.Equal(t, moscow, zone)
+}
+
+func TestLocationService_Validation(t *testing.T) {
+	service := setupLocationService(t, time.UTC)
+
+	err := service.CreateLocation(1, &models.Location{Name: " ", Timezone: "Europe/Moscow"})
+	assert.ErrorIs(t, err, services.ErrInvalidLocation)
+
+	err = service.CreateLocation(1, &models.Location{Name: "Центр", Timezone: "Moscow"})
+	assert.ErrorIs(t, err, services.ErrInvalidTimezone)
+
+	err = service.CreateLocation(1, &models.Location{
+		Name: "Центр", Timezone: "Europe/Moscow",
+		OpeningHours: []models.OpeningHours{{Day: models.Monday, Open: models.NewTimeOfDay(20, 0), Close: models.NewTimeOfDay(10, 0)}},
+	})
+	assert.ErrorIs(t, err, services.ErrInvalidOpeningHours)
+}
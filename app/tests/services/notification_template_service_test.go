@@ -0,0 +1,165 @@
+package services
+
+import (
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/repositories"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/services"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type templateFixture struct {
+	db        *gorm.DB
+	templates services.NotificationTemplateService
+	client    *models.Client
+	service   *models.Service
+}
+
+// setupTemplateService создает сервис шаблонов над базой с клиентом «Иван» и услугой «Стрижка»
+// на 60 минут; часовой пояс по умолчанию — UTC
+func setupTemplateService(t *testing.T) *templateFixture {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&models.NotificationTemplate{}, &models.Notification{}, &models.Location{}, &models.HistoryLogs{},
+		&models.Bookings{}, &models.BookingItem{}, &models.BookingStatusChange{}, &models.Client{}, &models.ClientNote{}, &models.Service{}, &models.User{},
+	))
+
+	history := services.NewHistoryService(repositories.NewHistoryRepository(db))
+	locations := services.NewLocationService(repositories.NewLocationRepository(db), history, time.UTC)
+	f := &templateFixture{
+		db:        db,
+		templates: services.NewNotificationTemplateService(repositories.NewNotificationTemplateRepository(db), repositories.NewBookingRepository(db), locations),
+		client:    &models.Client{FirstName: "Иван", PhoneNumber: "+79990000001"},
+		service:   &models.Service{Name: "Стрижка", Price: models.NewMoney(100000, "RUB"), Duration: 60, IsActive: true},
+	}
+	require.NoError(t, db.Create(f.client).Error)
+	require.NoError(t, db.Create(f.service).Error)
+	return f
+}
+
+// createVisit сохраняет подтвержденное бронирование клиента на время start в филиале locationID
+func createVisit(t *testing.T, f *templateFixture, locationID int, start time.Time) *models.Bookings {
+	booking := &models.Bookings{ClientID: f.client.ID, UserID: 1, ServiceID: f.service.ID, LocationID: locationID, BookingTime: start,
+		Status: models.BookingStatusConfirmed, Price: f.service.Price, Duration: f.service.Duration,
+		Items: []models.BookingItem{{ServiceID: f.service.ID, Price: f.service.Price, Duration: f.service.Duration}}}
+	require.NoError(t, repositories.NewBookingRepository(f.db).CreateBooking(booking))
+	return booking
+}
+
+func TestNotificationTemplateService_RenderFallsBackToDefaultLanguage(t *testing.T) {
+	templates := setupTemplateService(t).templates
+	require.NoError(t, templates.CreateTemplate(&models.NotificationTemplate{Code: models.TemplateBookingConfirmed, Language: models.LanguageRU, Body: "Здравствуйте, {{.Client.FirstName}}"}))
+	require.NoError(t, templates.CreateTemplate(&models.NotificationTemplate{Code: models.TemplateBookingCancelled, Language: models.LanguageRU, Body: "Запись отменена"}))
+	require.NoError(t, templates.CreateTemplate(&models.NotificationTemplate{Code: models.TemplateBookingCancelled, Language: models.LanguageEN, Body: "Booking cancelled"}))
+	data := services.TemplateData{Client: models.Client{FirstName: "John"}}
+
+	// Шаблона на английском нет — используется русский
+	text, err := templates.Render(models.TemplateBookingConfirmed, models.LanguageEN, data)
+	require.NoError(t, err)
+	assert.Equal(t, "Здравствуйте, John", text)
+	text, err = templates.Render(models.TemplateBookingConfirmed, "", data)
+	require.NoError(t, err)
+	assert.Equal(t, "Здравствуйте, John", text)
+
+	text, err = templates.Render(models.TemplateBookingCancelled, models.LanguageEN, data)
+	require.NoError(t, err)
+	assert.Equal(t, "Booking cancelled", text)
+	text, err = templates.Render(models.TemplateBookingCancelled, models.LanguageRU, data)
+	require.NoError(t, err)
+	assert.Equal(t, "Запись отменена", text)
+
+	_, err = templates.Render(models.TemplateBirthdayGreeting, models.LanguageEN, data)
+	assert.ErrorIs(t, err, repositories.ErrTemplateNotFound)
+}
+
+func TestNotificationTemplateService_Validation(t *testing.T) {
+	templates := setupTemplateService(t).templates
+
+	for name, tmpl := range map[string]*models.NotificationTemplate{
+		"неизвестное поле":    {Code: "custom", Language: models.LanguageRU, Body: "{{.Client.Nickname}}"},
+		"отсутствующий ключ":  {Code: "custom", Language: models.LanguageRU, Body: "{{.Client.CustomFields.vip}}"},
+		"неизвестная функция": {Code: "custom", Language: models.LanguageRU, Body: "{{money .Service.Price}}"},
+		"незакрытое действие": {Code: "custom", Language: models.LanguageRU, Body: "{{.Client.FirstName"},
+		"пустой текст":        {Code: "custom", Language: models.LanguageRU, Body: "  "},
+		"пустой код":          {Code: " ", Language: models.LanguageRU, Body: "Текст"},
+	} {
+		assert.ErrorIs(t, templates.CreateTemplate(tmpl), services.ErrInvalidTemplate, name)
+	}
+	assert.ErrorIs(t, templates.CreateTemplate(&models.NotificationTemplate{Code: "custom", Language: "de", Body: "Text"}), services.ErrInvalidLanguage)
+
+	// Некорректное изменение отклоняется, сохраненный шаблон не меняется
+	tmpl := &models.NotificationTemplate{Code: "custom", Language: models.LanguageRU, Body: "{{.Service.Name}} {{range .Services}}{{.Name}}{{end}}"}
+	require.NoError(t, templates.CreateTemplate(tmpl))
+	err := templates.UpdateTemplate(tmpl.ID, &models.NotificationTemplate{Code: "custom", Language: models.LanguageRU, Body: "{{.Booking.Missing}}"})
+	assert.ErrorIs(t, err, services.ErrInvalidTemplate)
+	stored, err := templates.GetTemplateByID(tmpl.ID)
+	require.NoError(t, err)
+	assert.Equal(t, tmpl.Body, stored.Body)
+}
+
+func TestNotificationTemplateService_PreviewUsesLocationTimezone(t *testing.T) {
+	f := setupTemplateService(t)
+	templates := f.templates
+	tmpl := &models.NotificationTemplate{Code: models.TemplateBookingConfirmed, Language: models.LanguageRU,
+		Body: "{{.Client.FirstName}}, {{.Service.Name}}: {{datetime .Booking.BookingTime}} ({{date .Booking.BookingTime}} в {{time .Booking.BookingTime}})"}
+	require.NoError(t, templates.CreateTemplate(tmpl))
+
+	// Пример бронирования в часовом поясе по умолчанию
+	text, err := templates.Preview(tmpl.ID, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "Иван, Мужская стрижка: 14.03.2025 15:30 (14.03.2025 в 15:30)", text)
+
+	// 20:30 UTC в Екатеринбурге (UTC+5) — уже следующий день
+	yekaterinburg := &models.Location{Name: "Екатеринбург", Timezone: "Asia/Yekaterinburg", IsActive: true}
+	require.NoError(t, f.db.Create(yekaterinburg).Error)
+	booking := createVisit(t, f, yekaterinburg.ID, time.Date(2025, 3, 14, 20, 30, 0, 0, time.UTC))
+	text, err = templates.Preview(tmpl.ID, booking.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Иван, Стрижка: 15.03.2025 01:30 (15.03.2025 в 01:30)", text)
+
+	// Без филиала время выводится в часовом поясе по умолчанию
+	booking = createVisit(t, f, 0, time.Date(2025, 3, 14, 22, 30, 0, 0, time.UTC))
+	text, err = templates.Preview(tmpl.ID, booking.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Иван, Стрижка: 14.03.2025 22:30 (14.03.2025 в 22:30)", text)
+
+	_, err = templates.Preview(tmpl.ID, 999)
+	assert.Error(t, err)
+}
+
+func TestReminderService_FallsBackToGenericReminder(t *testing.T) {
+	f := setupTemplateService(t)
+	templates := f.templates
+	require.NoError(t, templates.CreateTemplate(&models.NotificationTemplate{Code: models.TemplateReminder24h, Language: models.LanguageRU, Body: "Завтра в {{time .Booking.BookingTime}}"}))
+	require.NoError(t, templates.CreateTemplate(&models.NotificationTemplate{Code: models.TemplateReminder, Language: models.LanguageRU, Body: "Скоро визит в {{time .Booking.BookingTime}}"}))
+	// Клиент говорит по-английски, английских шаблонов нет
+	require.NoError(t, f.db.Model(&models.Client{}).Where("id = ?", f.client.ID).Update("language", models.LanguageEN).Error)
+
+	notifications := repositories.NewNotificationRepository(f.db)
+	reminders := services.NewReminderService(notifications, repositories.NewBookingRepository(f.db), templates,
+		[]time.Duration{24 * time.Hour, 2 * time.Hour, 30 * time.Minute}, []string{models.NotificationTypeSMS})
+	start := nextMonday().Add(10 * time.Hour)
+	booking := createVisit(t, f, 0, start)
+	require.NoError(t, reminders.ScheduleBookingReminders(booking))
+
+	var queued []models.Notification
+	require.NoError(t, f.db.Where("booking_id = ?", booking.ID).Order("scheduled_for").Find(&queued).Error)
+	require.Len(t, queued, 3)
+	assert.Equal(t, "Завтра в 10:00", queued[0].Message)
+	assert.Equal(t, "Скоро визит в 10:00", queued[1].Message)
+	assert.Equal(t, "Скоро визит в 10:00", queued[2].Message)
+	assert.True(t, start.Add(-24*time.Hour).Equal(*queued[0].ScheduledFor))
+	assert.Equal(t, models.NotificationTypeSMS, queued[0].NotificationType)
+
+	// Без общего шаблона напоминание для интервала без своего шаблона не создается
+	reminder, err := repositories.NewNotificationTemplateRepository(f.db).GetTemplateByCode(models.TemplateReminder, models.LanguageRU)
+	require.NoError(t, err)
+	require.NoError(t, templates.DeleteTemplate(reminder.ID))
+	assert.ErrorIs(t, reminders.ScheduleBookingReminders(booking), repositories.ErrTemplateNotFound)
+}
@@ -0,0 +1,184 @@
+package services
+
+import (
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/models"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/repositories"
+	"github.com/0sokrat0/GoGRAFFApi.git/app/internal/services"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupScheduleService(t *testing.T, loc *time.Location) (*gorm.DB, services.ScheduleService) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Schedule{}, &models.ScheduleOverride{}, &models.Location{}, &models.HistoryLogs{}))
+
+	history := services.NewHistoryService(repositories.NewHistoryRepository(db))
+	service := services.NewScheduleService(
+		repositories.NewScheduleRepository(db),
+		repositories.NewScheduleOverrideRepository(db),
+		history,
+		services.NewLocationService(repositories.NewLocationRepository(db), history, loc),
+	)
+	return db, service
+}
+
+// monday — понедельник, 6 января 2025
+var monday = time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC)
+
+func at(day time.Time, hour, minute int) time.Time {
+	return models.NewTimeOfDay(hour, minute).On(day)
+}
+
+func TestScheduleService_WorkingIntervals(t *testing.T) {
+	_, service := setupScheduleService(t, time.UTC)
+
+	weekly := []models.Schedule{
+		{UserID: 1, ScheduleDay: models.Monday, StartTime: models.NewTimeOfDay(9, 0), EndTime: models.NewTimeOfDay(13, 0)},
+		{UserID: 1, ScheduleDay: models.Monday, StartTime: models.NewTimeOfDay(14, 0), EndTime: models.NewTimeOfDay(18, 0)},
+		{UserID: 1, ScheduleDay: models.Tuesday, StartTime: models.NewTimeOfDay(10, 0), EndTime: models.NewTimeOfDay(19, 0)},
+	}
+	for i := range weekly {
+		require.NoError(t, service.CreateSchedule(1, &weekly[i]))
+	}
+
+	work, err := service.WorkingIntervals(0, 1, monday)
+	require.NoError(t, err)
+	assert.Equal(t, []services.WorkingInterval{
+		{Start: at(monday, 9, 0), End: at(monday, 13, 0)},
+		{Start: at(monday, 14, 0), End: at(monday, 18, 0)},
+	}, work)
+
+	// Выходной отменяет еженедельные часы, дополнительная смена при этом сохраняется
+	nextMonday := monday.AddDate(0, 0, 7)
+	require.NoError(t, service.CreateOverride(1, &models.ScheduleOverride{UserID: 1, Date: "2025-01-13", Kind: models.ScheduleOverrideDayOff}))
+	work, err = service.WorkingIntervals(0, 1, nextMonday)
+	require.NoError(t, err)
+	assert.Empty(t, work)
+
+	require.NoError(t, service.CreateOverride(1, &models.ScheduleOverride{
+		UserID: 1, Date: "2025-01-13", Kind: models.ScheduleOverrideExtra,
+		StartTime: models.NewTimeOfDay(18, 0), EndTime: models.NewTimeOfDay(20, 0),
+	}))
+	work, err = service.WorkingIntervals(0, 1, nextMonday)
+	require.NoError(t, err)
+	assert.Equal(t, []services.WorkingInterval{{Start: at(nextMonday, 18, 0), End: at(nextMonday, 20, 0)}}, work)
+
+	// Особые часы заменяют еженедельные, смежная дополнительная смена объединяется с ними
+	tuesday := monday.AddDate(0, 0, 1)
+	require.NoError(t, service.CreateOverride(1, &models.ScheduleOverride{
+		UserID: 1, Date: "2025-01-07", Kind: models.ScheduleOverrideHours,
+		StartTime: models.NewTimeOfDay(10, 0), EndTime: models.NewTimeOfDay(14, 0),
+	}))
+	require.NoError(t, service.CreateOverride(1, &models.ScheduleOverride{
+		UserID: 1, Date: "2025-01-07", Kind: models.ScheduleOverrideExtra,
+		StartTime: models.NewTimeOfDay(14, 0), EndTime: models.NewTimeOfDay(15, 0),
+	}))
+	work, err = service.WorkingIntervals(0, 1, tuesday)
+	require.NoError(t, err)
+	assert.Equal(t, []services.WorkingInterval{{Start: at(tuesday, 10, 0), End: at(tuesday, 15, 0)}}, work)
+}
+
+func TestScheduleService_WorkingIntervalsByUser(t *testing.T) {
+	_, service := setupScheduleService(t, time.UTC)
+
+	require.NoError(t, service.CreateSchedule(1, &models.Schedule{UserID: 1, ScheduleDay: models.Monday, StartTime: models.NewTimeOfDay(9, 0), EndTime: models.NewTimeOfDay(18, 0)}))
+	require.NoError(t, service.CreateSchedule(1, &models.Schedule{UserID: 2, ScheduleDay: models.Monday, StartTime: models.NewTimeOfDay(9, 0), EndTime: models.NewTimeOfDay(18, 0)}))
+	require.NoError(t, service.CreateOverride(1, &models.ScheduleOverride{UserID: 2, Date: "2025-01-06", Kind: models.ScheduleOverrideDayOff}))
+	// Сотрудник 3 не работает по понедельникам, но вышел на дополнительную смену
+	require.NoError(t, service.CreateOverride(1, &models.ScheduleOverride{
+		UserID: 3, Date: "2025-01-06", Kind: models.ScheduleOverrideExtra,
+		StartTime: models.NewTimeOfDay(12, 0), EndTime: models.NewTimeOfDay(16, 0),
+	}))
+
+	work, err := service.WorkingIntervalsByUser(0, monday)
+	require.NoError(t, err)
+	assert.Equal(t, map[int][]services.WorkingInterval{
+		1: {{Start: at(monday, 9, 0), End: at(monday, 18, 0)}},
+		3: {{Start: at(monday, 12, 0), End: at(monday, 16, 0)}},
+	}, work)
+}
+
+func TestScheduleService_WorkingIntervalsAcrossDST(t *testing.T) {
+	losAngeles, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+	_, service := setupScheduleService(t, losAngeles)
+
+	// 9 марта 2025 в Лос-Анджелесе часы переводятся вперед в 02:00
+	require.NoError(t, service.CreateSchedule(1, &models.Schedule{UserID: 1, ScheduleDay: models.Sunday, StartTime: models.NewTimeOfDay(1, 0), EndTime: models.NewTimeOfDay(5, 0)}))
+
+	// Дата передается как полночь UTC, но день определяется по календарю салона
+	work, err := service.WorkingIntervals(0, 1, time.Date(2025, 3, 9, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Len(t, work, 1)
+	assert.True(t, work[0].Start.Equal(time.Date(2025, 3, 9, 9, 0, 0, 0, time.UTC)))
+	assert.True(t, work[0].End.Equal(time.Date(2025, 3, 9, 12, 0, 0, 0, time.UTC)))
+	assert.Equal(t, 3*time.Hour, work[0].End.Sub(work[0].Start))
+}
+
+func TestScheduleService_WorkingIntervalsPerLocation(t *testing.T) {
+	db, service := setupScheduleService(t, time.UTC)
+
+	// Утром сотрудник работает в Москве, где филиал открывается в 10:00, вечером — в Екатеринбурге (UTC+5)
+	moscow := &models.Location{
+		Name: "Москва", Timezone: "Europe/Moscow",
+		OpeningHours: []models.OpeningHours{{Day: models.Monday, Open: models.NewTimeOfDay(10, 0), Close: models.NewTimeOfDay(20, 0)}},
+	}
+	yekaterinburg := &models.Location{Name: "Екатеринбург", Timezone: "Asia/Yekaterinburg"}
+	require.NoError(t, db.Create(moscow).Error)
+	require.NoError(t, db.Create(yekaterinburg).Error)
+
+	require.NoError(t, service.CreateSchedule(1, &models.Schedule{UserID: 1, LocationID: moscow.ID, ScheduleDay: models.Monday, StartTime: models.NewTimeOfDay(9, 0), EndTime: models.NewTimeOfDay(18, 0)}))
+	require.NoError(t, service.CreateSchedule(1, &models.Schedule{UserID: 1, LocationID: yekaterinburg.ID, ScheduleDay: models.Monday, StartTime: models.NewTimeOfDay(21, 0), EndTime: models.NewTimeOfDay(23, 0)}))
+
+	inMoscow := services.WorkingInterval{Start: at(monday, 7, 0), End: at(monday, 15, 0)}
+	inYekaterinburg := services.WorkingInterval{Start: at(monday, 16, 0), End: at(monday, 18, 0)}
+	assertWork := func(locationID int, expected ...services.WorkingInterval) {
+		t.Helper()
+		work, err := service.WorkingIntervals(locationID, 1, monday)
+		require.NoError(t, err)
+		require.Len(t, work, len(expected))
+		for i := range expected {
+			assert.True(t, expected[i].Start.Equal(work[i].Start), "start %v, got %v", expected[i].Start, work[i].Start)
+			assert.True(t, expected[i].End.Equal(work[i].End), "end %v, got %v", expected[i].End, work[i].End)
+		}
+	}
+	assertWork(moscow.ID, inMoscow)
+	assertWork(yekaterinburg.ID, inYekaterinburg)
+	assertWork(0, inMoscow, inYekaterinburg)
+
+	// Выходной в одном филиале не затрагивает другой
+	require.NoError(t, service.CreateOverride(1, &models.ScheduleOverride{UserID: 1, LocationID: yekaterinburg.ID, Date: "2025-01-06", Kind: models.ScheduleOverrideDayOff}))
+	assertWork(0, inMoscow)
+
+	byUser, err := service.WorkingIntervalsByUser(yekaterinburg.ID, monday)
+	require.NoError(t, err)
+	assert.Empty(t, byUser)
+}
+
+func TestScheduleService_Validation(t *testing.T) {
+	_, service := setupScheduleService(t, time.UTC)
+
+	err := service.CreateSchedule(1, &models.Schedule{UserID: 1, StartTime: models.NewTimeOfDay(9, 0), EndTime: models.NewTimeOfDay(18, 0)})
+	assert.ErrorIs(t, err, services.ErrInvalidScheduleDay)
+
+	err = service.CreateSchedule(1, &models.Schedule{UserID: 1, ScheduleDay: models.Monday, StartTime: models.NewTimeOfDay(18, 0), EndTime: models.NewTimeOfDay(9, 0)})
+	assert.ErrorIs(t, err, services.ErrInvalidScheduleTime)
+
+	err = service.CreateOverride(1, &models.ScheduleOverride{UserID: 1, Date: "06.01.2025", Kind: models.ScheduleOverrideDayOff})
+	assert.ErrorIs(t, err, services.ErrInvalidOverrideDate)
+
+	err = service.CreateOverride(1, &models.ScheduleOverride{UserID: 1, Date: "2025-01-06", Kind: "vacation"})
+	assert.ErrorIs(t, err, services.ErrInvalidOverrideKind)
+
+	err = service.CreateOverride(1, &models.ScheduleOverride{
+		UserID: 1, Date: "2025-01-06", Kind: models.ScheduleOverrideDayOff,
+		StartTime: models.NewTimeOfDay(9, 0), EndTime: models.NewTimeOfDay(12, 0),
+	})
+	assert.ErrorIs(t, err, services.ErrInvalidOverrideHours)
+}